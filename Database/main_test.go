@@ -0,0 +1,121 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the tests against a fresh Database/user.db in a scratch directory, next to a
+// copy of difficulties.json for the difficulty checks
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "passgame-database-")
+	if err != nil {
+		log.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+		data, err := os.ReadFile(filepath.Join("..", "config", "difficulties.json"))
+		if err != nil {
+			log.Printf("Failed to read difficulties.json: %v", err)
+			return 1
+		}
+		for _, sub := range []string{"config", "Database"} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+				log.Printf("Failed to create %s: %v", sub, err)
+				return 1
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config", "difficulties.json"), data, 0644); err != nil {
+			log.Printf("Failed to copy difficulties.json: %v", err)
+			return 1
+		}
+		if err := os.Chdir(dir); err != nil {
+			log.Printf("Failed to enter test directory: %v", err)
+			return 1
+		}
+		if err := InitDB(); err != nil {
+			log.Printf("Failed to initialize the test database: %v", err)
+			return 1
+		}
+		defer CloseDB()
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// useEmptyDB gives the test an empty users table, cleared again afterwards
+func useEmptyDB(t *testing.T) {
+	t.Helper()
+	deleteUsers := func() {
+		if _, err := db.Exec("DELETE FROM users"); err != nil {
+			t.Fatalf("failed to clear users: %v", err)
+		}
+	}
+	deleteUsers()
+	t.Cleanup(deleteUsers)
+}
+
+// insertTestUser registers a user and returns its ID
+func insertTestUser(t *testing.T, username, difficulty string) int64 {
+	t.Helper()
+	userID, err := InsertUser(username, difficulty)
+	if err != nil {
+		t.Fatalf("InsertUser(%q, %q) error = %v", username, difficulty, err)
+	}
+	return userID
+}
+
+// mustGetUser loads a user that must exist
+func mustGetUser(t *testing.T, userID int64) *User {
+	t.Helper()
+	user, err := GetUser(userID)
+	if err != nil {
+		t.Fatalf("GetUser(%d) error = %v", userID, err)
+	}
+	return user
+}
+
+// useLegacyDB points the package at a scratch database created with an older users schema,
+// restoring the test database afterwards
+func useLegacyDB(t *testing.T, schema string) {
+	t.Helper()
+	legacy, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("failed to open legacy database: %v", err)
+	}
+	if _, err := legacy.Exec(schema); err != nil {
+		legacy.Close()
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	previous := db
+	db = legacy
+	t.Cleanup(func() {
+		db = previous
+		legacy.Close()
+	})
+}
+
+// userColumns lists the users table's columns
+func userColumns(t *testing.T) map[string]bool {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM pragma_table_info('users')")
+	if err != nil {
+		t.Fatalf("failed to read users schema: %v", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		columns[name] = true
+	}
+	return columns
+}
@@ -2,31 +2,35 @@ package database
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"passgame/config"
+
 	_ "modernc.org/sqlite"
 )
 
 var db *sql.DB
 
+// databaseDSN opens Database/user.db with pragmas applied to every pooled connection:
+//   - journal_mode(WAL): readers no longer block the writer (and vice versa), at the cost of
+//     -wal and -shm files next to the database, which must be kept with it when copying
+//   - busy_timeout(5000): a writer waits up to 5s for the lock instead of failing with
+//     "database is locked"
+//   - synchronous(NORMAL): safe with WAL; a power loss can drop the last few commits but
+//     never corrupts the database, in exchange for far fewer fsyncs
+const databaseDSN = "file:Database/user.db?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(NORMAL)"
+
 // GetDB returns the database connection
 func GetDB() *sql.DB {
 	return db
 }
 
-// DifficultyConfig represents the configuration for a difficulty level
-type DifficultyConfig struct {
-	Name        string `json:"name"`
-	Icon        string `json:"icon"`
-	Color       string `json:"color"`
-	Description string `json:"description"`
-}
-
 // User represents a user in the database
 type User struct {
 	ID          int64     `json:"id"`
@@ -36,6 +40,13 @@ type User struct {
 	TimeSpent   int       `json:"time_spent"` // in seconds
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// TimedOut marks a run that hit its difficulty's time limit; RuleReached is where it stopped
+	TimedOut bool `json:"timed_out"`
+	// Completed is set by RecordCompletion and stays set even if the difficulty later gains rules
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Score is computed on read, see ComputeScore
+	Score int `json:"score"`
 }
 
 // SortConfig holds sorting configuration
@@ -51,108 +62,96 @@ var validSortColumns = map[string]string{
 	"difficulty": "difficulty",
 	"joined":     "created_at",
 	"username":   "username",
+	"score":      "score",
 }
 
-// LoadDifficulties loads difficulty configurations from JSON file
-func LoadDifficulties() (map[string]DifficultyConfig, error) {
-	data, err := ioutil.ReadFile("config/difficulties.json")
-	if err != nil {
-		log.Printf("Error reading difficulties.json: %v", err)
-		return getDefaultDifficulties(), err
-	}
-
-	var difficulties map[string]DifficultyConfig
-	if err := json.Unmarshal(data, &difficulties); err != nil {
-		log.Printf("Error parsing difficulties.json: %v", err)
-		return getDefaultDifficulties(), err
-	}
-
-	return difficulties, nil
-}
-
-// getDefaultDifficulties returns hardcoded default difficulties as fallback
-func getDefaultDifficulties() map[string]DifficultyConfig {
-	return map[string]DifficultyConfig{
-		"basic": {
-			Name:        "Basic",
-			Icon:        "🟢",
-			Color:       "#4CAF50",
-			Description: "Standard rules",
-		},
-		"intermediate": {
-			Name:        "Intermediate",
-			Icon:        "🟡",
-			Color:       "#FF9800",
-			Description: "More challenging",
-		},
-		"hard": {
-			Name:        "Hard",
-			Icon:        "🔴",
-			Color:       "#F44336",
-			Description: "Expert level",
-		},
-		"expert": {
-			Name:        "Expert",
-			Icon:        "🟣",
-			Color:       "#9C27B0",
-			Description: "Master level",
-		},
-		"fun": {
-			Name:        "Fun",
-			Icon:        "🎉",
-			Color:       "#E91E63",
-			Description: "Quirky rules",
-		},
-	}
-}
-
-// getDynamicDifficulties gets valid difficulties from the config
-func getDynamicDifficulties() []string {
-	difficulties, err := LoadDifficulties()
-	if err != nil {
-		// Fallback to default difficulties if config loading fails
-		return []string{"basic", "intermediate", "hard", "expert", "fun"}
-	}
+// Leaderboard score: each rule reached is worth scorePointsPerRule times its difficulty's
+// score_weight, minus a point per scoreSecondsPerPoint seconds spent, never below zero.
+// ComputeScore and scoreExpression must stay in step.
+const (
+	scorePointsPerRule   = 100
+	scoreSecondsPerPoint = 10
+)
+
+// ComputeScore returns the leaderboard score for a player's progress
+func ComputeScore(ruleReached, timeSpent int, difficulty string) int {
+	return scoreWithWeights(ruleReached, timeSpent, difficulty, config.ScoreWeights())
+}
 
-	var validDiffs []string
-	for key := range difficulties {
-		validDiffs = append(validDiffs, key)
+// scoreWithWeights is ComputeScore with the weights already loaded, for scoring many rows
+func scoreWithWeights(ruleReached, timeSpent int, difficulty string, weights map[string]float64) int {
+	weight, ok := weights[strings.ToLower(difficulty)]
+	if !ok {
+		weight = 1
+	}
+	score := int(float64(ruleReached)*weight*scorePointsPerRule) - timeSpent/scoreSecondsPerPoint
+	if score < 0 {
+		return 0
 	}
-	return validDiffs
+	return score
 }
 
-// InitDB initializes the SQLite database with improved schema
-func InitDB() error {
-	var err error
+// scoreExpression is ComputeScore as SQL, for ordering by score inside the query
+func scoreExpression() string {
+	weights := config.ScoreWeights()
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	// Create the database file in the Database directory
-	db, err = sql.Open("sqlite", "Database/user.db")
-	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+	var weight strings.Builder
+	weight.WriteString("CASE difficulty")
+	for _, key := range keys {
+		fmt.Fprintf(&weight, " WHEN '%s' THEN %s", strings.ReplaceAll(key, "'", "''"), strconv.FormatFloat(weights[key], 'f', -1, 64))
 	}
+	weight.WriteString(" ELSE 1 END")
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	return fmt.Sprintf("MAX(0, CAST(rule_reached * (%s) * %d AS INTEGER) - time_spent / %d)",
+		weight.String(), scorePointsPerRule, scoreSecondsPerPoint)
+}
 
-	// Test the connection
-	if err = db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %v", err)
+// getDynamicDifficulties gets valid difficulties from the config in their configured order.
+// LoadDifficulties falls back to the defaults when difficulties.json can't be read.
+func getDynamicDifficulties() []string {
+	return config.DifficultyOrder()
+}
+
+// difficultyRankExpression ranks the difficulty column in the configured order, or the reverse
+// when descending, for ORDER BY. Difficulties missing from the config sort last either way.
+func difficultyRankExpression(descending bool) string {
+	order := getDynamicDifficulties()
+
+	var rank strings.Builder
+	rank.WriteString("CASE difficulty")
+	for i, key := range order {
+		position := i + 1
+		if descending {
+			position = len(order) - i
+		}
+		fmt.Fprintf(&rank, " WHEN '%s' THEN %d", strings.ReplaceAll(key, "'", "''"), position)
 	}
+	fmt.Fprintf(&rank, " ELSE %d END", len(order)+1)
+	return rank.String()
+}
 
-	// Create the users table with improved schema
-	createTableSQL := `
+// createUsersTableSQL is the users schema. Difficulties are configurable in difficulties.json,
+// so the CHECK only rejects an empty value and InsertUser validates the name with
+// config.ValidateDifficulty.
+const createUsersTableSQL = `
 	CREATE TABLE IF NOT EXISTS users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		username TEXT UNIQUE NOT NULL COLLATE NOCASE,
-		difficulty TEXT NOT NULL CHECK(difficulty IN ('basic', 'intermediate', 'hard', 'expert', 'fun')),
+		difficulty TEXT NOT NULL CHECK(difficulty <> ''),
 		rule_reached INTEGER DEFAULT 0 CHECK(rule_reached >= 0 AND rule_reached <= 50),
 		time_spent INTEGER DEFAULT 0 CHECK(time_spent >= 0),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
-	
+	`
+
+// usersIndexesSQL creates the indexes and trigger on users; rerun after the table is rebuilt
+const usersIndexesSQL = `
 	-- Create optimized indexes
 	CREATE INDEX IF NOT EXISTS idx_username ON users(username COLLATE NOCASE);
 	CREATE INDEX IF NOT EXISTS idx_leaderboard ON users(rule_reached DESC, time_spent ASC);
@@ -168,14 +167,195 @@ func InitDB() error {
 		END;
 	`
 
-	if _, err = db.Exec(createTableSQL); err != nil {
+// InitDB initializes the SQLite database with improved schema
+func InitDB() error {
+	var err error
+
+	// Create the database file in the Database directory
+	db, err = sql.Open("sqlite", databaseDSN)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Set connection pool settings. SQLite still allows a single writer at a time; with WAL the
+	// other connections keep reading, and busy_timeout queues writers instead of failing them.
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Test the connection
+	if err = db.Ping(); err != nil {
+		return fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	// Create the users table with improved schema
+	if _, err = db.Exec(createUsersTableSQL + usersIndexesSQL); err != nil {
 		return fmt.Errorf("failed to create table and indexes: %v", err)
 	}
 
+	if err = migrateUsersTable(); err != nil {
+		return err
+	}
+
 	log.Println("✅ Database initialized successfully with optimized schema")
 	return nil
 }
 
+// migrateUsersTable adds columns introduced after the original schema to existing databases
+func migrateUsersTable() error {
+	columns := make(map[string]bool)
+	rows, err := db.Query("PRAGMA table_info(users)")
+	if err != nil {
+		return fmt.Errorf("failed to read users schema: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return fmt.Errorf("failed to scan users schema: %v", err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read users schema: %v", err)
+	}
+
+	if !columns["stuck_rule"] {
+		// The rule a player was stuck on when they abandoned the game (0 = not recorded)
+		if _, err := db.Exec("ALTER TABLE users ADD COLUMN stuck_rule INTEGER DEFAULT 0 CHECK(stuck_rule >= 0)"); err != nil {
+			return fmt.Errorf("failed to add stuck_rule column: %v", err)
+		}
+		log.Println("✅ Migrated users table: added stuck_rule")
+	}
+
+	if err := relaxDifficultyCheck(); err != nil {
+		return err
+	}
+
+	if !columns["timed_out"] {
+		// Set when a run on a timed difficulty ran out of time
+		if _, err := db.Exec("ALTER TABLE users ADD COLUMN timed_out INTEGER DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add timed_out column: %v", err)
+		}
+		log.Println("✅ Migrated users table: added timed_out")
+	}
+
+	if !columns["completed"] {
+		// Completion used to be derived from rule_reached and the current final rule, so adding a
+		// rule to a difficulty un-completed everyone who had finished it. Store it instead.
+		statements := []string{
+			"ALTER TABLE users ADD COLUMN completed INTEGER DEFAULT 0",
+			"ALTER TABLE users ADD COLUMN completed_at DATETIME",
+		}
+		for _, statement := range statements {
+			if _, err := db.Exec(statement); err != nil {
+				return fmt.Errorf("failed to add completed columns: %v", err)
+			}
+		}
+		if err := backfillCompleted(); err != nil {
+			return err
+		}
+		log.Println("✅ Migrated users table: added completed and completed_at")
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_completed_at ON users(completed, completed_at DESC)"); err != nil {
+		return fmt.Errorf("failed to create completed index: %v", err)
+	}
+
+	return nil
+}
+
+// backfillCompleted marks the existing players who reached their difficulty's final rule as
+// completed, using their last update as the completion time. A player who finished before rules
+// were appended is at the old final rule, so backfillFinalRuleFunc can supply that one instead.
+func backfillCompleted() error {
+	rows, err := db.Query("SELECT DISTINCT difficulty FROM users")
+	if err != nil {
+		return fmt.Errorf("failed to read difficulties for completion backfill: %v", err)
+	}
+	var difficulties []string
+	for rows.Next() {
+		var difficulty string
+		if err := rows.Scan(&difficulty); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan difficulty for completion backfill: %v", err)
+		}
+		difficulties = append(difficulties, difficulty)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read difficulties for completion backfill: %v", err)
+	}
+
+	lookup := finalRuleFunc
+	if backfillFinalRuleFunc != nil {
+		lookup = backfillFinalRuleFunc
+	}
+	for _, difficulty := range difficulties {
+		final := lookup(difficulty)
+		if final <= 0 {
+			continue
+		}
+		_, err := db.Exec(`
+			UPDATE users SET completed = 1, completed_at = updated_at
+			WHERE difficulty = ? AND rule_reached >= ? AND timed_out = 0
+		`, difficulty, final)
+		if err != nil {
+			return fmt.Errorf("failed to backfill completions for %s: %v", difficulty, err)
+		}
+	}
+	return nil
+}
+
+// relaxDifficultyCheck rebuilds a users table created with the old hardcoded
+// CHECK(difficulty IN (...)), which rejected difficulties added in difficulties.json.
+// SQLite can't drop a constraint, so the rows are copied into a table with the current schema.
+func relaxDifficultyCheck() error {
+	var tableSQL string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tableSQL); err != nil {
+		return fmt.Errorf("failed to read users table definition: %v", err)
+	}
+	if !strings.Contains(tableSQL, "difficulty IN (") {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin difficulty migration: %v", err)
+	}
+	defer tx.Rollback()
+
+	const columns = "id, username, difficulty, rule_reached, time_spent, created_at, updated_at, stuck_rule"
+	statements := []string{
+		"ALTER TABLE users RENAME TO users_old",
+		createUsersTableSQL,
+		"ALTER TABLE users ADD COLUMN stuck_rule INTEGER DEFAULT 0 CHECK(stuck_rule >= 0)",
+		"INSERT INTO users (" + columns + ") SELECT " + columns + " FROM users_old",
+		"DROP TABLE users_old",
+		usersIndexesSQL,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to migrate difficulty constraint: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit difficulty migration: %v", err)
+	}
+
+	log.Println("✅ Migrated users table: difficulty is no longer limited to the built-in tiers")
+	return nil
+}
+
 // CloseDB closes the database connection gracefully
 func CloseDB() error {
 	if db != nil {
@@ -202,26 +382,6 @@ func CheckUsernameExists(username string) (bool, error) {
 	return count > 0, nil
 }
 
-// ValidateDifficulty checks if the difficulty is valid
-func ValidateDifficulty(difficulty string) bool {
-	if difficulty == "all" {
-		return true
-	}
-
-	diffs, err := LoadDifficulties()
-	if err != nil {
-		return false
-	}
-
-	// Check against loaded difficulties (case-insensitive)
-	for k := range diffs {
-		if strings.EqualFold(difficulty, k) {
-			return true
-		}
-	}
-	return false
-}
-
 // InsertUser inserts a new user with validation
 func InsertUser(username, difficulty string) (int64, error) {
 	// Validate inputs
@@ -236,21 +396,13 @@ func InsertUser(username, difficulty string) (int64, error) {
 		return 0, fmt.Errorf("username too long (max 50 characters)")
 	}
 
-	if !ValidateDifficulty(difficulty) {
+	if !config.ValidateDifficulty(difficulty) {
 		validDiffs := getDynamicDifficulties()
 		return 0, fmt.Errorf("invalid difficulty: %s (valid: %v)", difficulty, validDiffs)
 	}
 
-	// Check if username exists
-	exists, err := CheckUsernameExists(username)
-	if err != nil {
-		return 0, fmt.Errorf("failed to check username: %v", err)
-	}
-	if exists {
-		return 0, fmt.Errorf("username '%s' already exists", username)
-	}
-
-	// Insert user
+	// Insert user. The UNIQUE constraint on username is the only check: a separate lookup first
+	// would let two concurrent registrations for the same name both pass it.
 	query := `
 		INSERT INTO users (username, difficulty, rule_reached, time_spent, created_at, updated_at)
 		VALUES (?, ?, 0, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
@@ -258,6 +410,9 @@ func InsertUser(username, difficulty string) (int64, error) {
 
 	result, err := db.Exec(query, username, difficulty)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrUsernameTaken
+		}
 		return 0, fmt.Errorf("failed to insert user: %v", err)
 	}
 
@@ -270,26 +425,59 @@ func InsertUser(username, difficulty string) (int64, error) {
 	return userID, nil
 }
 
-// UpdateUserProgress updates user progress with validation
+// ErrUsernameTaken is returned by InsertUser when the username is already registered
+var ErrUsernameTaken = errors.New("username already exists")
+
+// isUniqueViolation reports whether err is SQLite rejecting a row for a UNIQUE constraint
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// maxRuleReachedLimit mirrors the CHECK constraint on users.rule_reached
+const maxRuleReachedLimit = 50
+
+// maxRuleReached is the highest rule progress can record, set from the rule pool at startup
+var maxRuleReached = maxRuleReachedLimit
+
+// SetMaxRuleReached sets the ceiling UpdateUserProgress validates against. Values outside
+// 1..maxRuleReachedLimit are ignored since the table constraint would reject them anyway.
+func SetMaxRuleReached(maxRule int) {
+	if maxRule <= 0 || maxRule > maxRuleReachedLimit {
+		log.Printf("⚠️ Ignoring max rule %d (must be 1-%d)", maxRule, maxRuleReachedLimit)
+		return
+	}
+	maxRuleReached = maxRule
+}
+
+// MaxRuleReached returns the current progress ceiling
+func MaxRuleReached() int {
+	return maxRuleReached
+}
+
+// UpdateUserProgress records a player's progress. The update is monotonic: rule_reached never
+// goes down, and time_spent only changes along with a higher rule, so duplicate or out-of-order
+// writes can't regress a player.
 func UpdateUserProgress(userID int64, ruleReached, timeSpent int) error {
 	// Validate inputs
 	if userID <= 0 {
 		return fmt.Errorf("invalid user ID: %d", userID)
 	}
-	if ruleReached < 0 || ruleReached > 50 {
-		return fmt.Errorf("invalid rule reached: %d (must be 0-50)", ruleReached)
+	if ruleReached < 0 || ruleReached > maxRuleReached {
+		return fmt.Errorf("invalid rule reached: %d (must be between 0 and %d)", ruleReached, maxRuleReached)
 	}
 	if timeSpent < 0 {
 		return fmt.Errorf("invalid time spent: %d (must be >= 0)", timeSpent)
 	}
 
+	// SQLite evaluates every SET expression against the old row, so the CASE sees the previous rule_reached
 	query := `
 		UPDATE users 
-		SET rule_reached = ?, time_spent = ?
+		SET time_spent = CASE WHEN ? > rule_reached THEN ? ELSE time_spent END,
+			rule_reached = MAX(rule_reached, ?)
 		WHERE id = ?
 	`
 
-	result, err := db.Exec(query, ruleReached, timeSpent, userID)
+	result, err := db.Exec(query, ruleReached, timeSpent, ruleReached, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update user progress: %v", err)
 	}
@@ -307,6 +495,383 @@ func UpdateUserProgress(userID int64, ruleReached, timeSpent int) error {
 	return nil
 }
 
+// RecordCompletion stores a finished game. rule_reached moves up to the difficulty's final rule
+// ID and time_spent becomes the completion time, even if the final rule was reached earlier.
+// The user is flagged as completed, which is what unlocks, promotion and stats read.
+func RecordCompletion(userID int64, finalRule, timeSpent int) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+	if finalRule <= 0 || finalRule > maxRuleReached {
+		return fmt.Errorf("invalid final rule: %d (must be between 1 and %d)", finalRule, maxRuleReached)
+	}
+	if timeSpent < 0 {
+		return fmt.Errorf("invalid time spent: %d (must be >= 0)", timeSpent)
+	}
+
+	result, err := db.Exec(`
+		UPDATE users
+		SET rule_reached = MAX(rule_reached, ?), time_spent = ?, completed = 1, completed_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, finalRule, timeSpent, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record completion: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %d", userID)
+	}
+	return nil
+}
+
+// RecordStuckRule stores the rule a player was stuck on when they abandoned their game
+func RecordStuckRule(userID int64, rule int) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+	if rule <= 0 || rule > maxRuleReached {
+		return fmt.Errorf("invalid stuck rule: %d (must be between 1 and %d)", rule, maxRuleReached)
+	}
+
+	result, err := db.Exec("UPDATE users SET stuck_rule = ? WHERE id = ?", rule, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record stuck rule: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %d", userID)
+	}
+
+	log.Printf("🧱 User ID %d abandoned the game stuck on rule %d", userID, rule)
+	return nil
+}
+
+// MarkUserTimedOut flags a run that ran out of time, recording the time it was allowed
+func MarkUserTimedOut(userID int64, timeSpent int) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+	if timeSpent < 0 {
+		return fmt.Errorf("invalid time spent: %d (must be >= 0)", timeSpent)
+	}
+
+	result, err := db.Exec("UPDATE users SET timed_out = 1, time_spent = ? WHERE id = ?", timeSpent, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark user timed out: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %d", userID)
+	}
+
+	log.Printf("⏱️ User ID %d ran out of time after %ds", userID, timeSpent)
+	return nil
+}
+
+// ResetUserProgress clears a user's progress so they can replay from rule 1
+func ResetUserProgress(userID int64) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+
+	query := `
+		UPDATE users 
+		SET rule_reached = 0, time_spent = 0, timed_out = 0, completed = 0, completed_at = NULL
+		WHERE id = ?
+	`
+
+	result, err := db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset user progress: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %d", userID)
+	}
+
+	log.Printf("🔄 Progress reset for user ID %d", userID)
+	return nil
+}
+
+// ImportSkip records a row ImportUsers left out and why
+type ImportSkip struct {
+	Index    int    `json:"index"`
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+// ImportResult summarizes a bulk import
+type ImportResult struct {
+	Inserted int          `json:"inserted"`
+	Skipped  []ImportSkip `json:"skipped"`
+}
+
+// ImportUsers inserts users in a single transaction, skipping invalid rows and
+// usernames that already exist, and returns how many were inserted
+func ImportUsers(users []User) (int, error) {
+	result, err := ImportUsersWithReport(users)
+	return result.Inserted, err
+}
+
+// ImportUsersWithReport is ImportUsers with the per-row skip reasons. Any database
+// error rolls back the whole batch.
+func ImportUsersWithReport(users []User) (ImportResult, error) {
+	result := ImportResult{Skipped: []ImportSkip{}}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin import: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO users (username, difficulty, rule_reached, time_spent, created_at, updated_at, completed, completed_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)
+	`)
+	if err != nil {
+		return result, fmt.Errorf("failed to prepare import: %v", err)
+	}
+	defer stmt.Close()
+
+	seen := make(map[string]bool)
+	for i, user := range users {
+		username := strings.TrimSpace(user.Username)
+		difficulty := strings.ToLower(strings.TrimSpace(user.Difficulty))
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, ImportSkip{Index: i, Username: username, Reason: reason})
+		}
+
+		switch {
+		case username == "":
+			skip("username cannot be empty")
+			continue
+		case len(username) > 50:
+			skip("username too long (max 50 characters)")
+			continue
+		case difficulty == "all" || !config.ValidateDifficulty(difficulty):
+			skip(fmt.Sprintf("invalid difficulty: %s", user.Difficulty))
+			continue
+		case user.RuleReached < 0 || user.RuleReached > maxRuleReached:
+			skip(fmt.Sprintf("invalid rule reached: %d (must be between 0 and %d)", user.RuleReached, maxRuleReached))
+			continue
+		case user.TimeSpent < 0:
+			skip(fmt.Sprintf("invalid time spent: %d (must be >= 0)", user.TimeSpent))
+			continue
+		case seen[strings.ToLower(username)]:
+			skip("duplicate username in batch")
+			continue
+		}
+		seen[strings.ToLower(username)] = true
+
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM users WHERE username = ? COLLATE NOCASE", username).Scan(&count)
+		if err != nil {
+			return ImportResult{Skipped: []ImportSkip{}}, fmt.Errorf("failed to check username %q: %v", username, err)
+		}
+		if count > 0 {
+			skip("username already exists")
+			continue
+		}
+
+		createdAt := user.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now().UTC()
+		}
+
+		// Keep the completion flag from the export; a completed row without a timestamp gets now
+		var completedAt interface{}
+		if user.Completed {
+			completedAt = time.Now().UTC()
+			if user.CompletedAt != nil {
+				completedAt = *user.CompletedAt
+			}
+		}
+
+		if _, err := stmt.Exec(username, difficulty, user.RuleReached, user.TimeSpent, createdAt, user.Completed, completedAt); err != nil {
+			return ImportResult{Skipped: []ImportSkip{}}, fmt.Errorf("failed to import user %q: %v", username, err)
+		}
+		result.Inserted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ImportResult{Skipped: []ImportSkip{}}, fmt.Errorf("failed to commit import: %v", err)
+	}
+
+	log.Printf("📥 Imported %d users (%d skipped)", result.Inserted, len(result.Skipped))
+	return result, nil
+}
+
+// Difficulty unlock gating. difficultyUnlockOrder lists the tiers from easiest to hardest;
+// gating is disabled while it's empty, and difficulties missing from it are always open.
+var (
+	difficultyUnlockOrder []string
+	finalRuleFunc         = func(string) int { return maxRuleReached }
+	backfillFinalRuleFunc func(string) int
+)
+
+// SetDifficultyUnlockOrder enables unlock gating with the given tier order
+func SetDifficultyUnlockOrder(order []string) {
+	difficultyUnlockOrder = nil
+	for _, difficulty := range order {
+		difficultyUnlockOrder = append(difficultyUnlockOrder, strings.ToLower(strings.TrimSpace(difficulty)))
+	}
+}
+
+// SetFinalRuleFunc sets how the ID of a difficulty's final rule is looked up. The migration that
+// adds the completed column uses it to mark the players who already reached it, so set it
+// before InitDB.
+func SetFinalRuleFunc(fn func(difficulty string) int) {
+	if fn != nil {
+		finalRuleFunc = fn
+	}
+}
+
+// SetBackfillFinalRuleFunc sets the final rules the completed-flag migration checks existing
+// players against, for difficulties that gained rules after some players had finished them.
+// Without it the migration uses the SetFinalRuleFunc lookup.
+func SetBackfillFinalRuleFunc(fn func(difficulty string) int) {
+	backfillFinalRuleFunc = fn
+}
+
+// difficultyTier returns the position of a difficulty in the unlock order, or -1 if it isn't gated
+func difficultyTier(difficulty string) int {
+	difficulty = strings.ToLower(strings.TrimSpace(difficulty))
+	for i, d := range difficultyUnlockOrder {
+		if d == difficulty {
+			return i
+		}
+	}
+	return -1
+}
+
+// DifficultyPrerequisite returns the difficulty that must be completed to unlock the given one,
+// or "" when it has no prerequisite
+func DifficultyPrerequisite(difficulty string) string {
+	if tier := difficultyTier(difficulty); tier > 0 {
+		return difficultyUnlockOrder[tier-1]
+	}
+	return ""
+}
+
+// IsDifficultyUnlocked reports whether the player may play a difficulty: it's ungated, it's the
+// first tier, the player already plays it or a harder tier, or they completed the tier before it
+func IsDifficultyUnlocked(username, difficulty string) (bool, error) {
+	prerequisite := DifficultyPrerequisite(difficulty)
+	if prerequisite == "" {
+		return true, nil
+	}
+
+	exists, err := CheckUsernameExists(username)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+
+	user, err := GetUserByUsername(username)
+	if err != nil {
+		return false, err
+	}
+
+	userTier := difficultyTier(user.Difficulty)
+	if userTier >= difficultyTier(difficulty) {
+		return true, nil
+	}
+	if strings.EqualFold(user.Difficulty, prerequisite) {
+		return user.Completed, nil
+	}
+	return false, nil
+}
+
+// PromoteUser moves a player to a new difficulty and clears their progress for it
+func PromoteUser(userID int64, difficulty string) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+	difficulty = strings.ToLower(strings.TrimSpace(difficulty))
+	if difficulty == "all" || !config.ValidateDifficulty(difficulty) {
+		return fmt.Errorf("invalid difficulty: %s", difficulty)
+	}
+
+	result, err := db.Exec(`
+		UPDATE users
+		SET difficulty = ?, rule_reached = 0, time_spent = 0, timed_out = 0, completed = 0, completed_at = NULL
+		WHERE id = ?
+	`, difficulty, userID)
+	if err != nil {
+		return fmt.Errorf("failed to promote user: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %d", userID)
+	}
+
+	log.Printf("⬆️ User ID %d promoted to %s", userID, difficulty)
+	return nil
+}
+
+// ErrProgressRecorded is returned by UpdateUserDifficulty once the user has reached a rule
+var ErrProgressRecorded = errors.New("progress already recorded")
+
+// UpdateUserDifficulty moves a player who hasn't reached any rule yet to another difficulty.
+// Unlike PromoteUser it refuses once progress exists, so leaderboard entries can't be moved.
+func UpdateUserDifficulty(userID int64, difficulty string) error {
+	if userID <= 0 {
+		return fmt.Errorf("invalid user ID: %d", userID)
+	}
+	difficulty = strings.ToLower(strings.TrimSpace(difficulty))
+	if difficulty == "all" || !config.ValidateDifficulty(difficulty) {
+		return fmt.Errorf("invalid difficulty: %s", difficulty)
+	}
+
+	result, err := db.Exec("UPDATE users SET difficulty = ?, time_spent = 0, timed_out = 0 WHERE id = ? AND rule_reached = 0", difficulty, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user difficulty: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing user apart from one who already has progress
+		var ruleReached int
+		if err := db.QueryRow("SELECT rule_reached FROM users WHERE id = ?", userID).Scan(&ruleReached); err != nil {
+			return fmt.Errorf("no user found with ID: %d", userID)
+		}
+		return ErrProgressRecorded
+	}
+
+	log.Printf("🔀 User ID %d switched to %s", userID, difficulty)
+	return nil
+}
+
 // GetUser retrieves a user by ID with error handling
 func GetUser(userID int64) (*User, error) {
 	if userID <= 0 {
@@ -314,7 +879,7 @@ func GetUser(userID int64) (*User, error) {
 	}
 
 	query := `
-		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
 		FROM users WHERE id = ?
 	`
 
@@ -327,6 +892,9 @@ func GetUser(userID int64) (*User, error) {
 		&user.TimeSpent,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.TimedOut,
+		&user.Completed,
+		&user.CompletedAt,
 	)
 
 	if err != nil {
@@ -335,6 +903,7 @@ func GetUser(userID int64) (*User, error) {
 		}
 		return nil, fmt.Errorf("failed to get user: %v", err)
 	}
+	user.Score = ComputeScore(user.RuleReached, user.TimeSpent, user.Difficulty)
 
 	return user, nil
 }
@@ -347,7 +916,7 @@ func GetUserByUsername(username string) (*User, error) {
 	}
 
 	query := `
-		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
 		FROM users WHERE username = ? COLLATE NOCASE
 	`
 
@@ -360,6 +929,9 @@ func GetUserByUsername(username string) (*User, error) {
 		&user.TimeSpent,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.TimedOut,
+		&user.Completed,
+		&user.CompletedAt,
 	)
 
 	if err != nil {
@@ -368,6 +940,7 @@ func GetUserByUsername(username string) (*User, error) {
 		}
 		return nil, fmt.Errorf("failed to get user: %v", err)
 	}
+	user.Score = ComputeScore(user.RuleReached, user.TimeSpent, user.Difficulty)
 
 	return user, nil
 }
@@ -391,7 +964,7 @@ func GetLeaderboardSorted(limit int, sortBy, sortOrder string) ([]User, error) {
 	orderBy := buildOrderByClause(sortConfig)
 
 	query := fmt.Sprintf(`
-		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
 		FROM users 
 		ORDER BY %s
 		LIMIT ?
@@ -411,7 +984,7 @@ func GetLeaderboardByDifficulty(difficulty string, limit int, sortBy, sortOrder
 
 	// Validate difficulty
 	difficulty = strings.ToLower(strings.TrimSpace(difficulty))
-	if !ValidateDifficulty(difficulty) {
+	if !config.ValidateDifficulty(difficulty) {
 		return nil, fmt.Errorf("invalid difficulty: %s", difficulty)
 	}
 
@@ -420,7 +993,7 @@ func GetLeaderboardByDifficulty(difficulty string, limit int, sortBy, sortOrder
 	orderBy := buildOrderByClause(sortConfig)
 
 	query := fmt.Sprintf(`
-		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
 		FROM users 
 		WHERE difficulty = ?
 		ORDER BY %s
@@ -430,6 +1003,100 @@ func GetLeaderboardByDifficulty(difficulty string, limit int, sortBy, sortOrder
 	return executeUserQueryWithParam(query, difficulty, limit)
 }
 
+// RankedUser is a leaderboard row together with its overall position
+type RankedUser struct {
+	User
+	Rank int `json:"rank"`
+}
+
+// GetUserRank returns the user's position on the default leaderboard ordering, or 0 when
+// the user has no progress yet and so isn't ranked
+func GetUserRank(userID int64) (int, error) {
+	if userID <= 0 {
+		return 0, fmt.Errorf("invalid user ID: %d", userID)
+	}
+
+	query := `
+		WITH ranked AS (
+			SELECT id,
+				ROW_NUMBER() OVER (ORDER BY rule_reached DESC, time_spent ASC, created_at DESC, id ASC) AS position
+			FROM users
+			WHERE rule_reached > 0
+		)
+		SELECT position FROM ranked WHERE id = ?
+	`
+
+	var rank int
+	err := db.QueryRow(query, userID).Scan(&rank)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user rank: %v", err)
+	}
+	return rank, nil
+}
+
+// GetLeaderboardWindow returns the players ranked within radius places of userID on the
+// default leaderboard ordering, including the user's own row. Players without progress
+// aren't ranked, so an empty slice is returned for them.
+func GetLeaderboardWindow(userID int64, radius int) ([]RankedUser, error) {
+	if radius < 0 {
+		radius = 0
+	}
+	if radius > 10 {
+		radius = 10 // Prevent excessive queries
+	}
+
+	query := `
+		WITH ranked AS (
+			SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at,
+				ROW_NUMBER() OVER (ORDER BY rule_reached DESC, time_spent ASC, created_at DESC, id ASC) AS position
+			FROM users
+			WHERE rule_reached > 0
+		)
+		SELECT r.id, r.username, r.difficulty, r.rule_reached, r.time_spent, r.created_at, r.updated_at, r.timed_out, r.completed, r.completed_at, r.position
+		FROM ranked r, (SELECT position FROM ranked WHERE id = ?) me
+		WHERE r.position BETWEEN me.position - ? AND me.position + ?
+		ORDER BY r.position
+	`
+
+	rows, err := db.Query(query, userID, radius, radius)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard window: %v", err)
+	}
+	defer rows.Close()
+
+	weights := config.ScoreWeights()
+	var users []RankedUser
+	for rows.Next() {
+		var user RankedUser
+		if err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Difficulty,
+			&user.RuleReached,
+			&user.TimeSpent,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.TimedOut,
+			&user.Completed,
+			&user.CompletedAt,
+			&user.Rank,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %v", err)
+		}
+		user.Score = scoreWithWeights(user.RuleReached, user.TimeSpent, user.Difficulty, weights)
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return users, nil
+}
+
 // validateSortConfig validates and normalizes sort configuration
 func validateSortConfig(sortBy, sortOrder string) SortConfig {
 	// Validate sort column
@@ -466,22 +1133,10 @@ func buildOrderByClause(config SortConfig) string {
 		return "time_spent ASC, rule_reached DESC, created_at DESC"
 
 	case strings.Contains(config.Column, "difficulty"):
-		if config.Order == "desc" {
-			return `CASE difficulty 
-				WHEN 'expert' THEN 1 
-				WHEN 'hard' THEN 2 
-				WHEN 'intermediate' THEN 3 
-				WHEN 'basic' THEN 4 
-				WHEN 'fun' THEN 5 
-				ELSE 6 END ASC, rule_reached DESC, time_spent ASC`
-		}
-		return `CASE difficulty 
-			WHEN 'basic' THEN 1 
-			WHEN 'intermediate' THEN 2 
-			WHEN 'hard' THEN 3 
-			WHEN 'expert' THEN 4 
-			WHEN 'fun' THEN 5 
-			ELSE 6 END ASC, rule_reached DESC, time_spent ASC`
+		return difficultyRankExpression(config.Order == "desc") + " ASC, rule_reached DESC, time_spent ASC"
+
+	case config.Column == "score":
+		return fmt.Sprintf("%s %s, rule_reached DESC, time_spent ASC", scoreExpression(), strings.ToUpper(config.Order))
 
 	case strings.Contains(config.Column, "created_at"):
 		return fmt.Sprintf("created_at %s, rule_reached DESC, time_spent ASC", strings.ToUpper(config.Order))
@@ -518,6 +1173,7 @@ func executeUserQueryWithParam(query string, param interface{}, limit int) ([]Us
 
 // scanUsers scans database rows into User structs
 func scanUsers(rows *sql.Rows) ([]User, error) {
+	weights := config.ScoreWeights()
 	var users []User
 
 	for rows.Next() {
@@ -530,10 +1186,14 @@ func scanUsers(rows *sql.Rows) ([]User, error) {
 			&user.TimeSpent,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.TimedOut,
+			&user.Completed,
+			&user.CompletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %v", err)
 		}
+		user.Score = scoreWithWeights(user.RuleReached, user.TimeSpent, user.Difficulty, weights)
 		users = append(users, user)
 	}
 
@@ -562,6 +1222,8 @@ func GetUserStats() (map[string]interface{}, error) {
 		stats["highest_rule"] = 0
 		stats["average_time"] = 0.0
 		stats["completion_rates"] = make(map[string]float64)
+		stats["stuck_distribution"] = make(map[int]int)
+		stats["completed_by_difficulty"] = make(map[string]int)
 		return stats, nil
 	}
 
@@ -595,25 +1257,49 @@ func GetUserStats() (map[string]interface{}, error) {
 	}
 	stats["completion_rates"] = completionRates
 
+	// Where players abandon the game
+	stuckDistribution, err := getStuckDistribution()
+	if err != nil {
+		return nil, err
+	}
+	stats["stuck_distribution"] = stuckDistribution
+
+	// How many players finished each difficulty
+	completedByDifficulty, err := getCompletedByDifficulty()
+	if err != nil {
+		return nil, err
+	}
+	stats["completed_by_difficulty"] = completedByDifficulty
+
 	return stats, nil
 }
 
+// getStuckDistribution counts abandoned games by the rule the player was stuck on
+func getStuckDistribution() (map[int]int, error) {
+	rows, err := db.Query("SELECT stuck_rule, COUNT(*) FROM users WHERE stuck_rule > 0 GROUP BY stuck_rule")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stuck distribution: %v", err)
+	}
+	defer rows.Close()
+
+	distribution := make(map[int]int)
+	for rows.Next() {
+		var rule, count int
+		if err := rows.Scan(&rule, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan stuck distribution: %v", err)
+		}
+		distribution[rule] = count
+	}
+	return distribution, rows.Err()
+}
+
 // getUsersByDifficulty gets user count by difficulty
 func getUsersByDifficulty() (map[string]int, error) {
 	diffQuery := `
 		SELECT difficulty, COUNT(*) as count 
 		FROM users 
 		GROUP BY difficulty 
-		ORDER BY 
-			CASE difficulty 
-				WHEN 'basic' THEN 1 
-				WHEN 'intermediate' THEN 2 
-				WHEN 'hard' THEN 3 
-				WHEN 'expert' THEN 4 
-				WHEN 'fun' THEN 5 
-				ELSE 6 
-			END
-	`
+		ORDER BY ` + difficultyRankExpression(false)
 
 	rows, err := db.Query(diffQuery)
 	if err != nil {
@@ -634,6 +1320,30 @@ func getUsersByDifficulty() (map[string]int, error) {
 	return diffStats, nil
 }
 
+// getCompletedByDifficulty counts the players flagged as completed in each difficulty. Played
+// difficulties that have rules are listed even when nobody finished them yet.
+func getCompletedByDifficulty() (map[string]int, error) {
+	rows, err := db.Query("SELECT difficulty, COALESCE(SUM(completed), 0) FROM users GROUP BY difficulty")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completion counts: %v", err)
+	}
+	defer rows.Close()
+
+	completed := make(map[string]int)
+	for rows.Next() {
+		var difficulty string
+		var count int
+		if err := rows.Scan(&difficulty, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan completion counts: %v", err)
+		}
+		if count > 0 || finalRuleFunc(difficulty) > 0 {
+			completed[difficulty] = count
+		}
+	}
+
+	return completed, rows.Err()
+}
+
 // getCompletionRates calculates completion rates for different rule milestones
 func getCompletionRates() (map[string]float64, error) {
 	milestones := []int{5, 10, 15, 20}
@@ -712,7 +1422,7 @@ func GetRecentUsers(limit int) ([]User, error) {
 	}
 
 	query := `
-		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
 		FROM users 
 		ORDER BY created_at DESC
 		LIMIT ?
@@ -721,6 +1431,30 @@ func GetRecentUsers(limit int) ([]User, error) {
 	return executeUserQuery(query, limit)
 }
 
+// GetRecentCompletions returns the players who most recently completed their difficulty, newest
+// first
+func GetRecentCompletions(limit int) ([]User, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, username, difficulty, rule_reached, time_spent, created_at, updated_at, timed_out, completed, completed_at
+		FROM users
+		WHERE completed = 1
+		ORDER BY completed_at DESC, id DESC
+		LIMIT ?
+	`
+	users, err := executeUserQuery(query, limit)
+	if err == nil && users == nil {
+		users = []User{}
+	}
+	return users, err
+}
+
 // HealthCheck performs a basic database health check
 func HealthCheck() error {
 	if db == nil {
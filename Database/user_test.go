@@ -0,0 +1,1036 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"passgame/config"
+)
+
+func TestResetUserProgress(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "replayer", "basic")
+	other := insertTestUser(t, "bystander", "basic")
+	for _, id := range []int64{userID, other} {
+		if err := UpdateUserProgress(id, 6, 120); err != nil {
+			t.Fatalf("UpdateUserProgress(%d) error = %v", id, err)
+		}
+	}
+
+	if err := ResetUserProgress(userID); err != nil {
+		t.Fatalf("ResetUserProgress() error = %v", err)
+	}
+	if user := mustGetUser(t, userID); user.RuleReached != 0 || user.TimeSpent != 0 || user.TimedOut {
+		t.Errorf("after reset got rule %d, time %d, timed out %v, want 0, 0, false", user.RuleReached, user.TimeSpent, user.TimedOut)
+	}
+	if user := mustGetUser(t, other); user.RuleReached != 6 || user.TimeSpent != 120 {
+		t.Errorf("other user changed to rule %d, time %d", user.RuleReached, user.TimeSpent)
+	}
+
+	// The replay records its own progress instead of climbing back to the old best
+	if err := UpdateUserProgress(userID, 2, 15); err != nil {
+		t.Fatalf("UpdateUserProgress() after reset error = %v", err)
+	}
+	if user := mustGetUser(t, userID); user.RuleReached != 2 || user.TimeSpent != 15 {
+		t.Errorf("replay got rule %d, time %d, want 2, 15", user.RuleReached, user.TimeSpent)
+	}
+
+	for _, id := range []int64{0, -1, 9999} {
+		if err := ResetUserProgress(id); err == nil {
+			t.Errorf("ResetUserProgress(%d) = nil, want an error", id)
+		}
+	}
+}
+
+// setCreatedAt backdates when a user joined
+func setCreatedAt(t *testing.T, userID int64, createdAt time.Time) {
+	t.Helper()
+	if _, err := db.Exec("UPDATE users SET created_at = ? WHERE id = ?", createdAt.UTC().Format("2006-01-02 15:04:05"), userID); err != nil {
+		t.Fatalf("failed to set created_at: %v", err)
+	}
+}
+
+func TestGetRecentUsers(t *testing.T) {
+	useEmptyDB(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Inserted out of order so the result can't just follow insertion
+	for _, seed := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{"middle", 2 * time.Hour},
+		{"newest", time.Minute},
+		{"oldest", 48 * time.Hour},
+		{"older", 5 * time.Hour},
+	} {
+		setCreatedAt(t, insertTestUser(t, seed.name, "basic"), base.Add(-seed.age))
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"all", 50, []string{"newest", "middle", "older", "oldest"}},
+		{"limited", 2, []string{"newest", "middle"}},
+		{"zero uses the default", 0, []string{"newest", "middle", "older", "oldest"}},
+		{"over the maximum is clamped", 1000, []string{"newest", "middle", "older", "oldest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := GetRecentUsers(tt.limit)
+			if err != nil {
+				t.Fatalf("GetRecentUsers(%d) error = %v", tt.limit, err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.Username)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRecentUsers(%d) = %v, want %v", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUserProgressMonotonic(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "steady", "basic")
+
+	steps := []struct {
+		name      string
+		rule      int
+		timeSpent int
+		wantRule  int
+		wantTime  int
+	}{
+		{"first progress", 3, 30, 3, 30},
+		{"replayed lower rule", 2, 45, 3, 30},
+		{"duplicate of the same rule", 3, 50, 3, 30},
+		{"better rule", 5, 60, 5, 60},
+		{"stale retry", 4, 70, 5, 60},
+	}
+
+	for _, step := range steps {
+		if err := UpdateUserProgress(userID, step.rule, step.timeSpent); err != nil {
+			t.Fatalf("%s: UpdateUserProgress() error = %v", step.name, err)
+		}
+		if user := mustGetUser(t, userID); user.RuleReached != step.wantRule || user.TimeSpent != step.wantTime {
+			t.Errorf("%s: rule %d after %ds, want rule %d after %ds", step.name, user.RuleReached, user.TimeSpent, step.wantRule, step.wantTime)
+		}
+	}
+}
+
+func TestUpdateUserProgressConcurrent(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "racer", "expert")
+
+	// Each rule is written several times, in an order that mixes higher and lower rules
+	const maxRule = 25
+	var wg sync.WaitGroup
+	errs := make(chan error, maxRule*4)
+	for round := 0; round < 4; round++ {
+		for i := 0; i < maxRule; i++ {
+			rule := (i*7+round)%maxRule + 1
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- UpdateUserProgress(userID, rule, rule*10)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("UpdateUserProgress() error = %v", err)
+		}
+	}
+	if user := mustGetUser(t, userID); user.RuleReached != maxRule || user.TimeSpent != maxRule*10 {
+		t.Errorf("rule %d after %ds, want rule %d after %ds", user.RuleReached, user.TimeSpent, maxRule, maxRule*10)
+	}
+}
+
+func TestUpdateUserProgressCeiling(t *testing.T) {
+	useEmptyDB(t)
+	previous := MaxRuleReached()
+	t.Cleanup(func() { maxRuleReached = previous })
+	SetMaxRuleReached(31)
+	userID := insertTestUser(t, "climber", "expert")
+
+	tests := []struct {
+		name    string
+		rule    int
+		wantErr bool
+	}{
+		{"below the ceiling", 30, false},
+		{"at the ceiling", 31, false},
+		{"past the ceiling", 32, true},
+		{"past the table limit", maxRuleReachedLimit + 1, true},
+		{"negative", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UpdateUserProgress(userID, tt.rule, 60)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateUserProgress(rule %d) error = %v, want error %v", tt.rule, err, tt.wantErr)
+			}
+		})
+	}
+	if user := mustGetUser(t, userID); user.RuleReached != 31 {
+		t.Errorf("RuleReached = %d, want the ceiling 31", user.RuleReached)
+	}
+
+	// Ceilings the table constraint can't hold are ignored
+	for _, ceiling := range []int{0, -5, maxRuleReachedLimit + 1} {
+		SetMaxRuleReached(ceiling)
+		if got := MaxRuleReached(); got != 31 {
+			t.Errorf("SetMaxRuleReached(%d) changed the ceiling to %d", ceiling, got)
+		}
+	}
+}
+
+func TestImportUsersWithReport(t *testing.T) {
+	useEmptyDB(t)
+	insertTestUser(t, "existing", "basic")
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	finished := joined.Add(90 * time.Second)
+
+	result, err := ImportUsersWithReport([]User{
+		{Username: "alice", Difficulty: "basic", RuleReached: 6, TimeSpent: 90, CreatedAt: joined, Completed: true, CompletedAt: &finished},
+		{Username: "Existing", Difficulty: "hard"},
+		{Username: "bob", Difficulty: "Expert", RuleReached: 3},
+		{Username: "ALICE", Difficulty: "basic"},
+		{Username: "  ", Difficulty: "basic"},
+		{Username: "carol", Difficulty: "nightmare"},
+		{Username: "dave", Difficulty: "all"},
+		{Username: "erin", Difficulty: "basic", RuleReached: maxRuleReached + 1},
+		{Username: "frank", Difficulty: "basic", TimeSpent: -1},
+	})
+	if err != nil {
+		t.Fatalf("ImportUsersWithReport() error = %v", err)
+	}
+
+	if result.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2", result.Inserted)
+	}
+	wantSkipped := map[int]string{
+		1: "username already exists",
+		3: "duplicate username in batch",
+		4: "username cannot be empty",
+		5: "invalid difficulty",
+		6: "invalid difficulty",
+		7: "invalid rule reached",
+		8: "invalid time spent",
+	}
+	if len(result.Skipped) != len(wantSkipped) {
+		t.Errorf("skipped %d rows, want %d: %+v", len(result.Skipped), len(wantSkipped), result.Skipped)
+	}
+	for _, skip := range result.Skipped {
+		if want, ok := wantSkipped[skip.Index]; !ok || !strings.HasPrefix(skip.Reason, want) {
+			t.Errorf("row %d skipped with %q, want %q", skip.Index, skip.Reason, want)
+		}
+	}
+
+	alice, err := GetUserByUsername("alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername(alice) error = %v", err)
+	}
+	if alice.RuleReached != 6 || alice.TimeSpent != 90 || !alice.CreatedAt.Equal(joined) {
+		t.Errorf("alice = rule %d, time %d, joined %v", alice.RuleReached, alice.TimeSpent, alice.CreatedAt)
+	}
+	if !alice.Completed || alice.CompletedAt == nil || !alice.CompletedAt.Equal(finished) {
+		t.Errorf("alice completed = %v at %v, want true at %v", alice.Completed, alice.CompletedAt, finished)
+	}
+	if bob, err := GetUserByUsername("bob"); err != nil || bob.Difficulty != "expert" || bob.Completed {
+		t.Errorf("GetUserByUsername(bob) = %+v, %v, want a normalized, unfinished expert user", bob, err)
+	}
+	if count, _ := GetUserCount(); count != 3 {
+		t.Errorf("GetUserCount() = %d, want 3", count)
+	}
+}
+
+// useUnlockOrder enables unlock gating for the test with fixed final rules per difficulty,
+// disabling it again afterwards
+func useUnlockOrder(t *testing.T, order []string, finalRules map[string]int) {
+	t.Helper()
+	previous := finalRuleFunc
+	SetDifficultyUnlockOrder(order)
+	SetFinalRuleFunc(func(difficulty string) int { return finalRules[difficulty] })
+	t.Cleanup(func() {
+		SetDifficultyUnlockOrder(nil)
+		finalRuleFunc = previous
+	})
+}
+
+func TestIsDifficultyUnlocked(t *testing.T) {
+	useEmptyDB(t)
+	useUnlockOrder(t, []string{"basic", "intermediate", "hard"}, map[string]int{"basic": 6, "intermediate": 25, "hard": 31})
+
+	progress := map[string]struct {
+		difficulty string
+		rule       int
+		completed  bool
+	}{
+		"starter":  {"basic", 5, false},
+		"graduate": {"basic", 6, true},
+		// Reached the final rule but the completion was never recorded
+		"unrecorded": {"basic", 6, false},
+		"climber":    {"intermediate", 3, false},
+		"finisher":   {"intermediate", 25, true},
+		"veteran":    {"hard", 0, false},
+		// Finished when basic still had seven rules
+		"overshoot": {"basic", 7, true},
+		// Finished before intermediate gained later rules
+		"earlybird": {"intermediate", 17, true},
+	}
+	for username, p := range progress {
+		userID := insertTestUser(t, username, p.difficulty)
+		if p.completed {
+			setCompleted(t, userID, p.rule)
+		} else if err := UpdateUserProgress(userID, p.rule, 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		username   string
+		difficulty string
+		want       bool
+	}{
+		{"nobody", "basic", true},
+		{"nobody", "intermediate", false},
+		{"nobody", "fun", true},
+		{"starter", "intermediate", false},
+		{"graduate", "intermediate", true},
+		{"graduate", "Intermediate", true},
+		{"graduate", "hard", false},
+		{"unrecorded", "intermediate", false},
+		{"overshoot", "intermediate", true},
+		{"climber", "basic", true},
+		{"climber", "intermediate", true},
+		{"climber", "hard", false},
+		{"finisher", "hard", true},
+		{"earlybird", "hard", true},
+		{"veteran", "intermediate", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username+" "+tt.difficulty, func(t *testing.T) {
+			got, err := IsDifficultyUnlocked(tt.username, tt.difficulty)
+			if err != nil {
+				t.Fatalf("IsDifficultyUnlocked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsDifficultyUnlocked(%s, %s) = %v, want %v", tt.username, tt.difficulty, got, tt.want)
+			}
+		})
+	}
+
+	for difficulty, want := range map[string]string{"basic": "", "intermediate": "basic", "hard": "intermediate", "fun": ""} {
+		if got := DifficultyPrerequisite(difficulty); got != want {
+			t.Errorf("DifficultyPrerequisite(%s) = %q, want %q", difficulty, got, want)
+		}
+	}
+}
+
+func TestIsDifficultyUnlockedWithoutGating(t *testing.T) {
+	useEmptyDB(t)
+	for _, difficulty := range []string{"basic", "intermediate", "hard", "expert"} {
+		if unlocked, err := IsDifficultyUnlocked("nobody", difficulty); err != nil || !unlocked {
+			t.Errorf("IsDifficultyUnlocked(nobody, %s) = %v, %v, want every difficulty open", difficulty, unlocked, err)
+		}
+	}
+}
+
+func TestMigrateUsersTableAddsStuckRule(t *testing.T) {
+	useLegacyDB(t, createUsersTableSQL+`INSERT INTO users (username, difficulty, rule_reached) VALUES ('veteran', 'basic', 3);`)
+	if userColumns(t)["stuck_rule"] {
+		t.Fatal("legacy schema already has stuck_rule")
+	}
+
+	if err := migrateUsersTable(); err != nil {
+		t.Fatalf("migrateUsersTable() error = %v", err)
+	}
+	if !userColumns(t)["stuck_rule"] {
+		t.Fatal("stuck_rule column was not added")
+	}
+
+	var userID int64
+	var stuck int
+	if err := db.QueryRow("SELECT id, stuck_rule FROM users WHERE username = 'veteran'").Scan(&userID, &stuck); err != nil {
+		t.Fatalf("existing user was lost: %v", err)
+	}
+	if stuck != 0 {
+		t.Errorf("existing user stuck_rule = %d, want 0", stuck)
+	}
+	if err := RecordStuckRule(userID, 4); err != nil {
+		t.Errorf("RecordStuckRule() on the migrated table error = %v", err)
+	}
+
+	// A second run finds nothing to do
+	if err := migrateUsersTable(); err != nil {
+		t.Errorf("second migrateUsersTable() error = %v", err)
+	}
+}
+
+func TestMigrateUsersTableBackfillsCompleted(t *testing.T) {
+	// hard ended at rule 17 until rule 28 was appended to it
+	useUnlockOrder(t, nil, map[string]int{"basic": 6, "hard": 28})
+	SetBackfillFinalRuleFunc(func(difficulty string) int { return map[string]int{"basic": 6, "hard": 17}[difficulty] })
+	t.Cleanup(func() { SetBackfillFinalRuleFunc(nil) })
+	useLegacyDB(t, createUsersTableSQL+`
+		INSERT INTO users (username, difficulty, rule_reached) VALUES
+			('graduate', 'basic', 6), ('overshoot', 'basic', 7), ('starter', 'basic', 5),
+			('finisher', 'hard', 17), ('climber', 'hard', 12);`)
+
+	if err := migrateUsersTable(); err != nil {
+		t.Fatalf("migrateUsersTable() error = %v", err)
+	}
+	if columns := userColumns(t); !columns["completed"] || !columns["completed_at"] {
+		t.Fatal("completed columns were not added")
+	}
+
+	for username, want := range map[string]bool{"graduate": true, "overshoot": true, "starter": false, "finisher": true, "climber": false} {
+		user, err := GetUserByUsername(username)
+		if err != nil {
+			t.Fatalf("existing user %s was lost: %v", username, err)
+		}
+		if user.Completed != want || (user.CompletedAt != nil) != want {
+			t.Errorf("%s completed = %v at %v, want %v", username, user.Completed, user.CompletedAt, want)
+		}
+	}
+
+	// A second run finds nothing to do
+	if err := migrateUsersTable(); err != nil {
+		t.Errorf("second migrateUsersTable() error = %v", err)
+	}
+}
+
+// addTestDifficulty configures an extra difficulty in difficulties.json (in the test copy),
+// restoring the file afterwards
+func addTestDifficulty(t *testing.T, key string, order int) {
+	t.Helper()
+	path := config.DifficultiesFile
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := os.WriteFile(path, original, 0644); err != nil {
+			t.Errorf("failed to restore %s: %v", path, err)
+		}
+	})
+
+	var difficulties map[string]map[string]interface{}
+	if err := json.Unmarshal(original, &difficulties); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+	difficulties[key] = map[string]interface{}{"name": "Nightmare", "icon": "💀", "color": "#000000", "order": order}
+	data, err := json.MarshalIndent(difficulties, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestMigrateUsersTableRelaxesDifficultyCheck(t *testing.T) {
+	addTestDifficulty(t, "nightmare", 99)
+	useLegacyDB(t, strings.Replace(createUsersTableSQL,
+		"CHECK(difficulty <> '')",
+		"CHECK(difficulty IN ('basic', 'intermediate', 'hard', 'expert', 'fun'))", 1)+
+		`INSERT INTO users (username, difficulty, rule_reached, time_spent) VALUES ('veteran', 'hard', 9, 300);`)
+
+	if _, err := InsertUser("early_bird", "nightmare"); err == nil {
+		t.Fatal("legacy CHECK accepted a configured difficulty, the test schema is wrong")
+	}
+
+	if err := migrateUsersTable(); err != nil {
+		t.Fatalf("migrateUsersTable() error = %v", err)
+	}
+
+	var tableSQL string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tableSQL); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(tableSQL, "difficulty IN (") {
+		t.Errorf("users table still limits difficulty:\n%s", tableSQL)
+	}
+
+	veteran, err := GetUserByUsername("veteran")
+	if err != nil {
+		t.Fatalf("existing user was lost: %v", err)
+	}
+	if veteran.Difficulty != "hard" || veteran.RuleReached != 9 || veteran.TimeSpent != 300 {
+		t.Errorf("existing user = %+v, want hard at rule 9 after 300s", veteran)
+	}
+
+	userID, err := InsertUser("newcomer", "nightmare")
+	if err != nil {
+		t.Fatalf("InsertUser() with a configured difficulty error = %v", err)
+	}
+	if user := mustGetUser(t, userID); user.Difficulty != "nightmare" {
+		t.Errorf("difficulty = %q, want nightmare", user.Difficulty)
+	}
+
+	// Go still rejects difficulties that aren't configured, and the CHECK rejects empty ones
+	if _, err := InsertUser("lost", "purgatory"); err == nil {
+		t.Error("InsertUser() accepted an unconfigured difficulty")
+	}
+	if _, err := db.Exec("INSERT INTO users (username, difficulty) VALUES ('blank', '')"); err == nil {
+		t.Error("the relaxed CHECK accepted an empty difficulty")
+	}
+
+	// A second run finds nothing to do
+	if err := migrateUsersTable(); err != nil {
+		t.Errorf("second migrateUsersTable() error = %v", err)
+	}
+}
+
+func TestRecordStuckRule(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "quitter", "basic")
+
+	tests := []struct {
+		name    string
+		userID  int64
+		rule    int
+		wantErr bool
+	}{
+		{"valid", userID, 5, false},
+		{"rule zero", userID, 0, true},
+		{"rule above the ceiling", userID, maxRuleReached + 1, true},
+		{"no such user", userID + 1000, 5, true},
+		{"invalid user ID", 0, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RecordStuckRule(tt.userID, tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("RecordStuckRule(%d, %d) error = %v, wantErr %v", tt.userID, tt.rule, err, tt.wantErr)
+			}
+		})
+	}
+
+	var stuck int
+	if err := db.QueryRow("SELECT stuck_rule FROM users WHERE id = ?", userID).Scan(&stuck); err != nil {
+		t.Fatal(err)
+	}
+	if stuck != 5 {
+		t.Errorf("stuck_rule = %d, want 5 after rejected updates", stuck)
+	}
+}
+
+func TestGetUserStatsStuckDistribution(t *testing.T) {
+	useEmptyDB(t)
+
+	stats, err := GetUserStats()
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if got := stats["stuck_distribution"].(map[int]int); len(got) != 0 {
+		t.Errorf("stuck_distribution with no users = %v, want empty", got)
+	}
+
+	// Three abandoned games, two of them on rule 4, and one player still going
+	for i, rule := range []int{4, 4, 7} {
+		userID := insertTestUser(t, "abandoned"+string(rune('a'+i)), "basic")
+		if err := RecordStuckRule(userID, rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insertTestUser(t, "playing", "basic")
+
+	stats, err = GetUserStats()
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	want := map[int]int{4: 2, 7: 1}
+	if got := stats["stuck_distribution"].(map[int]int); !reflect.DeepEqual(got, want) {
+		t.Errorf("stuck_distribution = %v, want %v", got, want)
+	}
+}
+
+// setProgress stores a user's progress directly, bypassing the monotonic update rules
+func setProgress(t *testing.T, userID int64, ruleReached, timeSpent int) {
+	t.Helper()
+	if _, err := db.Exec("UPDATE users SET rule_reached = ?, time_spent = ? WHERE id = ?", ruleReached, timeSpent, userID); err != nil {
+		t.Fatalf("failed to set progress for user %d: %v", userID, err)
+	}
+}
+
+func TestGetRecentCompletions(t *testing.T) {
+	useEmptyDB(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, seed := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{"middle", 2 * time.Hour},
+		{"newest", time.Minute},
+		{"oldest", 48 * time.Hour},
+	} {
+		userID := insertTestUser(t, seed.name, "basic")
+		setCompleted(t, userID, 6)
+		finished := base.Add(-seed.age).Format("2006-01-02 15:04:05")
+		if _, err := db.Exec("UPDATE users SET completed_at = ? WHERE id = ?", finished, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Touched after everyone else but never finished, so it isn't a completion
+	setProgress(t, insertTestUser(t, "playing", "basic"), 6, 30)
+
+	for limit, want := range map[int][]string{
+		50: {"newest", "middle", "oldest"},
+		2:  {"newest", "middle"},
+		0:  {"newest", "middle", "oldest"},
+	} {
+		users, err := GetRecentCompletions(limit)
+		if err != nil {
+			t.Fatalf("GetRecentCompletions(%d) error = %v", limit, err)
+		}
+		var got []string
+		for _, user := range users {
+			got = append(got, user.Username)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetRecentCompletions(%d) = %v, want %v", limit, got, want)
+		}
+	}
+
+	useEmptyDB(t)
+	if users, err := GetRecentCompletions(10); err != nil || users == nil || len(users) != 0 {
+		t.Errorf("GetRecentCompletions() without completions = %v, %v, want an empty slice", users, err)
+	}
+}
+
+// setCompleted records a finished game at finalRule, as the game does on the last rule
+func setCompleted(t *testing.T, userID int64, finalRule int) {
+	t.Helper()
+	if err := RecordCompletion(userID, finalRule, 60); err != nil {
+		t.Fatalf("failed to record completion for user %d: %v", userID, err)
+	}
+}
+
+func TestRecordCompletionMarksCompleted(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "finisher", "basic")
+
+	if err := UpdateUserProgress(userID, 6, 90); err != nil {
+		t.Fatal(err)
+	}
+	if user := mustGetUser(t, userID); user.Completed || user.CompletedAt != nil {
+		t.Fatalf("progress alone marked the user completed at %v", user.CompletedAt)
+	}
+
+	setCompleted(t, userID, 6)
+	user := mustGetUser(t, userID)
+	if !user.Completed || user.CompletedAt == nil {
+		t.Fatalf("after RecordCompletion completed = %v at %v, want a completion time", user.Completed, user.CompletedAt)
+	}
+
+	if err := ResetUserProgress(userID); err != nil {
+		t.Fatal(err)
+	}
+	if user := mustGetUser(t, userID); user.Completed || user.CompletedAt != nil {
+		t.Errorf("after reset completed = %v at %v, want cleared", user.Completed, user.CompletedAt)
+	}
+
+	setCompleted(t, userID, 6)
+	if err := PromoteUser(userID, "intermediate"); err != nil {
+		t.Fatal(err)
+	}
+	if user := mustGetUser(t, userID); user.Completed || user.CompletedAt != nil {
+		t.Errorf("after promotion completed = %v at %v, want cleared", user.Completed, user.CompletedAt)
+	}
+}
+
+func TestGetLeaderboardWindow(t *testing.T) {
+	useEmptyDB(t)
+
+	// Ranked by rule reached, then time: p1 is first, p8 last; "fresh" has no progress
+	ids := make(map[string]int64)
+	for i, name := range []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8"} {
+		ids[name] = insertTestUser(t, name, "basic")
+		setProgress(t, ids[name], 20-i, 100)
+	}
+	ids["fresh"] = insertTestUser(t, "fresh", "basic")
+
+	tests := []struct {
+		name   string
+		user   string
+		radius int
+		want   []string
+	}{
+		{"mid-pack", "p5", 2, []string{"p3", "p4", "p5", "p6", "p7"}},
+		{"top of the board", "p1", 2, []string{"p1", "p2", "p3"}},
+		{"bottom of the board", "p8", 2, []string{"p6", "p7", "p8"}},
+		{"zero radius", "p4", 0, []string{"p4"}},
+		{"negative radius", "p4", -3, []string{"p4"}},
+		{"unranked user", "fresh", 2, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := GetLeaderboardWindow(ids[tt.user], tt.radius)
+			if err != nil {
+				t.Fatalf("GetLeaderboardWindow() error = %v", err)
+			}
+			var got []string
+			for i, user := range users {
+				got = append(got, user.Username)
+				if i > 0 && user.Rank != users[i-1].Rank+1 {
+					t.Errorf("ranks %d and %d aren't consecutive", users[i-1].Rank, user.Rank)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("window = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	users, err := GetLeaderboardWindow(ids["p5"], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users[2].Rank != 5 {
+		t.Errorf("p5 rank = %d, want 5", users[2].Rank)
+	}
+}
+
+func TestGetUserStatsCompletedByDifficulty(t *testing.T) {
+	useEmptyDB(t)
+	useUnlockOrder(t, nil, map[string]int{"basic": 6, "hard": 31, "expert": 29})
+
+	seeds := []struct {
+		difficulty  string
+		ruleReached int
+		completed   bool
+	}{
+		{"basic", 6, true},
+		// Finished when basic still had seven rules
+		{"basic", 7, true},
+		{"basic", 5, false},
+		// Finished when hard ended at rule 17; later rules don't take that away
+		{"hard", 17, true},
+		{"hard", 31, false},
+		{"hard", 0, false},
+		// Its rules were dropped from assignments.json, so it has no final rule
+		{"fun", 40, false},
+	}
+	for i, seed := range seeds {
+		userID := insertTestUser(t, fmt.Sprintf("player%d", i), seed.difficulty)
+		if seed.completed {
+			setCompleted(t, userID, seed.ruleReached)
+		} else {
+			setProgress(t, userID, seed.ruleReached, 60)
+		}
+	}
+
+	stats, err := GetUserStats()
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	want := map[string]int{"basic": 2, "hard": 1}
+	if got := stats["completed_by_difficulty"].(map[string]int); !reflect.DeepEqual(got, want) {
+		t.Errorf("completed_by_difficulty = %v, want %v", got, want)
+	}
+}
+
+func TestDatabasePragmas(t *testing.T) {
+	tests := []struct {
+		pragma string
+		want   string
+	}{
+		{"journal_mode", "wal"},
+		{"busy_timeout", "5000"},
+		{"synchronous", "1"}, // NORMAL
+	}
+	for _, tt := range tests {
+		var got string
+		if err := db.QueryRow("PRAGMA " + tt.pragma).Scan(&got); err != nil {
+			t.Fatalf("PRAGMA %s error = %v", tt.pragma, err)
+		}
+		if got != tt.want {
+			t.Errorf("PRAGMA %s = %s, want %s", tt.pragma, got, tt.want)
+		}
+	}
+}
+
+func TestConcurrentWritersDontLock(t *testing.T) {
+	useEmptyDB(t)
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS seed_words (word TEXT PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DROP TABLE seed_words") })
+
+	// Registrations and progress saves race a table seeding transaction, as they do at startup
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*2+1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tx, err := db.Begin()
+		if err != nil {
+			errs <- err
+			return
+		}
+		for i := 0; i < 200; i++ {
+			if _, err := tx.Exec("INSERT INTO seed_words (word) VALUES (?)", fmt.Sprintf("word%d", i)); err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+		}
+		errs <- tx.Commit()
+	}()
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID, err := InsertUser(fmt.Sprintf("writer%d", i), "basic")
+			if err != nil {
+				errs <- err
+				return
+			}
+			for rule := 1; rule <= 6; rule++ {
+				if err := UpdateUserProgress(userID, rule, rule*10); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent write failed: %v", err)
+		}
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != writers {
+		t.Errorf("%d users registered, want %d", count, writers)
+	}
+}
+
+func TestInsertUserDuplicateUsername(t *testing.T) {
+	useEmptyDB(t)
+	insertTestUser(t, "taken", "basic")
+
+	if _, err := InsertUser("taken", "hard"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("InsertUser() of a taken name error = %v, want ErrUsernameTaken", err)
+	}
+	if _, err := InsertUser("other", "nightmare"); err == nil || errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("InsertUser() with a bad difficulty error = %v, want a different error", err)
+	}
+
+	// Concurrent registrations of one name: exactly one wins, the rest get ErrUsernameTaken
+	const racers = 8
+	var wg sync.WaitGroup
+	errs := make([]error, racers)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = InsertUser("contested", "basic")
+		}(i)
+	}
+	wg.Wait()
+
+	won := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, ErrUsernameTaken):
+			t.Errorf("losing InsertUser() error = %v, want ErrUsernameTaken", err)
+		}
+	}
+	if won != 1 {
+		t.Errorf("%d registrations of the same name succeeded, want 1", won)
+	}
+}
+
+func TestComputeScore(t *testing.T) {
+	tests := []struct {
+		ruleReached int
+		timeSpent   int
+		difficulty  string
+		want        int
+	}{
+		{5, 0, "basic", 500},
+		{5, 0, "intermediate", 750},
+		{5, 0, "hard", 1000},
+		{5, 0, "expert", 1500},
+		{5, 0, "EXPERT", 1500},
+		{5, 0, "unknown", 500},
+		{5, 120, "basic", 488},
+		{5, 129, "basic", 488},
+		{1, 5000, "basic", 0},
+		{0, 0, "expert", 0},
+	}
+	for _, tt := range tests {
+		if got := ComputeScore(tt.ruleReached, tt.timeSpent, tt.difficulty); got != tt.want {
+			t.Errorf("ComputeScore(%d, %d, %q) = %d, want %d", tt.ruleReached, tt.timeSpent, tt.difficulty, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardSortedByScore(t *testing.T) {
+	useEmptyDB(t)
+	players := []struct {
+		username    string
+		difficulty  string
+		ruleReached int
+		timeSpent   int
+	}{
+		{"basic5", "basic", 5, 60},
+		{"expert5", "expert", 5, 60},
+		{"hard5", "hard", 5, 60},
+		{"basic6", "basic", 6, 60},
+		{"slowexpert", "expert", 5, 12000},
+	}
+	for _, p := range players {
+		setProgress(t, insertTestUser(t, p.username, p.difficulty), p.ruleReached, p.timeSpent)
+	}
+
+	users, err := GetLeaderboardSorted(10, "score", "desc")
+	if err != nil {
+		t.Fatalf("GetLeaderboardSorted() error = %v", err)
+	}
+	var order []string
+	for i, user := range users {
+		order = append(order, user.Username)
+		if want := ComputeScore(user.RuleReached, user.TimeSpent, user.Difficulty); user.Score != want {
+			t.Errorf("%s score = %d, want %d", user.Username, user.Score, want)
+		}
+		// The SQL ordering agrees with the Go scores
+		if i > 0 && user.Score > users[i-1].Score {
+			t.Errorf("%s (%d) ranks below %s (%d)", user.Username, user.Score, users[i-1].Username, users[i-1].Score)
+		}
+	}
+	// At equal depth and time, the harder difficulty ranks higher
+	want := []string{"expert5", "hard5", "basic6", "basic5", "slowexpert"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("score order = %v, want %v", order, want)
+	}
+
+	users, err = GetLeaderboardSorted(10, "score", "asc")
+	if err != nil {
+		t.Fatalf("GetLeaderboardSorted() ascending error = %v", err)
+	}
+	if len(users) != len(want) || users[0].Username != "slowexpert" {
+		t.Errorf("ascending score order starts with %v, want slowexpert", users)
+	}
+}
+
+func TestUpdateUserDifficulty(t *testing.T) {
+	useEmptyDB(t)
+	fresh := insertTestUser(t, "undecided", "basic")
+	started := insertTestUser(t, "committed", "basic")
+	setProgress(t, started, 2, 30)
+
+	if err := UpdateUserDifficulty(fresh, " Hard "); err != nil {
+		t.Fatalf("UpdateUserDifficulty() error = %v", err)
+	}
+	if user := mustGetUser(t, fresh); user.Difficulty != "hard" {
+		t.Errorf("difficulty = %s, want hard", user.Difficulty)
+	}
+
+	if err := UpdateUserDifficulty(started, "hard"); !errors.Is(err, ErrProgressRecorded) {
+		t.Errorf("UpdateUserDifficulty() with progress error = %v, want ErrProgressRecorded", err)
+	}
+	if user := mustGetUser(t, started); user.Difficulty != "basic" || user.RuleReached != 2 {
+		t.Errorf("player with progress changed to %s at rule %d", user.Difficulty, user.RuleReached)
+	}
+
+	tests := []struct {
+		name       string
+		userID     int64
+		difficulty string
+	}{
+		{"missing user", 9999, "hard"},
+		{"invalid ID", 0, "hard"},
+		{"unknown difficulty", fresh, "nightmare"},
+		{"all difficulties", fresh, "all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UpdateUserDifficulty(tt.userID, tt.difficulty)
+			if err == nil || errors.Is(err, ErrProgressRecorded) {
+				t.Errorf("UpdateUserDifficulty(%d, %q) error = %v, want a different error", tt.userID, tt.difficulty, err)
+			}
+		})
+	}
+}
+
+func TestDifficultySortFollowsConfiguredOrder(t *testing.T) {
+	useEmptyDB(t)
+	// Order 3 ties with hard, and ties go alphabetically, so nightmare slots in before expert
+	addTestDifficulty(t, "nightmare", 3)
+	for _, difficulty := range []string{"fun", "expert", "nightmare", "hard", "intermediate", "basic"} {
+		insertTestUser(t, difficulty+"_player", difficulty)
+	}
+
+	want := []string{"basic", "intermediate", "hard", "nightmare", "expert", "fun"}
+	if got := getDynamicDifficulties(); !reflect.DeepEqual(got, want) {
+		t.Errorf("getDynamicDifficulties() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"asc", want},
+		{"desc", []string{"fun", "expert", "nightmare", "hard", "intermediate", "basic"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			users, err := GetLeaderboardSorted(10, "difficulty", tt.order)
+			if err != nil {
+				t.Fatalf("GetLeaderboardSorted() error = %v", err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.Difficulty)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sorted difficulties = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Difficulties that are no longer configured sort last in both directions
+	if _, err := db.Exec("INSERT INTO users (username, difficulty) VALUES ('retired_player', 'retired')"); err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range []string{"asc", "desc"} {
+		users, err := GetLeaderboardSorted(10, "difficulty", order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last := users[len(users)-1].Difficulty; last != "retired" {
+			t.Errorf("%s: last difficulty = %s, want the unconfigured one", order, last)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package component
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	database "passgame/Database"
+	"passgame/rules"
+)
+
+// AdminSessionInfo is the sanitized view of a session exposed to operators
+type AdminSessionInfo struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	Difficulty string    `json:"difficulty"`
+	Kind       string    `json:"kind"` // player, practice or test
+	MaxRule    int       `json:"max_rule"`
+	StartTime  time.Time `json:"start_time"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// sessionPublicID derives a stable, non-secret identifier from a session cookie value
+func sessionPublicID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeJSONError writes a JSON error body with the given status code
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// isAdminRequest reports whether the request carries the configured admin credential,
+// either as X-Admin-Token, an Authorization bearer token, or basic-auth
+func isAdminRequest(r *http.Request) bool {
+	if Config.AdminToken == "" {
+		return false
+	}
+
+	token := r.Header.Get("X-Admin-Token")
+	if token == "" {
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		} else if username, password, ok := r.BasicAuth(); ok {
+			if subtle.ConstantTimeCompare([]byte(username), []byte(Config.AdminUsername)) != 1 {
+				return false
+			}
+			token = password
+		}
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(Config.AdminToken)) == 1
+}
+
+// RequireAdmin rejects requests that don't carry the configured admin credential
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if Config.AdminToken == "" {
+			writeJSONError(w, http.StatusForbidden, "Admin API is disabled")
+			return
+		}
+
+		if !isAdminRequest(r) {
+			// Let browsers prompt for credentials on the admin page
+			w.Header().Set("WWW-Authenticate", `Basic realm="passgame admin"`)
+			writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// RequireAdminForWrites guards mutating requests, leaving GET/HEAD public when
+// Config.PublicReadOnlyAPI is enabled
+func RequireAdminForWrites(next http.HandlerFunc) http.HandlerFunc {
+	guarded := RequireAdmin(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if Config.PublicReadOnlyAPI && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			next(w, r)
+			return
+		}
+		guarded(w, r)
+	}
+}
+
+// ListSessions returns a sanitized snapshot of all active sessions, most recently seen first
+func ListSessions() []AdminSessionInfo {
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+
+	sessions := make([]AdminSessionInfo, 0, len(UserSessions))
+	for sessionID, session := range UserSessions {
+		sessions = append(sessions, AdminSessionInfo{
+			ID:         sessionPublicID(sessionID),
+			Username:   session.Username,
+			Difficulty: session.Difficulty,
+			Kind:       SessionKind(session),
+			MaxRule:    session.MaxRule,
+			StartTime:  session.StartTime,
+			LastSeen:   session.LastSeen,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions
+}
+
+// EvictSession removes the session with the given public ID, reporting whether one was found
+func EvictSession(publicID string) bool {
+	sessionsMutex.RLock()
+	var match string
+	for sessionID := range UserSessions {
+		if sessionPublicID(sessionID) == publicID {
+			match = sessionID
+			break
+		}
+	}
+	sessionsMutex.RUnlock()
+
+	if match == "" {
+		return false
+	}
+	AbandonSession(match)
+	return true
+}
+
+// recentCompletionsLimit is how many completions the admin overview lists
+const recentCompletionsLimit = 10
+
+// AdminOverview is the data feed for the admin dashboard
+type AdminOverview struct {
+	TotalUsers        int                                `json:"total_users"`
+	ActiveSessions    int                                `json:"active_sessions"`
+	SessionsByKind    map[string]int                     `json:"sessions_by_kind"`
+	RecentCompletions []database.User                    `json:"recent_completions"`
+	ExternalAPIs      map[string]rules.ExternalAPIHealth `json:"external_apis"`
+	Challenges        map[string]string                  `json:"challenges"`
+	GeneratedAt       time.Time                          `json:"generated_at"`
+}
+
+// BuildAdminOverview gathers the dashboard data. Database failures are logged and leave their
+// fields empty so the rest of the panel still renders.
+func BuildAdminOverview() AdminOverview {
+	overview := AdminOverview{
+		SessionsByKind: make(map[string]int),
+		ExternalAPIs:   rules.ExternalAPIStatus(),
+		Challenges:     rules.DebugAnswers(),
+		GeneratedAt:    time.Now(),
+	}
+
+	sessions := ListSessions()
+	overview.ActiveSessions = len(sessions)
+	for _, session := range sessions {
+		overview.SessionsByKind[session.Kind]++
+	}
+
+	if count, err := database.GetUserCount(); err != nil {
+		log.Printf("Error getting user count for admin overview: %v", err)
+	} else {
+		overview.TotalUsers = count
+	}
+
+	completions, err := database.GetRecentCompletions(recentCompletionsLimit)
+	if err != nil {
+		log.Printf("Error getting recent completions for admin overview: %v", err)
+	}
+	if completions == nil {
+		completions = []database.User{}
+	}
+	overview.RecentCompletions = completions
+
+	return overview
+}
+
+// HandleAdminOverview serves GET /api/admin/overview
+func HandleAdminOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BuildAdminOverview())
+}
+
+// HandleAdminSessions lists the active sessions, optionally only those of one ?kind=
+func HandleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessions := ListSessions()
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		filtered := make([]AdminSessionInfo, 0, len(sessions))
+		for _, session := range sessions {
+			if session.Kind == kind {
+				filtered = append(filtered, session)
+			}
+		}
+		sessions = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// HandleAdminEvictSession forcibly removes an active session by its public ID
+func HandleAdminEvictSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.ID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Missing session id")
+		return
+	}
+
+	if !EvictSession(request.ID) {
+		writeJSONError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "evicted",
+		"id":     request.ID,
+	})
+}
+
+// maxImportUsers bounds a single import request
+const maxImportUsers = 1000
+
+// HandleAdminImport bulk-inserts users from a JSON array, e.g. to seed a demo leaderboard
+func HandleAdminImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var users []database.User
+	if err := json.NewDecoder(r.Body).Decode(&users); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON: expected an array of users")
+		return
+	}
+	if len(users) > maxImportUsers {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Too many users (max %d per import)", maxImportUsers))
+		return
+	}
+
+	result, err := database.ImportUsersWithReport(users)
+	if err != nil {
+		log.Printf("Error importing users: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Import failed, no users were added")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RefreshResult is the outcome of refreshing one dynamic challenge
+type RefreshResult struct {
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// challengeRefresher rolls one dynamic challenge and returns its new value
+type challengeRefresher struct {
+	key     string
+	refresh func(ctx context.Context) (string, error)
+}
+
+// challengeRefreshers are the subsystems RefreshAllChallenges rolls, in order
+var challengeRefreshers = []challengeRefresher{
+	{"captcha", func(ctx context.Context) (string, error) {
+		return rules.GenerateNewCaptcha(), nil
+	}},
+	{"qr_word", func(ctx context.Context) (string, error) {
+		if _, err := rules.RefreshQRCodeWithAPI(ctx); err != nil {
+			return "", err
+		}
+		return rules.GetCurrentQRWord(), nil
+	}},
+	{"color", func(ctx context.Context) (string, error) {
+		if err := rules.RefreshColor(); err != nil {
+			return "", err
+		}
+		_, hexCode := rules.GetCurrentColor()
+		return hexCode, nil
+	}},
+	{"constant", func(ctx context.Context) (string, error) {
+		if err := rules.RefreshMathConstant(); err != nil {
+			return "", err
+		}
+		name, _ := rules.GetCurrentMathConstant()
+		return name, nil
+	}},
+	{"chess", rules.GenerateNewChessPosition},
+}
+
+// RefreshAllChallenges rolls every dynamic challenge value, keyed by subsystem.
+// A failure in one subsystem doesn't stop the others.
+func RefreshAllChallenges(ctx context.Context) map[string]RefreshResult {
+	results := make(map[string]RefreshResult)
+	for _, refresher := range challengeRefreshers {
+		if value, err := refresher.refresh(ctx); err != nil {
+			results[refresher.key] = RefreshResult{Error: err.Error()}
+		} else {
+			results[refresher.key] = RefreshResult{Value: value}
+		}
+	}
+	return results
+}
+
+// HandleAdminRefreshAll rolls a new captcha, QR word, color, constant and chess position at once
+func HandleAdminRefreshAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	results := RefreshAllChallenges(r.Context())
+
+	failed := 0
+	for subsystem, result := range results {
+		if result.Error != "" {
+			failed++
+			log.Printf("⚠️ Admin refresh-all: %s failed: %s", subsystem, result.Error)
+		}
+	}
+
+	status := "refreshed"
+	if failed == len(results) {
+		status = "failed"
+	} else if failed > 0 {
+		status = "partial"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"results": results,
+	})
+}
@@ -0,0 +1,475 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+	"passgame/rules"
+)
+
+const testAdminToken = "test-admin-token"
+
+// useAdminToken enables the admin API with testAdminToken for the test
+func useAdminToken(t *testing.T) {
+	t.Helper()
+	useConfig(t)
+	Config.AdminToken = testAdminToken
+}
+
+// adminRequest builds a request carrying the test admin token
+func adminRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("X-Admin-Token", testAdminToken)
+	return r
+}
+
+// seedAdminSessions stores one session of each kind and returns their cookie values by kind
+func seedAdminSessions(t *testing.T) map[string]string {
+	t.Helper()
+	useSessions(t)
+
+	seeds := map[string]*UserSession{
+		SessionKindPlayer:   {UserID: 7, Username: "alice", Difficulty: "basic"},
+		SessionKindPractice: {Username: "bob", Difficulty: "hard", Practice: true},
+		SessionKindTest:     {UserID: -1, Username: "Test User", Difficulty: "expert"},
+	}
+	ids := make(map[string]string)
+	for kind, session := range seeds {
+		session.StartTime = time.Now()
+		id := "session-" + kind
+		storeSession(id, session)
+		ids[kind] = id
+	}
+	return ids
+}
+
+func TestHandleAdminSessionsListing(t *testing.T) {
+	useAdminToken(t)
+	ids := seedAdminSessions(t)
+	handler := RequireAdmin(HandleAdminSessions)
+
+	tests := []struct {
+		name      string
+		target    string
+		wantKinds []string
+	}{
+		{"all sessions", "/api/admin/sessions", []string{SessionKindPlayer, SessionKindPractice, SessionKindTest}},
+		{"players only", "/api/admin/sessions?kind=player", []string{SessionKindPlayer}},
+		{"test sessions only", "/api/admin/sessions?kind=test", []string{SessionKindTest}},
+		{"unknown kind", "/api/admin/sessions?kind=robot", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, adminRequest(http.MethodGet, tt.target, ""))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var sessions []AdminSessionInfo
+			if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(sessions) != len(tt.wantKinds) {
+				t.Fatalf("got %d sessions, want %d", len(sessions), len(tt.wantKinds))
+			}
+
+			want := make(map[string]bool)
+			for _, kind := range tt.wantKinds {
+				want[sessionPublicID(ids[kind])] = true
+			}
+			for _, session := range sessions {
+				if !want[session.ID] {
+					t.Errorf("unexpected session %+v", session)
+				}
+				if strings.HasPrefix(session.ID, "session-") {
+					t.Errorf("session %q exposes its cookie value", session.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleAdminEvictSession(t *testing.T) {
+	useAdminToken(t)
+	ids := seedAdminSessions(t)
+	handler := RequireAdmin(HandleAdminEvictSession)
+	practiceID := sessionPublicID(ids[SessionKindPractice])
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"evicts an active session", `{"id":"` + practiceID + `"}`, http.StatusOK},
+		{"already evicted", `{"id":"` + practiceID + `"}`, http.StatusNotFound},
+		{"unknown id", `{"id":"0000000000000000"}`, http.StatusNotFound},
+		{"missing id", `{}`, http.StatusBadRequest},
+		{"invalid JSON", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, adminRequest(http.MethodPost, "/api/admin/sessions/evict", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	if _, exists := GetSession(ids[SessionKindPractice]); exists {
+		t.Error("evicted session is still stored")
+	}
+	for _, kind := range []string{SessionKindPlayer, SessionKindTest} {
+		if _, exists := GetSession(ids[kind]); !exists {
+			t.Errorf("%s session was evicted too", kind)
+		}
+	}
+}
+
+func TestAdminSessionEndpointsRejectMissingToken(t *testing.T) {
+	useConfig(t)
+	seedAdminSessions(t)
+
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+		wantStatus int
+	}{
+		{"admin API disabled", "", testAdminToken, http.StatusForbidden},
+		{"no token", testAdminToken, "", http.StatusUnauthorized},
+		{"wrong token", testAdminToken, "guess", http.StatusUnauthorized},
+	}
+
+	endpoints := []struct {
+		method  string
+		target  string
+		handler http.HandlerFunc
+	}{
+		{http.MethodGet, "/api/admin/sessions", HandleAdminSessions},
+		{http.MethodPost, "/api/admin/sessions/evict", HandleAdminEvictSession},
+	}
+
+	for _, tt := range tests {
+		for _, endpoint := range endpoints {
+			t.Run(tt.name+" "+endpoint.target, func(t *testing.T) {
+				Config.AdminToken = tt.adminToken
+				r := httptest.NewRequest(endpoint.method, endpoint.target, strings.NewReader(`{"id":"x"}`))
+				if tt.header != "" {
+					r.Header.Set("X-Admin-Token", tt.header)
+				}
+
+				w := httptest.NewRecorder()
+				RequireAdmin(endpoint.handler)(w, r)
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+			})
+		}
+	}
+
+	if sessions := ListSessions(); len(sessions) != 3 {
+		t.Errorf("rejected requests changed the sessions: %d left, want 3", len(sessions))
+	}
+}
+
+func TestRequireAdminMutations(t *testing.T) {
+	useConfig(t)
+	Config.AdminToken = testAdminToken
+	Config.AdminUsername = "admin"
+
+	tests := []struct {
+		name       string
+		authorize  func(r *http.Request)
+		wantStatus int
+	}{
+		{"admin token header", func(r *http.Request) { r.Header.Set("X-Admin-Token", testAdminToken) }, http.StatusOK},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+testAdminToken) }, http.StatusOK},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("admin", testAdminToken) }, http.StatusOK},
+		{"basic auth with the wrong username", func(r *http.Request) { r.SetBasicAuth("root", testAdminToken) }, http.StatusUnauthorized},
+		{"wrong token", func(r *http.Request) { r.Header.Set("X-Admin-Token", "nope") }, http.StatusUnauthorized},
+		{"wrong bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := RequireAdmin(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/api/admin/import", strings.NewReader("[]"))
+			tt.authorize(r)
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler called = %v, want %v", called, !called)
+			}
+		})
+	}
+}
+
+func TestRequireAdminForWrites(t *testing.T) {
+	useConfig(t)
+	Config.AdminToken = testAdminToken
+
+	tests := []struct {
+		name       string
+		publicRead bool
+		method     string
+		token      string
+		wantCalled bool
+	}{
+		{"public read allowed", true, http.MethodGet, "", true},
+		{"public HEAD allowed", true, http.MethodHead, "", true},
+		{"unauthorized write rejected", true, http.MethodPost, "", false},
+		{"unauthorized delete rejected", true, http.MethodDelete, "", false},
+		{"authorized write allowed", true, http.MethodPost, testAdminToken, true},
+		{"reads need the token when not public", false, http.MethodGet, "", false},
+		{"authorized read when not public", false, http.MethodGet, testAdminToken, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Config.PublicReadOnlyAPI = tt.publicRead
+			called := false
+			handler := RequireAdminForWrites(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})
+
+			r := httptest.NewRequest(tt.method, "/api/assignments", nil)
+			if tt.token != "" {
+				r.Header.Set("X-Admin-Token", tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v (status %d)", called, tt.wantCalled, w.Code)
+			}
+			if !tt.wantCalled && w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestHandleAdminImport(t *testing.T) {
+	useAdminToken(t)
+	useEmptyDB(t)
+	insertTestUser(t, "existing", "basic")
+	handler := RequireAdmin(HandleAdminImport)
+
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantInserted int
+		wantSkipped  int
+	}{
+		{"mixed batch", `[{"username":"demo1","difficulty":"basic","rule_reached":4},{"username":"existing","difficulty":"basic"},{"username":"demo2","difficulty":"nope"}]`, http.StatusOK, 1, 2},
+		{"empty batch", `[]`, http.StatusOK, 0, 0},
+		{"not an array", `{"username":"demo3"}`, http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, adminRequest(http.MethodPost, "/api/admin/import", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result database.ImportResult
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Inserted != tt.wantInserted || len(result.Skipped) != tt.wantSkipped {
+				t.Errorf("inserted %d, skipped %d, want %d and %d", result.Inserted, len(result.Skipped), tt.wantInserted, tt.wantSkipped)
+			}
+		})
+	}
+
+	if count, _ := database.GetUserCount(); count != 2 {
+		t.Errorf("GetUserCount() = %d, want 2", count)
+	}
+}
+
+// refreshAll posts /api/admin/refresh-all with the admin token and decodes the response
+func refreshAll(t *testing.T) (string, map[string]RefreshResult) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	RequireAdmin(HandleAdminRefreshAll)(w, adminRequest(http.MethodPost, "/api/admin/refresh-all", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Status  string                   `json:"status"`
+		Results map[string]RefreshResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return response.Status, response.Results
+}
+
+func TestHandleAdminRefreshAll(t *testing.T) {
+	useAdminToken(t)
+
+	status, results := refreshAll(t)
+	if status != "refreshed" {
+		t.Errorf("status = %q, want refreshed", status)
+	}
+	want := map[string]RefreshResult{
+		"captcha":  {Value: rules.GetCurrentCaptchaID()},
+		"qr_word":  {Value: rules.TestQRWord},
+		"color":    {Value: rules.TestColorHex},
+		"constant": {Value: rules.TestConstantName},
+		"chess":    {Value: rules.TestChessMove},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+}
+
+func TestHandleAdminRefreshAllReportsFailures(t *testing.T) {
+	useAdminToken(t)
+	previous := challengeRefreshers
+	t.Cleanup(func() { challengeRefreshers = previous })
+
+	var called []string
+	failing := map[string]bool{}
+	challengeRefreshers = nil
+	for _, refresher := range previous {
+		key := refresher.key
+		challengeRefreshers = append(challengeRefreshers, challengeRefresher{key, func(ctx context.Context) (string, error) {
+			called = append(called, key)
+			if failing[key] {
+				return "", errors.New(key + " is down")
+			}
+			return "new " + key, nil
+		}})
+	}
+
+	failing["qr_word"], failing["chess"] = true, true
+	status, results := refreshAll(t)
+	if status != "partial" {
+		t.Errorf("status = %q, want partial", status)
+	}
+	if want := []string{"captcha", "qr_word", "color", "constant", "chess"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("refreshed %v, want every subsystem %v", called, want)
+	}
+	for _, key := range []string{"captcha", "qr_word", "color", "constant", "chess"} {
+		want := RefreshResult{Value: "new " + key}
+		if failing[key] {
+			want = RefreshResult{Error: key + " is down"}
+		}
+		if results[key] != want {
+			t.Errorf("%s = %+v, want %+v", key, results[key], want)
+		}
+	}
+
+	for _, refresher := range previous {
+		failing[refresher.key] = true
+	}
+	if status, _ := refreshAll(t); status != "failed" {
+		t.Errorf("status with every subsystem down = %q, want failed", status)
+	}
+}
+
+func TestHandleAdminOverview(t *testing.T) {
+	useAdminToken(t)
+	useEmptyDB(t)
+	seedAdminSessions(t)
+	RefreshAllChallenges(context.Background())
+
+	finisher := insertTestUser(t, "finisher", "basic")
+	straggler := insertTestUser(t, "straggler", "basic")
+	if err := database.RecordCompletion(finisher, rules.GetFinalRuleID("basic"), 90); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateUserProgress(straggler, 2, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	RequireAdmin(HandleAdminOverview)(w, adminRequest(http.MethodGet, "/api/admin/overview", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var overview AdminOverview
+	if err := json.NewDecoder(w.Body).Decode(&overview); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if overview.TotalUsers != 2 {
+		t.Errorf("TotalUsers = %d, want 2", overview.TotalUsers)
+	}
+	wantKinds := map[string]int{SessionKindPlayer: 1, SessionKindPractice: 1, SessionKindTest: 1}
+	if overview.ActiveSessions != 3 || !reflect.DeepEqual(overview.SessionsByKind, wantKinds) {
+		t.Errorf("sessions = %d %v, want 3 %v", overview.ActiveSessions, overview.SessionsByKind, wantKinds)
+	}
+	if len(overview.RecentCompletions) != 1 || overview.RecentCompletions[0].Username != "finisher" {
+		t.Errorf("RecentCompletions = %+v, want only finisher", overview.RecentCompletions)
+	}
+	if want := rules.DebugAnswers(); !reflect.DeepEqual(overview.Challenges, want) {
+		t.Errorf("Challenges = %v, want %v", overview.Challenges, want)
+	}
+	if overview.Challenges["qr_word"] != rules.TestQRWord || overview.Challenges["wordle"] != rules.TestWordleAnswer {
+		t.Errorf("Challenges = %v, want the test mode values", overview.Challenges)
+	}
+	if want := rules.ExternalAPIStatus(); len(overview.ExternalAPIs) != len(want) {
+		t.Errorf("ExternalAPIs = %v, want %v", overview.ExternalAPIs, want)
+	}
+	if time.Since(overview.GeneratedAt) > time.Minute {
+		t.Errorf("GeneratedAt = %v, want now", overview.GeneratedAt)
+	}
+
+	// The overview exposes the answers, so it is admin-only
+	w = httptest.NewRecorder()
+	RequireAdmin(HandleAdminOverview)(w, httptest.NewRequest(http.MethodGet, "/api/admin/overview", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleAdminOverviewEmpty(t *testing.T) {
+	useAdminToken(t)
+	useEmptyDB(t)
+	useSessions(t)
+
+	w := httptest.NewRecorder()
+	RequireAdmin(HandleAdminOverview)(w, adminRequest(http.MethodGet, "/api/admin/overview", ""))
+	body := w.Body.String()
+	var overview AdminOverview
+	if err := json.Unmarshal([]byte(body), &overview); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if overview.TotalUsers != 0 || overview.ActiveSessions != 0 {
+		t.Errorf("overview = %+v, want no users or sessions", overview)
+	}
+	// Empty lists are sent as [] so the dashboard can iterate them
+	if !strings.Contains(body, `"recent_completions":[]`) {
+		t.Errorf("body %s doesn't have an empty recent_completions list", body)
+	}
+}
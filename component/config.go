@@ -2,99 +2,254 @@ package component
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"os"
 	"strings"
+	"time"
+
+	"passgame/config"
+	"passgame/rules"
 )
 
 // AppConfig holds the application configuration
 type AppConfig struct {
-	// ShowHints controls whether to display rule hints to the user
+	// ShowHints controls whether to display rule hints to the user. A difficulty's show_hints
+	// in difficulties.json overrides it.
 	ShowHints bool `json:"showHints"`
+	// AdminToken protects the admin API; admin endpoints are disabled when empty
+	AdminToken string `json:"adminToken"`
+	// AdminUsername is the basic-auth username accepted alongside AdminToken
+	AdminUsername string `json:"adminUsername"`
+	// PublicReadOnlyAPI keeps GET requests to admin-backed APIs (rule pool, assignments) public
+	PublicReadOnlyAPI bool `json:"publicReadOnlyAPI"`
+	// ValidateRatePerSecond and ValidateBurst bound how often a client may call /validate
+	ValidateRatePerSecond float64 `json:"validateRatePerSecond"`
+	ValidateBurst         int     `json:"validateBurst"`
+	// WordleTimezone is the IANA timezone used to pick the day's Wordle puzzle (default UTC)
+	WordleTimezone string `json:"wordleTimezone"`
+	// CompletionWebhookURL receives a POST whenever a player completes a game; disabled when empty
+	CompletionWebhookURL string `json:"completionWebhookURL"`
+	// RevealMode is "sequential" (default, each rule appears once the previous is satisfied) or "all"
+	RevealMode string `json:"revealMode"`
+	// LeaderboardSize is how many players /leaderboard lists when ?top= isn't given (default 20,
+	// at most 100)
+	LeaderboardSize int `json:"leaderboardSize"`
+	// FatalFailureMode is what happens when a player loses, e.g. more black squares than Rule 24
+	// tolerates: "reset" (default) restarts from rule 1, "fail" ends the run at the rule reached
+	// until the player resets. Running out of time always ends the run.
+	FatalFailureMode string `json:"fatalFailureMode"`
+	// StrictAssignments stops the server at startup when assignments.json references rule IDs
+	// missing from the pool, instead of only logging them
+	StrictAssignments bool `json:"strictAssignments"`
+	// DifficultyUnlockOrder lists difficulties from easiest to hardest; when set, each one must be
+	// completed before the next can be picked. Empty disables gating.
+	DifficultyUnlockOrder []string `json:"difficultyUnlockOrder"`
+	// Locales lists the languages whose day and month names Rules 7 and 10 accept, e.g.
+	// ["en", "es"]; English is always accepted. Players see hints in their browser's language
+	// when it's one of these.
+	Locales []string `json:"locales"`
+	// QRLanguage picks the QR code word list from rules/words/<lang>.txt (default "en")
+	QRLanguage string `json:"qrLanguage"`
+	// QRRefreshInterval and ConstantRefreshInterval are durations such as "10m" or "6h" between
+	// rotations of the QR word and of the math constant/color. Set them very large to freeze the
+	// values, e.g. for a tournament. Empty keeps the defaults.
+	QRRefreshInterval       string `json:"qrRefreshInterval"`
+	ConstantRefreshInterval string `json:"constantRefreshInterval"`
+	// ValidatorTiming records how long each rule's validator takes, exposed at /metrics
+	ValidatorTiming bool `json:"validatorTiming"`
+	// RomanNumeralMinimum makes Rule 5 require a valid Roman numeral of at least this value
+	// (e.g. 10 for "X"); 0 accepts any numeral character
+	RomanNumeralMinimum int `json:"romanNumeralMinimum"`
+	// ImposterCount is how many imposter characters Rule 25 plants (default 3, capped by the
+	// number of non-space characters in the password)
+	ImposterCount int `json:"imposterCount"`
+	// BlackSquareMinimum is how many black squares Rule 24 injects before deleting them all
+	// satisfies it (default 2)
+	BlackSquareMinimum int `json:"blackSquareMinimum"`
+	// MonotonicRunLength and MonotonicRunDirection configure Rule 28: how many digits in a row
+	// (default 3) must be "increasing" (default), "decreasing" or "either"
+	MonotonicRunLength    int    `json:"monotonicRunLength"`
+	MonotonicRunDirection string `json:"monotonicRunDirection"`
+	// PrimeMin and PrimeMax bound the primes that satisfy Rule 6 (default 2-47)
+	PrimeMin int `json:"primeMin"`
+	PrimeMax int `json:"primeMax"`
+	// CaptchaCharset is "digits" (default) or "alphanumeric" for Rule 15's captcha
+	CaptchaCharset string `json:"captchaCharset"`
+	// StrengthEmoji and StrengthCount theme Rule 20 (default 3 × 🏋️)
+	StrengthEmoji string `json:"strengthEmoji"`
+	StrengthCount int    `json:"strengthCount"`
+	// VictoryRedirect sends players to the server-rendered /victory page when they complete a game
+	// instead of leaving the celebration to the client
+	VictoryRedirect bool `json:"victoryRedirect"`
+	// AssetRoot is the directory /style.css, /flip-animations.js and /admin are served from
+	// (default "Frontend")
+	AssetRoot string `json:"assetRoot"`
+	// CookieSecure marks the session cookie Secure so it's only sent over HTTPS. Enable it
+	// whenever the game is served behind TLS.
+	CookieSecure bool `json:"cookieSecure"`
+	// CookieSameSite is "lax" (default), "strict" or "none"; "none" needs CookieSecure
+	CookieSameSite string `json:"cookieSameSite"`
+	// ProgressSaveInterval is a duration such as "2s"; each session's progress is written to the
+	// database at most this often, and always on completion. Empty writes every new rule.
+	ProgressSaveInterval string `json:"progressSaveInterval"`
+}
+
+// ParseRefreshInterval parses a configured refresh interval. An empty value returns 0,
+// meaning the default interval.
+func ParseRefreshInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh interval %q: %v", value, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("refresh interval must be positive, got %s", value)
+	}
+	return interval, nil
 }
 
 // Config holds the global application configuration
 var Config = AppConfig{
-	ShowHints: true, // Default to showing hints
+	ShowHints:         true, // Default to showing hints
+	AdminUsername:     "admin",
+	PublicReadOnlyAPI: true,
+	// Generous enough for fast typing, which fires a request per keystroke
+	ValidateRatePerSecond: 20,
+	ValidateBurst:         40,
+	ImposterCount:         rules.DefaultImposterCount,
+	LeaderboardSize:       DefaultLeaderboardSize,
+	BlackSquareMinimum:    rules.DefaultBlackSquareMinimum,
+	StrengthEmoji:         rules.DefaultStrengthEmoji,
+	StrengthCount:         rules.DefaultStrengthRequiredCount,
+	AssetRoot:             DefaultAssetRoot,
+	CookieSameSite:        "lax",
+	FatalFailureMode:      FailureModeReset,
 }
 
-// DifficultyConfig represents the configuration for a difficulty level
-type DifficultyConfig struct {
-	Name        string `json:"name"`
-	Icon        string `json:"icon"`
-	Color       string `json:"color"`
-	Description string `json:"description"`
-}
+// LoadConfig loads config/app.json (if present) over the defaults and applies
+// environment overrides (PASSGAME_ADMIN_TOKEN, PASSGAME_ADMIN_USER, PASSGAME_COMPLETION_WEBHOOK)
+func LoadConfig() error {
+	data, err := ioutil.ReadFile("config/app.json")
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Error reading app.json: %v", err)
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &Config); err != nil {
+			log.Printf("Error parsing app.json: %v", err)
+			return err
+		}
+	}
 
-// ValidateDifficulty checks if the given difficulty is valid according to the loaded configuration
-func ValidateDifficulty(difficulty string) bool {
-	if difficulty == "all" {
-		return true
+	if token := os.Getenv("PASSGAME_ADMIN_TOKEN"); token != "" {
+		Config.AdminToken = token
+	}
+	if username := os.Getenv("PASSGAME_ADMIN_USER"); username != "" {
+		Config.AdminUsername = username
+	}
+	if webhookURL := os.Getenv("PASSGAME_COMPLETION_WEBHOOK"); webhookURL != "" {
+		Config.CompletionWebhookURL = webhookURL
 	}
 
-	diffs, err := LoadDifficulties()
-	if err != nil {
-		return false
+	if cookieSameSite() == http.SameSiteNoneMode && !Config.CookieSecure {
+		log.Printf("Warning: cookieSameSite \"none\" without cookieSecure, browsers will reject the session cookie")
 	}
 
-	// Check against loaded difficulties (case-insensitive)
-	for k := range diffs {
-		if strings.EqualFold(difficulty, k) {
-			return true
+	Config.FatalFailureMode = strings.ToLower(strings.TrimSpace(Config.FatalFailureMode))
+	if Config.FatalFailureMode != FailureModeFail && Config.FatalFailureMode != FailureModeReset {
+		if Config.FatalFailureMode != "" {
+			log.Printf("Warning: unknown fatalFailureMode %q, using %s", Config.FatalFailureMode, FailureModeReset)
 		}
+		Config.FatalFailureMode = FailureModeReset
+	}
+
+	return nil
+}
+
+// cookieSameSite maps Config.CookieSameSite to its http.SameSite mode, defaulting to Lax
+func cookieSameSite() http.SameSite {
+	switch strings.ToLower(Config.CookieSameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "", "lax":
+		return http.SameSiteLaxMode
+	default:
+		log.Printf("Warning: unknown cookieSameSite %q, using lax", Config.CookieSameSite)
+		return http.SameSiteLaxMode
+	}
+}
+
+// SessionCookie builds the user_session cookie with the configured Secure and SameSite
+// attributes. A negative maxAge deletes the cookie.
+func SessionCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     "user_session",
+		Value:    value,
+		HttpOnly: true,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   Config.CookieSecure,
+		SameSite: cookieSameSite(),
 	}
-	return false
 }
 
-// LoadDifficulties loads difficulty configurations from JSON file
-func LoadDifficulties() (map[string]DifficultyConfig, error) {
-	data, err := ioutil.ReadFile("config/difficulties.json")
+// lookupDifficulty returns the difficulties.json entry for a difficulty, matched
+// case-insensitively. ok is false when the file can't be loaded or has no such entry.
+func lookupDifficulty(difficulty string) (config.DifficultyConfig, bool) {
+	diffs, err := config.LoadDifficulties()
 	if err != nil {
-		log.Printf("Error reading difficulties.json: %v", err)
-		return getDefaultDifficulties(), err
+		return config.DifficultyConfig{}, false
+	}
+	for k, diff := range diffs {
+		if strings.EqualFold(difficulty, k) {
+			return diff, true
+		}
 	}
+	return config.DifficultyConfig{}, false
+}
+
+// GetAntiPasteConfig returns the anti-paste settings for a difficulty, or nil when it isn't enabled
+func GetAntiPasteConfig(difficulty string) *config.AntiPasteConfig {
+	diff, _ := lookupDifficulty(difficulty)
+	return diff.AntiPaste
+}
 
-	var difficulties map[string]DifficultyConfig
-	if err := json.Unmarshal(data, &difficulties); err != nil {
-		log.Printf("Error parsing difficulties.json: %v", err)
-		return getDefaultDifficulties(), err
+// GetDigitSumTarget returns the configured digit-sum target for a difficulty, or 0 when none is set
+func GetDigitSumTarget(difficulty string) int {
+	diff, _ := lookupDifficulty(difficulty)
+	return diff.DigitSumTarget
+}
+
+// GetTimeLimit returns the time budget for a difficulty, or 0 when it is untimed
+func GetTimeLimit(difficulty string) time.Duration {
+	if diff, ok := lookupDifficulty(difficulty); ok && diff.TimeLimitSeconds > 0 {
+		return time.Duration(diff.TimeLimitSeconds) * time.Second
 	}
+	return 0
+}
 
-	return difficulties, nil
+// ShowHintsFor reports whether rule hints are shown on a difficulty: its show_hints setting in
+// difficulties.json when present, the global ShowHints otherwise
+func ShowHintsFor(difficulty string) bool {
+	if diff, ok := lookupDifficulty(difficulty); ok && diff.ShowHints != nil {
+		return *diff.ShowHints
+	}
+	return Config.ShowHints
 }
 
-// getDefaultDifficulties returns hardcoded default difficulties as fallback
-func getDefaultDifficulties() map[string]DifficultyConfig {
-	return map[string]DifficultyConfig{
-		"basic": {
-			Name:        "Basic",
-			Icon:        "🟢",
-			Color:       "#4CAF50",
-			Description: "Standard rules",
-		},
-		"intermediate": {
-			Name:        "Intermediate",
-			Icon:        "🟡",
-			Color:       "#FF9800",
-			Description: "More challenging",
-		},
-		"hard": {
-			Name:        "Hard",
-			Icon:        "🔴",
-			Color:       "#F44336",
-			Description: "Expert level",
-		},
-		"expert": {
-			Name:        "Expert",
-			Icon:        "🟣",
-			Color:       "#9C27B0",
-			Description: "Master level",
-		},
-		"fun": {
-			Name:        "Fun",
-			Icon:        "🎉",
-			Color:       "#E91E63",
-			Description: "Quirky rules",
-		},
+// LoadDifficultiesWithRuleCounts loads difficulty configurations and fills in how many rules each one has
+func LoadDifficultiesWithRuleCounts() (map[string]config.DifficultyConfig, error) {
+	difficulties, err := config.LoadDifficulties()
+	for key, diff := range difficulties {
+		diff.RuleCount = rules.GetRuleCount(key)
+		difficulties[key] = diff
 	}
+	return difficulties, err
 }
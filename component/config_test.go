@@ -0,0 +1,266 @@
+package component
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+	"passgame/config"
+)
+
+func TestParseRefreshInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"10m", 10 * time.Minute, false},
+		{"6h", 6 * time.Hour, false},
+		{"8760h", 8760 * time.Hour, false},
+		{"1ms", time.Millisecond, false},
+		{"0s", 0, true},
+		{"-5m", 0, true},
+		{"ten minutes", 0, true},
+		{"10", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRefreshInterval(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRefreshInterval(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRefreshInterval(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLookupDifficulty(t *testing.T) {
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["hard"]["time_limit_seconds"] = 90
+	})
+
+	for _, name := range []string{"hard", "Hard", "HARD"} {
+		diff, ok := lookupDifficulty(name)
+		if !ok || diff.TimeLimitSeconds != 90 {
+			t.Errorf("lookupDifficulty(%q) = %+v, %v, want the hard entry", name, diff, ok)
+		}
+	}
+	if diff, ok := lookupDifficulty("nightmare"); ok || diff.AntiPaste != nil || diff.ShowHints != nil {
+		t.Errorf("lookupDifficulty(nightmare) = %+v, %v, want a zero config and false", diff, ok)
+	}
+}
+
+func TestGetDigitSumTarget(t *testing.T) {
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["hard"]["digit_sum_target"] = 30
+		difficulties["intermediate"]["digit_sum_target"] = 25
+		delete(difficulties["basic"], "digit_sum_target")
+	})
+
+	tests := []struct {
+		difficulty string
+		want       int
+	}{
+		{"hard", 30},
+		{"HARD", 30},
+		{"intermediate", 25},
+		{"basic", 0},
+		{"nightmare", 0},
+	}
+
+	for _, tt := range tests {
+		if got := GetDigitSumTarget(tt.difficulty); got != tt.want {
+			t.Errorf("GetDigitSumTarget(%q) = %d, want %d", tt.difficulty, got, tt.want)
+		}
+	}
+}
+
+func TestDifficultiesSharedByDatabaseAndComponent(t *testing.T) {
+	useEmptyDB(t)
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["nightmare"] = map[string]interface{}{
+			"name": "Nightmare", "icon": "💀", "color": "#111111", "description": "Added at runtime", "order": 99,
+		}
+		delete(difficulties, "fun")
+	})
+
+	// The database side accepts exactly the configured difficulties
+	if _, err := database.InsertUser("dreamer", "nightmare"); err != nil {
+		t.Errorf("database rejected the configured nightmare difficulty: %v", err)
+	}
+	if _, err := database.InsertUser("joker", "fun"); err == nil {
+		t.Error("database accepted the removed fun difficulty")
+	}
+
+	// And the component side sees the same set
+	if got := getDifficultyIcon("nightmare"); got != "💀" {
+		t.Errorf("getDifficultyIcon(nightmare) = %q, want 💀", got)
+	}
+	withCounts, err := LoadDifficultiesWithRuleCounts()
+	if err != nil {
+		t.Fatalf("LoadDifficultiesWithRuleCounts() error = %v", err)
+	}
+	if _, ok := withCounts["nightmare"]; !ok {
+		t.Error("component difficulties are missing nightmare")
+	}
+	if _, ok := withCounts["fun"]; ok {
+		t.Error("component difficulties still include fun")
+	}
+
+	order := config.DifficultyOrder()
+	if len(order) != len(withCounts) || order[len(order)-1] != "nightmare" {
+		t.Errorf("DifficultyOrder() = %v, want the %d component difficulties ending with nightmare", order, len(withCounts))
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	useConfig(t)
+
+	tests := []struct {
+		secure       bool
+		sameSite     string
+		wantSameSite http.SameSite
+	}{
+		{false, "", http.SameSiteLaxMode},
+		{false, "lax", http.SameSiteLaxMode},
+		{true, "Strict", http.SameSiteStrictMode},
+		{true, "none", http.SameSiteNoneMode},
+		{false, "sideways", http.SameSiteLaxMode},
+	}
+
+	for _, tt := range tests {
+		Config.CookieSecure, Config.CookieSameSite = tt.secure, tt.sameSite
+		for _, maxAge := range []int{3600, -1} {
+			cookie := SessionCookie("abc", maxAge)
+			if cookie.Name != "user_session" || cookie.Value != "abc" || cookie.MaxAge != maxAge {
+				t.Errorf("SessionCookie(abc, %d) = %s", maxAge, cookie)
+			}
+			if !cookie.HttpOnly || cookie.Path != "/" {
+				t.Errorf("SessionCookie(abc, %d) = %s, want HttpOnly for /", maxAge, cookie)
+			}
+			if cookie.Secure != tt.secure || cookie.SameSite != tt.wantSameSite {
+				t.Errorf("secure %v, sameSite %q: cookie = %s, want Secure %v and SameSite %v",
+					tt.secure, tt.sameSite, cookie, tt.secure, tt.wantSameSite)
+			}
+		}
+	}
+}
+
+func TestSessionCookieCallSites(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	useConfig(t)
+	Config.CookieSecure, Config.CookieSameSite = true, "strict"
+
+	practice := httptest.NewRequest(http.MethodPost, "/practice", strings.NewReader(url.Values{"username": {"trainee"}, "difficulty": {"basic"}}.Encode()))
+	practice.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	handlers := map[string]func() *httptest.ResponseRecorder{
+		"registration": func() *httptest.ResponseRecorder {
+			return registerRequest("cookie_user", "basic", "")
+		},
+		"test session": func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			HandlePasswordGame(w, httptest.NewRequest(http.MethodGet, "/?test_session=true&difficulty=basic", nil))
+			return w
+		},
+		"practice": func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			HandleStartPractice(w, practice)
+			return w
+		},
+	}
+
+	for name, handler := range handlers {
+		var cookie *http.Cookie
+		for _, c := range handler().Result().Cookies() {
+			if c.Name == "user_session" {
+				cookie = c
+			}
+		}
+		if cookie == nil {
+			t.Errorf("%s set no session cookie", name)
+			continue
+		}
+		if !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || !cookie.HttpOnly {
+			t.Errorf("%s cookie = %s, want Secure, HttpOnly and SameSite=Strict", name, cookie)
+		}
+	}
+}
+
+func TestShowHintsFor(t *testing.T) {
+	useConfig(t)
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["basic"]["show_hints"] = true
+		difficulties["hard"]["show_hints"] = false
+	})
+
+	for _, global := range []bool{true, false} {
+		Config.ShowHints = global
+		tests := []struct {
+			difficulty string
+			want       bool
+		}{
+			{"basic", true},
+			{"Basic", true},
+			{"hard", false},
+			{"expert", global}, // unset, so the global setting applies
+			{"nightmare", global},
+		}
+		for _, tt := range tests {
+			if got := ShowHintsFor(tt.difficulty); got != tt.want {
+				t.Errorf("global %v: ShowHintsFor(%q) = %v, want %v", global, tt.difficulty, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestHandleValidateHintsPerDifficulty(t *testing.T) {
+	useConfig(t)
+	Config.ShowHints = false
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["basic"]["show_hints"] = true
+		difficulties["hard"]["show_hints"] = false
+	})
+
+	tests := []struct {
+		difficulty string
+		wantHints  bool
+	}{
+		{"basic", true},
+		{"hard", false},
+		{"expert", false}, // falls back to the global setting
+	}
+	for _, tt := range tests {
+		t.Run(tt.difficulty, func(t *testing.T) {
+			sessionID := useTestSession(t, tt.difficulty)
+
+			// Rule 1 is shown and unsatisfied, so its hint renders when hints are on
+			body := validateRequest(sessionID, "abc", nil).Body.String()
+			if got := strings.Contains(body, `class="rule-hint"`); got != tt.wantHints {
+				t.Errorf("validate renders hints = %v, want %v", got, tt.wantHints)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+			w := httptest.NewRecorder()
+			HandlePasswordGame(w, r)
+			// display.html has a placeholder hint of its own, so count the rendered ones
+			hints := strings.Count(w.Body.String(), `<div class="rule-hint">`) - strings.Count(w.Body.String(), "Try adding more characters")
+			if got := hints > 0; got != tt.wantHints {
+				t.Errorf("page renders %d hints, want hints %v", hints, tt.wantHints)
+			}
+
+			if state := getGameState(t, sessionID, "?hints=1"); state.HintsEnabled != tt.wantHints {
+				t.Errorf("game state HintsEnabled = %v, want %v", state.HintsEnabled, tt.wantHints)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"passgame/rules"
+)
+
+// DebugEnv registers the debug endpoints when set to exactly "1"
+const DebugEnv = "PASSGAME_DEBUG"
+
+// HandleDebugAnswers serves GET /api/debug/answers with the solution of every dynamic rule.
+// main only registers it when PASSGAME_DEBUG=1, so in production the route doesn't exist. The
+// update string is the one of the caller's game.
+func HandleDebugAnswers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	answers := rules.DebugAnswers()
+	if session := getUserSession(r); session != nil {
+		answers["update_string"] = CyberSecurityFor(session).UpdateString()
+	}
+	json.NewEncoder(w).Encode(answers)
+}
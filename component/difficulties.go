@@ -0,0 +1,152 @@
+package component
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	database "passgame/Database"
+	"passgame/config"
+	"passgame/rules"
+)
+
+// difficultyPreviewLength is how many of a difficulty's first rules are included as a preview
+const difficultyPreviewLength = 3
+
+// DifficultyDetails is everything the difficulty picker shows for one difficulty
+type DifficultyDetails struct {
+	config.DifficultyConfig
+	// Preview is the first few rules of the difficulty, in play order
+	Preview []rules.RulePreview `json:"preview"`
+	// Unlocked is false only when unlock gating is on and the player hasn't completed Prerequisite
+	Unlocked     bool   `json:"unlocked"`
+	Prerequisite string `json:"prerequisite,omitempty"`
+}
+
+// BuildDifficultyDetails combines difficulties.json, the rule counts and previews from
+// assignments.json, and the unlock state for username ("" for an unknown player)
+func BuildDifficultyDetails(username string) (map[string]DifficultyDetails, error) {
+	difficulties, err := LoadDifficultiesWithRuleCounts()
+
+	details := make(map[string]DifficultyDetails, len(difficulties))
+	for key, diff := range difficulties {
+		preview := rules.PreviewRules(key)
+		if len(preview) > difficultyPreviewLength {
+			preview = preview[:difficultyPreviewLength]
+		}
+
+		unlocked := database.DifficultyPrerequisite(key) == ""
+		if !unlocked && username != "" {
+			var unlockErr error
+			unlocked, unlockErr = database.IsDifficultyUnlocked(username, key)
+			if unlockErr != nil {
+				log.Printf("Error checking difficulty unlock for %s: %v", username, unlockErr)
+			}
+		}
+
+		details[key] = DifficultyDetails{
+			DifficultyConfig: diff,
+			Preview:          preview,
+			Unlocked:         unlocked,
+			Prerequisite:     database.DifficultyPrerequisite(key),
+		}
+	}
+	return details, err
+}
+
+// HandleDifficultiesFull serves GET /api/difficulties/full. Unlock state is for the player of the
+// current session, or for ?username= before they've registered.
+func HandleDifficultiesFull(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if session := getUserSession(r); session != nil {
+		username = session.Username
+	}
+
+	details, err := BuildDifficultyDetails(username)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not load difficulties")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// HandleChangeDifficulty serves POST /api/game/change-difficulty. A player who picked the wrong
+// difficulty can switch until their first rule is recorded; after that the run stays on the
+// leaderboard under the difficulty it was played on.
+func HandleChangeDifficulty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		writeJSONError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+
+	difficulty := strings.ToLower(strings.TrimSpace(r.FormValue("difficulty")))
+	if difficulty == "" || difficulty == "all" || !config.ValidateDifficulty(difficulty) {
+		writeJSONError(w, http.StatusBadRequest, "Invalid difficulty")
+		return
+	}
+
+	sessionsMutex.RLock()
+	current := session.Difficulty
+	hasProgress := session.MaxRule > 0 || session.PendingRule > 0 || session.IsCompleted
+	sessionsMutex.RUnlock()
+
+	if hasProgress {
+		writeJSONError(w, http.StatusConflict, "Difficulty can't be changed once progress is recorded")
+		return
+	}
+
+	if difficulty != current && HasDatabaseUser(session) {
+		unlocked, err := database.IsDifficultyUnlocked(session.Username, difficulty)
+		if err != nil {
+			log.Printf("Error checking difficulty unlock: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to change difficulty")
+			return
+		}
+		if !unlocked {
+			writeJSONError(w, http.StatusForbidden, fmt.Sprintf("%s is locked. Complete %s first to unlock it.",
+				difficulty, database.DifficultyPrerequisite(difficulty)))
+			return
+		}
+
+		err = database.UpdateUserDifficulty(session.UserID, difficulty)
+		if errors.Is(err, database.ErrProgressRecorded) {
+			writeJSONError(w, http.StatusConflict, "Difficulty can't be changed once progress is recorded")
+			return
+		}
+		if err != nil {
+			log.Printf("Error changing difficulty for %s: %v", session.Username, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to change difficulty")
+			return
+		}
+	}
+
+	// The rule set is rebuilt from the session's difficulty on the next request
+	sessionsMutex.Lock()
+	session.Difficulty = difficulty
+	sessionsMutex.Unlock()
+	ResetSessionProgress(session)
+
+	log.Printf("🔀 %s changed difficulty from %s to %s", session.Username, current, difficulty)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "changed",
+		"difficulty": difficulty,
+	})
+}
@@ -0,0 +1,232 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	database "passgame/Database"
+	"passgame/config"
+	"passgame/rules"
+)
+
+// getDifficultiesFull requests /api/difficulties/full with the query and decodes the response
+func getDifficultiesFull(t *testing.T, query string) map[string]DifficultyDetails {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/difficulties/full"+query, nil)
+	w := httptest.NewRecorder()
+	HandleDifficultiesFull(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var details map[string]DifficultyDetails
+	if err := json.NewDecoder(w.Body).Decode(&details); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return details
+}
+
+func TestHandleDifficultiesFull(t *testing.T) {
+	difficulties, err := config.LoadDifficulties()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details := getDifficultiesFull(t, "")
+	if len(details) != len(difficulties) {
+		t.Fatalf("got %d difficulties, want the %d in difficulties.json", len(details), len(difficulties))
+	}
+	for key, diff := range difficulties {
+		got, ok := details[key]
+		if !ok {
+			t.Errorf("%s is missing", key)
+			continue
+		}
+		if got.Name != diff.Name || got.Icon != diff.Icon || got.Color != diff.Color {
+			t.Errorf("%s metadata = %s %s %s, want %s %s %s", key, got.Name, got.Icon, got.Color, diff.Name, diff.Icon, diff.Color)
+		}
+		if got.RuleCount != rules.GetRuleCount(key) {
+			t.Errorf("%s rule count = %d, want %d", key, got.RuleCount, rules.GetRuleCount(key))
+		}
+
+		// The preview is the first rules in play order
+		all := rules.PreviewRules(key)
+		if len(got.Preview) != min(difficultyPreviewLength, len(all)) {
+			t.Fatalf("%s preview has %d rules, want %d", key, len(got.Preview), min(difficultyPreviewLength, len(all)))
+		}
+		for i, rule := range got.Preview {
+			if rule.ID != all[i].ID || rule.Description == "" {
+				t.Errorf("%s preview rule %d = %+v, want rule %d", key, i, rule, all[i].ID)
+			}
+		}
+
+		// Without gating everything is open
+		if !got.Unlocked || got.Prerequisite != "" {
+			t.Errorf("%s unlocked = %v with prerequisite %q, want unlocked", key, got.Unlocked, got.Prerequisite)
+		}
+	}
+}
+
+func TestHandleDifficultiesFullUnlockGating(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	database.SetDifficultyUnlockOrder([]string{"basic", "intermediate", "hard"})
+	t.Cleanup(func() { database.SetDifficultyUnlockOrder(nil) })
+	userID := insertTestUser(t, "climber", "basic")
+
+	tests := []struct {
+		name       string
+		query      string
+		difficulty string
+		unlocked   bool
+		prereq     string
+	}{
+		{"first tier", "", "basic", true, ""},
+		{"ungated difficulty", "", "fun", true, ""},
+		{"unknown player", "", "intermediate", false, "basic"},
+		{"before completing basic", "?username=climber", "intermediate", false, "basic"},
+		{"skipping a tier", "?username=climber", "hard", false, "intermediate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getDifficultiesFull(t, tt.query)[tt.difficulty]
+			if got.Unlocked != tt.unlocked || got.Prerequisite != tt.prereq {
+				t.Errorf("%s unlocked = %v with prerequisite %q, want %v with %q", tt.difficulty, got.Unlocked, got.Prerequisite, tt.unlocked, tt.prereq)
+			}
+		})
+	}
+
+	if err := database.RecordCompletion(userID, rules.GetFinalRuleID("basic"), 120); err != nil {
+		t.Fatal(err)
+	}
+
+	// The session's player wins over ?username=
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{UserID: userID, Username: "climber", Difficulty: "basic"})
+	r := httptest.NewRequest(http.MethodGet, "/api/difficulties/full?username=nobody", nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleDifficultiesFull(w, r)
+
+	var details map[string]DifficultyDetails
+	if err := json.NewDecoder(w.Body).Decode(&details); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !details["intermediate"].Unlocked {
+		t.Error("intermediate still locked after completing basic")
+	}
+	if details["hard"].Unlocked {
+		t.Error("hard unlocked before completing intermediate")
+	}
+}
+
+// changeDifficultyRequest posts the difficulty to /api/game/change-difficulty for the session
+func changeDifficultyRequest(sessionID, difficulty string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/game/change-difficulty", strings.NewReader(url.Values{"difficulty": {difficulty}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if sessionID != "" {
+		r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	}
+	w := httptest.NewRecorder()
+	HandleChangeDifficulty(w, r)
+	return w
+}
+
+func TestHandleChangeDifficulty(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	userID := insertTestUser(t, "switcher", "basic")
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{UserID: userID, Username: "switcher", Difficulty: "basic", LastPasswordLength: 4})
+
+	w := changeDifficultyRequest(sessionID, "Hard")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["status"] != "changed" || body["difficulty"] != "hard" {
+		t.Errorf("response = %v, want changed to hard", body)
+	}
+	session, _ := GetSession(sessionID)
+	if session.Difficulty != "hard" || session.LastPasswordLength != 0 {
+		t.Errorf("session = %+v, want hard with its progress reset", session)
+	}
+	if user, err := database.GetUser(userID); err != nil || user.Difficulty != "hard" {
+		t.Errorf("database row = %+v, %v, want hard", user, err)
+	}
+
+	// A test session without a database row switches too
+	testSession := useTestSession(t, "basic")
+	if w := changeDifficultyRequest(testSession, "fun"); w.Code != http.StatusOK {
+		t.Errorf("test session status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	if session, _ := GetSession(testSession); session.Difficulty != "fun" {
+		t.Errorf("test session difficulty = %s, want fun", session.Difficulty)
+	}
+}
+
+func TestHandleChangeDifficultyRejected(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	database.SetDifficultyUnlockOrder([]string{"basic", "intermediate", "hard"})
+	t.Cleanup(func() { database.SetDifficultyUnlockOrder(nil) })
+
+	fresh := insertTestUser(t, "fresh", "basic")
+	recorded := insertTestUser(t, "recorded", "basic")
+	if err := database.UpdateUserProgress(recorded, 2, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		session    *UserSession
+		difficulty string
+		want       int
+	}{
+		{"reached a rule", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic", MaxRule: 3}, "fun", http.StatusConflict},
+		{"rule pending", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic", PendingRule: 2}, "fun", http.StatusConflict},
+		{"completed", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic", IsCompleted: true}, "fun", http.StatusConflict},
+		// The session is fresh but an earlier session recorded progress in the database
+		{"progress in the database", &UserSession{UserID: recorded, Username: "recorded", Difficulty: "basic"}, "fun", http.StatusConflict},
+		{"locked difficulty", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic"}, "hard", http.StatusForbidden},
+		{"unknown difficulty", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic"}, "nightmare", http.StatusBadRequest},
+		{"all difficulties", &UserSession{UserID: fresh, Username: "fresh", Difficulty: "basic"}, "all", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionID := "session-" + t.Name()
+			storeSession(sessionID, tt.session)
+
+			w := changeDifficultyRequest(sessionID, tt.difficulty)
+			if w.Code != tt.want {
+				t.Errorf("status = %d %q, want %d", w.Code, w.Body.String(), tt.want)
+			}
+			if session, _ := GetSession(sessionID); session.Difficulty != "basic" {
+				t.Errorf("session difficulty changed to %s", session.Difficulty)
+			}
+			if user, err := database.GetUser(tt.session.UserID); err != nil || user.Difficulty != "basic" {
+				t.Errorf("database row = %+v, %v, want basic", user, err)
+			}
+		})
+	}
+
+	if w := changeDifficultyRequest("", "fun"); w.Code != http.StatusUnauthorized {
+		t.Errorf("without a session status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/game/change-difficulty", nil)
+	w := httptest.NewRecorder()
+	HandleChangeDifficulty(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
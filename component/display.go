@@ -1,21 +1,29 @@
 package component
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	database "passgame/Database"
+	"passgame/config"
 	"passgame/rules" // Unified rules package
 )
 
 // Template functions
 var funcMap = template.FuncMap{
+	"difficultyOrder": config.DifficultyOrder,
 	"add": func(a, b int) int {
 		return a + b
 	},
@@ -24,11 +32,22 @@ var funcMap = template.FuncMap{
 	},
 }
 
-// Global template variable - parse all templates at startup
-var tmpl = template.Must(template.New("").Funcs(funcMap).ParseFiles(
-	"Frontend/display.html",
-	"Frontend/user-modal.html",
-))
+// Global template variable, parsed by LoadTemplates at startup
+var tmpl *template.Template
+
+// LoadTemplates parses the page templates. main calls it before serving; they're read relative
+// to the working directory, so tests call it once they've moved to a copy of the assets.
+func LoadTemplates() error {
+	parsed, err := template.New("").Funcs(funcMap).ParseFiles(
+		"Frontend/display.html",
+		"Frontend/user-modal.html",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to parse templates: %v", err)
+	}
+	tmpl = parsed
+	return nil
+}
 
 type PageData struct {
 	Password string
@@ -52,10 +71,208 @@ type UserSession struct {
 	StartTime   time.Time `json:"start_time"`
 	MaxRule     int       `json:"max_rule"`
 	IsCompleted bool      `json:"is_completed"`
+	LastSeen    time.Time `json:"last_seen"`
+	// Practice marks a game started from /api/game/practice; it has no database row (UserID 0)
+	Practice bool `json:"practice"`
+	// Locale is the language the day and month hints are shown in, see requestLocale
+	Locale string `json:"locale"`
+	// RuleIDs pins the rules of the current run, see sessionRuleSet
+	RuleIDs []int `json:"-"`
+	// Last validated rule states keyed by rule ID, used to restore the game after a reload.
+	// The password itself is never stored.
+	SatisfiedStates map[string]bool `json:"-"`
+	VisibleStates   map[string]bool `json:"-"`
+	// Paste detection for difficulties with anti-paste enabled. A paste stays flagged
+	// until the password is cleared.
+	LastPasswordLength int  `json:"-"`
+	PasteDetected      bool `json:"-"`
+	// TimedOut is set once a run on a timed difficulty has used up its time limit
+	TimedOut bool `json:"timed_out"`
+	// Failure is set once the run is lost; see FailSession
+	Failure GameFailure `json:"failure"`
+	// Transcript is the validation history for /api/game/transcript, oldest first
+	Transcript []TranscriptEntry `json:"-"`
+	// Progress reached but not yet written to the database; see flushProgress
+	PendingRule      int       `json:"-"`
+	PendingTimeSpent int       `json:"-"`
+	LastProgressSave time.Time `json:"-"`
+	// lastValidation answers a repeated, unchanged password without re-running the validators
+	lastValidation *validationResult
+	// cyberSecurity is the state of this run's cybersecurity rules, see CyberSecurityFor
+	cyberSecurity *rules.CyberSecurityRules
 }
 
 // Global session storage (in production, use Redis or similar)
-var UserSessions = make(map[string]*UserSession)
+var (
+	UserSessions  = make(map[string]*UserSession)
+	sessionsMutex sync.RWMutex
+)
+
+// storeSession registers a session under its cookie value
+func storeSession(sessionID string, session *UserSession) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	session.LastSeen = time.Now()
+	UserSessions[sessionID] = session
+}
+
+// GetSession returns the session stored under the given cookie value
+func GetSession(sessionID string) (*UserSession, bool) {
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+	session, exists := UserSessions[sessionID]
+	return session, exists
+}
+
+// DeleteSession removes the session stored under the given cookie value
+func DeleteSession(sessionID string) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	delete(UserSessions, sessionID)
+}
+
+// sessionIdleTimeout matches the session cookie lifetime; idler sessions count as abandoned
+const sessionIdleTimeout = 24 * time.Hour
+
+// stuckRuleLocked returns the lowest visible but unsatisfied rule in the session's saved
+// states, or 0 if there is none. Callers must hold sessionsMutex.
+func stuckRuleLocked(session *UserSession) int {
+	stuck := 0
+	for key, visible := range session.VisibleStates {
+		if !visible || session.SatisfiedStates[key] {
+			continue
+		}
+		if id, err := strconv.Atoi(key); err == nil && (stuck == 0 || id < stuck) {
+			stuck = id
+		}
+	}
+	return stuck
+}
+
+// recordAbandonment stores the rule a registered player was stuck on when they left an
+// unfinished game
+func recordAbandonment(userID int64, stuckRule int) {
+	if userID <= 0 || stuckRule == 0 {
+		return
+	}
+	if err := database.RecordStuckRule(userID, stuckRule); err != nil {
+		log.Printf("Error recording stuck rule: %v", err)
+	}
+}
+
+// AbandonSession removes a session the player walked away from, recording where they got stuck
+func AbandonSession(sessionID string) {
+	sessionsMutex.Lock()
+	session, exists := UserSessions[sessionID]
+	var userID int64
+	var stuck int
+	if exists {
+		if !session.IsCompleted {
+			userID, stuck = session.UserID, stuckRuleLocked(session)
+		}
+		delete(UserSessions, sessionID)
+	}
+	sessionsMutex.Unlock()
+
+	if exists && HasDatabaseUser(session) {
+		flushProgress(session, true)
+	}
+	recordAbandonment(userID, stuck)
+}
+
+// progressSaveInterval is the minimum time between progress writes for one session (0 writes every time)
+var progressSaveInterval time.Duration
+
+// SetProgressSaveInterval throttles how often a session's progress is written to the database
+func SetProgressSaveInterval(interval time.Duration) {
+	progressSaveInterval = interval
+}
+
+// recordProgress queues a session's new best rule and writes it unless the session was saved
+// less than progressSaveInterval ago. The session's MaxRule is kept current by the caller.
+func recordProgress(session *UserSession, rule, timeSpent int) {
+	sessionsMutex.Lock()
+	session.PendingRule, session.PendingTimeSpent = rule, timeSpent
+	sessionsMutex.Unlock()
+
+	flushProgress(session, false)
+}
+
+// flushProgress writes the session's pending progress once the save interval has passed, or
+// straight away with force
+func flushProgress(session *UserSession, force bool) {
+	sessionsMutex.Lock()
+	rule, timeSpent := session.PendingRule, session.PendingTimeSpent
+	due := rule > 0 && (force || progressSaveInterval <= 0 || time.Since(session.LastProgressSave) >= progressSaveInterval)
+	if due {
+		session.PendingRule, session.PendingTimeSpent = 0, 0
+		session.LastProgressSave = time.Now()
+	}
+	sessionsMutex.Unlock()
+
+	if !due {
+		return
+	}
+
+	if err := database.UpdateUserProgress(session.UserID, rule, timeSpent); err != nil {
+		log.Printf("Error updating user progress for rule %d: %v", rule, err)
+		return
+	}
+	log.Printf("📈 Database updated for user %s: Rule %d satisfied in %ds", session.Username, rule, timeSpent)
+}
+
+// FlushAllProgress writes every session's pending progress, e.g. before shutting down
+func FlushAllProgress() {
+	sessionsMutex.RLock()
+	sessions := make([]*UserSession, 0, len(UserSessions))
+	for _, session := range UserSessions {
+		sessions = append(sessions, session)
+	}
+	sessionsMutex.RUnlock()
+
+	for _, session := range sessions {
+		if HasDatabaseUser(session) {
+			flushProgress(session, true)
+		}
+	}
+}
+
+// StartSessionCleanupLoop periodically abandons sessions idle for longer than sessionIdleTimeout
+// until ctx is cancelled
+func StartSessionCleanupLoop(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cleanupIdleSessions(time.Now().Add(-sessionIdleTimeout))
+			}
+		}
+	}()
+}
+
+// cleanupIdleSessions abandons every session last seen before cutoff
+func cleanupIdleSessions(cutoff time.Time) {
+	sessionsMutex.RLock()
+	var idle []string
+	for sessionID, session := range UserSessions {
+		if session.LastSeen.Before(cutoff) {
+			idle = append(idle, sessionID)
+		}
+	}
+	sessionsMutex.RUnlock()
+
+	for _, sessionID := range idle {
+		AbandonSession(sessionID)
+	}
+	if len(idle) > 0 {
+		log.Printf("🧹 Removed %d idle sessions", len(idle))
+	}
+}
 
 const rulesPartialTemplate = `{{range $index, $rule := .SortedRules}}
 <div class="rule-item {{if .IsSatisfied}}satisfied{{end}} {{if .NewlyRevealed}}newly-revealed{{end}} {{if .NewlySatisfied}}newly-satisfied{{end}}" data-rule-id="{{.ID}}">
@@ -97,18 +314,18 @@ const rulesPartialTemplate = `{{range $index, $rule := .SortedRules}}
         </div>
         {{- else if eq .ID 19 -}}
         <div class="chess-container">
-            <img src="/chess.png" alt="Chess Board" class="chess-image" id="chess-{{.ID}}">
+            <img src="/chess.svg" alt="Chess Board" class="chess-image" id="chess-{{.ID}}">
             <button type="button" class="refresh-chess-btn" onclick="refreshChess({{.ID}})">🔄</button>
         </div>
         {{- end -}}
         {{end}}
         
         {{- if eq .ID 20 -}}
-        <div class="rule20-progress-container">
+        <div class="rule20-progress-container" data-emoji="{{$.StrengthEmoji}}" data-required="{{$.StrengthRequired}}">
             <div class="rule20-progress-bar-bg">
-                <div class="rule20-progress-bar" id="rule20-progress-bar-{{.ID}}" style="width:0%"></div>
+                <div class="rule20-progress-bar" id="rule20-progress-bar-{{.ID}}" style="width:{{printf "%.2f" $.StrengthPercent}}%"></div>
             </div>
-            <div class="rule20-progress-label" id="rule20-progress-label-{{.ID}}">0/3 🏋️</div>
+            <div class="rule20-progress-label" id="rule20-progress-label-{{.ID}}">{{$.StrengthCount}}/{{$.StrengthRequired}} {{$.StrengthEmoji}}</div>
         </div>
         {{- else if eq .ID 22 -}}
         <div class="rule22-pdf-link">
@@ -140,8 +357,37 @@ type TemplateData struct {
 	RuleChanges        RuleChangeAnalysis
 	Title              string
 	UserSession        *UserSession
-	Difficulties       map[string]DifficultyConfig
+	Difficulties       map[string]config.DifficultyConfig
 	ShowHints          bool
+	RulesHTML          template.HTML
+	SatisfiedStates    map[string]bool
+	VisibleStates      map[string]bool
+	// StrengthCount and StrengthPercent drive the Rule 20 emoji progress bar
+	StrengthCount    int
+	StrengthPercent  float64
+	StrengthEmoji    string
+	StrengthRequired int
+}
+
+// Rules partial template, parsed once and shared by the page and validate handlers
+var rulesTmpl = template.Must(template.New("rules").Funcs(funcMap).Parse(rulesPartialTemplate))
+
+// renderRulesPartial renders the rules partial to HTML for embedding in the full page
+func renderRulesPartial(data TemplateData) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := rulesTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// statesFromMap converts a rule-ID keyed state map into a slice aligned with the rule set
+func statesFromMap(ruleSet *rules.RuleSet, stateMap map[string]bool) []bool {
+	states := make([]bool, len(ruleSet.Rules))
+	for i := 0; i < len(ruleSet.Rules); i++ {
+		states[i] = stateMap[strconv.Itoa(ruleSet.Rules[i].ID)] // Use actual rule ID
+	}
+	return states
 }
 
 func analyzeRuleChanges(currentRules []rules.Rule, previousSatisfied, previousVisible []bool) RuleChangeAnalysis {
@@ -189,11 +435,127 @@ func analyzeRuleChanges(currentRules []rules.Rule, previousSatisfied, previousVi
 	return analysis
 }
 
+// ruleStateMaps returns the satisfied and visible states keyed by rule ID
+func ruleStateMaps(ruleSet *rules.RuleSet) (map[string]bool, map[string]bool) {
+	satisfied := make(map[string]bool)
+	visible := make(map[string]bool)
+	for _, rule := range ruleSet.Rules {
+		satisfied[strconv.Itoa(rule.ID)] = rule.IsSatisfied
+		visible[strconv.Itoa(rule.ID)] = rule.IsVisible
+	}
+	return satisfied, visible
+}
+
 // Generate a simple session ID (in production, use crypto/rand)
 func generateSessionID() string {
 	return fmt.Sprintf("session_%d", time.Now().UnixNano())
 }
 
+// requestLocale picks the player's language for day and month names: ?locale= or the form field
+// if given, otherwise the browser's Accept-Language
+func requestLocale(r *http.Request) string {
+	if locale := r.FormValue("locale"); locale != "" {
+		return rules.ResolveLocale(locale)
+	}
+	return rules.ResolveLocale(r.Header.Get("Accept-Language"))
+}
+
+// HasDatabaseUser reports whether the session belongs to a registered user with a database row.
+// Test sessions use a negative UserID and practice sessions UserID 0; neither must ever be
+// written to the database.
+func HasDatabaseUser(session *UserSession) bool {
+	return session.UserID > 0 && !session.Practice
+}
+
+// Session kinds, as reported by SessionKind
+const (
+	SessionKindPlayer   = "player"
+	SessionKindPractice = "practice"
+	SessionKindTest     = "test"
+)
+
+// SessionKind tells registered players apart from practice and test sessions
+func SessionKind(session *UserSession) string {
+	switch {
+	case session.Practice:
+		return SessionKindPractice
+	case session.UserID < 0:
+		return SessionKindTest
+	default:
+		return SessionKindPlayer
+	}
+}
+
+// ResetSessionProgress restarts a session's run from rule 1. The start time is reset too,
+// so time from the abandoned run doesn't carry over into the next recorded result. The
+// cybersecurity rules start over for this session only; other players' games are untouched.
+func ResetSessionProgress(session *UserSession) {
+	sessionsMutex.Lock()
+	session.MaxRule = 0
+	session.IsCompleted = false
+	session.StartTime = time.Now()
+	session.SatisfiedStates = nil
+	session.VisibleStates = nil
+	session.LastPasswordLength = 0
+	session.PasteDetected = false
+	session.TimedOut = false
+	session.Failure = GameFailure{}
+	session.PendingRule, session.PendingTimeSpent = 0, 0
+	session.Transcript = nil
+	session.lastValidation = nil
+	session.RuleIDs = nil
+	session.cyberSecurity = nil
+	sessionsMutex.Unlock()
+}
+
+// sessionRuleSet builds the rule set for a session's run. The rule IDs are pinned on the first
+// call, so rules added to assignments.json don't lengthen a game that is already under way; the
+// next run (see ResetSessionProgress) picks them up. The cybersecurity rules are bound to the
+// session's own state.
+func sessionRuleSet(session *UserSession) *rules.RuleSet {
+	sessionsMutex.RLock()
+	ruleIDs := session.RuleIDs
+	sessionsMutex.RUnlock()
+
+	var ruleSet *rules.RuleSet
+	if ruleIDs != nil {
+		ruleSet = rules.NewRuleSetFromIDs(session.Difficulty, ruleIDs)
+	} else {
+		ruleSet = rules.NewRuleSet(session.Difficulty)
+		ruleIDs = make([]int, len(ruleSet.Rules))
+		for i, rule := range ruleSet.Rules {
+			ruleIDs[i] = rule.ID
+		}
+		sessionsMutex.Lock()
+		session.RuleIDs = ruleIDs
+		sessionsMutex.Unlock()
+	}
+
+	rules.BindCyberSecurity(ruleSet, CyberSecurityFor(session))
+	return ruleSet
+}
+
+// CyberSecurityFor returns the cybersecurity rule state of the session's run, starting a fresh
+// one the first time it is asked for
+func CyberSecurityFor(session *UserSession) *rules.CyberSecurityRules {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	if session.cyberSecurity == nil {
+		session.cyberSecurity = rules.NewCyberSecurityRules()
+	}
+	return session.cyberSecurity
+}
+
+// CyberSecurityForRequest returns the cybersecurity rule state of the request's session, or
+// false if the request has no live session
+func CyberSecurityForRequest(r *http.Request) (*rules.CyberSecurityRules, bool) {
+	session := getUserSession(r)
+	if session == nil {
+		return nil, false
+	}
+	return CyberSecurityFor(session), true
+}
+
 // Get user session from cookie
 func getUserSession(r *http.Request) *UserSession {
 	cookie, err := r.Cookie("user_session")
@@ -201,14 +563,60 @@ func getUserSession(r *http.Request) *UserSession {
 		return nil
 	}
 
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
 	session, exists := UserSessions[cookie.Value]
 	if !exists {
 		return nil
 	}
+	session.LastSeen = time.Now()
 
 	return session
 }
 
+// usernameProblem returns why a username can't be registered, or "" when it is acceptable
+func usernameProblem(username string) string {
+	if len(username) < 3 || len(username) > 20 {
+		return "Username must be between 3-20 characters"
+	}
+	for _, r := range username {
+		if unicode.IsControl(r) {
+			return "Username contains invalid characters"
+		}
+	}
+	return ""
+}
+
+// HandleUsernameAvailable reports whether ?u= is free, so the registration modal can check as the user types
+func HandleUsernameAvailable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := strings.TrimSpace(r.URL.Query().Get("u"))
+	if problem := usernameProblem(username); problem != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"available": false,
+			"error":     problem,
+		})
+		return
+	}
+
+	exists, err := database.CheckUsernameExists(username)
+	if err != nil {
+		log.Printf("Error checking username: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Database error occurred")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"available": !exists})
+}
+
 // HandleRegisterUser handles user registration
 func HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -220,8 +628,8 @@ func HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 	difficulty := r.FormValue("difficulty")
 
 	// Validate input
-	if len(username) < 3 || len(username) > 20 {
-		http.Error(w, `<div class="error-message">Username must be between 3-20 characters</div>`, http.StatusBadRequest)
+	if problem := usernameProblem(username); problem != "" {
+		http.Error(w, `<div class="error-message">`+problem+`</div>`, http.StatusBadRequest)
 		return
 	}
 
@@ -238,19 +646,50 @@ func HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if exists {
-		http.Error(w, `<div class="error-message">Username already exists. Please choose another.</div>`, http.StatusBadRequest)
+	// Enforce difficulty unlock gating (no-op unless an unlock order is configured)
+	unlocked, err := database.IsDifficultyUnlocked(username, difficulty)
+	if err != nil {
+		log.Printf("Error checking difficulty unlock: %v", err)
+		http.Error(w, `<div class="error-message">Database error occurred</div>`, http.StatusInternalServerError)
 		return
 	}
-
-	// Insert user into database
-	userID, err := database.InsertUser(username, difficulty)
-	if err != nil {
-		log.Printf("Error inserting user: %v", err)
-		http.Error(w, `<div class="error-message">Failed to create user account</div>`, http.StatusInternalServerError)
+	if !unlocked {
+		http.Error(w, lockedDifficultyMessage(difficulty), http.StatusForbidden)
 		return
 	}
 
+	var userID int64
+	if exists {
+		// A returning player may only reuse their name to move up to a tier they just unlocked,
+		// and only from the session that owns the account. Anyone else gets the usual error.
+		user, err := database.GetUserByUsername(username)
+		current := getUserSession(r)
+		if err != nil || current == nil || current.UserID != user.ID ||
+			database.DifficultyPrerequisite(difficulty) == "" || strings.EqualFold(user.Difficulty, difficulty) {
+			http.Error(w, `<div class="error-message">Username already exists. Please choose another.</div>`, http.StatusBadRequest)
+			return
+		}
+		if err := database.PromoteUser(user.ID, difficulty); err != nil {
+			log.Printf("Error promoting user: %v", err)
+			http.Error(w, `<div class="error-message">Failed to unlock difficulty</div>`, http.StatusInternalServerError)
+			return
+		}
+		userID = user.ID
+	} else {
+		// Insert user into database
+		userID, err = database.InsertUser(username, difficulty)
+		if errors.Is(err, database.ErrUsernameTaken) {
+			// Another registration for the same name won the race since the check above
+			http.Error(w, `<div class="error-message">Username already exists. Please choose another.</div>`, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Error inserting user: %v", err)
+			http.Error(w, `<div class="error-message">Failed to create user account</div>`, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Create session
 	sessionID := generateSessionID()
 	userSession := &UserSession{
@@ -259,26 +698,25 @@ func HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 		Difficulty: difficulty,
 		StartTime:  time.Now(),
 		MaxRule:    0,
+		Locale:     requestLocale(r),
 	}
 
-	// Reset cybersecurity rules for the new session
-	rules.ResetCyberSecurityRules()
-
-	UserSessions[sessionID] = userSession
+	storeSession(sessionID, userSession)
 
 	// Set session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user_session",
-		Value:    sessionID,
-		HttpOnly: true,
-		Path:     "/",
-		MaxAge:   24 * 60 * 60, // 24 hours
-	})
+	http.SetCookie(w, SessionCookie(sessionID, 24*60*60)) // 24 hours
 
 	// Return success response (you might want to redirect or return JSON)
 	w.WriteHeader(http.StatusOK)
 }
 
+// lockedDifficultyMessage explains which difficulty must be completed first
+func lockedDifficultyMessage(difficulty string) string {
+	return fmt.Sprintf(`<div class="error-message">%s is locked. Complete %s first to unlock it.</div>`,
+		template.HTMLEscapeString(difficulty),
+		template.HTMLEscapeString(database.DifficultyPrerequisite(difficulty)))
+}
+
 // HandlePasswordGame handles the main password game page
 func HandlePasswordGame(w http.ResponseWriter, r *http.Request) {
 	// Check if this is a test session request
@@ -296,24 +734,16 @@ func HandlePasswordGame(w http.ResponseWriter, r *http.Request) {
 			Difficulty: difficulty,
 			StartTime:  time.Now(),
 			MaxRule:    0,
+			Locale:     requestLocale(r),
 		}
 
 		// Create a temporary session ID for the test session
 		sessionID := "test_" + fmt.Sprint(time.Now().UnixNano())
 
-		// Reset cybersecurity rules for the test session
-		rules.ResetCyberSecurityRules()
-
-		UserSessions[sessionID] = testUser
+		storeSession(sessionID, testUser)
 
 		// Set session cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "user_session",
-			Value:    sessionID,
-			HttpOnly: true,
-			Path:     "/",
-			MaxAge:   60 * 60, // 1 hour
-		})
+		http.SetCookie(w, SessionCookie(sessionID, 60*60)) // 1 hour
 
 		// Redirect to the game
 		http.Redirect(w, r, "/display", http.StatusSeeOther)
@@ -338,10 +768,33 @@ func HandlePasswordGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ruleSet := rules.NewRuleSet(userSession.Difficulty)
+	// Sessions created before gating was enabled may point at a locked difficulty
+	if HasDatabaseUser(userSession) {
+		unlocked, err := database.IsDifficultyUnlocked(userSession.Username, userSession.Difficulty)
+		if err != nil {
+			log.Printf("Error checking difficulty unlock: %v", err)
+		} else if !unlocked {
+			http.Error(w, lockedDifficultyMessage(userSession.Difficulty), http.StatusForbidden)
+			return
+		}
+	}
 
-	// Show rule 1 by default (even with empty password)
-	ruleSet.Rules[0].IsVisible = true
+	ruleSet := sessionRuleSet(userSession)
+	rules.LocalizeRuleSet(ruleSet, userSession.Locale)
+
+	sessionsMutex.RLock()
+	savedSatisfied := userSession.SatisfiedStates
+	savedVisible := userSession.VisibleStates
+	sessionsMutex.RUnlock()
+
+	if savedVisible != nil {
+		// Resuming after a reload: re-run validation against the saved states so every
+		// rule the player had already unlocked stays visible. The textbox starts empty.
+		rules.ValidatePassword(ruleSet, "", statesFromMap(ruleSet, savedSatisfied), statesFromMap(ruleSet, savedVisible))
+	} else {
+		// Show rule 1 by default (even with empty password)
+		ruleSet.Rules[0].IsVisible = true
+	}
 
 	satisfiedCount := rules.GetSatisfiedCount(ruleSet)
 	sortedRules := rules.GetSortedVisibleRules(ruleSet)
@@ -356,7 +809,19 @@ func HandlePasswordGame(w http.ResponseWriter, r *http.Request) {
 		AllSatisfied:       false,
 		HasPassword:        false,
 		UserSession:        userSession,
-		ShowHints:          Config.ShowHints,
+		ShowHints:          ShowHintsFor(userSession.Difficulty),
+		StrengthEmoji:      rules.StrengthEmoji(),
+		StrengthRequired:   rules.StrengthRequiredCount(),
+	}
+
+	if savedVisible != nil {
+		data.SatisfiedStates, data.VisibleStates = ruleStateMaps(ruleSet)
+		rulesHTML, err := renderRulesPartial(data)
+		if err != nil {
+			log.Printf("Error rendering restored rules: %v", err)
+		} else {
+			data.RulesHTML = rulesHTML
+		}
 	}
 
 	// Execute the display.html template with data
@@ -370,7 +835,7 @@ func HandlePasswordGame(w http.ResponseWriter, r *http.Request) {
 
 // HandleUserModal handles user modal requests
 func HandleUserModal(w http.ResponseWriter, r *http.Request) {
-	difficulties, err := LoadDifficulties()
+	difficulties, err := LoadDifficultiesWithRuleCounts()
 	if err != nil {
 		log.Printf("Warning: Could not load difficulties: %v", err)
 	}
@@ -388,6 +853,61 @@ func HandleUserModal(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// applyAntiPaste flags one-shot pastes and keeps the difficulty's higher rules unsatisfied
+// until the password is cleared and typed in again
+func applyAntiPaste(session *UserSession, ruleSet *rules.RuleSet, password string) {
+	antiPaste := GetAntiPasteConfig(session.Difficulty)
+	if antiPaste == nil {
+		return
+	}
+
+	sessionsMutex.Lock()
+	if password == "" {
+		session.PasteDetected = false
+	} else if !rules.IsIncrementalGrowth(session.LastPasswordLength, password, antiPaste.MaxJump) {
+		if !session.PasteDetected {
+			log.Printf("📋 Paste detected for user %s", session.Username)
+		}
+		session.PasteDetected = true
+	}
+	session.LastPasswordLength = utf8.RuneCountInString(password)
+	pasted := session.PasteDetected
+	sessionsMutex.Unlock()
+
+	if pasted {
+		rules.BlockPastedRules(ruleSet, antiPaste.FromRule)
+	}
+}
+
+// hasVisibleRule reports whether the rule with the given ID is visible in the rule set
+func hasVisibleRule(ruleSet *rules.RuleSet, id int) bool {
+	for _, rule := range ruleSet.Rules {
+		if rule.ID == id {
+			return rule.IsVisible
+		}
+	}
+	return false
+}
+
+// handleTimeout ends a run that has used up its time limit. The first request past the limit
+// records the timeout; progress already reached stays as the run's final rule.
+func handleTimeout(w http.ResponseWriter, session *UserSession, timeLimit time.Duration) {
+	sessionsMutex.Lock()
+	session.TimedOut = true
+	maxRule := session.MaxRule
+	sessionsMutex.Unlock()
+
+	// Running out of time always ends the run, whatever Config.FatalFailureMode says
+	if FailSession(session, "time's up") && HasDatabaseUser(session) {
+		if err := database.MarkUserTimedOut(session.UserID, int(timeLimit.Seconds())); err != nil {
+			log.Printf("Error recording timeout for user %s: %v", session.Username, err)
+		}
+	}
+
+	w.Header().Set("X-Timed-Out", "true")
+	fmt.Fprintf(w, `<div class="error-message">⏱️ Time's up! You reached rule %d in %s.</div>`, maxRule, formatDuration(int(timeLimit.Seconds())))
+}
+
 // HandleValidate handles password validation
 func HandleValidate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -404,19 +924,55 @@ func HandleValidate(w http.ResponseWriter, r *http.Request) {
 
 	password := r.FormValue("password")
 
+	// A lost run accepts no more progress until the player resets it
+	if failure := sessionFailure(userSession); failure.Failed {
+		if userSession.TimedOut {
+			w.Header().Set("X-Timed-Out", "true")
+		}
+		writeFailure(w, failure)
+		return
+	}
+
+	// Timed difficulties stop accepting progress once their budget is spent
+	if timeLimit := GetTimeLimit(userSession.Difficulty); timeLimit > 0 {
+		remaining := timeLimit - time.Since(userSession.StartTime)
+		if remaining < 0 {
+			remaining = 0
+		}
+		w.Header().Set("X-Time-Remaining", strconv.Itoa(int(remaining.Seconds())))
+		if remaining == 0 && !userSession.IsCompleted {
+			handleTimeout(w, userSession, timeLimit)
+			return
+		}
+	}
+
+	// An unchanged password with unchanged rule states gets the previous response, so stateful
+	// rules (imposters, black squares) aren't re-triggered by a duplicate request
+	cacheKey := validationKey(userSession, r, password)
+	if serveCachedValidation(w, userSession, cacheKey) {
+		return
+	}
+
 	// Create rule set based on user's difficulty
-	ruleSet := rules.NewRuleSet(userSession.Difficulty)
+	ruleSet := sessionRuleSet(userSession)
+	rules.LocalizeRuleSet(ruleSet, userSession.Locale)
+
+	// Fall back to the states saved on the session when the client didn't send any,
+	// e.g. right after a page reload
+	sessionsMutex.RLock()
+	savedSatisfied := userSession.SatisfiedStates
+	savedVisible := userSession.VisibleStates
+	sessionsMutex.RUnlock()
 
 	// Get previous satisfied states
 	var previousSatisfiedStates []bool
 	if states := r.Header.Get("X-Satisfied-States"); states != "" {
 		stateMap := make(map[string]bool)
 		if err := json.Unmarshal([]byte(states), &stateMap); err == nil {
-			previousSatisfiedStates = make([]bool, len(ruleSet.Rules))
-			for i := 0; i < len(ruleSet.Rules); i++ {
-				previousSatisfiedStates[i] = stateMap[strconv.Itoa(ruleSet.Rules[i].ID)] // Use actual rule ID
-			}
+			previousSatisfiedStates = statesFromMap(ruleSet, stateMap)
 		}
+	} else if savedSatisfied != nil {
+		previousSatisfiedStates = statesFromMap(ruleSet, savedSatisfied)
 	}
 
 	// Get previous visible states
@@ -424,14 +980,15 @@ func HandleValidate(w http.ResponseWriter, r *http.Request) {
 	if states := r.Header.Get("X-Visible-States"); states != "" {
 		stateMap := make(map[string]bool)
 		if err := json.Unmarshal([]byte(states), &stateMap); err == nil {
-			previousVisibleStates = make([]bool, len(ruleSet.Rules))
-			for i := 0; i < len(ruleSet.Rules); i++ {
-				previousVisibleStates[i] = stateMap[strconv.Itoa(ruleSet.Rules[i].ID)] // Use actual rule ID
-			}
+			previousVisibleStates = statesFromMap(ruleSet, stateMap)
 		}
+	} else if savedVisible != nil {
+		previousVisibleStates = statesFromMap(ruleSet, savedVisible)
 	}
 
 	rules.ValidatePassword(ruleSet, password, previousSatisfiedStates, previousVisibleStates)
+	applyAntiPaste(userSession, ruleSet, password)
+	rules.RecordValidation(ruleSet)
 
 	// Track if we need to update the database
 	shouldUpdateDB := false
@@ -448,35 +1005,62 @@ func HandleValidate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Only update database if there are newly satisfied rules AND it's a higher rule than previously reached
-	if shouldUpdateDB && highestNewlySatisfiedRule > userSession.MaxRule {
-		timeSpent := int(time.Since(userSession.StartTime).Seconds())
+	// Test sessions have no database row, so their progress is only tracked in memory
+	persistProgress := HasDatabaseUser(userSession)
 
-		// Update max rule reached in session
+	// Only update database if there are newly satisfied rules AND it's a higher rule than previously reached.
+	// The check and the session update happen together so a double-submitted request can't write twice.
+	sessionsMutex.Lock()
+	improved := shouldUpdateDB && highestNewlySatisfiedRule > userSession.MaxRule
+	if improved {
 		userSession.MaxRule = highestNewlySatisfiedRule
+	}
+	sessionsMutex.Unlock()
 
-		// Update database
-		err := database.UpdateUserProgress(userSession.UserID, highestNewlySatisfiedRule, timeSpent)
-		if err != nil {
-			log.Printf("Error updating user progress for rule %d: %v", highestNewlySatisfiedRule, err)
+	// Update database, throttled to one write per progressSaveInterval; a later request
+	// writes whatever is still pending once the interval has passed
+	if persistProgress {
+		if improved {
+			recordProgress(userSession, highestNewlySatisfiedRule, int(time.Since(userSession.StartTime).Seconds()))
 		} else {
-			log.Printf("📈 Database updated for user %s: Rule %d satisfied in %ds",
-				userSession.Username, highestNewlySatisfiedRule, timeSpent)
+			flushProgress(userSession, false)
 		}
 	}
 
 	// Check if all rules are satisfied (game completed)
 	satisfiedCount := rules.GetSatisfiedCount(ruleSet)
 	rulesLen := len(ruleSet.Rules)
-	if satisfiedCount == rulesLen && !userSession.IsCompleted {
+	sessionsMutex.Lock()
+	justCompleted := satisfiedCount == rulesLen && !userSession.IsCompleted
+	if justCompleted {
 		userSession.IsCompleted = true
+		// The completion write below supersedes any throttled progress
+		userSession.PendingRule, userSession.PendingTimeSpent = 0, 0
+	}
+	sessionsMutex.Unlock()
+	if justCompleted {
 		timeSpent := int(time.Since(userSession.StartTime).Seconds())
 
-		err := database.UpdateUserProgress(userSession.UserID, rulesLen, timeSpent) // Use actual rule count
-		if err != nil {
-			log.Printf("Error updating completion: %v", err)
+		notifyCompletion(CompletionEvent{
+			Username:    userSession.Username,
+			Difficulty:  userSession.Difficulty,
+			TimeSpent:   timeSpent,
+			CompletedAt: time.Now().UTC(),
+		})
+
+		if persistProgress {
+			err := database.RecordCompletion(userSession.UserID, ruleSet.FinalRuleID(), timeSpent)
+			if err != nil {
+				log.Printf("Error updating completion: %v", err)
+			} else {
+				log.Printf("🎉 Game completed by user %s in %d seconds!", userSession.Username, timeSpent)
+			}
 		} else {
-			log.Printf("🎉 Game completed by user %s in %d seconds!", userSession.Username, timeSpent)
+			log.Printf("🎉 Test game completed in %d seconds (not recorded)", timeSpent)
+		}
+
+		if Config.VictoryRedirect {
+			w.Header().Set("HX-Redirect", "/victory")
 		}
 	}
 
@@ -498,17 +1082,22 @@ func HandleValidate(w http.ResponseWriter, r *http.Request) {
 		AllSatisfied:       allSatisfied,
 		HasPassword:        len(password) > 0,
 		RuleChanges:        ruleChanges,
-		ShowHints:          Config.ShowHints,
+		ShowHints:          ShowHintsFor(userSession.Difficulty),
 		UserSession:        userSession,
+		StrengthEmoji:      rules.StrengthEmoji(),
+		StrengthRequired:   rules.StrengthRequiredCount(),
 	}
+	data.StrengthCount, data.StrengthPercent = rules.StrengthProgress(password)
 
 	// Send the satisfied and visible states back to client
-	satisfiedStateMap := make(map[string]bool)
-	visibleStateMap := make(map[string]bool)
-	for _, rule := range ruleSet.Rules {
-		satisfiedStateMap[strconv.Itoa(rule.ID)] = rule.IsSatisfied
-		visibleStateMap[strconv.Itoa(rule.ID)] = rule.IsVisible
-	}
+	satisfiedStateMap, visibleStateMap := ruleStateMaps(ruleSet)
+
+	// Remember the states so the game can be restored after a reload
+	sessionsMutex.Lock()
+	userSession.SatisfiedStates = satisfiedStateMap
+	userSession.VisibleStates = visibleStateMap
+	appendTranscriptLocked(userSession, password, ruleSet)
+	sessionsMutex.Unlock()
 
 	if statesJSON, err := json.Marshal(satisfiedStateMap); err == nil {
 		w.Header().Set("X-Satisfied-States", string(statesJSON))
@@ -518,7 +1107,29 @@ func HandleValidate(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Visible-States", string(visibleJSON))
 	}
 
+	// Expose the transitions so clients can animate or automate without diffing the partial
+	if newlySatisfiedJSON, err := json.Marshal(ruleChanges.NewlySatisfied); err == nil {
+		w.Header().Set("X-Newly-Satisfied", string(newlySatisfiedJSON))
+	}
+
+	if newlyVisibleJSON, err := json.Marshal(ruleChanges.NewlyVisible); err == nil {
+		w.Header().Set("X-Newly-Visible", string(newlyVisibleJSON))
+	}
+
+	// Point at injected black squares and imposter characters once their rules are in play
+	if hasVisibleRule(ruleSet, ransomwareRuleID) || hasVisibleRule(ruleSet, insiderThreatRuleID) {
+		if positionsJSON, err := json.Marshal(CyberSecurityFor(userSession).InjectedPositions(password)); err == nil {
+			w.Header().Set("X-Injected-Positions", string(positionsJSON))
+		}
+	}
+
 	// Return just the rules partial for HTMX
-	ruleTmpl := template.Must(template.New("rules").Funcs(funcMap).Parse(rulesPartialTemplate))
-	ruleTmpl.Execute(w, data)
+	var body bytes.Buffer
+	if err := rulesTmpl.Execute(&body, data); err != nil {
+		log.Printf("Error executing rules template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	cacheValidation(w, userSession, cacheKey, body.Bytes())
+	w.Write(body.Bytes())
 }
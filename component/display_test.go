@@ -0,0 +1,839 @@
+package component
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+	"passgame/rules"
+)
+
+// useTestSession stores a test session (no database row) for the difficulty and returns its
+// cookie value
+func useTestSession(t *testing.T, difficulty string) string {
+	t.Helper()
+	useSessions(t)
+	id := "session-" + t.Name()
+	storeSession(id, &UserSession{UserID: -1, Username: "Test User", Difficulty: difficulty, StartTime: time.Now()})
+	return id
+}
+
+// validateRequest posts a password to /validate for the session, passing along the rule
+// states of a previous response when given
+func validateRequest(sessionID, password string, previous http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(url.Values{"password": {password}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	if previous != nil {
+		r.Header.Set("X-Satisfied-States", previous.Get("X-Satisfied-States"))
+		r.Header.Set("X-Visible-States", previous.Get("X-Visible-States"))
+	}
+	w := httptest.NewRecorder()
+	HandleValidate(w, r)
+	return w
+}
+
+// headerIDs decodes a rule ID list header such as X-Newly-Satisfied
+func headerIDs(t *testing.T, w *httptest.ResponseRecorder, name string) []int {
+	t.Helper()
+	var ids []int
+	if err := json.Unmarshal([]byte(w.Header().Get(name)), &ids); err != nil {
+		t.Fatalf("invalid %s header %q: %v", name, w.Header().Get(name), err)
+	}
+	return ids
+}
+
+// readTestAssignments returns the assignment lists of the test copy of assignments.json
+func readTestAssignments(t *testing.T) map[string][]int {
+	t.Helper()
+	data, err := os.ReadFile("rules/assignments.json")
+	if err != nil {
+		t.Fatalf("failed to read assignments: %v", err)
+	}
+	var assignments map[string][]int
+	if err := json.Unmarshal(data, &assignments); err != nil {
+		t.Fatalf("failed to parse assignments: %v", err)
+	}
+	return assignments
+}
+
+// writeTestAssignments replaces the test copy of assignments.json for one test, restoring it
+// and the rules cache afterwards
+func writeTestAssignments(t *testing.T, data string) {
+	t.Helper()
+	const path = "rules/assignments.json"
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := os.WriteFile(path, original, 0644); err != nil {
+			t.Errorf("failed to restore %s: %v", path, err)
+		}
+		rules.ReloadAssignments()
+	})
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	rules.ReloadAssignments()
+}
+
+func TestSessionRuleSetPinsRules(t *testing.T) {
+	id := useTestSession(t, "basic")
+	session, _ := GetSession(id)
+	writeTestAssignments(t, `{"basic": [1, 2, 3]}`)
+
+	ruleIDs := func() []int {
+		var ids []int
+		for _, rule := range sessionRuleSet(session).Rules {
+			ids = append(ids, rule.ID)
+		}
+		return ids
+	}
+	if got := ruleIDs(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("session rules = %v, want [1 2 3]", got)
+	}
+
+	// A rule appended mid-game doesn't lengthen the run in progress
+	writeTestAssignments(t, `{"basic": [1, 2, 3, 4]}`)
+	if got := ruleIDs(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("session rules after the append = %v, want [1 2 3]", got)
+	}
+	if final := sessionRuleSet(session).FinalRuleID(); final != 3 {
+		t.Errorf("FinalRuleID() = %d, want 3", final)
+	}
+
+	// The next run plays the current assignments
+	ResetSessionProgress(session)
+	if got := ruleIDs(); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Errorf("session rules after a reset = %v, want [1 2 3 4]", got)
+	}
+}
+
+func TestSessionRuleSetBindsUpdateString(t *testing.T) {
+	writeTestAssignments(t, `{"expert": [14]}`)
+	first, _ := GetSession(useTestSession(t, "expert"))
+	second := &UserSession{UserID: -1, Username: "Other User", Difficulty: "expert"}
+
+	updateString := CyberSecurityFor(first).UpdateString()
+	if !sessionRuleSet(first).Rules[0].Validator(updateString) {
+		t.Errorf("rule 14 rejected the session's own update string %q", updateString)
+	}
+	if other := CyberSecurityFor(second).UpdateString(); other != updateString && sessionRuleSet(second).Rules[0].Validator(updateString) {
+		t.Errorf("rule 14 of another session accepted %q", updateString)
+	}
+	if hint := sessionRuleSet(first).Rules[0].Hint; !strings.Contains(hint, updateString) {
+		t.Errorf("rule 14 hint = %q, want the session's update string", hint)
+	}
+}
+
+func TestHandleUserModalRuleCounts(t *testing.T) {
+	assignments := readTestAssignments(t)
+	difficulties, err := LoadDifficultiesWithRuleCounts()
+	if err != nil {
+		t.Fatalf("LoadDifficultiesWithRuleCounts() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	HandleUserModal(w, httptest.NewRequest(http.MethodGet, "/user-modal", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+
+	for key, diff := range difficulties {
+		ids, assigned := assignments[key]
+		if !assigned {
+			continue
+		}
+		t.Run(key, func(t *testing.T) {
+			if diff.RuleCount != len(ids) {
+				t.Errorf("RuleCount = %d, want %d from assignments.json", diff.RuleCount, len(ids))
+			}
+			option := fmt.Sprintf("%s - %s (%d rules)", diff.Name, diff.Description, len(ids))
+			if !strings.Contains(body, option) {
+				t.Errorf("modal has no option %q", option)
+			}
+		})
+	}
+}
+
+func TestHandleValidateTransitionHeaders(t *testing.T) {
+	sessionID := useTestSession(t, "basic")
+
+	tests := []struct {
+		name               string
+		password           string
+		wantSatisfied      bool
+		wantNewlySatisfied bool
+	}{
+		{"too short", "abc", false, false},
+		{"reaches 8 characters", "abcdefgh", true, true},
+		{"stays satisfied", "abcdefghi", true, false},
+		{"drops below 8 characters", "abcdefg", false, false},
+	}
+
+	var previous http.Header
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := validateRequest(sessionID, tt.password, previous)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var satisfied map[string]bool
+			if err := json.Unmarshal([]byte(w.Header().Get("X-Satisfied-States")), &satisfied); err != nil {
+				t.Fatalf("invalid X-Satisfied-States: %v", err)
+			}
+			if satisfied["1"] != tt.wantSatisfied {
+				t.Errorf("rule 1 satisfied = %v, want %v", satisfied["1"], tt.wantSatisfied)
+			}
+			if got := slices.Contains(headerIDs(t, w, "X-Newly-Satisfied"), 1); got != tt.wantNewlySatisfied {
+				t.Errorf("X-Newly-Satisfied %s lists rule 1 = %v, want %v", w.Header().Get("X-Newly-Satisfied"), got, tt.wantNewlySatisfied)
+			}
+			headerIDs(t, w, "X-Newly-Visible")
+			previous = w.Header()
+		})
+	}
+}
+
+func TestResetSessionProgress(t *testing.T) {
+	sessionID := useTestSession(t, "expert")
+	session, _ := GetSession(sessionID)
+	startedAt := time.Now().Add(-time.Hour)
+	session.StartTime = startedAt
+	session.MaxRule = 12
+	session.IsCompleted = true
+	session.TimedOut = true
+	session.SatisfiedStates = map[string]bool{"1": true}
+	session.VisibleStates = map[string]bool{"1": true}
+	session.PendingRule, session.PendingTimeSpent = 12, 300
+	CyberSecurityFor(session).SetAdWatched(true)
+	CyberSecurityFor(session).SetUpdateAlertShown(true)
+
+	ResetSessionProgress(session)
+
+	if session.MaxRule != 0 || session.IsCompleted || session.TimedOut {
+		t.Errorf("MaxRule, IsCompleted, TimedOut = %d, %v, %v, want 0, false, false", session.MaxRule, session.IsCompleted, session.TimedOut)
+	}
+	if !session.StartTime.After(startedAt) {
+		t.Errorf("StartTime = %v, want a restarted clock", session.StartTime)
+	}
+	if session.SatisfiedStates != nil || session.VisibleStates != nil {
+		t.Error("saved rule states survived the reset")
+	}
+	if session.PendingRule != 0 || session.PendingTimeSpent != 0 {
+		t.Errorf("pending progress = %d, %d, want nothing left to write", session.PendingRule, session.PendingTimeSpent)
+	}
+	if CyberSecurityFor(session).IsAdWatched() || CyberSecurityFor(session).IsUpdateAlertShown() {
+		t.Error("cybersecurity rule state survived the reset")
+	}
+	if session.Username != "Test User" || session.Difficulty != "expert" {
+		t.Errorf("identity changed to %q on %q", session.Username, session.Difficulty)
+	}
+}
+
+func TestHandlePasswordGameRestoresVisibleRules(t *testing.T) {
+	sessionID := useTestSession(t, "basic")
+	const password = "Abcdefgh"
+	const rule3 = "Must include a special character"
+
+	reload := func() string {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/display", nil)
+		r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+		w := httptest.NewRecorder()
+		HandlePasswordGame(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return w.Body.String()
+	}
+
+	if strings.Contains(reload(), rule3) {
+		t.Fatal("a fresh game already shows rule 3")
+	}
+
+	// Rules 1 and 2 pass, which reveals rule 3
+	if w := validateRequest(sessionID, password, nil); w.Code != http.StatusOK {
+		t.Fatalf("validate status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	body := reload()
+	if !strings.Contains(body, rule3) {
+		t.Error("rule 3 is hidden again after the reload")
+	}
+	if strings.Contains(body, password) {
+		t.Error("the reloaded page echoes the password")
+	}
+}
+
+func TestHandleValidateTestSessionSkipsDatabase(t *testing.T) {
+	useEmptyDB(t)
+	sessionID := useTestSession(t, "basic")
+	logs := captureLog(t)
+
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"} {
+		w := validateRequest(sessionID, password, previous)
+		if w.Code != http.StatusOK {
+			t.Fatalf("validate %q status = %d, want %d", password, w.Code, http.StatusOK)
+		}
+		previous = w.Header()
+	}
+
+	session, _ := GetSession(sessionID)
+	if !session.IsCompleted {
+		t.Fatal("the test session did not complete basic")
+	}
+	if count, err := database.GetUserCount(); err != nil || count != 0 {
+		t.Errorf("GetUserCount() = %d, %v, want no rows written", count, err)
+	}
+	for _, unwanted := range []string{"no user found", "invalid user ID", "Error updating"} {
+		if strings.Contains(logs.String(), unwanted) {
+			t.Errorf("log mentions %q:\n%s", unwanted, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "not recorded") {
+		t.Errorf("log does not note the unrecorded test completion:\n%s", logs.String())
+	}
+}
+
+// satisfiedStates decodes the X-Satisfied-States header of a validate response
+func satisfiedStates(t *testing.T, w *httptest.ResponseRecorder) map[string]bool {
+	t.Helper()
+	var states map[string]bool
+	if err := json.Unmarshal([]byte(w.Header().Get("X-Satisfied-States")), &states); err != nil {
+		t.Fatalf("invalid X-Satisfied-States %q: %v", w.Header().Get("X-Satisfied-States"), err)
+	}
+	return states
+}
+
+func TestHandleValidateAntiPaste(t *testing.T) {
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["basic"]["anti_paste"] = map[string]int{"max_jump": 3, "from_rule": 3}
+	})
+
+	tests := []struct {
+		name          string
+		passwords     []string
+		wantRule3     bool
+		wantCompleted bool
+	}{
+		{"typed in", []string{"A", "Abc", "Abcdef", "Abcdef!X", "Abcdef!X7"}, true, true},
+		{"pasted in one go", []string{"Abcdef!X7"}, false, false},
+		{"pasted then extended", []string{"Abcdef!X", "Abcdef!X7"}, false, false},
+		{"pasted, cleared and typed in", []string{"Abcdef!X7", "", "Abc", "Abcdef", "Abcdef!X", "Abcdef!X7"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionID := useTestSession(t, "basic")
+			var w *httptest.ResponseRecorder
+			for _, password := range tt.passwords {
+				w = validateRequest(sessionID, password, nil)
+				if w.Code != http.StatusOK {
+					t.Fatalf("validate %q status = %d, want %d", password, w.Code, http.StatusOK)
+				}
+			}
+
+			states := satisfiedStates(t, w)
+			if !states["1"] || !states["2"] {
+				t.Errorf("rules below from_rule were blocked: %v", states)
+			}
+			if states["3"] != tt.wantRule3 {
+				t.Errorf("rule 3 satisfied = %v, want %v", states["3"], tt.wantRule3)
+			}
+			session, _ := GetSession(sessionID)
+			if session.IsCompleted != tt.wantCompleted {
+				t.Errorf("IsCompleted = %v, want %v", session.IsCompleted, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+// registerRequest posts the registration form, from the session cookie when one is given
+func registerRequest(username, difficulty, sessionID string) *httptest.ResponseRecorder {
+	form := url.Values{"username": {username}, "difficulty": {difficulty}}
+	r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if sessionID != "" {
+		r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	}
+	w := httptest.NewRecorder()
+	HandleRegisterUser(w, r)
+	return w
+}
+
+// sessionCookieValue returns the user_session cookie a response set
+func sessionCookieValue(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "user_session" {
+			return cookie.Value
+		}
+	}
+	t.Fatal("response set no session cookie")
+	return ""
+}
+
+func TestHandleRegisterUserUnlockGating(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	database.SetDifficultyUnlockOrder([]string{"basic", "intermediate", "hard"})
+	t.Cleanup(func() { database.SetDifficultyUnlockOrder(nil) })
+
+	if w := registerRequest("newbie", "intermediate", ""); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "Complete basic first") {
+		t.Fatalf("locked registration got %d %q, want 403 naming basic", w.Code, w.Body.String())
+	}
+
+	w := registerRequest("newbie", "basic", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("basic registration status = %d, want %d", w.Code, http.StatusOK)
+	}
+	sessionID := sessionCookieValue(t, w)
+	session, _ := GetSession(sessionID)
+
+	if w := registerRequest("newbie", "intermediate", sessionID); w.Code != http.StatusForbidden {
+		t.Errorf("intermediate before finishing basic got %d, want 403", w.Code)
+	}
+
+	if err := database.RecordCompletion(session.UserID, rules.GetFinalRuleID("basic"), 120); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := registerRequest("newbie", "intermediate", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "already exists") {
+		t.Errorf("unlock from another client got %d %q, want the username taken error", w.Code, w.Body.String())
+	}
+	if w := registerRequest("newbie", "hard", sessionID); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "Complete intermediate first") {
+		t.Errorf("skipping a tier got %d %q, want 403 naming intermediate", w.Code, w.Body.String())
+	}
+
+	w = registerRequest("newbie", "intermediate", sessionID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlocked intermediate got %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	user, err := database.GetUserByUsername("newbie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != session.UserID || user.Difficulty != "intermediate" || user.RuleReached != 0 {
+		t.Errorf("promoted user = %+v, want the same account on intermediate with no progress", user)
+	}
+}
+
+func TestHandleRegisterUserConcurrentDuplicates(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+
+	// Each round fires two identical registrations at once; the UNIQUE constraint picks the winner
+	for round := 0; round < 10; round++ {
+		username := fmt.Sprintf("racer%d", round)
+		start := make(chan struct{})
+		responses := make([]*httptest.ResponseRecorder, 2)
+		var wg sync.WaitGroup
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				responses[i] = registerRequest(username, "basic", "")
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		codes := map[int]int{}
+		for _, w := range responses {
+			codes[w.Code]++
+			if w.Code == http.StatusBadRequest && !strings.Contains(w.Body.String(), "already exists") {
+				t.Errorf("%s: rejected registration says %q, want the username taken error", username, w.Body.String())
+			}
+		}
+		if codes[http.StatusOK] != 1 || codes[http.StatusBadRequest] != 1 {
+			t.Errorf("%s: got statuses %v, want one 200 and one 400", username, codes)
+		}
+	}
+
+	if count, err := database.GetUserCount(); err != nil || count != 10 {
+		t.Errorf("GetUserCount() = %d, %v, want one row per username", count, err)
+	}
+}
+
+func TestAbandonedSessionsRecordStuckRule(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	now := time.Now()
+
+	seeds := []struct {
+		username  string
+		completed bool
+		lastSeen  time.Time
+		visible   map[string]bool
+		satisfied map[string]bool
+	}{
+		// Stuck on rule 3; rule 5 shows too but the lowest unsatisfied rule counts
+		{"gaveup", false, now, map[string]bool{"1": true, "2": true, "3": true, "5": true}, map[string]bool{"1": true, "2": true}},
+		// Idle past the timeout, stuck on rule 3 as well
+		{"wandered", false, now.Add(-2 * sessionIdleTimeout), map[string]bool{"1": true, "2": true, "3": true}, map[string]bool{"1": true, "2": true}},
+		// Idle and stuck on rule 2
+		{"napping", false, now.Add(-2 * sessionIdleTimeout), map[string]bool{"1": true, "2": true}, map[string]bool{"1": true}},
+		// Finished games aren't abandoned
+		{"winner", true, now.Add(-2 * sessionIdleTimeout), map[string]bool{"1": true, "2": true}, map[string]bool{"1": true, "2": true}},
+		// Still playing
+		{"active", false, now, map[string]bool{"1": true}, map[string]bool{}},
+	}
+	for _, seed := range seeds {
+		userID := insertTestUser(t, seed.username, "basic")
+		session := &UserSession{
+			UserID:          userID,
+			Username:        seed.username,
+			Difficulty:      "basic",
+			StartTime:       now,
+			IsCompleted:     seed.completed,
+			VisibleStates:   seed.visible,
+			SatisfiedStates: seed.satisfied,
+		}
+		storeSession("session-"+seed.username, session)
+		// storeSession marks the session as just seen
+		session.LastSeen = seed.lastSeen
+	}
+
+	AbandonSession("session-gaveup")
+	cleanupIdleSessions(now.Add(-sessionIdleTimeout))
+
+	for _, username := range []string{"gaveup", "wandered", "napping", "winner"} {
+		if _, exists := GetSession("session-" + username); exists {
+			t.Errorf("%s's session is still stored", username)
+		}
+	}
+	if _, exists := GetSession("session-active"); !exists {
+		t.Error("the active session was abandoned")
+	}
+
+	stats, err := database.GetUserStats()
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	want := map[int]int{2: 1, 3: 2}
+	if got := stats["stuck_distribution"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("stuck_distribution = %v, want %v", got, want)
+	}
+}
+
+// injectedPositions decodes the X-Injected-Positions header
+func injectedPositions(t *testing.T, w *httptest.ResponseRecorder) rules.InjectedPositions {
+	t.Helper()
+	header := w.Header().Get("X-Injected-Positions")
+	if header == "" {
+		t.Fatal("response has no X-Injected-Positions header")
+	}
+	var positions rules.InjectedPositions
+	if err := json.Unmarshal([]byte(header), &positions); err != nil {
+		t.Fatalf("invalid X-Injected-Positions %q: %v", header, err)
+	}
+	return positions
+}
+
+func TestHandleValidateInjectedPositions(t *testing.T) {
+	if err := rules.SetRevealMode(rules.RevealAll); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rules.SetRevealMode(rules.RevealSequential) })
+
+	// Intermediate plays rules 24 and 25; multibyte runes come before the squares so byte
+	// offsets would be wrong
+	sessionID := useTestSession(t, "intermediate")
+	session, _ := GetSession(sessionID)
+	other := &UserSession{UserID: -1, Username: "Other User", Difficulty: "intermediate"}
+	password := "é🙂xkqwz⬛vb⬛"
+	runes := []rune(password)
+
+	positions := injectedPositions(t, validateRequest(sessionID, password, nil))
+	if !slices.Equal(positions.BlackSquares, []int{7, 10}) {
+		t.Errorf("black squares at %v, want rune positions [7 10]", positions.BlackSquares)
+	}
+	imposters := CyberSecurityFor(session).ImposterIndices()
+	if len(imposters) == 0 || !slices.Equal(positions.Imposters, imposters) {
+		t.Fatalf("imposters reported at %v, want the planted %v", positions.Imposters, imposters)
+	}
+
+	// The imposters belong to this game only
+	if planted := CyberSecurityFor(other).ImposterIndices(); len(planted) != 0 {
+		t.Errorf("another session has imposters %v before validating anything", planted)
+	}
+	if positions := CyberSecurityFor(other).InjectedPositions(password); len(positions.Imposters) != 0 {
+		t.Errorf("another session reports imposters at %v", positions.Imposters)
+	}
+	for _, idx := range positions.Imposters {
+		if idx >= len(runes) {
+			t.Errorf("imposter position %d is past the %d runes of the password", idx, len(runes))
+		}
+	}
+
+	// Deleting the last imposter (so the others don't shift) drops it from the report
+	last := slices.Max(imposters)
+	edited := append(append([]rune{}, runes[:last]...), runes[last+1:]...)
+	positions = injectedPositions(t, validateRequest(sessionID, string(edited), nil))
+	if want := slices.DeleteFunc(slices.Clone(imposters), func(idx int) bool { return idx == last }); !slices.Equal(positions.Imposters, want) {
+		t.Errorf("after deleting imposter %d, imposters reported at %v, want %v", last, positions.Imposters, want)
+	}
+	for _, idx := range positions.BlackSquares {
+		if edited[idx] != '⬛' {
+			t.Errorf("reported black square %d holds %q", idx, edited[idx])
+		}
+	}
+
+	// Difficulties without rules 24 and 25 don't get the header
+	basic := useTestSession(t, "basic")
+	if header := validateRequest(basic, password, nil).Header().Get("X-Injected-Positions"); header != "" {
+		t.Errorf("basic game got X-Injected-Positions %s", header)
+	}
+}
+
+func TestHandleValidateStrengthCount(t *testing.T) {
+	if err := rules.SetRevealMode(rules.RevealAll); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rules.SetRevealMode(rules.RevealSequential) })
+	sessionID := useTestSession(t, "expert")
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"none yet", "password", "0/3 🏋️"},
+		{"VS-16 and bare forms", "pass\U0001F3CB\uFE0Fword\U0001F3CB", "2/3 🏋️"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := validateRequest(sessionID, tt.password, nil).Body.String()
+			if !strings.Contains(body, ">"+tt.want+"</div>") {
+				t.Errorf("rule 20 progress label doesn't read %q", tt.want)
+			}
+		})
+	}
+}
+
+// usernameAvailable requests /api/username-available for name and decodes the response
+func usernameAvailable(t *testing.T, name string) (int, map[string]interface{}) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/username-available?"+url.Values{"u": {name}}.Encode(), nil)
+	w := httptest.NewRecorder()
+	HandleUsernameAvailable(w, r)
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON for %q: %v", name, err)
+	}
+	return w.Code, body
+}
+
+func TestHandleUsernameAvailable(t *testing.T) {
+	useEmptyDB(t)
+	insertTestUser(t, "taken_name", "basic")
+
+	tests := []struct {
+		name          string
+		username      string
+		wantStatus    int
+		wantAvailable bool
+		wantError     string
+	}{
+		{"available", "fresh_name", http.StatusOK, true, ""},
+		{"taken", "taken_name", http.StatusOK, false, ""},
+		{"taken with other casing", "TAKEN_Name", http.StatusOK, false, ""},
+		{"taken with surrounding spaces", "  taken_name ", http.StatusOK, false, ""},
+		{"too short", "ab", http.StatusBadRequest, false, "Username must be between 3-20 characters"},
+		{"too long", strings.Repeat("a", 21), http.StatusBadRequest, false, "Username must be between 3-20 characters"},
+		{"empty", "", http.StatusBadRequest, false, "Username must be between 3-20 characters"},
+		{"control character", "bad\tname", http.StatusBadRequest, false, "Username contains invalid characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := usernameAvailable(t, tt.username)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if body["available"] != tt.wantAvailable {
+				t.Errorf("available = %v, want %v", body["available"], tt.wantAvailable)
+			}
+			if tt.wantError != "" && body["error"] != tt.wantError {
+				t.Errorf("error = %v, want %q", body["error"], tt.wantError)
+			}
+		})
+	}
+
+	handler := NewRateLimiter(UsernameCheckRatePerSecond, UsernameCheckBurst).Middleware(HandleUsernameAvailable)
+	for i := 0; i <= UsernameCheckBurst; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/api/username-available?u=fresh_name", nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if i < UsernameCheckBurst && w.Code != http.StatusOK {
+			t.Fatalf("check %d got status %d within the burst", i+1, w.Code)
+		}
+		if i == UsernameCheckBurst && w.Code != http.StatusTooManyRequests {
+			t.Errorf("check past the burst got status %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+	}
+}
+
+// useTimeLimit gives basic a time budget for the test
+func useTimeLimit(t *testing.T, seconds int) {
+	t.Helper()
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["basic"]["time_limit_seconds"] = seconds
+	})
+	if got := GetTimeLimit("basic"); got != time.Duration(seconds)*time.Second {
+		t.Fatalf("GetTimeLimit(basic) = %v, want %ds", got, seconds)
+	}
+}
+
+func TestHandleValidateWithinTimeLimit(t *testing.T) {
+	useTimeLimit(t, 60)
+	sessionID := useTestSession(t, "basic")
+	session, _ := GetSession(sessionID)
+	session.StartTime = time.Now().Add(-10 * time.Second)
+
+	w := validateRequest(sessionID, "abcdefgh", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	remaining, err := strconv.Atoi(w.Header().Get("X-Time-Remaining"))
+	if err != nil || remaining < 49 || remaining > 50 {
+		t.Errorf("X-Time-Remaining = %q, want about 50", w.Header().Get("X-Time-Remaining"))
+	}
+	if w.Header().Get("X-Timed-Out") != "" {
+		t.Error("a run within its time limit was timed out")
+	}
+	if !satisfiedStates(t, w)["1"] {
+		t.Error("progress within the time limit was not accepted")
+	}
+
+	// Untimed difficulties don't report a remaining time
+	w = validateRequest(useTestSession(t, "intermediate"), "abcdefgh", nil)
+	if got := w.Header().Get("X-Time-Remaining"); got != "" {
+		t.Errorf("untimed difficulty X-Time-Remaining = %q", got)
+	}
+}
+
+func TestHandleValidateExpiredTimeLimit(t *testing.T) {
+	useTimeLimit(t, 60)
+	useEmptyDB(t)
+	useSessions(t)
+	userID := insertTestUser(t, "slowpoke", "basic")
+	if err := database.UpdateUserProgress(userID, 3, 40); err != nil {
+		t.Fatal(err)
+	}
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{
+		UserID:     userID,
+		Username:   "slowpoke",
+		Difficulty: "basic",
+		StartTime:  time.Now().Add(-61 * time.Second),
+		MaxRule:    3,
+	})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		w := validateRequest(sessionID, "Abcdef!X7", nil)
+		if w.Header().Get("X-Timed-Out") != "true" {
+			t.Fatalf("attempt %d: X-Timed-Out = %q, want true", attempt, w.Header().Get("X-Timed-Out"))
+		}
+		if w.Header().Get("X-Satisfied-States") != "" {
+			t.Errorf("attempt %d: an expired run still validated the password", attempt)
+		}
+		if attempt == 1 {
+			if got := w.Header().Get("X-Time-Remaining"); got != "0" {
+				t.Errorf("X-Time-Remaining = %q, want 0", got)
+			}
+			if !strings.Contains(w.Body.String(), "Time's up! You reached rule 3") {
+				t.Errorf("body = %q, want the timeout message", w.Body.String())
+			}
+		}
+	}
+
+	session, _ := GetSession(sessionID)
+	if !session.TimedOut || session.IsCompleted {
+		t.Errorf("session TimedOut = %v, IsCompleted = %v, want a timed-out, unfinished run", session.TimedOut, session.IsCompleted)
+	}
+
+	user, err := database.GetUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.TimedOut || user.RuleReached != 3 || user.TimeSpent != 60 {
+		t.Errorf("user = %+v, want timed out at rule 3 after the 60s limit", user)
+	}
+}
+
+// useProgressSaveInterval throttles progress writes for the test
+func useProgressSaveInterval(t *testing.T, interval time.Duration) {
+	t.Helper()
+	previous := progressSaveInterval
+	SetProgressSaveInterval(interval)
+	t.Cleanup(func() { SetProgressSaveInterval(previous) })
+}
+
+func TestHandleValidateThrottlesProgressWrites(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	useProgressSaveInterval(t, time.Hour)
+	userID := insertTestUser(t, "typist", "basic")
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{UserID: userID, Username: "typist", Difficulty: "basic", StartTime: time.Now()})
+	logs := captureLog(t)
+
+	// Four new rules in quick succession
+	var previous http.Header
+	for _, password := range []string{"abcdefgh", "Abcdefgh", "Abcdefgh!", "Abcdefgh!4"} {
+		w := validateRequest(sessionID, password, previous)
+		previous = w.Header()
+	}
+
+	if writes := strings.Count(logs.String(), "📈 Database updated"); writes != 1 {
+		t.Errorf("%d progress writes within the save interval, want 1:\n%s", writes, logs.String())
+	}
+	session, _ := GetSession(sessionID)
+	if session.MaxRule != 4 {
+		t.Errorf("in-memory MaxRule = %d, want 4 straight away", session.MaxRule)
+	}
+	if user, _ := database.GetUser(userID); user.RuleReached != 1 {
+		t.Errorf("RuleReached = %d, want only the first rule written", user.RuleReached)
+	}
+
+	// Flushing writes the latest pending rule
+	FlushAllProgress()
+	if user, _ := database.GetUser(userID); user.RuleReached != 4 {
+		t.Errorf("RuleReached after flushing = %d, want 4", user.RuleReached)
+	}
+	if writes := strings.Count(logs.String(), "📈 Database updated"); writes != 2 {
+		t.Errorf("%d progress writes after flushing, want 2", writes)
+	}
+
+	// Completing the game is always written, even inside the interval
+	w := validateRequest(sessionID, "Abcdef!X7", previous)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	session, _ = GetSession(sessionID)
+	if !session.IsCompleted {
+		t.Fatal("the session did not complete basic")
+	}
+	if user, _ := database.GetUser(userID); user.RuleReached != rules.GetFinalRuleID("basic") {
+		t.Errorf("RuleReached after completing = %d, want %d", user.RuleReached, rules.GetFinalRuleID("basic"))
+	}
+}
@@ -0,0 +1,69 @@
+package component
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Fatal failure modes, see Config.FatalFailureMode
+const (
+	// FailureModeReset restarts the player's run from rule 1 when the game is lost (the default)
+	FailureModeReset = "reset"
+	// FailureModeFail ends the run where it stands; validation is refused until the player resets
+	FailureModeFail = "fail"
+)
+
+// GameFailure records how a run was lost. Timeouts always fail the run; other fatal conditions
+// such as a ransomware overrun only do in FailureModeFail.
+type GameFailure struct {
+	Failed bool      `json:"failed"`
+	Reason string    `json:"reason,omitempty"`
+	AtRule int       `json:"at_rule,omitempty"` // highest rule reached when the run was lost
+	At     time.Time `json:"at,omitempty"`
+}
+
+// FailsOnFatal reports whether fatal conditions end the run instead of restarting it
+func FailsOnFatal() bool {
+	return Config.FatalFailureMode == FailureModeFail
+}
+
+// FailSession ends a session's run, keeping its progress as the failure point. It reports
+// whether this call failed it, so callers only log and persist the first failure.
+func FailSession(session *UserSession, reason string) bool {
+	sessionsMutex.Lock()
+	if session.Failure.Failed {
+		sessionsMutex.Unlock()
+		return false
+	}
+	session.Failure = GameFailure{
+		Failed: true,
+		Reason: reason,
+		AtRule: session.MaxRule,
+		At:     time.Now(),
+	}
+	atRule := session.MaxRule
+	sessionsMutex.Unlock()
+
+	log.Printf("💀 %s lost their %s game at rule %d: %s", session.Username, session.Difficulty, atRule, reason)
+	if HasDatabaseUser(session) {
+		flushProgress(session, true)
+	}
+	return true
+}
+
+// sessionFailure returns the session's failure state
+func sessionFailure(session *UserSession) GameFailure {
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+	return session.Failure
+}
+
+// writeFailure answers /validate for a lost run: the client disables the input on X-Game-Failed
+func writeFailure(w http.ResponseWriter, failure GameFailure) {
+	w.Header().Set("X-Game-Failed", "true")
+	fmt.Fprintf(w, `<div class="error-message">💀 Game over: %s. You reached rule %d. Reset your progress to play again.</div>`,
+		template.HTMLEscapeString(failure.Reason), failure.AtRule)
+}
@@ -0,0 +1,58 @@
+package component
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFailSessionRefusesValidation(t *testing.T) {
+	sessionID := useTestSession(t, "basic")
+	session, _ := GetSession(sessionID)
+
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh"} {
+		previous = validateRequest(sessionID, password, previous).Header()
+	}
+	maxRule := session.MaxRule
+	if maxRule == 0 {
+		t.Fatal("no progress before the failure")
+	}
+
+	if !FailSession(session, "the ransomware took over your password") {
+		t.Fatal("FailSession() = false for a running game")
+	}
+	if FailSession(session, "time's up") {
+		t.Error("FailSession() = true for a game that already failed")
+	}
+	failure := sessionFailure(session)
+	if !failure.Failed || failure.Reason != "the ransomware took over your password" || failure.AtRule != maxRule || failure.At.IsZero() {
+		t.Errorf("failure = %+v, want the first reason at rule %d", failure, maxRule)
+	}
+
+	// A password that would satisfy every rule is refused and records nothing
+	for i := 0; i < 2; i++ {
+		w := validateRequest(sessionID, "Abcdef!X7", previous)
+		if w.Header().Get("X-Game-Failed") != "true" {
+			t.Fatalf("validate %d after failing has no X-Game-Failed header", i+1)
+		}
+		if body := w.Body.String(); !strings.Contains(body, "Game over: the ransomware took over your password") {
+			t.Errorf("validate %d after failing = %q, want the game over message", i+1, body)
+		}
+		if w.Header().Get("X-Satisfied-States") != "" {
+			t.Errorf("validate %d after failing reported rule states", i+1)
+		}
+	}
+	if session.MaxRule != maxRule || session.IsCompleted {
+		t.Errorf("failed run moved to rule %d (completed %v), want it kept at %d", session.MaxRule, session.IsCompleted, maxRule)
+	}
+
+	// Resetting starts a fresh run
+	ResetSessionProgress(session)
+	if sessionFailure(session).Failed {
+		t.Fatal("failure survived a reset")
+	}
+	if w := validateRequest(sessionID, "abc", nil); w.Header().Get("X-Game-Failed") != "" {
+		t.Error("validate after the reset was refused")
+	}
+}
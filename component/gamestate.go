@@ -0,0 +1,348 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"passgame/rules"
+)
+
+// Pool rule IDs that depend on rotating server-side values
+const (
+	mathConstantRuleID  = 13
+	updateAlertRuleID   = 14
+	captchaRuleID       = 15
+	wordleRuleID        = 16
+	qrCodeRuleID        = 17
+	colorRuleID         = 18
+	chessRuleID         = 19
+	passwordLockRuleID  = 23
+	ransomwareRuleID    = 24
+	insiderThreatRuleID = 25
+)
+
+// GameState is the dynamic data a custom client needs to render a session's rules.
+// Sections are omitted when the session's difficulty doesn't include the rule, and
+// answers are only filled in when hints are requested.
+type GameState struct {
+	Username     string              `json:"username"`
+	Difficulty   string              `json:"difficulty"`
+	MaxRule      int                 `json:"max_rule"`
+	IsCompleted  bool                `json:"is_completed"`
+	RuleIDs      []int               `json:"rule_ids"`
+	HintsEnabled bool                `json:"hints_enabled"`
+	Captcha      *ImageRuleState     `json:"captcha,omitempty"`
+	QRCode       *ImageRuleState     `json:"qr_code,omitempty"`
+	Color        *ColorRuleState     `json:"color,omitempty"`
+	MathConstant *ConstantRuleState  `json:"math_constant,omitempty"`
+	Chess        *ChessRuleState     `json:"chess,omitempty"`
+	Wordle       *WordleRuleState    `json:"wordle,omitempty"`
+	CyberSec     *CyberSecRulesState `json:"cysec,omitempty"`
+}
+
+// ImageRuleState points at the image a player must read the answer from
+type ImageRuleState struct {
+	ImageURL string `json:"image_url"`
+	Answer   string `json:"answer,omitempty"`
+}
+
+// ColorRuleState describes the color swatch rule
+type ColorRuleState struct {
+	ImageURL string `json:"image_url"`
+	Name     string `json:"name,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+}
+
+// ConstantRuleState describes the mathematical constant rule
+type ConstantRuleState struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// ChessRuleState describes the best-move rule
+type ChessRuleState struct {
+	ImageURL string `json:"image_url"`
+	BestMove string `json:"best_move,omitempty"`
+}
+
+// WordleRuleState describes the Wordle rule
+type WordleRuleState struct {
+	Date   string `json:"date"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// CyberSecRulesState holds the cybersecurity rule flags and unlock strings
+type CyberSecRulesState struct {
+	UpdateAlertShown bool   `json:"update_alert_shown"`
+	UpdateString     string `json:"update_string,omitempty"`
+	AdWatched        bool   `json:"ad_watched"`
+	RaidUnlockString string `json:"raid_unlock_string,omitempty"`
+	BlackSquareCount int    `json:"black_square_count"`
+}
+
+// buildGameState assembles the dynamic rule values for a session
+func buildGameState(session *UserSession, includeHints bool) GameState {
+	ruleSet := sessionRuleSet(session)
+	rules.LocalizeRuleSet(ruleSet, session.Locale)
+	hasRule := make(map[int]bool)
+	ruleIDs := make([]int, 0, len(ruleSet.Rules))
+	for _, rule := range ruleSet.Rules {
+		hasRule[rule.ID] = true
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+
+	sessionsMutex.RLock()
+	state := GameState{
+		Username:     session.Username,
+		Difficulty:   session.Difficulty,
+		MaxRule:      session.MaxRule,
+		IsCompleted:  session.IsCompleted,
+		RuleIDs:      ruleIDs,
+		HintsEnabled: includeHints,
+	}
+	sessionsMutex.RUnlock()
+
+	if hasRule[captchaRuleID] {
+		// The captcha answer is never exposed; it must be read from the image
+		state.Captcha = &ImageRuleState{ImageURL: "/captcha.png"}
+	}
+
+	if hasRule[qrCodeRuleID] {
+		state.QRCode = &ImageRuleState{ImageURL: "/qrcode.png"}
+		if includeHints {
+			state.QRCode.Answer = rules.GetCurrentQRWord()
+		}
+	}
+
+	if hasRule[colorRuleID] {
+		state.Color = &ColorRuleState{ImageURL: "/color.png"}
+		if includeHints {
+			state.Color.Name, state.Color.Hex = rules.GetCurrentColor()
+		}
+	}
+
+	if hasRule[mathConstantRuleID] {
+		name, value := rules.GetCurrentMathConstant()
+		state.MathConstant = &ConstantRuleState{Name: name}
+		if includeHints {
+			state.MathConstant.Value = value
+		}
+	}
+
+	if hasRule[chessRuleID] {
+		state.Chess = &ChessRuleState{ImageURL: "/chess.png"}
+		if includeHints {
+			_, state.Chess.BestMove = rules.GetCurrentChessPosition()
+		}
+	}
+
+	if hasRule[wordleRuleID] {
+		state.Wordle = &WordleRuleState{Date: rules.CurrentWordleDate()}
+		if includeHints {
+			state.Wordle.Answer = rules.GetTodaysAnswerForHint()
+		}
+	}
+
+	if hasRule[updateAlertRuleID] || hasRule[passwordLockRuleID] || hasRule[ransomwareRuleID] {
+		cyberSecurity := CyberSecurityFor(session)
+		status := cyberSecurity.Status()
+		state.CyberSec = &CyberSecRulesState{
+			UpdateAlertShown: status.UpdateAlertShown,
+			AdWatched:        status.AdWatched,
+			BlackSquareCount: status.BlackSquareCount,
+		}
+		// The update and raid strings are shown to the player once their alert or ad appears
+		if includeHints || status.UpdateAlertShown {
+			state.CyberSec.UpdateString = cyberSecurity.UpdateString()
+		}
+		if includeHints || status.AdWatched {
+			state.CyberSec.RaidUnlockString = status.RaidUnlockString
+		}
+	}
+
+	return state
+}
+
+// HandleGameState serves GET /api/game/state for the session cookie. Answers are only
+// included with ?hints=1 when hints are enabled.
+func HandleGameState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		writeJSONError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+
+	includeHints := r.URL.Query().Get("hints") == "1" && ShowHintsFor(session.Difficulty)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildGameState(session, includeHints))
+}
+
+// GameProgress is a session's progress summarized for a progress bar
+type GameProgress struct {
+	Satisfied int     `json:"satisfied"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+	Completed bool    `json:"completed"`
+}
+
+// buildGameProgress summarizes the rule states saved by the session's last validation
+func buildGameProgress(session *UserSession) GameProgress {
+	ruleSet := sessionRuleSet(session)
+
+	sessionsMutex.RLock()
+	savedSatisfied := session.SatisfiedStates
+	completed := session.IsCompleted
+	sessionsMutex.RUnlock()
+
+	for i, satisfied := range statesFromMap(ruleSet, savedSatisfied) {
+		ruleSet.Rules[i].IsSatisfied = satisfied
+	}
+
+	progress := GameProgress{
+		Satisfied: rules.GetSatisfiedCount(ruleSet),
+		Total:     len(ruleSet.Rules),
+		Completed: completed,
+	}
+	if progress.Total > 0 {
+		progress.Percent = (float64(progress.Satisfied) / float64(progress.Total)) * 100
+	}
+	return progress
+}
+
+// HandleGameProgress serves GET /api/game/progress for the session cookie
+func HandleGameProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		writeJSONError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildGameProgress(session))
+}
+
+// RemainingRule is a visible rule the session hasn't satisfied yet
+type RemainingRule struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Hint        string `json:"hint,omitempty"`
+}
+
+// buildRemainingRules lists the visible, unsatisfied rules saved by the session's last
+// validation, in the order the game shows them. Hints are only filled in with includeHints.
+func buildRemainingRules(session *UserSession, includeHints bool) []RemainingRule {
+	ruleSet := sessionRuleSet(session)
+	rules.LocalizeRuleSet(ruleSet, session.Locale)
+
+	sessionsMutex.RLock()
+	savedSatisfied := session.SatisfiedStates
+	savedVisible := session.VisibleStates
+	sessionsMutex.RUnlock()
+
+	// Before the first validation no rule is visible yet, so nothing remains
+	visibleStates := statesFromMap(ruleSet, savedVisible)
+	for i, satisfied := range statesFromMap(ruleSet, savedSatisfied) {
+		ruleSet.Rules[i].IsSatisfied = satisfied
+		ruleSet.Rules[i].IsVisible = visibleStates[i]
+	}
+
+	remaining := make([]RemainingRule, 0, len(ruleSet.Rules))
+	for _, rule := range rules.GetSortedVisibleRules(ruleSet) {
+		if rule.IsSatisfied {
+			continue
+		}
+		entry := RemainingRule{ID: rule.ID, Description: rule.Description}
+		if includeHints {
+			entry.Hint = rule.Hint
+		}
+		remaining = append(remaining, entry)
+	}
+	return remaining
+}
+
+// HandleGameRemaining serves GET /api/game/remaining, the rules the player still has to
+// satisfy. Hints follow the difficulty's hint setting.
+func HandleGameRemaining(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		writeJSONError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+
+	includeHints := ShowHintsFor(session.Difficulty)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"hints_enabled": includeHints,
+		"rules":         buildRemainingRules(session, includeHints),
+	})
+}
+
+// maxTranscriptEntries caps a session's transcript; the oldest entries are dropped first
+const maxTranscriptEntries = 500
+
+// TranscriptEntry records one validation. Only the password's length is kept, never the password.
+type TranscriptEntry struct {
+	Time           time.Time `json:"time"`
+	PasswordLength int       `json:"password_length"`
+	Satisfied      []int     `json:"satisfied"`
+}
+
+// appendTranscriptLocked adds a validation to the session's transcript. The caller holds sessionsMutex.
+func appendTranscriptLocked(session *UserSession, password string, ruleSet *rules.RuleSet) {
+	satisfied := make([]int, 0, len(ruleSet.Rules))
+	for _, rule := range ruleSet.Rules {
+		if rule.IsSatisfied {
+			satisfied = append(satisfied, rule.ID)
+		}
+	}
+
+	session.Transcript = append(session.Transcript, TranscriptEntry{
+		Time:           time.Now().UTC(),
+		PasswordLength: utf8.RuneCountInString(password),
+		Satisfied:      satisfied,
+	})
+	if excess := len(session.Transcript) - maxTranscriptEntries; excess > 0 {
+		session.Transcript = append([]TranscriptEntry(nil), session.Transcript[excess:]...)
+	}
+}
+
+// HandleGameTranscript serves GET /api/game/transcript, the session's validation history
+func HandleGameTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		writeJSONError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+
+	sessionsMutex.RLock()
+	transcript := make([]TranscriptEntry, len(session.Transcript))
+	copy(transcript, session.Transcript)
+	sessionsMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transcript)
+}
@@ -0,0 +1,371 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"passgame/rules"
+)
+
+// getGameState requests /api/game/state for the session and decodes the response
+func getGameState(t *testing.T, sessionID, query string) GameState {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/game/state"+query, nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleGameState(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var state GameState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return state
+}
+
+func TestHandleGameStateSpoilers(t *testing.T) {
+	useConfig(t)
+	RefreshAllChallenges(context.Background())
+	sessionID := useTestSession(t, "expert")
+	session, _ := GetSession(sessionID)
+	updateString := CyberSecurityFor(session).UpdateString()
+
+	tests := []struct {
+		name      string
+		query     string
+		showHints bool
+		wantHints bool
+	}{
+		{"no flag", "", true, false},
+		{"flag with hints enabled", "?hints=1", true, true},
+		{"flag with hints disabled", "?hints=1", false, false},
+		{"other flag value", "?hints=yes", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Config.ShowHints = tt.showHints
+			state := getGameState(t, sessionID, tt.query)
+
+			if state.Difficulty != "expert" || len(state.RuleIDs) != rules.GetRuleCount("expert") {
+				t.Errorf("state is for %q with %d rules", state.Difficulty, len(state.RuleIDs))
+			}
+			if state.HintsEnabled != tt.wantHints {
+				t.Errorf("HintsEnabled = %v, want %v", state.HintsEnabled, tt.wantHints)
+			}
+			if state.Captcha == nil || state.QRCode == nil || state.Color == nil || state.MathConstant == nil ||
+				state.Chess == nil || state.Wordle == nil || state.CyberSec == nil {
+				t.Fatalf("expert state is missing a section: %+v", state)
+			}
+			if state.Captcha.ImageURL == "" || state.Captcha.Answer != "" {
+				t.Errorf("captcha = %+v, want an image and never the answer", state.Captcha)
+			}
+			if state.Wordle.Date == "" || state.MathConstant.Name == "" {
+				t.Errorf("non-spoiler fields are missing: wordle %+v, constant %+v", state.Wordle, state.MathConstant)
+			}
+
+			spoilers := map[string][2]string{
+				"QR word":       {state.QRCode.Answer, rules.TestQRWord},
+				"color hex":     {state.Color.Hex, rules.TestColorHex},
+				"constant":      {state.MathConstant.Value, rules.TestConstantValue},
+				"chess move":    {state.Chess.BestMove, rules.TestChessMove},
+				"Wordle answer": {state.Wordle.Answer, rules.TestWordleAnswer},
+				"update string": {state.CyberSec.UpdateString, updateString},
+				"raid string":   {state.CyberSec.RaidUnlockString, rules.GetRaidUnlockString()},
+			}
+			for name, spoiler := range spoilers {
+				got, answer := spoiler[0], spoiler[1]
+				if tt.wantHints && got != answer {
+					t.Errorf("%s = %q, want %q", name, got, answer)
+				}
+				if !tt.wantHints && got != "" {
+					t.Errorf("%s = %q leaked without hints", name, got)
+				}
+			}
+		})
+	}
+
+	t.Run("basic has no dynamic rules", func(t *testing.T) {
+		state := getGameState(t, useTestSession(t, "basic"), "?hints=1")
+		if state.Captcha != nil || state.QRCode != nil || state.Wordle != nil || state.CyberSec != nil {
+			t.Errorf("basic state has dynamic sections: %+v", state)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/game/state", nil)
+	w := httptest.NewRecorder()
+	HandleGameState(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+// getGameProgress requests /api/game/progress for the session and decodes the response
+func getGameProgress(t *testing.T, sessionID string) GameProgress {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/game/progress", nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleGameProgress(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var progress GameProgress
+	if err := json.NewDecoder(w.Body).Decode(&progress); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return progress
+}
+
+func TestHandleGameProgress(t *testing.T) {
+	useConfig(t)
+	sessionID := useTestSession(t, "basic")
+	total := rules.GetRuleCount("basic")
+
+	if progress := getGameProgress(t, sessionID); progress != (GameProgress{Total: total}) {
+		t.Errorf("before validating = %+v, want nothing satisfied out of %d", progress, total)
+	}
+
+	// Rules are revealed one at a time, so type the password in steps
+	var w *httptest.ResponseRecorder
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!"} {
+		w = validateRequest(sessionID, password, previous)
+		previous = w.Header()
+	}
+	satisfied := 0
+	for _, ok := range satisfiedStates(t, w) {
+		if ok {
+			satisfied++
+		}
+	}
+	if satisfied == 0 || satisfied == total {
+		t.Fatalf("%d of %d rules satisfied, want a partially complete set", satisfied, total)
+	}
+
+	progress := getGameProgress(t, sessionID)
+	want := GameProgress{
+		Satisfied: satisfied,
+		Total:     total,
+		Percent:   float64(satisfied) / float64(total) * 100,
+	}
+	if progress != want {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/game/progress", nil)
+	w = httptest.NewRecorder()
+	HandleGameProgress(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+// getGameTranscript requests /api/game/transcript for the session and decodes the response
+func getGameTranscript(t *testing.T, sessionID string) []TranscriptEntry {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/game/transcript", nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleGameTranscript(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var transcript []TranscriptEntry
+	if err := json.NewDecoder(w.Body).Decode(&transcript); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return transcript
+}
+
+func TestHandleGameTranscript(t *testing.T) {
+	useConfig(t)
+	sessionID := useTestSession(t, "basic")
+
+	if transcript := getGameTranscript(t, sessionID); len(transcript) != 0 {
+		t.Errorf("transcript before validating = %+v, want empty", transcript)
+	}
+
+	passwords := []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"}
+	var w *httptest.ResponseRecorder
+	var previous http.Header
+	for _, password := range passwords {
+		w = validateRequest(sessionID, password, previous)
+		previous = w.Header()
+	}
+
+	transcript := getGameTranscript(t, sessionID)
+	if len(transcript) != len(passwords) {
+		t.Fatalf("transcript has %d entries, want %d", len(transcript), len(passwords))
+	}
+	for i, entry := range transcript {
+		if entry.PasswordLength != len(passwords[i]) {
+			t.Errorf("entry %d length = %d, want %d", i, entry.PasswordLength, len(passwords[i]))
+		}
+		if i > 0 && entry.Time.Before(transcript[i-1].Time) {
+			t.Errorf("entry %d at %v is before entry %d at %v", i, entry.Time, i-1, transcript[i-1].Time)
+		}
+		if i > 0 && len(entry.Satisfied) < len(transcript[i-1].Satisfied) {
+			t.Errorf("entry %d satisfies %v, fewer than %v before it", i, entry.Satisfied, transcript[i-1].Satisfied)
+		}
+	}
+
+	last := transcript[len(transcript)-1]
+	states := satisfiedStates(t, w)
+	if len(last.Satisfied) != len(states) {
+		t.Errorf("last entry satisfies %v, want all %d basic rules", last.Satisfied, len(states))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/game/transcript", nil)
+	w = httptest.NewRecorder()
+	HandleGameTranscript(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAppendTranscriptCap(t *testing.T) {
+	session := &UserSession{}
+	ruleSet := rules.NewRuleSet("basic")
+	for i := 1; i <= maxTranscriptEntries+10; i++ {
+		appendTranscriptLocked(session, strings.Repeat("x", i), ruleSet)
+	}
+
+	if len(session.Transcript) != maxTranscriptEntries {
+		t.Fatalf("transcript has %d entries, want the cap of %d", len(session.Transcript), maxTranscriptEntries)
+	}
+	// The oldest ten were dropped
+	if first := session.Transcript[0].PasswordLength; first != 11 {
+		t.Errorf("first kept entry has length %d, want 11", first)
+	}
+	if last := session.Transcript[maxTranscriptEntries-1].PasswordLength; last != maxTranscriptEntries+10 {
+		t.Errorf("last entry has length %d, want %d", last, maxTranscriptEntries+10)
+	}
+}
+
+// remainingResponse is the body of /api/game/remaining
+type remainingResponse struct {
+	HintsEnabled bool            `json:"hints_enabled"`
+	Rules        []RemainingRule `json:"rules"`
+}
+
+// getGameRemaining requests /api/game/remaining for the session and decodes the response
+func getGameRemaining(t *testing.T, sessionID string) remainingResponse {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/game/remaining", nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleGameRemaining(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var remaining remainingResponse
+	if err := json.NewDecoder(w.Body).Decode(&remaining); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return remaining
+}
+
+func TestHandleGameRemaining(t *testing.T) {
+	useConfig(t)
+	Config.ShowHints = true
+	sessionID := useTestSession(t, "basic")
+
+	// Nothing is visible before the first validation
+	if remaining := getGameRemaining(t, sessionID); remaining.Rules == nil || len(remaining.Rules) != 0 {
+		t.Errorf("rules before validating = %v, want an empty list", remaining.Rules)
+	}
+
+	var last *httptest.ResponseRecorder
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh"} {
+		var previous http.Header
+		if last != nil {
+			previous = last.Header()
+		}
+		last = validateRequest(sessionID, password, previous)
+	}
+	satisfied := satisfiedStates(t, last)
+	var visible map[string]bool
+	if err := json.Unmarshal([]byte(last.Header().Get("X-Visible-States")), &visible); err != nil {
+		t.Fatalf("invalid X-Visible-States: %v", err)
+	}
+
+	want := make(map[int]bool)
+	satisfiedVisible := 0
+	for key, shown := range visible {
+		if !shown {
+			continue
+		}
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if satisfied[key] {
+			satisfiedVisible++
+		} else {
+			want[id] = true
+		}
+	}
+	if satisfiedVisible == 0 || len(want) == 0 {
+		t.Fatalf("visible %v, satisfied %v: want both satisfied and unsatisfied rules on show", visible, satisfied)
+	}
+
+	remaining := getGameRemaining(t, sessionID)
+	if !remaining.HintsEnabled {
+		t.Error("hints_enabled = false with hints on")
+	}
+	if len(remaining.Rules) != len(want) {
+		t.Errorf("got %d rules %+v, want only the unsatisfied visible %v", len(remaining.Rules), remaining.Rules, want)
+	}
+	for _, rule := range remaining.Rules {
+		if !want[rule.ID] {
+			t.Errorf("rule %d is listed but is satisfied or hidden", rule.ID)
+		}
+		if rule.Description == "" || rule.Hint == "" {
+			t.Errorf("rule %d = %+v, want a description and hint", rule.ID, rule)
+		}
+	}
+
+	// With hints off for the difficulty the rules stay but their hints go
+	Config.ShowHints = false
+	hidden := getGameRemaining(t, sessionID)
+	if hidden.HintsEnabled || len(hidden.Rules) != len(remaining.Rules) {
+		t.Errorf("hints off: hints_enabled %v with %d rules, want false with %d", hidden.HintsEnabled, len(hidden.Rules), len(remaining.Rules))
+	}
+	for _, rule := range hidden.Rules {
+		if rule.Hint != "" {
+			t.Errorf("rule %d has hint %q with hints off", rule.ID, rule.Hint)
+		}
+	}
+}
+
+func TestHandleGameRemainingRequests(t *testing.T) {
+	useSessions(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/game/remaining", nil)
+	w := httptest.NewRecorder()
+	HandleGameRemaining(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without a session status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/api/game/remaining", nil)
+	w = httptest.NewRecorder()
+	HandleGameRemaining(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	database "passgame/Database"
+	"passgame/config"
 )
 
 // LeaderboardData holds data for the leaderboard template
@@ -17,32 +19,59 @@ type LeaderboardData struct {
 	Title        string
 	Users        []database.User
 	Stats        map[string]interface{}
-	Difficulties map[string]database.DifficultyConfig
+	Difficulties map[string]config.DifficultyConfig
 	HasUsers     bool
 	ErrorMsg     string
 	SortBy       string
 	SortOrder    string
 	Difficulty   string
+	Top          int
 	IsHtmx       bool
 }
 
+// Leaderboard sizes: DefaultLeaderboardSize applies unless Config.LeaderboardSize or ?top= set
+// another, and both are clamped to 1-MaxLeaderboardSize
+const (
+	DefaultLeaderboardSize = 20
+	MaxLeaderboardSize     = 100
+)
+
+// leaderboardSize returns how many players to list: ?top= if it's a number, else the config default
+func leaderboardSize(r *http.Request) int {
+	size := Config.LeaderboardSize
+	if top, err := strconv.Atoi(r.URL.Query().Get("top")); err == nil {
+		size = top
+	} else if size == 0 {
+		size = DefaultLeaderboardSize
+	}
+
+	if size < 1 {
+		return 1
+	}
+	if size > MaxLeaderboardSize {
+		return MaxLeaderboardSize
+	}
+	return size
+}
+
 // HandleLeaderboard handles the leaderboard page
 func HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
 	// Check if this is an HTMX request
 	isHtmx := r.Header.Get("HX-Request") == "true"
 
 	// Load difficulties from config
-	difficulties, err := database.LoadDifficulties()
+	difficulties, err := config.LoadDifficulties()
 	if err != nil {
 		log.Printf("Warning: Could not load difficulties: %v", err)
 		// Use empty map as fallback - the database has its own defaults
-		difficulties = make(map[string]database.DifficultyConfig)
+		difficulties = make(map[string]config.DifficultyConfig)
 	}
 
 	// Get sort parameters from URL with defaults
 	sortBy := getQueryParam(r, "sort", "rule")
 	sortOrder := getQueryParam(r, "order", "desc")
 	difficulty := getQueryParam(r, "difficulty", "all")
+	top := leaderboardSize(r)
 
 	// Get leaderboard data with sorting and filtering
 	var users []database.User
@@ -50,13 +79,13 @@ func HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
 
 	if difficulty != "all" {
 		// Validate the difficulty parameter
-		if !database.ValidateDifficulty(difficulty) {
+		if !config.ValidateDifficulty(difficulty) {
 			handleLeaderboardError(w, "Invalid difficulty level", isHtmx)
 			return
 		}
-		users, leaderboardErr = database.GetLeaderboardByDifficulty(difficulty, 20, sortBy, sortOrder)
+		users, leaderboardErr = database.GetLeaderboardByDifficulty(difficulty, top, sortBy, sortOrder)
 	} else {
-		users, leaderboardErr = database.GetLeaderboardSorted(20, sortBy, sortOrder)
+		users, leaderboardErr = database.GetLeaderboardSorted(top, sortBy, sortOrder)
 	}
 
 	if leaderboardErr != nil {
@@ -74,6 +103,7 @@ func HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
 		SortBy:       sortBy,
 		SortOrder:    sortOrder,
 		Difficulty:   difficulty,
+		Top:          top,
 		IsHtmx:       isHtmx,
 	}
 
@@ -97,6 +127,99 @@ func HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RecentUser is the public view of a recently joined player
+type RecentUser struct {
+	Username    string `json:"username"`
+	Difficulty  string `json:"difficulty"`
+	RuleReached int    `json:"rule_reached"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// HandleRecentUsers returns recently joined players as JSON for the "new players" widget
+func HandleRecentUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// GetRecentUsers clamps the limit to 1-50 and defaults invalid values to 10
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	users, err := database.GetRecentUsers(limit)
+	if err != nil {
+		log.Printf("Error getting recent users: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"Could not load recent users"}`))
+		return
+	}
+
+	recent := make([]RecentUser, 0, len(users))
+	for _, user := range users {
+		recent = append(recent, RecentUser{
+			Username:    user.Username,
+			Difficulty:  user.Difficulty,
+			RuleReached: user.RuleReached,
+			CreatedAt:   user.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recent)
+}
+
+// aroundMeRadius is how many players above and below the current player are shown
+const aroundMeRadius = 2
+
+// AroundMeData holds data for the "around me" leaderboard partial
+type AroundMeData struct {
+	Users    []database.RankedUser
+	UserID   int64
+	Message  string
+	HasUsers bool
+}
+
+// HandleLeaderboardAroundMe returns an HTMX partial with the players ranked just above and
+// below the current session's user, so players outside the top N can see where they stand
+func HandleLeaderboardAroundMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := AroundMeData{}
+	session := getUserSession(r)
+	if session == nil || !HasDatabaseUser(session) {
+		data.Message = "Start a game to see where you rank!"
+	} else {
+		users, err := database.GetLeaderboardWindow(session.UserID, aroundMeRadius)
+		if err != nil {
+			log.Printf("Error getting leaderboard window for user %s: %v", session.Username, err)
+			handleLeaderboardError(w, "Failed to load leaderboard data", true)
+			return
+		}
+		data.Users = users
+		data.UserID = session.UserID
+		data.HasUsers = len(users) > 0
+		if !data.HasUsers {
+			data.Message = "Complete your first rule to join the leaderboard!"
+		}
+	}
+
+	tmpl, err := template.New("leaderboard-around-me").Funcs(getTemplateFunctions()).Parse(leaderboardAroundMeTemplate)
+	if err != nil {
+		log.Printf("Error parsing around-me template: %v", err)
+		handleLeaderboardError(w, "Template error", true)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing around-me template: %v", err)
+		handleLeaderboardError(w, "Failed to render table", true)
+	}
+}
+
 // renderLeaderboardTable renders just the table for HTMX requests
 func renderLeaderboardTable(w http.ResponseWriter, data LeaderboardData) {
 	tmpl := template.New("leaderboard-table").Funcs(getTemplateFunctions())
@@ -153,6 +276,7 @@ func getTemplateFunctions() template.FuncMap {
 		"getSortIcon":        getSortIcon,
 		"toggleSortOrder":    toggleSortOrder,
 		"getNextDifficulty":  getNextDifficulty,
+		"difficultyOrder":    config.DifficultyOrder,
 		"json": func(v interface{}) (template.JS, error) {
 			a, err := json.Marshal(v)
 			if err != nil {
@@ -236,7 +360,7 @@ func getRank(index int) int {
 }
 
 func getDifficultyIcon(difficulty string) string {
-	difficulties, err := database.LoadDifficulties()
+	difficulties, err := config.LoadDifficulties()
 	if err != nil {
 		return "⚪"
 	}
@@ -248,7 +372,7 @@ func getDifficultyIcon(difficulty string) string {
 }
 
 func getDifficultyColor(difficulty string) string {
-	difficulties, err := database.LoadDifficulties()
+	difficulties, err := config.LoadDifficulties()
 	if err != nil {
 		return "#64748b"
 	}
@@ -279,24 +403,15 @@ func toggleSortOrder(currentSort, columnSort, currentOrder string) string {
 	return "desc"
 }
 
-// getNextDifficulty cycles through difficulty filters
+// getNextDifficulty cycles through difficulty filters in the configured order, then "all"
 func getNextDifficulty(currentDifficulty string) string {
-	difficulties, err := database.LoadDifficulties()
-	if err != nil {
+	keys := config.DifficultyOrder()
+	if len(keys) == 0 {
 		return "all"
 	}
 
 	if currentDifficulty == "all" {
-		// Return the first difficulty in the map
-		for key := range difficulties {
-			return key
-		}
-	}
-
-	// Convert map keys to slice for ordered access
-	var keys []string
-	for key := range difficulties {
-		keys = append(keys, key)
+		return keys[0]
 	}
 
 	// Find current difficulty in the slice
@@ -310,10 +425,7 @@ func getNextDifficulty(currentDifficulty string) string {
 	}
 
 	// If not found, return first difficulty
-	if len(keys) > 0 {
-		return keys[0]
-	}
-	return "all"
+	return keys[0]
 }
 
 // leaderboardTemplate is the HTML template for the full leaderboard page
@@ -413,7 +525,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
     <main>
         <div class="content">
             <div class="leaderboard-container">
-                <h1 class="leaderboard-title">🏆 Leaderboard (Top 20)</h1>
+                <h1 class="leaderboard-title">🏆 Leaderboard (Top {{.Top}})</h1>
                 
                 {{if .Stats}}
                 <!-- Stats Overview -->
@@ -432,6 +544,19 @@ const leaderboardTemplate = `<!DOCTYPE html>
                     </div>
                 </div>
                 
+                {{with .Stats.completed_by_difficulty}}
+                <!-- Finishers per difficulty -->
+                <div class="stats-overview">
+                    {{$finishers := .}}
+                    {{range $difficulty := difficultyOrder}}
+                    <div class="stat-item">
+                        <div class="stat-value">{{index $finishers $difficulty}}</div>
+                        <div class="stat-label">{{getDifficultyIcon $difficulty}} {{$difficulty}} finishers</div>
+                    </div>
+                    {{end}}
+                </div>
+                {{end}}
+                
                 <!-- Charts Section -->
                 <div class="charts-container">
                     <div class="chart-card">
@@ -454,7 +579,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
                 <div id="error-message"></div>
                 
                 <!-- Leaderboard Content -->
-                <div id="leaderboard-content" class="table-responsive" data-difficulties='{{.Difficulties | json}}'>
+                <div id="leaderboard-content" class="table-responsive" data-difficulties='{{.Difficulties | json}}' data-difficulty-order='{{difficultyOrder | json}}'>
                     {{template "leaderboard-table" .}}
                 </div>
             </div>
@@ -466,7 +591,10 @@ const leaderboardTemplate = `<!DOCTYPE html>
         let currentSort = '{{.SortBy}}';
         let currentOrder = '{{.SortOrder}}';
         let currentDifficulty = '{{if .Difficulty}}{{.Difficulty}}{{else}}all{{end}}';
+        const currentTop = {{.Top}};
         const difficulties = JSON.parse(document.querySelector('[data-difficulties]')?.dataset.difficulties || '{}');
+        // Difficulty keys in the configured order; object keys come back alphabetical
+        const difficultyOrder = JSON.parse(document.querySelector('[data-difficulty-order]')?.dataset.difficultyOrder || 'null') || Object.keys(difficulties);
         
         document.addEventListener('DOMContentLoaded', function() {
             {{if .Stats}}
@@ -499,7 +627,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
         
         function handleDifficultyFilter(element) {
             // Get all available difficulties
-            const difficultyKeys = Object.keys(difficulties);
+            const difficultyKeys = difficultyOrder;
             const allDifficulties = ['all', ...difficultyKeys];
             
             // Find current difficulty or default to 'all'
@@ -516,7 +644,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
             updateDifficultyIndicator(element);
             
             // Make HTMX request with difficulty filter
-            let url = '/leaderboard?sort=' + currentSort + '&order=' + currentOrder;
+            let url = '/leaderboard?sort=' + currentSort + '&order=' + currentOrder + '&top=' + currentTop;
             if (currentDifficulty !== 'all') {
                 url += '&difficulty=' + currentDifficulty;
             }
@@ -552,7 +680,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
             currentOrder = newOrder;
             
             // Make HTMX request
-            let url = '/leaderboard?sort=' + sortType + '&order=' + newOrder;
+            let url = '/leaderboard?sort=' + sortType + '&order=' + newOrder + '&top=' + currentTop;
             if (currentDifficulty !== 'all') {
                 url += '&difficulty=' + currentDifficulty;
             }
@@ -622,7 +750,7 @@ const leaderboardTemplate = `<!DOCTYPE html>
             
             // Get difficulties from the data attribute
             const difficulties = JSON.parse(document.querySelector('[data-difficulties]').dataset.difficulties);
-            const difficultyKeys = Object.keys(difficulties);
+            const difficultyKeys = difficultyOrder;
             
             // Prepare chart data
             const data = difficultyKeys.map(diff => difficultyData[diff] || 0);
@@ -768,6 +896,11 @@ const leaderboardTableTemplate = `{{define "leaderboard-table"}}
             Time<span class="sort-icon">{{getSortIcon .SortBy "time" .SortOrder}}</span>
             <span class="sort-indicator htmx-indicator">↻</span>
         </div>
+        <div class="sortable-header {{if eq .SortBy "score"}}active-sort{{end}}" 
+             data-sort="score" title="Rules reached × difficulty weight, minus time">
+            Score<span class="sort-icon">{{getSortIcon .SortBy "score" .SortOrder}}</span>
+            <span class="sort-indicator htmx-indicator">↻</span>
+        </div>
         <div class="sortable-header {{if eq .SortBy "joined"}}active-sort{{end}}" 
              data-sort="joined">
             Joined<span class="sort-icon">{{getSortIcon .SortBy "joined" .SortOrder}}</span>
@@ -787,15 +920,39 @@ const leaderboardTableTemplate = `{{define "leaderboard-table"}}
                     {{getDifficultyIcon $user.Difficulty}} {{$user.Difficulty}}
                 </span>
             </div>
-            <div class="rule-progress">{{$user.RuleReached}}</div>
+            <div class="rule-progress">{{$user.RuleReached}}{{if $user.TimedOut}} <span title="Ran out of time">⏱️</span>{{end}}</div>
             <div class="time-spent">{{formatDuration $user.TimeSpent}}</div>
+            <div class="score">{{$user.Score}}</div>
             <div class="join-date">{{formatTime $user.CreatedAt}}</div>
         </div>
         {{end}}
     {{else}}
         <tr class="no-rows">
-            <td colspan="6" class="text-center">No players found for this difficulty level.</td>
+            <td colspan="7" class="text-center">No players found for this difficulty level.</td>
         </tr>
     {{end}}
 </div>
 {{end}}`
+
+// leaderboardAroundMeTemplate is the HTML template for the "around me" partial
+const leaderboardAroundMeTemplate = `<div id="leaderboard-around-me">
+    {{if .HasUsers}}
+        {{range .Users}}
+        <div class="table-row{{if eq .ID $.UserID}} current-player{{end}}">
+            <div class="rank">#{{.Rank}}</div>
+            <div class="username">{{.Username}}{{if eq .ID $.UserID}} (you){{end}}</div>
+            <div>
+                <span class="difficulty-badge" style="background-color: {{getDifficultyColor .Difficulty}}20; color: {{getDifficultyColor .Difficulty}};">
+                    {{getDifficultyIcon .Difficulty}} {{.Difficulty}}
+                </span>
+            </div>
+            <div class="rule-progress">{{.RuleReached}}{{if .TimedOut}} <span title="Ran out of time">⏱️</span>{{end}}</div>
+            <div class="time-spent">{{formatDuration .TimeSpent}}</div>
+            <div class="score">{{.Score}}</div>
+            <div class="join-date">{{formatTime .CreatedAt}}</div>
+        </div>
+        {{end}}
+    {{else}}
+        <div class="no-rows text-center">{{.Message}}</div>
+    {{end}}
+</div>`
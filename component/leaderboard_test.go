@@ -0,0 +1,253 @@
+package component
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+func TestHandleRecentUsers(t *testing.T) {
+	useEmptyDB(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"first", "second", "third"} {
+		userID := insertTestUser(t, name, "basic")
+		createdAt := base.Add(time.Duration(i) * time.Hour).Format("2006-01-02 15:04:05")
+		if _, err := database.GetDB().Exec("UPDATE users SET created_at = ? WHERE id = ?", createdAt, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/api/recent", []string{"third", "second", "first"}},
+		{"/api/recent?limit=2", []string{"third", "second"}},
+		{"/api/recent?limit=abc", []string{"third", "second", "first"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleRecentUsers(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			var users []map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&users); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(users) != len(tt.want) {
+				t.Fatalf("got %d users, want %d", len(users), len(tt.want))
+			}
+			for i, user := range users {
+				if user["username"] != tt.want[i] {
+					t.Errorf("user %d = %v, want %s", i, user["username"], tt.want[i])
+				}
+				if _, err := time.Parse(time.RFC3339, user["created_at"].(string)); err != nil {
+					t.Errorf("created_at %v is not RFC 3339: %v", user["created_at"], err)
+				}
+				for _, field := range []string{"id", "time_spent", "updated_at"} {
+					if _, exposed := user[field]; exposed {
+						t.Errorf("user %d exposes %s", i, field)
+					}
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	HandleRecentUsers(w, httptest.NewRequest(http.MethodPost, "/api/recent", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleLeaderboardAroundMe(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+
+	ids := make(map[string]int64)
+	for i, name := range []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8"} {
+		ids[name] = insertTestUser(t, name, "basic")
+		if _, err := database.GetDB().Exec("UPDATE users SET rule_reached = ?, time_spent = 100 WHERE id = ?", 20-i, ids[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids["fresh"] = insertTestUser(t, "fresh", "basic")
+	for name, userID := range ids {
+		storeSession("session-"+name, &UserSession{UserID: userID, Username: name, Difficulty: "basic", StartTime: time.Now()})
+	}
+
+	aroundMe := func(sessionID string) string {
+		r := httptest.NewRequest(http.MethodGet, "/leaderboard/around-me", nil)
+		if sessionID != "" {
+			r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		HandleLeaderboardAroundMe(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return w.Body.String()
+	}
+
+	t.Run("mid-pack user", func(t *testing.T) {
+		body := aroundMe("session-p5")
+		for _, name := range []string{"p3", "p4", "p5 (you)", "p6", "p7"} {
+			if !strings.Contains(body, name) {
+				t.Errorf("partial doesn't list %s", name)
+			}
+		}
+		for _, name := range []string{"p2", "p8"} {
+			if strings.Contains(body, ">"+name+"<") {
+				t.Errorf("partial lists %s outside the window", name)
+			}
+		}
+		if got := strings.Count(body, "current-player"); got != 1 {
+			t.Errorf("%d rows highlighted, want only the player's own", got)
+		}
+		if !strings.Contains(body, "#5") {
+			t.Error("partial doesn't show the player's rank")
+		}
+	})
+
+	t.Run("unranked user", func(t *testing.T) {
+		body := aroundMe("session-fresh")
+		if !strings.Contains(body, "Complete your first rule") || strings.Contains(body, "table-row") {
+			t.Errorf("unranked partial = %s, want the no-progress message", body)
+		}
+	})
+
+	t.Run("no session", func(t *testing.T) {
+		if body := aroundMe(""); !strings.Contains(body, "Start a game") {
+			t.Errorf("partial without a session = %s, want the start message", body)
+		}
+	})
+}
+
+func TestHandleLeaderboardFinishers(t *testing.T) {
+	useEmptyDB(t)
+	// Finishers are the players whose completion was recorded. The hard one at rule 17 finished
+	// before hard gained rule 28 and still counts.
+	for i, progress := range []struct {
+		difficulty  string
+		ruleReached int
+		completed   bool
+	}{{"basic", 6, true}, {"basic", 6, true}, {"basic", 3, false}, {"hard", 28, true}, {"hard", 17, true}, {"hard", 12, false}} {
+		userID := insertTestUser(t, fmt.Sprintf("player%d", i), progress.difficulty)
+		if progress.completed {
+			if err := database.RecordCompletion(userID, progress.ruleReached, 60); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := database.UpdateUserProgress(userID, progress.ruleReached, 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	HandleLeaderboard(w, httptest.NewRequest(http.MethodGet, "/leaderboard", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	for difficulty, want := range map[string]int{"basic": 2, "hard": 2, "expert": 0} {
+		pattern := regexp.MustCompile(fmt.Sprintf(`stat-value">(\d+)</div>\s*<div class="stat-label">[^<]*\b%s finishers`, difficulty))
+		match := pattern.FindStringSubmatch(w.Body.String())
+		if match == nil {
+			t.Errorf("page has no %s finishers count", difficulty)
+			continue
+		}
+		if match[1] != fmt.Sprint(want) {
+			t.Errorf("%s finishers = %s, want %d", difficulty, match[1], want)
+		}
+	}
+}
+
+func TestHandleLeaderboardTop(t *testing.T) {
+	useEmptyDB(t)
+	useConfig(t)
+	for i := 0; i < 8; i++ {
+		userID := insertTestUser(t, fmt.Sprintf("ranked%d", i), "basic")
+		if err := database.UpdateUserProgress(userID, i+1, 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows := regexp.MustCompile(`class="table-row`)
+	currentTop := regexp.MustCompile(`const currentTop = \s*5\s*;`)
+
+	for _, htmx := range []bool{false, true} {
+		r := httptest.NewRequest(http.MethodGet, "/leaderboard?top=5", nil)
+		if htmx {
+			r.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		HandleLeaderboard(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("htmx %v: status = %d, want %d", htmx, w.Code, http.StatusOK)
+		}
+
+		body := w.Body.String()
+		if got := len(rows.FindAllString(body, -1)); got != 5 {
+			t.Errorf("htmx %v: %d rows, want 5", htmx, got)
+		}
+		if !htmx && !strings.Contains(body, "Leaderboard (Top 5)") {
+			t.Error("page title doesn't say Top 5")
+		}
+		// The sort links keep the size
+		if !htmx && !currentTop.MatchString(body) {
+			t.Error("page doesn't carry top=5 into its sort requests")
+		}
+	}
+
+	tests := []struct {
+		query      string
+		configured int
+		want       int
+	}{
+		{"", 0, DefaultLeaderboardSize},
+		{"", 3, 3},
+		{"?top=6", 3, 6},
+		{"?top=0", 0, 1},
+		{"?top=-4", 0, 1},
+		{"?top=500", 0, MaxLeaderboardSize},
+		{"?top=many", 4, 4},
+	}
+	for _, tt := range tests {
+		Config.LeaderboardSize = tt.configured
+		r := httptest.NewRequest(http.MethodGet, "/leaderboard"+tt.query, nil)
+		if got := leaderboardSize(r); got != tt.want {
+			t.Errorf("leaderboardSize(%q) with config %d = %d, want %d", tt.query, tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestGetNextDifficultyFollowsConfiguredOrder(t *testing.T) {
+	writeTestDifficulties(t, func(difficulties map[string]map[string]interface{}) {
+		difficulties["nightmare"] = map[string]interface{}{"name": "Nightmare", "icon": "💀", "color": "#000000", "order": 3}
+	})
+
+	// Order 3 ties with hard, and ties go alphabetically
+	want := []string{"basic", "intermediate", "hard", "nightmare", "expert", "fun", "all"}
+	current := "all"
+	for _, next := range want {
+		if got := getNextDifficulty(current); got != next {
+			t.Errorf("getNextDifficulty(%s) = %s, want %s", current, got, next)
+		}
+		current = next
+	}
+	if got := getNextDifficulty("retired"); got != "basic" {
+		t.Errorf("getNextDifficulty(retired) = %s, want basic", got)
+	}
+}
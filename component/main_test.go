@@ -0,0 +1,157 @@
+package component
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	database "passgame/Database"
+	"passgame/config"
+	"passgame/rules"
+)
+
+// testDataFiles are the files the server reads relative to the repository root
+var testDataFiles = []string{
+	"config/difficulties.json",
+	"rules/assignments.json",
+	"rules/words",
+	"Frontend",
+}
+
+// TestMain runs the tests in test mode, so no challenge reaches an external API, from a
+// scratch copy of the data files that tests may rewrite, and parses the page templates from it.
+// The database is a fresh Database/user.db in the same scratch directory.
+func TestMain(m *testing.M) {
+	rules.SetTestMode(true)
+
+	dir, err := os.MkdirTemp("", "passgame-component-")
+	if err != nil {
+		log.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+		for _, path := range testDataFiles {
+			if err := copyTestData(filepath.Join("..", path), filepath.Join(dir, path)); err != nil {
+				log.Printf("Failed to copy %s: %v", path, err)
+				return 1
+			}
+		}
+		if err := os.Chdir(dir); err != nil {
+			log.Printf("Failed to enter test directory: %v", err)
+			return 1
+		}
+		if err := LoadTemplates(); err != nil {
+			log.Printf("Failed to load templates: %v", err)
+			return 1
+		}
+		if err := os.Mkdir("Database", 0755); err != nil {
+			log.Printf("Failed to create the database directory: %v", err)
+			return 1
+		}
+		if err := database.InitDB(); err != nil {
+			log.Printf("Failed to initialize the test database: %v", err)
+			return 1
+		}
+		defer database.CloseDB()
+		// Completion is judged by each difficulty's final rule, as main wires it
+		database.SetFinalRuleFunc(rules.GetFinalRuleID)
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// copyTestData copies a file or directory tree from src to dst
+func copyTestData(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.CopyFS(dst, os.DirFS(src))
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// useSessions gives the test an empty session table and restores the real one afterwards
+func useSessions(t *testing.T) {
+	t.Helper()
+	sessionsMutex.Lock()
+	previous := UserSessions
+	UserSessions = make(map[string]*UserSession)
+	sessionsMutex.Unlock()
+
+	t.Cleanup(func() {
+		sessionsMutex.Lock()
+		UserSessions = previous
+		sessionsMutex.Unlock()
+	})
+}
+
+// useConfig lets the test change Config and restores it afterwards
+func useConfig(t *testing.T) {
+	t.Helper()
+	previous := Config
+	t.Cleanup(func() { Config = previous })
+}
+
+// useEmptyDB gives the test an empty users table, cleared again afterwards
+func useEmptyDB(t *testing.T) {
+	t.Helper()
+	deleteUsers := func() {
+		if _, err := database.GetDB().Exec("DELETE FROM users"); err != nil {
+			t.Fatalf("failed to clear users: %v", err)
+		}
+	}
+	deleteUsers()
+	t.Cleanup(deleteUsers)
+}
+
+// insertTestUser registers a user and returns its ID
+func insertTestUser(t *testing.T, username, difficulty string) int64 {
+	t.Helper()
+	userID, err := database.InsertUser(username, difficulty)
+	if err != nil {
+		t.Fatalf("InsertUser(%q, %q) error = %v", username, difficulty, err)
+	}
+	return userID
+}
+
+// writeTestDifficulties edits difficulties.json (in the test copy) for one test, restoring it
+// afterwards. Entries are edited as raw JSON objects so fields the test doesn't touch survive.
+func writeTestDifficulties(t *testing.T, edit func(difficulties map[string]map[string]interface{})) {
+	t.Helper()
+	path := config.DifficultiesFile
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := os.WriteFile(path, original, 0644); err != nil {
+			t.Errorf("failed to restore %s: %v", path, err)
+		}
+	})
+
+	var difficulties map[string]map[string]interface{}
+	if err := json.Unmarshal(original, &difficulties); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+	edit(difficulties)
+	data, err := json.MarshalIndent(difficulties, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
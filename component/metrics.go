@@ -0,0 +1,37 @@
+package component
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"passgame/rules"
+)
+
+// HandleMetrics serves rule, external API and session metrics in Prometheus text format
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	sessionsMutex.RLock()
+	activeSessions := len(UserSessions)
+	sessionsMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	rules.WriteMetrics(w)
+
+	fmt.Fprintln(w, "# HELP passgame_active_sessions Sessions currently held in memory.")
+	fmt.Fprintln(w, "# TYPE passgame_active_sessions gauge")
+	fmt.Fprintf(w, "passgame_active_sessions %d\n", activeSessions)
+}
+
+// HandleRuleSatisfaction serves GET /api/analytics/rule-satisfaction: per rule, how often it
+// was shown and how often it was satisfied while shown, across all sessions
+func HandleRuleSatisfaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rules": rules.RuleSatisfactionReport(),
+	})
+}
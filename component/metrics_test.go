@@ -0,0 +1,160 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"passgame/rules"
+)
+
+// scrapeMetrics fetches /metrics and returns the sample values by metric name and labels
+func scrapeMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	HandleMetrics(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		cut := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[cut+1:], 64)
+		if cut < 0 || err != nil {
+			t.Fatalf("malformed sample line %q", line)
+		}
+		samples[line[:cut]] = value
+	}
+	return samples
+}
+
+func TestHandleMetrics(t *testing.T) {
+	sessionID := useTestSession(t, "basic")
+	before := scrapeMetrics(t)
+
+	if w := validateRequest(sessionID, "abcdefgh", nil); w.Code != http.StatusOK {
+		t.Fatalf("validate status = %d, want %d", w.Code, http.StatusOK)
+	}
+	after := scrapeMetrics(t)
+
+	tests := []struct {
+		sample    string
+		wantDelta float64
+	}{
+		{"passgame_validations_total", 1},
+		{`passgame_rule_satisfied_total{rule="1"}`, 1},
+	}
+	for _, tt := range tests {
+		value, exists := after[tt.sample]
+		if !exists {
+			t.Errorf("%s is missing", tt.sample)
+			continue
+		}
+		if delta := value - before[tt.sample]; delta != tt.wantDelta {
+			t.Errorf("%s went up by %g, want %g", tt.sample, delta, tt.wantDelta)
+		}
+	}
+	if got := after["passgame_active_sessions"]; got != 1 {
+		t.Errorf("passgame_active_sessions = %g, want 1", got)
+	}
+}
+
+// ruleSatisfaction fetches the admin rule satisfaction report, keyed by rule ID
+func ruleSatisfaction(t *testing.T) map[int]rules.RuleSatisfaction {
+	t.Helper()
+	w := httptest.NewRecorder()
+	RequireAdmin(HandleRuleSatisfaction)(w, adminRequest(http.MethodGet, "/api/analytics/rule-satisfaction", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var report struct {
+		Rules []rules.RuleSatisfaction `json:"rules"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	byID := make(map[int]rules.RuleSatisfaction, len(report.Rules))
+	for i, entry := range report.Rules {
+		if i > 0 && entry.RuleID <= report.Rules[i-1].RuleID {
+			t.Errorf("report is not ordered by rule ID: %d after %d", entry.RuleID, report.Rules[i-1].RuleID)
+		}
+		byID[entry.RuleID] = entry
+	}
+	return byID
+}
+
+func TestHandleRuleSatisfaction(t *testing.T) {
+	useAdminToken(t)
+	sessionID := useTestSession(t, "basic")
+	before := ruleSatisfaction(t)
+
+	// Count what each response showed and satisfied, to compare with the report
+	wantShown := make(map[int]int64)
+	wantSatisfied := make(map[int]int64)
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"} {
+		w := validateRequest(sessionID, password, previous)
+		previous = w.Header()
+
+		var visible, satisfied map[string]bool
+		if err := json.Unmarshal([]byte(w.Header().Get("X-Visible-States")), &visible); err != nil {
+			t.Fatalf("invalid X-Visible-States: %v", err)
+		}
+		if err := json.Unmarshal([]byte(w.Header().Get("X-Satisfied-States")), &satisfied); err != nil {
+			t.Fatalf("invalid X-Satisfied-States: %v", err)
+		}
+		for key, shown := range visible {
+			if !shown {
+				continue
+			}
+			id, err := strconv.Atoi(key)
+			if err != nil {
+				t.Fatalf("rule key %q is not an ID", key)
+			}
+			wantShown[id]++
+			if satisfied[key] {
+				wantSatisfied[id]++
+			}
+		}
+	}
+	if len(wantShown) < 2 {
+		t.Fatalf("only rules %v were shown", wantShown)
+	}
+
+	after := ruleSatisfaction(t)
+	for id, shown := range wantShown {
+		entry := after[id]
+		if delta := entry.Shown - before[id].Shown; delta != shown {
+			t.Errorf("rule %d shown %d more times, want %d", id, delta, shown)
+		}
+		if delta := entry.Satisfied - before[id].Satisfied; delta != wantSatisfied[id] {
+			t.Errorf("rule %d satisfied %d more times, want %d", id, delta, wantSatisfied[id])
+		}
+		if want := float64(entry.Satisfied) / float64(entry.Shown); entry.Rate != want {
+			t.Errorf("rule %d rate = %g, want %g", id, entry.Rate, want)
+		}
+	}
+	// Rules that were never shown aren't counted
+	for id, entry := range after {
+		if _, shown := wantShown[id]; !shown && entry.Shown != before[id].Shown {
+			t.Errorf("hidden rule %d was counted as shown", id)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	RequireAdmin(HandleRuleSatisfaction)(w, httptest.NewRequest(http.MethodGet, "/api/analytics/rule-satisfaction", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
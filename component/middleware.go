@@ -0,0 +1,147 @@
+package component
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// responseWriter wraps http.ResponseWriter to capture the status code written by a handler
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader records the status code before passing it on
+func (rw *responseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write marks the implicit 200 status the first time the body is written
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Status returns the status code sent to the client
+func (rw *responseWriter) Status() int {
+	return rw.status
+}
+
+// LogRequests logs the method, path, status code and latency of every request
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+
+		next.ServeHTTP(rw, r)
+
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rw.Status(), time.Since(start))
+	})
+}
+
+// tokenBucket tracks the available tokens for one client
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter is a per-client token-bucket limiter keyed by session cookie (or IP when there is none)
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+}
+
+// Username availability checks fire while typing, but anything faster than this is enumeration
+const (
+	UsernameCheckRatePerSecond = 3
+	UsernameCheckBurst         = 10
+)
+
+// rateLimiterIdleTTL is how long an untouched bucket is kept before being pruned
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// NewRateLimiter creates a limiter allowing rate requests per second with bursts up to burst
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    rate,
+		burst:   float64(burst),
+	}
+}
+
+// Allow consumes a token for the given key, reporting whether the request may proceed
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	bucket, exists := rl.buckets[key]
+	if !exists {
+		rl.pruneLocked(now)
+		bucket = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[key] = bucket
+	}
+
+	// Refill based on the time elapsed since the last request
+	bucket.tokens += now.Sub(bucket.lastSeen).Seconds() * rl.rate
+	if bucket.tokens > rl.burst {
+		bucket.tokens = rl.burst
+	}
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// pruneLocked drops buckets that have been idle long enough to be full again
+func (rl *RateLimiter) pruneLocked(now time.Time) {
+	for key, bucket := range rl.buckets {
+		if now.Sub(bucket.lastSeen) > rateLimiterIdleTTL {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies the caller by session, falling back to the remote IP. Only cookies that
+// name a live session count; otherwise a client could dodge its limit by inventing new values.
+func clientKey(r *http.Request) string {
+	if cookie, err := r.Cookie("user_session"); err == nil && cookie.Value != "" {
+		if _, exists := GetSession(cookie.Value); exists {
+			return "session:" + cookie.Value
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Middleware returns 429 Too Many Requests once a client exhausts its bucket
+func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rl.Allow(clientKey(r)) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package component
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureLog collects what the standard logger writes during the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestLogRequestsStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{"implicit 200", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK},
+		{"body only", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }, http.StatusOK},
+		{"explicit status", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) }, http.StatusNotFound},
+		{"http.Error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}, http.StatusInternalServerError},
+		{"first status wins", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			w.WriteHeader(http.StatusOK)
+		}, http.StatusTeapot},
+		{"status after body is ignored", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+			w.WriteHeader(http.StatusBadRequest)
+		}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			w := httptest.NewRecorder()
+			LogRequests(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/example", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("client got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			want := fmt.Sprintf("POST /api/example %d ", tt.wantStatus)
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("log %q does not contain %q", logs.String(), want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	useSessions(t)
+	storeSession("session-live", &UserSession{UserID: -1, Username: "Test User", Difficulty: "basic"})
+	const burst = 5
+
+	tests := []struct {
+		name   string
+		cookie string
+	}{
+		{"by IP", ""},
+		{"by session", "session-live"},
+		{"unknown cookie falls back to the IP", "forged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRateLimiter(0.001, burst).Middleware(func(w http.ResponseWriter, r *http.Request) {})
+			request := func(remoteAddr, cookie string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+				r.RemoteAddr = remoteAddr
+				if cookie != "" {
+					r.AddCookie(&http.Cookie{Name: "user_session", Value: cookie})
+				}
+				w := httptest.NewRecorder()
+				handler(w, r)
+				return w
+			}
+
+			for i := 0; i < burst; i++ {
+				if w := request("192.0.2.1:1234", tt.cookie); w.Code != http.StatusOK {
+					t.Fatalf("request %d got status %d within the burst", i+1, w.Code)
+				}
+			}
+			w := request("192.0.2.1:1234", tt.cookie)
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("request past the burst got status %d, want %d", w.Code, http.StatusTooManyRequests)
+			}
+			if w.Header().Get("Retry-After") == "" {
+				t.Error("429 response has no Retry-After header")
+			}
+
+			// Another client still has its own bucket
+			if w := request("192.0.2.2:1234", ""); w.Code != http.StatusOK {
+				t.Errorf("other client got status %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+
+	t.Run("inventing cookies does not reset the limit", func(t *testing.T) {
+		handler := NewRateLimiter(0.001, burst).Middleware(func(w http.ResponseWriter, r *http.Request) {})
+		var last int
+		for i := 0; i <= burst; i++ {
+			r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+			r.RemoteAddr = "192.0.2.3:1234"
+			r.AddCookie(&http.Cookie{Name: "user_session", Value: fmt.Sprintf("forged-%d", i)})
+			w := httptest.NewRecorder()
+			handler(w, r)
+			last = w.Code
+		}
+		if last != http.StatusTooManyRequests {
+			t.Errorf("request past the burst got status %d, want %d", last, http.StatusTooManyRequests)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package component
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"passgame/config"
+)
+
+// defaultPracticeName is used when a practice game is started without a name
+const defaultPracticeName = "Practice"
+
+// HandleStartPractice serves POST /api/game/practice. It starts a game that plays exactly like a
+// registered one but has no database row, so nothing it does reaches the leaderboard. Practice
+// ignores difficulty unlock gating, letting players try a tier before they've earned it.
+func HandleStartPractice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("username"))
+	if name == "" {
+		name = defaultPracticeName
+	}
+	if problem := usernameProblem(name); problem != "" {
+		writeJSONError(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	difficulty := strings.ToLower(strings.TrimSpace(r.FormValue("difficulty")))
+	if difficulty == "" || difficulty == "all" || !config.ValidateDifficulty(difficulty) {
+		writeJSONError(w, http.StatusBadRequest, "Invalid difficulty")
+		return
+	}
+
+	session := &UserSession{
+		UserID:     0,
+		Username:   name,
+		Difficulty: difficulty,
+		StartTime:  time.Now(),
+		Practice:   true,
+		Locale:     requestLocale(r),
+	}
+
+	sessionID := "practice_" + generateSessionID()
+	storeSession(sessionID, session)
+	http.SetCookie(w, SessionCookie(sessionID, 60*60)) // 1 hour, like test sessions
+
+	log.Printf("🧪 Practice game started: %s on %s", name, difficulty)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "started",
+		"kind":       SessionKindPractice,
+		"username":   name,
+		"difficulty": difficulty,
+	})
+}
@@ -0,0 +1,111 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	database "passgame/Database"
+)
+
+// practiceRequest posts the practice form
+func practiceRequest(username, difficulty string) *httptest.ResponseRecorder {
+	form := url.Values{"username": {username}, "difficulty": {difficulty}}
+	r := httptest.NewRequest(http.MethodPost, "/api/game/practice", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	HandleStartPractice(w, r)
+	return w
+}
+
+func TestHandleStartPractice(t *testing.T) {
+	useSessions(t)
+
+	w := practiceRequest("", "basic")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["kind"] != SessionKindPractice || body["username"] != defaultPracticeName || body["difficulty"] != "basic" {
+		t.Errorf("response = %v, want a basic practice game named %s", body, defaultPracticeName)
+	}
+
+	sessionID := sessionCookieValue(t, w)
+	session, ok := GetSession(sessionID)
+	if !ok {
+		t.Fatal("practice session was not stored")
+	}
+	if !session.Practice || session.UserID != 0 || SessionKind(session) != SessionKindPractice || HasDatabaseUser(session) {
+		t.Errorf("session = %+v (kind %s), want a practice session without a database user", session, SessionKind(session))
+	}
+
+	tests := []struct {
+		name       string
+		username   string
+		difficulty string
+	}{
+		{"unknown difficulty", "Trainee", "nightmare"},
+		{"all difficulties", "Trainee", "all"},
+		{"invalid name", strings.Repeat("x", 100), "basic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := practiceRequest(tt.username, tt.difficulty); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestPracticeSessionSkipsDatabase(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	playerID := insertTestUser(t, "Trainee", "basic")
+	before, err := database.GetUser(playerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := captureLog(t)
+
+	// A practice game under a registered player's name still never touches their row
+	w := practiceRequest("Trainee", "basic")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	sessionID := sessionCookieValue(t, w)
+
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"} {
+		w := validateRequest(sessionID, password, previous)
+		if w.Code != http.StatusOK {
+			t.Fatalf("validate %q status = %d, want %d", password, w.Code, http.StatusOK)
+		}
+		previous = w.Header()
+	}
+
+	session, _ := GetSession(sessionID)
+	if !session.IsCompleted {
+		t.Fatal("the practice game did not complete basic")
+	}
+	if count, err := database.GetUserCount(); err != nil || count != 1 {
+		t.Errorf("GetUserCount() = %d, %v, want only the registered player", count, err)
+	}
+	after, err := database.GetUser(playerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.RuleReached != before.RuleReached || after.TimeSpent != before.TimeSpent {
+		t.Errorf("registered player changed from rule %d to %d", before.RuleReached, after.RuleReached)
+	}
+	for _, unwanted := range []string{"Database updated", "Error updating"} {
+		if strings.Contains(logs.String(), unwanted) {
+			t.Errorf("log mentions %q:\n%s", unwanted, logs.String())
+		}
+	}
+}
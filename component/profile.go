@@ -0,0 +1,59 @@
+package component
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	database "passgame/Database"
+)
+
+// UserProfile is the public view of a player, without IDs or internal bookkeeping
+type UserProfile struct {
+	Username    string `json:"username"`
+	Difficulty  string `json:"difficulty"`
+	RuleReached int    `json:"rule_reached"`
+	TimeSpent   int    `json:"time_spent"`
+	TimedOut    bool   `json:"timed_out"`
+	CreatedAt   string `json:"created_at"`
+	// Rank is the player's leaderboard position, or 0 before they've reached a rule
+	Rank int `json:"rank"`
+}
+
+// HandleUserProfile serves GET /api/user/{username}
+func HandleUserProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := strings.TrimPrefix(r.URL.Path, "/api/user/")
+	if username == "" || strings.Contains(username, "/") {
+		writeJSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	user, err := database.GetUserByUsername(username)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	rank, err := database.GetUserRank(user.ID)
+	if err != nil {
+		log.Printf("Error getting rank for user %s: %v", user.Username, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserProfile{
+		Username:    user.Username,
+		Difficulty:  user.Difficulty,
+		RuleReached: user.RuleReached,
+		TimeSpent:   user.TimeSpent,
+		TimedOut:    user.TimedOut,
+		CreatedAt:   user.CreatedAt.UTC().Format(time.RFC3339),
+		Rank:        rank,
+	})
+}
@@ -0,0 +1,91 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+// getProfile requests /api/user/{username} and decodes the response as raw JSON fields
+func getProfile(t *testing.T, username string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	HandleUserProfile(w, httptest.NewRequest(http.MethodGet, "/api/user/"+username, nil))
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON for %q: %v", username, err)
+	}
+	return w.Code, body
+}
+
+func TestHandleUserProfile(t *testing.T) {
+	useEmptyDB(t)
+	leaderID := insertTestUser(t, "leader", "basic")
+	chaserID := insertTestUser(t, "chaser", "basic")
+	if err := database.UpdateUserProgress(leaderID, 6, 90); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateUserProgress(chaserID, 3, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.RecordStuckRule(chaserID, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	status, profile := getProfile(t, "chaser")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+
+	// Only public fields are returned, no IDs or bookkeeping such as the stuck rule
+	var fields []string
+	for field := range profile {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	if want := []string{"created_at", "difficulty", "rank", "rule_reached", "time_spent", "timed_out", "username"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+
+	want := map[string]interface{}{
+		"username":     "chaser",
+		"difficulty":   "basic",
+		"rule_reached": float64(3),
+		"time_spent":   float64(40),
+		"timed_out":    false,
+		"rank":         float64(2),
+	}
+	for field, value := range want {
+		if profile[field] != value {
+			t.Errorf("%s = %v, want %v", field, profile[field], value)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, profile["created_at"].(string)); err != nil {
+		t.Errorf("created_at = %v, want an RFC 3339 time", profile["created_at"])
+	}
+
+	if _, leader := getProfile(t, "LEADER"); leader["username"] != "leader" || leader["rank"] != float64(1) {
+		t.Errorf("leader profile = %v, want rank 1 found in any casing", leader)
+	}
+}
+
+func TestHandleUserProfileMissing(t *testing.T) {
+	useEmptyDB(t)
+
+	for _, username := range []string{"ghost", "", "a/b"} {
+		status, body := getProfile(t, username)
+		if status != http.StatusNotFound {
+			t.Errorf("/api/user/%s status = %d, want %d", username, status, http.StatusNotFound)
+		}
+		if body["error"] != "User not found" {
+			t.Errorf("/api/user/%s error = %v", username, body["error"])
+		}
+	}
+}
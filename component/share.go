@@ -0,0 +1,79 @@
+package component
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+
+	database "passgame/Database"
+	"passgame/rules"
+)
+
+// Share card layout
+const (
+	shareCardWidth   = 480
+	shareCardHeight  = 220
+	shareCardPadding = 24
+	// maxShareNameLength keeps long usernames from running off the card
+	maxShareNameLength = 18
+)
+
+var (
+	shareBackground = color.RGBA{30, 30, 46, 255}
+	shareAccent     = color.RGBA{76, 175, 80, 255}
+	shareText       = color.RGBA{255, 255, 255, 255}
+	shareMuted      = color.RGBA{170, 170, 190, 255}
+)
+
+// renderShareCard draws a result card for a player
+func renderShareCard(user *database.User) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, shareCardWidth, shareCardHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{shareBackground}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, shareCardWidth, 8), &image.Uniform{shareAccent}, image.Point{}, draw.Src)
+
+	username := user.Username
+	if len([]rune(username)) > maxShareNameLength {
+		username = string([]rune(username)[:maxShareNameLength-3]) + "..."
+	}
+
+	y := shareCardPadding + 8
+	rules.DrawText(img, shareCardPadding, y, "PASSWORD GAME", 2, shareMuted)
+	y += 30
+	rules.DrawText(img, shareCardPadding, y, username, 4, shareText)
+	y += 48
+	rules.DrawText(img, shareCardPadding, y, "DIFFICULTY: "+strings.ToUpper(user.Difficulty), 2, shareText)
+	y += 24
+	rules.DrawText(img, shareCardPadding, y, fmt.Sprintf("RULE REACHED: %d", user.RuleReached), 2, shareText)
+	y += 24
+	rules.DrawText(img, shareCardPadding, y, "TIME: "+formatDuration(user.TimeSpent), 2, shareText)
+
+	return img
+}
+
+// HandleShareImage serves /api/share/{username}.png, a PNG card of a player's result
+func HandleShareImage(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/share/")
+	if !strings.HasSuffix(name, ".png") {
+		http.NotFound(w, r)
+		return
+	}
+	username := strings.TrimSuffix(name, ".png")
+
+	user, err := database.GetUserByUsername(username)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	// Progress changes while a game is running, so only cache briefly
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := png.Encode(w, renderShareCard(user)); err != nil {
+		log.Printf("Error encoding share image for %s: %v", user.Username, err)
+	}
+}
@@ -0,0 +1,55 @@
+package component
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	database "passgame/Database"
+)
+
+func TestHandleShareImage(t *testing.T) {
+	useEmptyDB(t)
+	userID := insertTestUser(t, "sharer", "intermediate")
+	if err := database.UpdateUserProgress(userID, 7, 95); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"/api/share/sharer.png", http.StatusOK},
+		{"/api/share/nobody.png", http.StatusNotFound},
+		{"/api/share/sharer", http.StatusNotFound},
+		{"/api/share/sharer.jpg", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleShareImage(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", ct)
+			}
+			if w.Header().Get("Cache-Control") == "" {
+				t.Error("no Cache-Control header")
+			}
+			img, err := png.Decode(w.Body)
+			if err != nil {
+				t.Fatalf("response is not a PNG: %v", err)
+			}
+			if bounds := img.Bounds(); bounds.Dx() == 0 || bounds.Dy() == 0 {
+				t.Errorf("image is %dx%d", bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
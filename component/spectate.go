@@ -0,0 +1,161 @@
+package component
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SpectatorRule is one visible rule as shown to spectators. Hints are left out because
+// they spell out the answers (Wordle word, update string, best move and so on).
+type SpectatorRule struct {
+	ID          int
+	Description string
+	IsSatisfied bool
+}
+
+// SpectatorData holds a read-only snapshot of a player's game
+type SpectatorData struct {
+	Title      string
+	Username   string
+	Difficulty string
+	TimeSpent  int
+	Satisfied  int
+	Total      int
+	Completed  bool
+	Practice   bool
+	Rules      []SpectatorRule
+}
+
+// findSessionByUsername returns the most recently seen active session for username
+func findSessionByUsername(username string) *UserSession {
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+
+	var found *UserSession
+	for _, session := range UserSessions {
+		if !strings.EqualFold(session.Username, username) {
+			continue
+		}
+		if found == nil || session.LastSeen.After(found.LastSeen) {
+			found = session
+		}
+	}
+	return found
+}
+
+// buildSpectatorData lists the rules the player can currently see, with the states saved by
+// their last validation. The password itself is never stored, so it can't leak here.
+func buildSpectatorData(session *UserSession) SpectatorData {
+	ruleSet := sessionRuleSet(session)
+
+	sessionsMutex.RLock()
+	data := SpectatorData{
+		Title:      "Spectating " + session.Username + " - The Ultimate Password Game",
+		Username:   session.Username,
+		Difficulty: session.Difficulty,
+		TimeSpent:  int(time.Since(session.StartTime).Seconds()),
+		Completed:  session.IsCompleted,
+		Practice:   session.Practice,
+		Total:      len(ruleSet.Rules),
+	}
+	satisfied := statesFromMap(ruleSet, session.SatisfiedStates)
+	visible := statesFromMap(ruleSet, session.VisibleStates)
+	sessionsMutex.RUnlock()
+
+	for i, rule := range ruleSet.Rules {
+		if satisfied[i] {
+			data.Satisfied++
+		}
+		// The first rule is on screen before the player has typed anything
+		if !visible[i] && i != 0 {
+			continue
+		}
+		data.Rules = append(data.Rules, SpectatorRule{
+			ID:          rule.ID,
+			Description: rule.Description,
+			IsSatisfied: satisfied[i],
+		})
+	}
+
+	return data
+}
+
+// HandleSpectate serves GET /spectate/{username}, a read-only view of an active game
+func HandleSpectate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := strings.TrimPrefix(r.URL.Path, "/spectate/")
+	if username == "" || strings.Contains(username, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	session := findSessionByUsername(username)
+	if session == nil {
+		http.Error(w, "That player has no game in progress", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	if err := spectateTmpl.Execute(w, buildSpectatorData(session)); err != nil {
+		log.Printf("Error executing spectate template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// spectateTmpl is parsed once and shared by every request
+var spectateTmpl = template.Must(template.New("spectate").Funcs(getTemplateFunctions()).Parse(spectateTemplate))
+
+// spectateTemplate is the HTML template for the spectator view; it reloads itself every few seconds
+const spectateTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="refresh" content="3">
+    <title>{{.Title}}</title>
+    <link rel="stylesheet" href="/style.css">
+</head>
+<body>
+    <main>
+        <div class="content">
+            <div class="leaderboard-container">
+                <h1 class="leaderboard-title">👀 {{.Username}}{{if .Completed}} finished!{{else}} is playing{{end}}{{if .Practice}} (practice){{end}}</h1>
+
+                <div class="stats-overview">
+                    <div class="stat-item">
+                        <div class="stat-value">{{getDifficultyIcon .Difficulty}} {{.Difficulty}}</div>
+                        <div class="stat-label">Difficulty</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-value">{{formatDuration .TimeSpent}}</div>
+                        <div class="stat-label">Time</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-value">{{.Satisfied}}/{{.Total}}</div>
+                        <div class="stat-label">Rules Satisfied</div>
+                    </div>
+                </div>
+
+                <div class="rules-container">
+                    {{range .Rules}}
+                    <div class="rule-item {{if .IsSatisfied}}satisfied{{end}}" data-rule-id="{{.ID}}">
+                        <div class="rule-content">
+                            <div class="rule-text">{{.Description}}</div>
+                        </div>
+                        <div class="checkmark">✓</div>
+                    </div>
+                    {{end}}
+                </div>
+            </div>
+        </div>
+    </main>
+</body>
+</html>`
@@ -0,0 +1,97 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"passgame/rules"
+)
+
+// spectatedRule matches one rule of the spectator view, capturing the satisfied class and ID
+var spectatedRule = regexp.MustCompile(`<div class="rule-item (satisfied)?" data-rule-id="(\d+)">`)
+
+// spectate requests the spectator view of username
+func spectate(username string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	HandleSpectate(w, httptest.NewRequest(http.MethodGet, "/spectate/"+username, nil))
+	return w
+}
+
+func TestHandleSpectateReflectsRuleStates(t *testing.T) {
+	useSessions(t)
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{UserID: -1, Username: "Streamer", Difficulty: "basic", StartTime: time.Now()})
+
+	var w *httptest.ResponseRecorder
+	var previous http.Header
+	password := ""
+	for _, password = range []string{"abc", "abcdefgh", "Abcdefgh!"} {
+		w = validateRequest(sessionID, password, previous)
+		previous = w.Header()
+	}
+	satisfied := satisfiedStates(t, w)
+	var visible map[string]bool
+	if err := json.Unmarshal([]byte(w.Header().Get("X-Visible-States")), &visible); err != nil {
+		t.Fatalf("invalid X-Visible-States: %v", err)
+	}
+
+	// No cookie is needed, and the name is matched in any casing
+	view := spectate("streamer")
+	if view.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", view.Code, http.StatusOK)
+	}
+	body := view.Body.String()
+
+	shown := map[string]bool{}
+	satisfiedCount := 0
+	for _, match := range spectatedRule.FindAllStringSubmatch(body, -1) {
+		id := match[2]
+		shown[id] = true
+		if got := match[1] == "satisfied"; got != satisfied[id] {
+			t.Errorf("rule %s satisfied = %v in the view, want %v", id, got, satisfied[id])
+		}
+	}
+	for id, isVisible := range visible {
+		if isVisible != shown[id] {
+			t.Errorf("rule %s shown = %v, want %v", id, shown[id], isVisible)
+		}
+		if satisfied[id] {
+			satisfiedCount++
+		}
+	}
+	if len(shown) == 0 || len(shown) == len(visible) && satisfiedCount == len(visible) {
+		t.Fatalf("the view shows %d rules, want a game in progress", len(shown))
+	}
+	if want := ">" + strconv.Itoa(satisfiedCount) + "/6</div>"; !strings.Contains(body, want) {
+		t.Errorf("view is missing the count %q", want)
+	}
+
+	// The view is read-only and never shows what the player typed
+	if strings.Contains(body, "<input") || strings.Contains(body, "<form") {
+		t.Error("spectator view has an input")
+	}
+	if strings.Contains(body, password) {
+		t.Error("spectator view shows the password")
+	}
+	for _, rule := range rules.NewRuleSet("basic").Rules {
+		if rule.Hint != "" && strings.Contains(body, rule.Hint) {
+			t.Errorf("spectator view shows the hint of rule %d", rule.ID)
+		}
+	}
+}
+
+func TestHandleSpectateWithoutActiveSession(t *testing.T) {
+	useSessions(t)
+
+	for _, path := range []string{"nobody", "", "a/b"} {
+		if w := spectate(path); w.Code != http.StatusNotFound {
+			t.Errorf("/spectate/%s status = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
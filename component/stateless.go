@@ -0,0 +1,59 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"passgame/config"
+	"passgame/rules"
+)
+
+// maxStatelessRequestBytes bounds the body accepted by /api/validate-stateless
+const maxStatelessRequestBytes = 64 << 10
+
+// StatelessValidateRequest is the body of POST /api/validate-stateless
+type StatelessValidateRequest struct {
+	Difficulty string `json:"difficulty"`
+	Password   string `json:"password"`
+}
+
+// HandleValidateStateless checks a password against a difficulty's rules without a session,
+// so developers and automated tests don't need to register a user or keep cookies.
+// Every rule is checked regardless of reveal order and nothing is written to the database.
+func HandleValidateStateless(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request StatelessValidateRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxStatelessRequestBytes)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if request.Difficulty == "" || request.Difficulty == "all" || !config.ValidateDifficulty(request.Difficulty) {
+		writeJSONError(w, http.StatusBadRequest, "Invalid difficulty")
+		return
+	}
+
+	checks := rules.CheckPassword(request.Difficulty, request.Password)
+	satisfied, supported := 0, 0
+	for _, check := range checks {
+		if check.Supported {
+			supported++
+		}
+		if check.Satisfied {
+			satisfied++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"difficulty":      request.Difficulty,
+		"rules":           checks,
+		"satisfied_count": satisfied,
+		"supported_count": supported,
+		"all_satisfied":   satisfied == supported,
+	})
+}
@@ -0,0 +1,123 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	database "passgame/Database"
+	"passgame/rules"
+)
+
+// statelessResponse is the body of a successful /api/validate-stateless call
+type statelessResponse struct {
+	Difficulty     string            `json:"difficulty"`
+	Rules          []rules.RuleCheck `json:"rules"`
+	SatisfiedCount int               `json:"satisfied_count"`
+	SupportedCount int               `json:"supported_count"`
+	AllSatisfied   bool              `json:"all_satisfied"`
+}
+
+// validateStateless posts a body to HandleValidateStateless
+func validateStateless(method, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	HandleValidateStateless(w, httptest.NewRequest(method, "/api/validate-stateless", strings.NewReader(body)))
+	return w
+}
+
+func TestHandleValidateStateless(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	rules.ResetCyberSecurityRules()
+	t.Cleanup(rules.ResetCyberSecurityRules)
+	assignments := readTestAssignments(t)
+
+	tests := []struct {
+		name          string
+		difficulty    string
+		password      string
+		wantSatisfied []int
+		wantAll       bool
+	}{
+		{"basic, every rule", "basic", "Abcdef!X7", []int{1, 2, 3, 4, 5, 6}, true},
+		{"basic, later rules without the first", "basic", "aB!7", []int{2, 3, 4, 6}, false},
+		// Rules 24 and 25 are unsupported, so rule 1 is all there is to satisfy
+		{"intermediate", "intermediate", "Abcdef!X7", []int{1}, true},
+		{"expert, stateless rules only", "expert", "Abcdef!X7", []int{1, 2, 3, 4, 5, 6, 9}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := validateStateless(http.MethodPost, `{"difficulty":"`+tt.difficulty+`","password":"`+tt.password+`"}`)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, http.StatusOK, w.Body.String())
+			}
+			var response statelessResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+
+			// Every assigned rule is reported, whatever the reveal order
+			if len(response.Rules) != len(assignments[tt.difficulty]) {
+				t.Fatalf("got %d rules, want the %d assigned to %s", len(response.Rules), len(assignments[tt.difficulty]), tt.difficulty)
+			}
+			want := make(map[int]bool)
+			for _, id := range tt.wantSatisfied {
+				want[id] = true
+			}
+			supported := 0
+			for _, check := range response.Rules {
+				if check.Supported == rules.IsStatefulRule(check.ID) {
+					t.Errorf("rule %d supported = %v", check.ID, check.Supported)
+				}
+				if check.Supported {
+					supported++
+				}
+				if check.Satisfied != want[check.ID] {
+					t.Errorf("rule %d satisfied = %v, want %v", check.ID, check.Satisfied, want[check.ID])
+				}
+			}
+			if response.SatisfiedCount != len(tt.wantSatisfied) || response.SupportedCount != supported || response.AllSatisfied != tt.wantAll {
+				t.Errorf("counts = %d satisfied of %d supported (all %v), want %d of %d (all %v)",
+					response.SatisfiedCount, response.SupportedCount, response.AllSatisfied, len(tt.wantSatisfied), supported, tt.wantAll)
+			}
+		})
+	}
+
+	// Stateful rules are skipped, so checking them left no game state or database rows behind
+	if count, _ := database.GetUserCount(); count != 0 {
+		t.Errorf("stateless validation wrote %d users", count)
+	}
+	if sessions := ListSessions(); len(sessions) != 0 {
+		t.Errorf("stateless validation created %d sessions", len(sessions))
+	}
+	if rules.GetBlackSquareCount() != 0 || len(rules.GetImposterIndices()) != 0 {
+		t.Error("stateless validation injected black squares or imposters")
+	}
+}
+
+func TestHandleValidateStatelessRejectsBadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"GET", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, `{`, http.StatusBadRequest},
+		{"missing difficulty", http.MethodPost, `{"password":"abc"}`, http.StatusBadRequest},
+		{"unknown difficulty", http.MethodPost, `{"difficulty":"nightmare","password":"abc"}`, http.StatusBadRequest},
+		{"all difficulties", http.MethodPost, `{"difficulty":"all","password":"abc"}`, http.StatusBadRequest},
+		{"oversized body", http.MethodPost, `{"difficulty":"basic","password":"` + strings.Repeat("a", maxStatelessRequestBytes) + `"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := validateStateless(tt.method, tt.body); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
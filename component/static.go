@@ -0,0 +1,68 @@
+package component
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultAssetRoot is the directory static assets are served from unless Config.AssetRoot is set
+const DefaultAssetRoot = "Frontend"
+
+// assetRoot returns the configured static asset directory
+func assetRoot() string {
+	if Config.AssetRoot == "" {
+		return DefaultAssetRoot
+	}
+	return Config.AssetRoot
+}
+
+// resolveAsset returns the path of name inside root, or false when it doesn't exist, isn't a
+// regular file, or resolves (through "..", an absolute path or a symlink) to outside root
+func resolveAsset(root, name string) (string, bool) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", false
+	}
+	absRoot, err = filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", false
+	}
+
+	if filepath.IsAbs(name) {
+		return "", false
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(absRoot, filepath.FromSlash(name)))
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(absRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return path, true
+}
+
+// ServeStatic serves name from the asset root with the given Content-Type, answering a
+// plain 404 when the file is missing or would escape the asset root
+func ServeStatic(name, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, ok := resolveAsset(assetRoot(), name)
+		if !ok {
+			log.Printf("⚠️ Static asset %q not found in %s", name, assetRoot())
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		http.ServeFile(w, r, path)
+	}
+}
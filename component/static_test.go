@@ -0,0 +1,93 @@
+package component
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useAssetRoot serves static assets from a scratch directory holding style.css, with a
+// secret file and a symlink to it just outside the root
+func useAssetRoot(t *testing.T) string {
+	t.Helper()
+	useConfig(t)
+	dir := t.TempDir()
+	root := filepath.Join(dir, "assets")
+
+	files := map[string]string{
+		filepath.Join(root, "style.css"):      "body { color: red; }",
+		filepath.Join(root, "nested", "a.js"): "console.log(1)",
+		filepath.Join(dir, "secret.txt"):      "top secret",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Logf("symlinks unavailable: %v", err)
+	}
+
+	Config.AssetRoot = root
+	return root
+}
+
+func TestServeStatic(t *testing.T) {
+	root := useAssetRoot(t)
+
+	tests := []struct {
+		name       string
+		file       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"present file", "style.css", http.StatusOK, "body { color: red; }"},
+		{"nested file", "nested/a.js", http.StatusOK, "console.log(1)"},
+		{"missing file", "flip-animations.js", http.StatusNotFound, ""},
+		{"directory", "nested", http.StatusNotFound, ""},
+		{"parent traversal", "../secret.txt", http.StatusNotFound, ""},
+		{"traversal through a subdirectory", "nested/../../secret.txt", http.StatusNotFound, ""},
+		{"absolute path", filepath.Join(filepath.Dir(root), "secret.txt"), http.StatusNotFound, ""},
+		{"symlink out of the root", "link.txt", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServeStatic(tt.file, "text/css")(w, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/css" {
+				t.Errorf("Content-Type = %q, want text/css", got)
+			}
+		})
+	}
+
+	// A missing asset root is a 404 too, not a 500
+	Config.AssetRoot = filepath.Join(root, "missing")
+	w := httptest.NewRecorder()
+	ServeStatic("style.css", "text/css")(w, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing asset root status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestAssetRootDefault(t *testing.T) {
+	useConfig(t)
+	Config.AssetRoot = ""
+	if got := assetRoot(); got != DefaultAssetRoot {
+		t.Errorf("assetRoot() = %q, want %q", got, DefaultAssetRoot)
+	}
+}
@@ -0,0 +1,89 @@
+package component
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"time"
+)
+
+// validationCacheTTL bounds how long a repeated password is answered from the cache. Rules that
+// depend on the clock or on server-side state (day, moon phase, captcha refreshes, black square
+// injection) are re-evaluated once it has passed, even if the password never changes.
+const validationCacheTTL = 10 * time.Second
+
+// cachedValidationHeaders are the response headers replayed on a cache hit
+var cachedValidationHeaders = []string{
+	"X-Satisfied-States",
+	"X-Visible-States",
+	"X-Newly-Satisfied",
+	"X-Newly-Visible",
+	"X-Injected-Positions",
+}
+
+// validationResult is the last /validate response of a session. Only a hash of the password is
+// kept, never the password itself.
+type validationResult struct {
+	key     [sha256.Size]byte
+	at      time.Time
+	headers http.Header
+	body    []byte
+}
+
+// validationKey hashes everything the validation result depends on: the password and the rule
+// states the client sent. Identical states matter because, in sequential reveal mode, the same
+// password can legitimately reveal one more rule per request.
+func validationKey(session *UserSession, r *http.Request, password string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, part := range []string{
+		session.Difficulty,
+		session.Locale,
+		r.Header.Get("X-Satisfied-States"),
+		r.Header.Get("X-Visible-States"),
+		password,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// serveCachedValidation answers from the session's last result when key matches and it is still
+// fresh, reporting whether it did. Validators, progress writes and the transcript are skipped.
+func serveCachedValidation(w http.ResponseWriter, session *UserSession, key [sha256.Size]byte) bool {
+	sessionsMutex.RLock()
+	cached := session.lastValidation
+	sessionsMutex.RUnlock()
+
+	if cached == nil || cached.key != key || time.Since(cached.at) > validationCacheTTL {
+		return false
+	}
+
+	for name, values := range cached.headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Validation-Cached", "true")
+	w.Write(cached.body)
+	return true
+}
+
+// cacheValidation stores a freshly rendered /validate response for the session
+func cacheValidation(w http.ResponseWriter, session *UserSession, key [sha256.Size]byte, body []byte) {
+	headers := make(http.Header)
+	for _, name := range cachedValidationHeaders {
+		if values := w.Header().Values(name); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(name)] = append([]string{}, values...)
+		}
+	}
+
+	sessionsMutex.Lock()
+	session.lastValidation = &validationResult{
+		key:     key,
+		at:      time.Now(),
+		headers: headers,
+		body:    body,
+	}
+	sessionsMutex.Unlock()
+}
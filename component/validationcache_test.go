@@ -0,0 +1,93 @@
+package component
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"passgame/rules"
+)
+
+// ruleOneCalls is the running count of Rule 1 validator calls from the metrics output
+var ruleOneCalls = regexp.MustCompile(`passgame_rule_validator_seconds_count\{rule="1"\} (\d+)`)
+
+// validatorCalls reports how often Rule 1's validator has run; it runs on every full validation
+func validatorCalls(t *testing.T) int {
+	t.Helper()
+	var metrics bytes.Buffer
+	rules.WriteMetrics(&metrics)
+	match := ruleOneCalls.FindStringSubmatch(metrics.String())
+	if match == nil {
+		return 0
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestHandleValidateCachesUnchangedPassword(t *testing.T) {
+	useConfig(t)
+	sessionID := useTestSession(t, "basic")
+	rules.SetValidatorTiming(true)
+	t.Cleanup(func() { rules.SetValidatorTiming(false) })
+
+	first := validateRequest(sessionID, "abcdefgh", nil)
+	if first.Header().Get("X-Validation-Cached") != "" {
+		t.Fatal("the first validation was served from the cache")
+	}
+	calls := validatorCalls(t)
+
+	second := validateRequest(sessionID, "abcdefgh", nil)
+	if second.Header().Get("X-Validation-Cached") != "true" {
+		t.Fatal("the repeated password was validated again")
+	}
+	if got := validatorCalls(t); got != calls {
+		t.Errorf("validators ran %d more times for the cached response", got-calls)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Error("the cached body differs from the first response")
+	}
+	for _, name := range []string{"X-Satisfied-States", "X-Visible-States"} {
+		if second.Header().Get(name) != first.Header().Get(name) {
+			t.Errorf("cached %s = %q, want %q", name, second.Header().Get(name), first.Header().Get(name))
+		}
+	}
+	session, _ := GetSession(sessionID)
+	if len(session.Transcript) != 1 {
+		t.Errorf("transcript has %d entries, want the cached request left out", len(session.Transcript))
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		previous http.Header
+		prepare  func()
+	}{
+		{"changed password", "abcdefghi", nil, func() {}},
+		// With the states from the last response, the same password may reveal the next rule
+		{"changed rule states", "abcdefghi", second.Header(), func() {}},
+		{"expired entry", "abcdefghi", second.Header(), func() {
+			sessionsMutex.Lock()
+			session.lastValidation.at = time.Now().Add(-validationCacheTTL - time.Second)
+			sessionsMutex.Unlock()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			calls := validatorCalls(t)
+			w := validateRequest(sessionID, tt.password, tt.previous)
+			if w.Header().Get("X-Validation-Cached") != "" {
+				t.Error("served from the cache")
+			}
+			if validatorCalls(t) == calls {
+				t.Error("validators did not run")
+			}
+		})
+	}
+}
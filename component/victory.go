@@ -0,0 +1,129 @@
+package component
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	database "passgame/Database"
+)
+
+// VictoryData holds the player's stats for the victory page
+type VictoryData struct {
+	Title      string
+	Username   string
+	Difficulty string
+	TimeSpent  int
+	RuleCount  int
+	// Rank is the player's leaderboard position, or 0 for test sessions and unranked players
+	Rank int
+}
+
+// buildVictoryData gathers a completed session's stats. Registered players get the time and
+// rank recorded in the database; test sessions fall back to the in-memory session.
+func buildVictoryData(session *UserSession) VictoryData {
+	sessionsMutex.RLock()
+	data := VictoryData{
+		Title:      "You Win! - The Ultimate Password Game",
+		Username:   session.Username,
+		Difficulty: session.Difficulty,
+		TimeSpent:  int(time.Since(session.StartTime).Seconds()),
+		RuleCount:  session.MaxRule,
+	}
+	sessionsMutex.RUnlock()
+
+	if HasDatabaseUser(session) {
+		if user, err := database.GetUser(session.UserID); err != nil {
+			log.Printf("Error getting user %s for victory page: %v", session.Username, err)
+		} else {
+			data.TimeSpent = user.TimeSpent
+			data.RuleCount = user.RuleReached
+		}
+
+		rank, err := database.GetUserRank(session.UserID)
+		if err != nil {
+			log.Printf("Error getting rank for user %s: %v", session.Username, err)
+		}
+		data.Rank = rank
+	}
+
+	return data
+}
+
+// HandleVictory renders the victory page for a session that has completed its game.
+// Anyone else is sent back to the game.
+func HandleVictory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getUserSession(r)
+	if session == nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	sessionsMutex.RLock()
+	completed := session.IsCompleted
+	sessionsMutex.RUnlock()
+	if !completed {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := victoryTmpl.Execute(w, buildVictoryData(session)); err != nil {
+		log.Printf("Error executing victory template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// victoryTmpl is parsed once and shared by every request
+var victoryTmpl = template.Must(template.New("victory").Funcs(getTemplateFunctions()).Parse(victoryTemplate))
+
+// victoryTemplate is the HTML template for the victory page
+const victoryTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <link rel="stylesheet" href="/style.css">
+</head>
+<body>
+    <main>
+        <div class="content">
+            <div class="leaderboard-container">
+                <h1 class="leaderboard-title">🎉 Congratulations, {{.Username}}!</h1>
+                <p class="text-center">You satisfied every rule. Your password is finally good enough.</p>
+
+                <div class="stats-overview">
+                    <div class="stat-item">
+                        <div class="stat-value">{{getDifficultyIcon .Difficulty}} {{.Difficulty}}</div>
+                        <div class="stat-label">Difficulty</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-value">{{formatDuration .TimeSpent}}</div>
+                        <div class="stat-label">Time Taken</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-value">{{.RuleCount}}</div>
+                        <div class="stat-label">Rules Completed</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-value">{{if .Rank}}#{{.Rank}}{{else}}—{{end}}</div>
+                        <div class="stat-label">Leaderboard Rank</div>
+                    </div>
+                </div>
+
+                <div class="text-center">
+                    <a href="/leaderboard" class="btn-primary">🏆 View Leaderboard</a>
+                    <a href="/" class="btn-primary">🔄 Play Again</a>
+                </div>
+            </div>
+        </div>
+    </main>
+</body>
+</html>`
@@ -0,0 +1,103 @@
+package component
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+// getVictory requests /victory for the session
+func getVictory(sessionID string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/victory", nil)
+	r.AddCookie(&http.Cookie{Name: "user_session", Value: sessionID})
+	w := httptest.NewRecorder()
+	HandleVictory(w, r)
+	return w
+}
+
+func TestHandleVictoryAfterCompletion(t *testing.T) {
+	useEmptyDB(t)
+	useSessions(t)
+	useConfig(t)
+	Config.VictoryRedirect = true
+
+	userID := insertTestUser(t, "winner", "basic")
+	sessionID := "session-" + t.Name()
+	storeSession(sessionID, &UserSession{UserID: userID, Username: "winner", Difficulty: "basic", StartTime: time.Now()})
+
+	// The victory page isn't available before the game is completed
+	if w := getVictory(sessionID); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Errorf("unfinished game = %d to %q, want a redirect to the game", w.Code, w.Header().Get("Location"))
+	}
+
+	var w *httptest.ResponseRecorder
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"} {
+		w = validateRequest(sessionID, password, previous)
+		if w.Code != http.StatusOK {
+			t.Fatalf("validate %q status = %d, want %d", password, w.Code, http.StatusOK)
+		}
+		if password != "Abcdef!X7" && w.Header().Get("HX-Redirect") != "" {
+			t.Errorf("validate %q redirected before completion", password)
+		}
+		previous = w.Header()
+	}
+	if got := w.Header().Get("HX-Redirect"); got != "/victory" {
+		t.Fatalf("completing the game HX-Redirect = %q, want /victory", got)
+	}
+
+	user, err := database.GetUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w = getVictory(sessionID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("victory status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"Congratulations, winner!",
+		"basic</div>",
+		formatDuration(user.TimeSpent),
+		">6</div>",
+		">#1</div>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("victory page is missing %q", want)
+		}
+	}
+}
+
+func TestHandleVictoryWithoutRedirect(t *testing.T) {
+	useConfig(t)
+	Config.VictoryRedirect = false
+	sessionID := useTestSession(t, "basic")
+
+	var w *httptest.ResponseRecorder
+	var previous http.Header
+	for _, password := range []string{"abc", "abcdefgh", "Abcdefgh!", "Abcdef!X7"} {
+		w = validateRequest(sessionID, password, previous)
+		previous = w.Header()
+	}
+	if got := w.Header().Get("HX-Redirect"); got != "" {
+		t.Errorf("HX-Redirect = %q with the redirect disabled", got)
+	}
+
+	// Test sessions aren't ranked
+	w = getVictory(sessionID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("victory status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Congratulations, Test User!") || !strings.Contains(body, ">—</div>") {
+		t.Errorf("test session victory page lacks the name or shows a rank:\n%s", body)
+	}
+
+	if w := getVictory("no-such-session"); w.Code != http.StatusSeeOther {
+		t.Errorf("unknown session status = %d, want %d", w.Code, http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,74 @@
+package component
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Completion webhook delivery settings
+const (
+	webhookTimeout    = 5 * time.Second
+	webhookMaxRetries = 3
+	webhookRetryDelay = time.Second
+)
+
+// CompletionEvent is the payload POSTed to Config.CompletionWebhookURL when a player finishes
+type CompletionEvent struct {
+	Username    string    `json:"username"`
+	Difficulty  string    `json:"difficulty"`
+	TimeSpent   int       `json:"time_spent"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// notifyCompletion delivers a completion event in the background; it's a no-op when no
+// webhook URL is configured
+func notifyCompletion(event CompletionEvent) {
+	url := Config.CompletionWebhookURL
+	if url == "" {
+		return
+	}
+
+	go func() {
+		if err := postWebhook(url, event); err != nil {
+			log.Printf("⚠️ Completion webhook failed for %s: %v", event.Username, err)
+		}
+	}()
+}
+
+// postWebhook POSTs the event as JSON, retrying with a growing delay on errors and 5xx responses
+func postWebhook(url string, event CompletionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := webhookRetryDelay
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookMaxRetries; attempt++ {
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			lastErr = fmt.Errorf("webhook returned status: %s", resp.Status)
+			if resp.StatusCode < 500 {
+				return lastErr // Client errors won't succeed on retry
+			}
+		} else {
+			lastErr = fmt.Errorf("failed to call webhook: %v", err)
+		}
+
+		if attempt < webhookMaxRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,72 @@
+package component
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompletionWebhookPayload(t *testing.T) {
+	events := make(chan CompletionEvent, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		var event CompletionEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		events <- event
+	}))
+	defer receiver.Close()
+
+	useConfig(t)
+	Config.CompletionWebhookURL = receiver.URL
+	sessionID := useTestSession(t, "basic")
+	started := time.Now()
+
+	for _, password := range []string{"Abc", "Abcdef", "Abcdef!X", "Abcdef!X7"} {
+		if w := validateRequest(sessionID, password, nil); w.Code != http.StatusOK {
+			t.Fatalf("validate %q status = %d, want %d", password, w.Code, http.StatusOK)
+		}
+	}
+
+	select {
+	case event := <-events:
+		if event.Username != "Test User" || event.Difficulty != "basic" {
+			t.Errorf("event = %+v, want Test User on basic", event)
+		}
+		if event.TimeSpent < 0 || event.CompletedAt.Before(started.Add(-time.Second)) {
+			t.Errorf("event time_spent %d, completed_at %v", event.TimeSpent, event.CompletedAt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook was never called")
+	}
+
+	// Validating the finished password again doesn't send a second event
+	validateRequest(sessionID, "Abcdef!X7!", nil)
+	select {
+	case event := <-events:
+		t.Errorf("second event %+v for an already completed game", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPostWebhookClientErrorIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	if err := postWebhook(receiver.URL, CompletionEvent{Username: "x"}); err == nil {
+		t.Error("postWebhook() = nil for a 400 response, want an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("webhook called %d times, want 1", got)
+	}
+}
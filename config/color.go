@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HexToRGB converts a hex color string such as "#ff8800", "ff8800" or the shorthand "#f80" to RGB values
+func HexToRGB(hexColor string) (r, g, b uint8, err error) {
+	// Remove the # prefix if present
+	hex := strings.TrimPrefix(strings.TrimSpace(hexColor), "#")
+
+	// Expand 3-digit shorthand, e.g. "f80" -> "ff8800"
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color format: %s (expected 3 or 6 hex digits)", hexColor)
+	}
+
+	// Parse the RGB values
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color: %s", hexColor)
+	}
+
+	// Extract the RGB components
+	r = uint8((rgb >> 16) & 0xFF)
+	g = uint8((rgb >> 8) & 0xFF)
+	b = uint8(rgb & 0xFF)
+
+	return r, g, b, nil
+}
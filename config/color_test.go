@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestHexToRGB(t *testing.T) {
+	tests := []struct {
+		input   string
+		r, g, b uint8
+		wantErr bool
+	}{
+		{input: "#ff8800", r: 0xff, g: 0x88, b: 0x00},
+		{input: "#FF8800", r: 0xff, g: 0x88, b: 0x00},
+		{input: "ff8800", r: 0xff, g: 0x88, b: 0x00},
+		{input: " #0a0b0c ", r: 0x0a, g: 0x0b, b: 0x0c},
+		{input: "#f80", r: 0xff, g: 0x88, b: 0x00},
+		{input: "f80", r: 0xff, g: 0x88, b: 0x00},
+		{input: "#000", r: 0, g: 0, b: 0},
+		{input: "", wantErr: true},
+		{input: "#", wantErr: true},
+		{input: "#ff88", wantErr: true},
+		{input: "#ff88001", wantErr: true},
+		{input: "#gg8800", wantErr: true},
+		{input: "#xyz", wantErr: true},
+		{input: "#-12345", wantErr: true},
+		{input: "##f80", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, g, b, err := HexToRGB(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("HexToRGB(%q) = %d, %d, %d, want an error", tt.input, r, g, b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HexToRGB(%q) error = %v", tt.input, err)
+			}
+			if r != tt.r || g != tt.g || b != tt.b {
+				t.Errorf("HexToRGB(%q) = %d, %d, %d, want %d, %d, %d", tt.input, r, g, b, tt.r, tt.g, tt.b)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DifficultiesFile is where the difficulty levels are configured
+const DifficultiesFile = "config/difficulties.json"
+
+// DifficultyConfig represents the configuration for a difficulty level
+type DifficultyConfig struct {
+	Name        string `json:"name"`
+	Icon        string `json:"icon"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	RuleCount   int    `json:"rule_count"`
+	// AntiPaste opts the difficulty into rejecting pasted passwords for its higher rules
+	AntiPaste *AntiPasteConfig `json:"anti_paste,omitempty"`
+	// DigitSumTarget is the total a digit-sum rule expects for this difficulty (0 when unused)
+	DigitSumTarget int `json:"digit_sum_target,omitempty"`
+	// TimeLimitSeconds gives each run a time budget; progress stops counting once it runs out
+	// (0 means untimed)
+	TimeLimitSeconds int `json:"time_limit_seconds,omitempty"`
+	// ScoreWeight multiplies the leaderboard score of every rule reached on this difficulty
+	// (0 means 1)
+	ScoreWeight float64 `json:"score_weight,omitempty"`
+	// ShowHints overrides the global showHints setting for this difficulty (unset uses the global)
+	ShowHints *bool `json:"show_hints,omitempty"`
+	// Order positions the difficulty in pickers, stats and difficulty sorting, lowest first.
+	// Difficulties without one come after the ordered ones, alphabetically.
+	Order int `json:"order,omitempty"`
+}
+
+// AntiPasteConfig controls paste detection for a difficulty
+type AntiPasteConfig struct {
+	// MaxJump is the most characters the password may grow in one validation (0 uses the default)
+	MaxJump int `json:"max_jump"`
+	// FromRule is the first rule ID that can't be satisfied by a pasted password (0 covers every rule)
+	FromRule int `json:"from_rule"`
+}
+
+// Cache for difficulties.json, refreshed whenever the file's modification time or size changes
+var (
+	difficultiesMutex  sync.Mutex
+	cachedDifficulties map[string]DifficultyConfig
+	cachedModTime      time.Time
+	cachedSize         int64
+)
+
+// LoadDifficulties returns the configured difficulties. The file is only re-read when it has
+// changed since the last load, so it's cheap enough for template helpers. The defaults are
+// returned along with the error when the file is missing or invalid.
+func LoadDifficulties() (map[string]DifficultyConfig, error) {
+	info, err := os.Stat(DifficultiesFile)
+	if err != nil {
+		log.Printf("Error reading difficulties.json: %v", err)
+		return getDefaultDifficulties(), err
+	}
+
+	difficultiesMutex.Lock()
+	defer difficultiesMutex.Unlock()
+
+	if cachedDifficulties != nil && info.ModTime().Equal(cachedModTime) && info.Size() == cachedSize {
+		return copyDifficulties(cachedDifficulties), nil
+	}
+
+	data, err := ioutil.ReadFile(DifficultiesFile)
+	if err != nil {
+		log.Printf("Error reading difficulties.json: %v", err)
+		return getDefaultDifficulties(), err
+	}
+
+	var difficulties map[string]DifficultyConfig
+	if err := json.Unmarshal(data, &difficulties); err != nil {
+		log.Printf("Error parsing difficulties.json: %v", err)
+		return getDefaultDifficulties(), err
+	}
+
+	// Bad entries are reported but still served, so players already on them can keep playing
+	problems := ValidateDifficulties(difficulties)
+	for _, problem := range problems {
+		log.Printf("Warning: difficulties.json: %v", problem)
+	}
+	if !hasValidDifficulty(difficulties) {
+		err := fmt.Errorf("difficulties.json has no valid difficulties")
+		log.Printf("Error loading difficulties.json: %v, using defaults", err)
+		return getDefaultDifficulties(), err
+	}
+
+	if cachedDifficulties != nil {
+		log.Printf("🔄 Reloaded difficulties.json (%d difficulties)", len(difficulties))
+	}
+	cachedDifficulties = difficulties
+	cachedModTime = info.ModTime()
+	cachedSize = info.Size()
+
+	return copyDifficulties(difficulties), nil
+}
+
+// ValidateDifficulty checks if the given difficulty is valid according to the loaded configuration.
+// "all" is accepted as the leaderboard's no-filter value.
+func ValidateDifficulty(difficulty string) bool {
+	if difficulty == "all" {
+		return true
+	}
+
+	diffs, err := LoadDifficulties()
+	if err != nil {
+		return false
+	}
+
+	// Check against loaded difficulties (case-insensitive)
+	for k := range diffs {
+		if strings.EqualFold(difficulty, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDifficulties checks that every difficulty has a name, icon and color and that the
+// color is a valid hex code. It returns one error per problem, sorted by difficulty key.
+func ValidateDifficulties(difficulties map[string]DifficultyConfig) []error {
+	keys := make([]string, 0, len(difficulties))
+	for key := range difficulties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, validateDifficulty(key, difficulties[key])...)
+	}
+	return errs
+}
+
+// validateDifficulty returns the problems with a single difficulty entry
+func validateDifficulty(key string, diff DifficultyConfig) []error {
+	var errs []error
+	if strings.TrimSpace(key) == "" {
+		errs = append(errs, fmt.Errorf("difficulty with an empty key"))
+	}
+	if strings.TrimSpace(diff.Name) == "" {
+		errs = append(errs, fmt.Errorf("difficulty %q is missing \"name\"", key))
+	}
+	if strings.TrimSpace(diff.Icon) == "" {
+		errs = append(errs, fmt.Errorf("difficulty %q is missing \"icon\"", key))
+	}
+	if strings.TrimSpace(diff.Color) == "" {
+		errs = append(errs, fmt.Errorf("difficulty %q is missing \"color\"", key))
+	} else if _, _, _, err := HexToRGB(diff.Color); err != nil {
+		errs = append(errs, fmt.Errorf("difficulty %q: %v", key, err))
+	}
+	return errs
+}
+
+// hasValidDifficulty reports whether at least one entry passes validation
+func hasValidDifficulty(difficulties map[string]DifficultyConfig) bool {
+	for key, diff := range difficulties {
+		if len(validateDifficulty(key, diff)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreWeights returns the leaderboard score weight of every configured difficulty, 1 where unset
+func ScoreWeights() map[string]float64 {
+	difficulties, _ := LoadDifficulties()
+	weights := make(map[string]float64, len(difficulties))
+	for key, diff := range difficulties {
+		weights[strings.ToLower(key)] = diff.ScoreWeight
+		if diff.ScoreWeight <= 0 {
+			weights[strings.ToLower(key)] = 1
+		}
+	}
+	return weights
+}
+
+// DifficultyOrder returns the configured difficulty keys in display order, see
+// DifficultyConfig.Order
+func DifficultyOrder() []string {
+	difficulties, _ := LoadDifficulties()
+	keys := make([]string, 0, len(difficulties))
+	for key := range difficulties {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, oj := difficulties[keys[i]].Order, difficulties[keys[j]].Order
+		if (oi > 0) != (oj > 0) {
+			return oi > 0
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// copyDifficulties returns a copy of the map so callers can't modify the cache
+func copyDifficulties(difficulties map[string]DifficultyConfig) map[string]DifficultyConfig {
+	copied := make(map[string]DifficultyConfig, len(difficulties))
+	for key, diff := range difficulties {
+		copied[key] = diff
+	}
+	return copied
+}
+
+// getDefaultDifficulties returns hardcoded default difficulties as fallback
+func getDefaultDifficulties() map[string]DifficultyConfig {
+	return map[string]DifficultyConfig{
+		"basic": {
+			Name:        "Basic",
+			Icon:        "🟢",
+			Color:       "#4CAF50",
+			Description: "Standard rules",
+			ScoreWeight: 1,
+			Order:       1,
+		},
+		"intermediate": {
+			Name:        "Intermediate",
+			Icon:        "🟡",
+			Color:       "#FF9800",
+			Description: "More challenging",
+			ScoreWeight: 1.5,
+			Order:       2,
+		},
+		"hard": {
+			Name:           "Hard",
+			Icon:           "🔴",
+			Color:          "#F44336",
+			Description:    "Expert level",
+			ScoreWeight:    2,
+			Order:          3,
+			DigitSumTarget: 30,
+		},
+		"expert": {
+			Name:        "Expert",
+			Icon:        "🟣",
+			Color:       "#9C27B0",
+			Description: "Master level",
+			ScoreWeight: 3,
+			Order:       4,
+		},
+		"fun": {
+			Name:        "Fun",
+			Icon:        "🎉",
+			Color:       "#E91E63",
+			Description: "Quirky rules",
+			ScoreWeight: 1,
+			Order:       5,
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDifficulties writes difficulties.json with the given modification time
+func writeDifficulties(t *testing.T, data string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(DifficultiesFile, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", DifficultiesFile, err)
+	}
+	if err := os.Chtimes(DifficultiesFile, modTime, modTime); err != nil {
+		t.Fatalf("failed to set the time of %s: %v", DifficultiesFile, err)
+	}
+}
+
+// basicIcon loads the difficulties and returns the icon of basic
+func basicIcon(t *testing.T) string {
+	t.Helper()
+	difficulties, err := LoadDifficulties()
+	if err != nil {
+		t.Fatalf("LoadDifficulties() error = %v", err)
+	}
+	return difficulties["basic"].Icon
+}
+
+func TestLoadDifficultiesReloadsChangedFile(t *testing.T) {
+	t.Cleanup(func() { os.Remove(DifficultiesFile) })
+	start := time.Now().Add(-time.Hour)
+
+	writeDifficulties(t, `{"basic": {"name": "Basic", "icon": "A", "color": "#4CAF50"}}`, start)
+	if got := basicIcon(t); got != "A" {
+		t.Fatalf("icon = %q, want A", got)
+	}
+
+	// Callers get a copy, so changing it doesn't touch the cache
+	difficulties, _ := LoadDifficulties()
+	difficulties["basic"] = DifficultyConfig{Icon: "changed"}
+	if got := basicIcon(t); got != "A" {
+		t.Errorf("icon after editing a loaded copy = %q, want A", got)
+	}
+
+	// A rewrite that keeps the size and time is not noticed, so the file isn't re-read
+	writeDifficulties(t, `{"basic": {"name": "Basic", "icon": "B", "color": "#4CAF50"}}`, start)
+	if got := basicIcon(t); got != "A" {
+		t.Errorf("icon with an unchanged time and size = %q, want the cached A", got)
+	}
+
+	// A newer modification time triggers a reload
+	writeDifficulties(t, `{"basic": {"name": "Basic", "icon": "C", "color": "#4CAF50"}}`, start.Add(time.Second))
+	if got := basicIcon(t); got != "C" {
+		t.Errorf("icon after the file changed = %q, want C", got)
+	}
+
+	// So does a change of size, even within the same second
+	writeDifficulties(t, `{"basic": {"name": "Basic", "icon": "DD", "color": "#4CAF50"}}`, start.Add(time.Second))
+	if got := basicIcon(t); got != "DD" {
+		t.Errorf("icon after the file grew = %q, want DD", got)
+	}
+}
+
+func TestLoadDifficultiesFallsBackToDefaults(t *testing.T) {
+	t.Cleanup(func() { os.Remove(DifficultiesFile) })
+	defaults := getDefaultDifficulties()
+
+	tests := []struct {
+		name  string
+		write func(t *testing.T)
+	}{
+		{"missing file", func(t *testing.T) { os.Remove(DifficultiesFile) }},
+		{"invalid JSON", func(t *testing.T) { writeDifficulties(t, `{`, time.Now().Add(time.Minute)) }},
+		{"no valid difficulty", func(t *testing.T) { writeDifficulties(t, `{"basic": {}}`, time.Now().Add(2*time.Minute)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.write(t)
+			difficulties, err := LoadDifficulties()
+			if err == nil {
+				t.Error("LoadDifficulties() error = nil, want an error")
+			}
+			if len(difficulties) != len(defaults) || difficulties["basic"] != defaults["basic"] {
+				t.Errorf("LoadDifficulties() = %v, want the defaults", difficulties)
+			}
+		})
+	}
+}
+
+func TestValidateDifficulties(t *testing.T) {
+	tests := []struct {
+		name         string
+		difficulties map[string]DifficultyConfig
+		want         []string
+	}{
+		{
+			name: "valid",
+			difficulties: map[string]DifficultyConfig{
+				"basic": {Name: "Basic", Icon: "🟢", Color: "#4CAF50"},
+				"hard":  {Name: "Hard", Icon: "🔴", Color: "f43"},
+			},
+		},
+		{
+			name: "missing color",
+			difficulties: map[string]DifficultyConfig{
+				"basic": {Name: "Basic", Icon: "🟢", Color: "#4CAF50"},
+				"hard":  {Name: "Hard", Icon: "🔴"},
+			},
+			want: []string{`difficulty "hard" is missing "color"`},
+		},
+		{
+			name: "invalid hex",
+			difficulties: map[string]DifficultyConfig{
+				"basic": {Name: "Basic", Icon: "🟢", Color: "#4CAF5"},
+				"hard":  {Name: "Hard", Icon: "🔴", Color: "#F44336"},
+			},
+			want: []string{`difficulty "basic": `},
+		},
+		{
+			name: "every problem, sorted by key",
+			difficulties: map[string]DifficultyConfig{
+				"zeta":  {Color: "red"},
+				"alpha": {Name: " ", Icon: "A"},
+			},
+			want: []string{
+				`difficulty "alpha" is missing "name"`,
+				`difficulty "alpha" is missing "color"`,
+				`difficulty "zeta" is missing "name"`,
+				`difficulty "zeta" is missing "icon"`,
+				`difficulty "zeta": `,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateDifficulties(tt.difficulties)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateDifficulties() = %v, want %d problems", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(errs[i].Error(), want) {
+					t.Errorf("problem %d = %q, want it to start with %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDifficultiesServesBadEntries(t *testing.T) {
+	t.Cleanup(func() { os.Remove(DifficultiesFile) })
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	writeDifficulties(t, `{
+		"basic": {"name": "Basic", "icon": "🟢", "color": "#4CAF50"},
+		"gray": {"name": "Gray", "icon": "⬜"},
+		"bad": {"name": "Bad", "icon": "❌", "color": "#nothex"}
+	}`, time.Now().Add(3*time.Minute))
+
+	difficulties, err := LoadDifficulties()
+	if err != nil {
+		t.Fatalf("LoadDifficulties() error = %v", err)
+	}
+	// Players already on a bad entry keep playing it, but each problem is logged
+	if len(difficulties) != 3 {
+		t.Errorf("LoadDifficulties() = %v, want all three entries", difficulties)
+	}
+	for _, want := range []string{`"gray" is missing "color"`, `difficulty "bad": `} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q doesn't report %s", logs.String(), want)
+		}
+	}
+	if strings.Contains(logs.String(), `"basic"`) {
+		t.Errorf("log %q reports the valid basic entry", logs.String())
+	}
+}
+
+func TestDifficultyOrder(t *testing.T) {
+	t.Cleanup(func() { os.Remove(DifficultiesFile) })
+	start := time.Now().Add(-time.Hour)
+
+	// Without difficulties.json the defaults keep their usual order
+	os.Remove(DifficultiesFile)
+	if got := strings.Join(DifficultyOrder(), ","); got != "basic,intermediate,hard,expert,fun" {
+		t.Errorf("default order = %s", got)
+	}
+
+	writeDifficulties(t, `{
+		"hard": {"name": "Hard", "icon": "H", "color": "#F44336", "order": 3},
+		"basic": {"name": "Basic", "icon": "B", "color": "#4CAF50", "order": 1},
+		"intermediate": {"name": "Intermediate", "icon": "I", "color": "#FF9800", "order": 2}
+	}`, start)
+	if got := strings.Join(DifficultyOrder(), ","); got != "basic,intermediate,hard" {
+		t.Errorf("order = %s, want basic,intermediate,hard", got)
+	}
+
+	// A new difficulty slots in by its order; unordered ones follow alphabetically
+	writeDifficulties(t, `{
+		"hard": {"name": "Hard", "icon": "H", "color": "#F44336", "order": 30},
+		"basic": {"name": "Basic", "icon": "B", "color": "#4CAF50", "order": 10},
+		"intermediate": {"name": "Intermediate", "icon": "I", "color": "#FF9800", "order": 20},
+		"medium": {"name": "Medium", "icon": "M", "color": "#FFC107", "order": 15},
+		"zen": {"name": "Zen", "icon": "Z", "color": "#00BCD4"},
+		"arcade": {"name": "Arcade", "icon": "A", "color": "#3F51B5"}
+	}`, start.Add(time.Minute))
+	if got := strings.Join(DifficultyOrder(), ","); got != "basic,medium,intermediate,hard,arcade,zen" {
+		t.Errorf("order = %s, want basic,medium,intermediate,hard,arcade,zen", got)
+	}
+}
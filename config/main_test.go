@@ -0,0 +1,30 @@
+package config
+
+import (
+	"log"
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests from a scratch directory, so they can write their own
+// config/difficulties.json
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "passgame-config-")
+	if err != nil {
+		log.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+		if err := os.Chdir(dir); err != nil {
+			log.Printf("Failed to enter test directory: %v", err)
+			return 1
+		}
+		if err := os.Mkdir("config", 0755); err != nil {
+			log.Printf("Failed to create the config directory: %v", err)
+			return 1
+		}
+		return m.Run()
+	}()
+	os.Exit(code)
+}
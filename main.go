@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -10,15 +11,77 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	database "passgame/Database"
 	"passgame/component"
+	"passgame/config"
 	"passgame/rules"
 )
 
 func main() {
+	// Load application config (admin token, hints)
+	if err := component.LoadConfig(); err != nil {
+		log.Printf("Warning: Could not load app config, using defaults: %v", err)
+	}
+	if err := rules.SetWordleTimezone(component.Config.WordleTimezone); err != nil {
+		log.Printf("Warning: %v, using UTC", err)
+	}
+	if err := rules.SetRevealMode(component.Config.RevealMode); err != nil {
+		log.Printf("Warning: %v, using sequential", err)
+	}
+	rules.SetQRLanguage(component.Config.QRLanguage)
+	if err := rules.SetAcceptedLocales(component.Config.Locales); err != nil {
+		log.Printf("Warning: %v, ignoring them", err)
+	}
+	rules.SetValidatorTiming(component.Config.ValidatorTiming)
+	rules.SetRomanNumeralMinimum(component.Config.RomanNumeralMinimum)
+	rules.SetImposterCount(component.Config.ImposterCount)
+	rules.SetBlackSquareMinimum(component.Config.BlackSquareMinimum)
+	if err := rules.SetMonotonicRun(component.Config.MonotonicRunLength, component.Config.MonotonicRunDirection); err != nil {
+		log.Printf("Warning: %v, using %s", err, rules.MonotonicIncreasing)
+	}
+	if err := rules.SetPrimeRange(component.Config.PrimeMin, component.Config.PrimeMax); err != nil {
+		log.Printf("Warning: %v, using %d-%d", err, rules.DefaultPrimeMin, rules.DefaultPrimeMax)
+	}
+	if err := rules.SetCaptchaCharset(component.Config.CaptchaCharset); err != nil {
+		log.Printf("Warning: %v, using %s", err, rules.CaptchaDigits)
+	}
+	if err := rules.SetStrengthRule(component.Config.StrengthEmoji, component.Config.StrengthCount); err != nil {
+		log.Printf("Warning: %v, using %s", err, rules.DefaultStrengthEmoji)
+	}
+
+	// Orphaned IDs in assignments.json would silently shorten a difficulty's rule set
+	if errs := rules.ValidateAssignments(); len(errs) > 0 {
+		for _, err := range errs {
+			log.Printf("Warning: %v", err)
+		}
+		if component.Config.StrictAssignments {
+			log.Fatalf("Invalid rules/assignments.json (%d problems), refusing to start", len(errs))
+		}
+	}
+
+	if err := component.LoadTemplates(); err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+
+	if rules.TestMode() {
+		log.Printf("🧪 %s=1: dynamic rules use fixed values and skip external APIs. Never run this in production!", rules.TestModeEnv)
+	}
+
+	// Progress can never exceed the highest rule in the pool
+	database.SetMaxRuleReached(rules.MaxRule())
+
+	// Optional difficulty unlock gating. Final rules are set before InitDB so the migration that
+	// adds the completed flag can mark the players who already finished.
+	database.SetDifficultyUnlockOrder(component.Config.DifficultyUnlockOrder)
+	database.SetFinalRuleFunc(rules.GetFinalRuleID)
+	database.SetBackfillFinalRuleFunc(rules.PreAppendFinalRuleID)
+
 	// Initialize database
 	err := database.InitDB()
 	if err != nil {
@@ -26,6 +89,9 @@ func main() {
 	}
 	defer database.CloseDB()
 
+	// Rule 31 reads its target from each difficulty's digit_sum_target
+	rules.SetDigitSumTargetFunc(component.GetDigitSumTarget)
+
 	// Initialize QR code table
 	err = rules.InitQRCodeTable()
 	if err != nil {
@@ -44,28 +110,84 @@ func main() {
 		log.Fatalf("Failed to initialize color codes table: %v", err)
 	}
 
-	// Generate initial QR code with a word from the API
-	err = rules.RefreshQRCodeWithAPI()
+	// Restore the challenges that were current before the last restart
+	err = rules.InitGameStateTable()
+	if err != nil {
+		log.Fatalf("Failed to initialize game state table: %v", err)
+	}
+	restored, err := rules.LoadGameState()
 	if err != nil {
-		log.Printf("Warning: Failed to generate initial QR code with API word: %v", err)
-		// Fall back to regular refresh if API fails
-		err = rules.RefreshQRCode()
+		log.Printf("Warning: Failed to restore game state: %v", err)
+	} else if restored > 0 {
+		log.Printf("♻️ Restored %d challenges from the previous run", restored)
+	}
+
+	// Keep the rule satisfaction analytics across restarts
+	err = rules.InitRuleStatsTable()
+	if err != nil {
+		log.Fatalf("Failed to initialize rule stats table: %v", err)
+	}
+	if _, err := rules.LoadRuleStats(); err != nil {
+		log.Printf("Warning: Failed to load rule stats: %v", err)
+	}
+
+	// Generate initial QR code with a word from the API
+	if rules.GetCurrentQRWord() == "" {
+		_, err = rules.RefreshQRCodeWithAPI(context.Background())
 		if err != nil {
-			log.Printf("Warning: Failed to generate initial QR code: %v", err)
+			log.Printf("Warning: Failed to generate initial QR code with API word: %v", err)
+			// Fall back to regular refresh if API fails
+			err = rules.RefreshQRCode()
+			if err != nil {
+				log.Printf("Warning: Failed to generate initial QR code: %v", err)
+			}
 		}
 	}
 
 	// Generate initial mathematical constant
-	err = rules.RefreshMathConstant()
-	if err != nil {
-		log.Printf("Warning: Failed to generate initial mathematical constant: %v", err)
+	if name, _ := rules.GetCurrentMathConstant(); name == "" {
+		err = rules.RefreshMathConstant()
+		if err != nil {
+			log.Printf("Warning: Failed to generate initial mathematical constant: %v", err)
+		}
 	}
 
 	// Generate initial color
-	err = rules.RefreshColor()
+	if name, _ := rules.GetCurrentColor(); name == "" {
+		err = rules.RefreshColor()
+		if err != nil {
+			log.Printf("Warning: Failed to generate initial color: %v", err)
+		}
+	}
+
+	// Cancelled on SIGINT/SIGTERM to stop background work and shut the server down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Periodically rotate the QR word, math constant and color
+	qrInterval, err := component.ParseRefreshInterval(component.Config.QRRefreshInterval)
 	if err != nil {
-		log.Printf("Warning: Failed to generate initial color: %v", err)
+		log.Printf("Warning: %v, using default QR refresh interval", err)
 	}
+	constantInterval, err := component.ParseRefreshInterval(component.Config.ConstantRefreshInterval)
+	if err != nil {
+		log.Printf("Warning: %v, using default constant refresh interval", err)
+	}
+	rules.StartQRCodeRefreshLoop(ctx, qrInterval)
+	rules.StartConstantsRefreshLoop(ctx, constantInterval)
+
+	// Throttle progress writes from fast typists
+	saveInterval, err := component.ParseRefreshInterval(component.Config.ProgressSaveInterval)
+	if err != nil {
+		log.Printf("Warning: %v, saving progress on every new rule", err)
+	}
+	component.SetProgressSaveInterval(saveInterval)
+
+	// Drop sessions that have been idle longer than the cookie lifetime
+	component.StartSessionCleanupLoop(ctx)
+
+	// Persist the rule satisfaction analytics
+	rules.StartRuleStatsSaveLoop(ctx, rules.DefaultRuleStatsSaveInterval)
 
 	// Create Database directory if it doesn't exist
 	if err := os.MkdirAll("Database", 0755); err != nil {
@@ -75,18 +197,34 @@ func main() {
 	// Main routes - both root and /display point to the same handler
 	http.HandleFunc("/", component.HandlePasswordGame)
 	http.HandleFunc("/display", component.HandlePasswordGame)
-	http.HandleFunc("/validate", component.HandleValidate)
+	registerValidateRoutes(http.DefaultServeMux)
 	http.HandleFunc("/register-user", component.HandleRegisterUser)
+	usernameLimiter := component.NewRateLimiter(component.UsernameCheckRatePerSecond, component.UsernameCheckBurst)
+	http.HandleFunc("/api/username-available", usernameLimiter.Middleware(component.HandleUsernameAvailable))
 	http.HandleFunc("/user-modal.html", component.HandleUserModal) // Now uses template execution
 	http.HandleFunc("/leaderboard", component.HandleLeaderboard)
+	http.HandleFunc("/leaderboard/around-me", component.HandleLeaderboardAroundMe)
+	http.HandleFunc("/api/recent", component.HandleRecentUsers)
+	http.HandleFunc("/api/game/state", component.HandleGameState)
+	http.HandleFunc("/api/game/progress", component.HandleGameProgress)
+	http.HandleFunc("/api/game/transcript", component.HandleGameTranscript)
+	http.HandleFunc("/api/game/remaining", component.HandleGameRemaining)
+	http.HandleFunc("/api/game/practice", component.HandleStartPractice)
+	http.HandleFunc("/api/game/change-difficulty", component.HandleChangeDifficulty)
+	http.HandleFunc("/victory", component.HandleVictory)
+	http.HandleFunc("/spectate/", component.HandleSpectate)
+	http.HandleFunc("/api/share/", component.HandleShareImage)
+	http.HandleFunc("/api/user/", component.HandleUserProfile) // /api/user/{username}; the fixed /api/user/* actions below take precedence
 
 	// Captcha routes
 	http.HandleFunc("/captcha.png", rules.ServeCaptchaImage)
 	http.HandleFunc("/refresh-captcha", rules.RefreshCaptcha)
 
 	// Chess routes
-	http.HandleFunc("/chess.png", rules.ServeChessImage)
+	http.HandleFunc("/chess.png", rules.ServeChessPNG)
+	http.HandleFunc("/chess.svg", rules.ServeChessImage)
 	http.HandleFunc("/refresh-chess", rules.RefreshChess)
+	http.HandleFunc("/api/chess/position", rules.ServeChessPosition)
 
 	// QR code routes
 	http.HandleFunc("/qrcode.png", rules.ServeQRCodeImage)
@@ -99,27 +237,41 @@ func main() {
 	// Math constant routes
 	http.HandleFunc("/refresh-constant", RefreshConstantHandler)
 
-	// Toggle hints
-	http.HandleFunc("/api/toggle-hints", HandleToggleHints)
-
-	// Serve static files from Frontend directory
-	http.HandleFunc("/style.css", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/css")
-		http.ServeFile(w, r, "Frontend/style.css")
-	})
+	// Toggle hints (global setting, admin only)
+	http.HandleFunc("/api/toggle-hints", component.RequireAdmin(HandleToggleHints))
 
-	http.HandleFunc("/flip-animations.js", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/javascript")
-		http.ServeFile(w, r, "Frontend/flip-animations.js")
-	})
+	// Serve static files from the asset root (Frontend by default)
+	http.HandleFunc("/style.css", component.ServeStatic("style.css", "text/css"))
+	http.HandleFunc("/flip-animations.js", component.ServeStatic("flip-animations.js", "application/javascript"))
 
 	// Admin API endpoints
-	http.HandleFunc("/api/rules/pool", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/metrics", component.HandleMetrics)
+
+	http.HandleFunc("/api/rules/pool", component.RequireAdminForWrites(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(rules.Pool())
+	}))
+
+	http.HandleFunc("/api/rules/max", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"max_rule": rules.MaxRule()})
 	})
 
-	http.HandleFunc("/api/rules/assignments", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/api/rules/preview", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		difficulty := r.URL.Query().Get("difficulty")
+		if difficulty == "" || difficulty == "all" || !config.ValidateDifficulty(difficulty) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Invalid difficulty"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"difficulty": difficulty,
+			"rules":      rules.PreviewRules(difficulty),
+		})
+	})
+
+	http.HandleFunc("/api/rules/assignments", component.RequireAdminForWrites(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		switch r.Method {
 		case http.MethodGet:
@@ -138,6 +290,17 @@ func main() {
 				w.Write([]byte(`{"error":"Invalid JSON"}`))
 				return
 			}
+			isKnownDifficulty := func(difficulty string) bool {
+				return difficulty != "all" && config.ValidateDifficulty(difficulty)
+			}
+			if assignErr := rules.CheckAssignments(assignments, isKnownDifficulty); assignErr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error":   assignErr.Error(),
+					"details": assignErr,
+				})
+				return
+			}
 			data, err := json.MarshalIndent(assignments, "", "  ")
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
@@ -149,15 +312,17 @@ func main() {
 				w.Write([]byte(`{"error":"Could not write assignments"}`))
 				return
 			}
+			// Make running servers pick up the new rule sets immediately
+			rules.ReloadAssignments()
 			w.Write([]byte(`{"status":"ok"}`))
 			return
 		}
 		w.WriteHeader(http.StatusMethodNotAllowed)
-	})
+	}))
 
 	http.HandleFunc("/api/difficulties", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		difficulties, err := component.LoadDifficulties()
+		difficulties, err := component.LoadDifficultiesWithRuleCounts()
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(`{"error":"Could not load difficulties"}`))
@@ -166,10 +331,10 @@ func main() {
 		json.NewEncoder(w).Encode(difficulties)
 	})
 
-	http.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		http.ServeFile(w, r, "Frontend/admin.html")
-	})
+	// Difficulties with rule previews and unlock state in one call; /api/difficulties is unchanged
+	http.HandleFunc("/api/difficulties/full", component.HandleDifficultiesFull)
+
+	http.HandleFunc("/admin", component.RequireAdmin(component.ServeStatic("admin.html", "text/html")))
 
 	// User delete endpoint for Rule 22
 	http.HandleFunc("/api/user/delete", func(w http.ResponseWriter, r *http.Request) {
@@ -182,17 +347,47 @@ func main() {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		session, exists := component.UserSessions[cookie.Value]
+		session, exists := component.GetSession(cookie.Value)
 		if !exists {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		err = database.DeleteUser(session.UserID)
+		// Practice and test sessions have no account to delete
+		if component.HasDatabaseUser(session) {
+			if err := database.DeleteUser(session.UserID); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		component.DeleteSession(cookie.Value)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// User progress reset endpoint (replay without deleting the account)
+	http.HandleFunc("/api/user/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cookie, err := r.Cookie("user_session")
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		delete(component.UserSessions, cookie.Value)
+		session, exists := component.GetSession(cookie.Value)
+		if !exists {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Test sessions never touch the database
+		if component.HasDatabaseUser(session) {
+			if err := database.ResetUserProgress(session.UserID); err != nil {
+				log.Printf("Error resetting progress for user %s: %v", session.Username, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		component.ResetSessionProgress(session)
 		w.WriteHeader(http.StatusOK)
 	})
 
@@ -207,63 +402,131 @@ func main() {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		// Remove session from memory but keep user in database
-		delete(component.UserSessions, cookie.Value)
+		// Remove session from memory but keep user in database, noting where they got stuck
+		component.AbandonSession(cookie.Value)
 
 		// Clear the session cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "user_session",
-			Value:    "",
-			HttpOnly: true,
-			Path:     "/",
-			MaxAge:   -1, // Expire immediately
-		})
+		http.SetCookie(w, component.SessionCookie("", -1)) // Expire immediately
 
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Admin session management
+	http.HandleFunc("/api/admin/overview", component.RequireAdmin(component.HandleAdminOverview))
+	http.HandleFunc("/api/admin/sessions", component.RequireAdmin(component.HandleAdminSessions))
+	http.HandleFunc("/api/admin/sessions/evict", component.RequireAdmin(component.HandleAdminEvictSession))
+	http.HandleFunc("/api/admin/import", component.RequireAdmin(component.HandleAdminImport))
+	http.HandleFunc("/api/admin/refresh-all", component.RequireAdmin(component.HandleAdminRefreshAll))
+	http.HandleFunc("/api/analytics/rule-satisfaction", component.RequireAdmin(component.HandleRuleSatisfaction))
+
+	registerDebugRoutes(http.DefaultServeMux)
+
 	// Cybersecurity rules routes
 	http.HandleFunc("/api/cysec/status", HandleCyberSecurityStatus)
 	http.HandleFunc("/api/cysec/update-alert", HandleUpdateAlert)
+	http.HandleFunc("/api/cysec/update-string", HandleUpdateString)
 	http.HandleFunc("/api/cysec/ad-watched", HandleAdWatched)
 	http.HandleFunc("/api/cysec/generate-black-squares", HandleGenerateBlackSquares)
-	http.HandleFunc("/api/cysec/reset", HandleResetCyberSecurity)
+	http.HandleFunc("/api/cysec/reset", component.RequireAdmin(HandleResetCyberSecurity))
 
 	log.Println("🚀 Password Game server starting on :8080")
 	log.Println("🌐 Open http://localhost:8080 in your browser")
 	log.Println("🎮 Password Game: http://localhost:8080/display")
 	log.Println("🏆 Leaderboard: http://localhost:8080/leaderboard")
-	log.Fatal(http.ListenAndServe(":8080", nil))
-}
 
-// hexToRGB converts a hex color string to RGB values
-func hexToRGB(hexColor string) (r, g, b uint8, err error) {
-	// Remove the # prefix if present
-	hexColor = strings.TrimPrefix(hexColor, "#")
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: component.LogRequests(http.DefaultServeMux),
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("🛑 Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: Server shutdown error: %v", err)
+	}
 
-	// Parse the hex color
-	if len(hexColor) != 6 {
-		return 0, 0, 0, fmt.Errorf("invalid hex color format: %s", hexColor)
+	// Write progress still held back by the save interval
+	component.FlushAllProgress()
+
+	// Keep the current challenges for players who are mid-game across the restart
+	if err := rules.SaveGameState(); err != nil {
+		log.Printf("Warning: Failed to save game state: %v", err)
+	}
+	if err := rules.SaveRuleStats(); err != nil {
+		log.Printf("Warning: Failed to save rule stats: %v", err)
 	}
+}
 
-	// Parse the RGB values
-	rgb, err := strconv.ParseUint(hexColor, 16, 32)
+// Color swatch size bounds for ?size=
+const (
+	defaultColorImageSize = 200
+	minColorImageSize     = 64
+	maxColorImageSize     = 512
+)
+
+// colorImageSize parses the ?size= value, clamping it to the allowed range
+func colorImageSize(value string) int {
+	size, err := strconv.Atoi(value)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid hex color: %s", hexColor)
+		return defaultColorImageSize
+	}
+	if size < minColorImageSize {
+		return minColorImageSize
 	}
+	if size > maxColorImageSize {
+		return maxColorImageSize
+	}
+	return size
+}
+
+// drawColorLabel writes the color name and hex along the bottom of the swatch,
+// in black or white depending on the swatch brightness
+func drawColorLabel(img *image.RGBA, name, hexCode string, red, green, blue uint8) {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
 
-	// Extract the RGB components
-	r = uint8((rgb >> 16) & 0xFF)
-	g = uint8((rgb >> 8) & 0xFF)
-	b = uint8(rgb & 0xFF)
+	textColor := color.RGBA{255, 255, 255, 255}
+	if int(red)*299+int(green)*587+int(blue)*114 > 128000 {
+		textColor = color.RGBA{0, 0, 0, 255}
+	}
+
+	scale := 2
+	if width < 160 {
+		scale = 1
+	}
+	lineHeight := 9 * scale
+	maxChars := (width - 8) / (6 * scale)
 
-	return r, g, b, nil
+	lines := []string{strings.ToUpper(hexCode)}
+	if name != "" {
+		lines = append([]string{name}, lines...)
+	}
+
+	y := height - 4 - len(lines)*lineHeight
+	for _, line := range lines {
+		if runes := []rune(line); len(runes) > maxChars {
+			line = string(runes[:maxChars])
+		}
+		x := (width - rules.TextWidth(line, scale)) / 2
+		rules.DrawText(img, x, y, line, scale, textColor)
+		y += lineHeight
+	}
 }
 
-// ServeColorImage serves an image of the current color
+// ServeColorImage serves an image of the current color. ?size= sets the dimensions
+// and ?label=1 overlays the color name and hex.
 func ServeColorImage(w http.ResponseWriter, r *http.Request) {
 	// Get the current color
-	_, hexCode := rules.GetCurrentColor()
+	colorName, hexCode := rules.GetCurrentColor()
 
 	if hexCode == "" {
 		// Generate a new color if none exists
@@ -272,18 +535,19 @@ func ServeColorImage(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Failed to generate color", http.StatusInternalServerError)
 			return
 		}
-		_, hexCode = rules.GetCurrentColor()
+		colorName, hexCode = rules.GetCurrentColor()
 	}
 
 	// Convert hex to RGB
-	red, green, blue, err := hexToRGB(hexCode)
+	red, green, blue, err := rules.HexToRGB(hexCode)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Invalid color format: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Create a new image
-	width, height := 200, 200
+	// Create a new image, optionally resized with ?size=
+	width := colorImageSize(r.URL.Query().Get("size"))
+	height := width
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 
 	// Fill the image with the color
@@ -293,6 +557,11 @@ func ServeColorImage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Overlay the color name and hex when ?label=1
+	if r.URL.Query().Get("label") == "1" {
+		drawColorLabel(img, colorName, hexCode, red, green, blue)
+	}
+
 	// Prevent caching to ensure fresh images
 	w.Header().Set("Content-Type", "image/png")
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -314,105 +583,236 @@ func RefreshColorHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the current color for the response
 	colorName, hexCode := rules.GetCurrentColor()
 
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{
-		"status":  "refreshed",
+	color := map[string]string{
 		"name":    colorName,
 		"hexCode": hexCode,
 	}
-	json.NewEncoder(w).Encode(response)
+	rules.WriteRefreshResponse(w, "color", color, color)
 }
 
-// HandleCyberSecurityStatus returns the current status of all cybersecurity rules
+// HandleCyberSecurityStatus returns the status of the cybersecurity rules of the caller's game
 func HandleCyberSecurityStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
 
-	status := rules.GetCyberSecurityStatus()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(cyberSecurity.Status())
+}
+
+// sessionCyberSecurity returns the cybersecurity rule state of the caller's game. Without a
+// session it answers 401 and reports false.
+func sessionCyberSecurity(w http.ResponseWriter, r *http.Request) (*rules.CyberSecurityRules, bool) {
+	cyberSecurity, ok := component.CyberSecurityForRequest(r)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Session expired"})
+	}
+	return cyberSecurity, ok
 }
 
-// HandleUpdateAlert handles the update alert for Rule 14
+// HandleUpdateAlert handles the update alert for Rule 14 of the caller's game
 func HandleUpdateAlert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
+
 	switch r.Method {
 	case http.MethodPost:
 		// Mark update alert as shown
-		rules.SetUpdateAlertShown(true)
+		cyberSecurity.SetUpdateAlertShown(true)
 		w.Header().Set("Content-Type", "application/json")
 		response := map[string]interface{}{
 			"status":        "shown",
-			"update_string": rules.GetUpdateString(),
+			"update_string": cyberSecurity.UpdateString(),
 		}
 		json.NewEncoder(w).Encode(response)
 	case http.MethodGet:
-		// Get update alert status
+		// Get update alert status; the string stays hidden until the alert has been shown
 		w.Header().Set("Content-Type", "application/json")
+		shown := cyberSecurity.IsUpdateAlertShown()
 		response := map[string]interface{}{
-			"shown":         rules.IsUpdateAlertShown(),
-			"update_string": rules.GetUpdateString(),
+			"shown": shown,
+		}
+		if shown {
+			response["update_string"] = cyberSecurity.UpdateString()
 		}
 		json.NewEncoder(w).Encode(response)
-	default:
+	}
+}
+
+// HandleUpdateString returns the caller's Rule 14 update string so the client can show it again
+// after a reload. It is only revealed once that game's update alert has been shown.
+func HandleUpdateString(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
 	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !cyberSecurity.IsUpdateAlertShown() {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Update alert has not been shown yet"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"update_string": cyberSecurity.UpdateString(),
+	})
 }
 
-// HandleAdWatched handles the ad watched status for Rule 23
+// HandleAdWatched handles the ad watched status for Rule 23 of the caller's game
 func HandleAdWatched(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
+
 	switch r.Method {
 	case http.MethodPost:
 		// Mark ad as watched
-		rules.SetAdWatched(true)
+		cyberSecurity.SetAdWatched(true)
 		w.Header().Set("Content-Type", "application/json")
 		response := map[string]interface{}{
 			"status":             "watched",
-			"raid_unlock_string": rules.GetRaidUnlockString(),
+			"raid_unlock_string": cyberSecurity.RaidUnlockString(),
 		}
 		json.NewEncoder(w).Encode(response)
 	case http.MethodGet:
-		// Get ad watched status
+		// Get ad watched status; the unlock string stays hidden until the ad has been watched
 		w.Header().Set("Content-Type", "application/json")
+		watched := cyberSecurity.IsAdWatched()
 		response := map[string]interface{}{
-			"watched":            rules.IsAdWatched(),
-			"raid_unlock_string": rules.GetRaidUnlockString(),
+			"watched": watched,
+		}
+		if watched {
+			response["raid_unlock_string"] = cyberSecurity.RaidUnlockString()
 		}
 		json.NewEncoder(w).Encode(response)
-	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
-// HandleGenerateBlackSquares generates black squares for Rule 24
+// HandleGenerateBlackSquares generates black squares for Rule 24 of the caller's game
 func HandleGenerateBlackSquares(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
 
-	blackSquares := rules.GenerateBlackSquares()
-	count := rules.GetBlackSquareCount()
+	blackSquares, count := cyberSecurity.GenerateBlackSquares()
 
 	w.Header().Set("Content-Type", "application/json")
+
+	if rules.IsBlackSquareCountFatal(count) {
+		writeRansomwareOverrun(w, r, count)
+		return
+	}
+
 	response := map[string]interface{}{
 		"status":  "generated",
 		"squares": blackSquares,
 		"count":   count,
-		"fatal":   count > 12,
+		"fatal":   false,
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// HandleResetCyberSecurity resets all cybersecurity rule states
+// registerValidateRoutes adds the validation endpoints to mux. Each gets its own limiter, so
+// stateless checks from a tool don't use up the budget of the game running in the same browser.
+func registerValidateRoutes(mux *http.ServeMux) {
+	validateLimiter := component.NewRateLimiter(component.Config.ValidateRatePerSecond, component.Config.ValidateBurst)
+	mux.HandleFunc("/validate", validateLimiter.Middleware(component.HandleValidate))
+	statelessLimiter := component.NewRateLimiter(component.Config.ValidateRatePerSecond, component.Config.ValidateBurst)
+	mux.HandleFunc("/api/validate-stateless", statelessLimiter.Middleware(component.HandleValidateStateless))
+}
+
+// registerDebugRoutes adds the local development endpoints to mux. Without PASSGAME_DEBUG=1
+// the routes are never registered, and /api/debug/ answers 404 instead of falling through to
+// the game page at "/".
+func registerDebugRoutes(mux *http.ServeMux) {
+	if os.Getenv(component.DebugEnv) == "1" {
+		log.Printf("🐛 %s=1: serving current answers at /api/debug/answers", component.DebugEnv)
+		mux.HandleFunc("/api/debug/answers", component.HandleDebugAnswers)
+	}
+	mux.HandleFunc("/api/debug/", http.NotFound)
+}
+
+// writeRansomwareOverrun ends the game lost to too many black squares. In "fail" mode the run
+// ends where it stands; otherwise the player's progress restarts.
+func writeRansomwareOverrun(w http.ResponseWriter, r *http.Request, count int) {
+	if component.FailsOnFatal() {
+		if cookie, err := r.Cookie("user_session"); err == nil {
+			if session, exists := component.GetSession(cookie.Value); exists {
+				component.FailSession(session, "the ransomware took over your password")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"status":  "fatal",
+					"squares": "",
+					"count":   count,
+					"fatal":   true,
+					"reset":   false,
+					"message": fmt.Sprintf("More than %d black squares: the ransomware won. Reset your progress to play again.", rules.FatalBlackSquareCount),
+				})
+				return
+			}
+		}
+	}
+
+	if cookie, err := r.Cookie("user_session"); err == nil {
+		if session, exists := component.GetSession(cookie.Value); exists {
+			if component.HasDatabaseUser(session) {
+				if err := database.ResetUserProgress(session.UserID); err != nil {
+					log.Printf("Error resetting progress for user %s: %v", session.Username, err)
+				}
+			}
+			component.ResetSessionProgress(session)
+			log.Printf("💀 Ransomware overran user %s (%d black squares), game reset", session.Username, count)
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "fatal",
+		"squares": "",
+		"count":   count,
+		"fatal":   true,
+		"reset":   true,
+		"message": fmt.Sprintf("More than %d black squares: the ransomware won. Your game has been reset.", rules.FatalBlackSquareCount),
+	})
+}
+
+// HandleResetCyberSecurity resets the cybersecurity rule states of the caller's game
 func HandleResetCyberSecurity(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	cyberSecurity, ok := sessionCyberSecurity(w, r)
+	if !ok {
+		return
+	}
 
-	rules.ResetCyberSecurityRules()
+	cyberSecurity.Reset()
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]string{
 		"status": "reset",
@@ -427,7 +827,7 @@ func HandleToggleHints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Toggle the ShowHints setting
+	// Toggle the global ShowHints setting; difficulties with their own show_hints keep it
 	component.Config.ShowHints = !component.Config.ShowHints
 
 	// Return the new state
@@ -450,11 +850,9 @@ func RefreshConstantHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the current constant for the response
 	constantName, constantValue := rules.GetCurrentMathConstant()
 
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{
-		"status": "refreshed",
-		"name":   constantName,
-		"value":  constantValue,
-	}
-	json.NewEncoder(w).Encode(response)
+	// The old top-level "value" now holds the standard value object, so only "name" stays there
+	rules.WriteRefreshResponse(w, "constant", map[string]string{
+		"name":  constantName,
+		"value": constantValue,
+	}, map[string]string{"name": constantName})
 }
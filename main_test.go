@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"passgame/component"
+	"passgame/rules"
+)
+
+// TestMain runs the tests in test mode, so no challenge reaches an external API
+func TestMain(m *testing.M) {
+	rules.SetTestMode(true)
+	os.Exit(m.Run())
+}
+
+// decodePNG decodes a handler's PNG response
+func decodePNG(t *testing.T, w *httptest.ResponseRecorder) image.Image {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", ct)
+	}
+	img, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("response is not a PNG: %v", err)
+	}
+	return img
+}
+
+func TestServeColorImageSize(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantSize int
+	}{
+		{"", defaultColorImageSize},
+		{"?label=1", defaultColorImageSize},
+		{"?size=300&label=1", 300},
+		{"?size=10&label=1", minColorImageSize},
+		{"?size=9999&label=1", maxColorImageSize},
+		{"?size=abc", defaultColorImageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServeColorImage(w, httptest.NewRequest(http.MethodGet, "/color.png"+tt.query, nil))
+			bounds := decodePNG(t, w).Bounds()
+			if bounds.Dx() != tt.wantSize || bounds.Dy() != tt.wantSize {
+				t.Errorf("image is %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantSize, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestServeColorImageLabel(t *testing.T) {
+	// distinctColors counts the different pixel colors in an image
+	distinctColors := func(img image.Image) int {
+		seen := make(map[[4]uint32]bool)
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, a := img.At(x, y).RGBA()
+				seen[[4]uint32{r, g, b, a}] = true
+			}
+		}
+		return len(seen)
+	}
+
+	plain := httptest.NewRecorder()
+	ServeColorImage(plain, httptest.NewRequest(http.MethodGet, "/color.png?size=128", nil))
+	if got := distinctColors(decodePNG(t, plain)); got != 1 {
+		t.Errorf("unlabeled swatch has %d colors, want a plain fill", got)
+	}
+
+	labeled := httptest.NewRecorder()
+	ServeColorImage(labeled, httptest.NewRequest(http.MethodGet, "/color.png?size=128&label=1", nil))
+	if got := distinctColors(decodePNG(t, labeled)); got < 2 {
+		t.Error("labeled swatch has no text drawn on it")
+	}
+}
+
+// startTestSession opens a test session (no database row) the way /?test_session=true does and
+// returns its cookie
+func startTestSession(t *testing.T, difficulty string) *http.Cookie {
+	t.Helper()
+	w := httptest.NewRecorder()
+	component.HandlePasswordGame(w, httptest.NewRequest(http.MethodGet, "/?test_session=true&difficulty="+difficulty, nil))
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "user_session" {
+			t.Cleanup(func() { component.DeleteSession(cookie.Value) })
+			return cookie
+		}
+	}
+	t.Fatal("no session cookie set")
+	return nil
+}
+
+func TestWriteRansomwareOverrun(t *testing.T) {
+	previousConfig := component.Config
+	t.Cleanup(func() { component.Config = previousConfig })
+	count := rules.FatalBlackSquareCount + 1
+
+	tests := []struct {
+		name        string
+		mode        string
+		withSession bool
+		wantReset   bool
+	}{
+		{"reset mode restarts the run", component.FailureModeReset, true, true},
+		{"fail mode ends the run", component.FailureModeFail, true, false},
+		{"no session falls back to a reset", component.FailureModeFail, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component.Config.FatalFailureMode = tt.mode
+			r := httptest.NewRequest(http.MethodPost, "/api/cysec/generate-black-squares", nil)
+			var session *component.UserSession
+			if tt.withSession {
+				cookie := startTestSession(t, "expert")
+				r.AddCookie(cookie)
+				session, _ = component.GetSession(cookie.Value)
+				session.MaxRule = 24
+			}
+
+			w := httptest.NewRecorder()
+			writeRansomwareOverrun(w, r, count)
+
+			var response struct {
+				Status string `json:"status"`
+				Count  int    `json:"count"`
+				Fatal  bool   `json:"fatal"`
+				Reset  bool   `json:"reset"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if response.Status != "fatal" || !response.Fatal || response.Count != count {
+				t.Errorf("response = %+v, want fatal with count %d", response, count)
+			}
+			if response.Reset != tt.wantReset {
+				t.Errorf("reset = %v, want %v", response.Reset, tt.wantReset)
+			}
+
+			if session == nil {
+				return
+			}
+			if tt.wantReset {
+				if session.MaxRule != 0 || session.Failure.Failed {
+					t.Errorf("session after reset = rule %d, failed %v, want a fresh run", session.MaxRule, session.Failure.Failed)
+				}
+				if got := component.CyberSecurityFor(session).BlackSquareCount(); got != 0 {
+					t.Errorf("GetBlackSquareCount() after reset = %d, want 0", got)
+				}
+				return
+			}
+			if !session.Failure.Failed || session.Failure.AtRule != 24 {
+				t.Errorf("session failure = %+v, want failed at rule 24", session.Failure)
+			}
+
+			// The lost run refuses further validation
+			validate := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("password=Password1%21"))
+			validate.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			validate.AddCookie(r.Cookies()[0])
+			w = httptest.NewRecorder()
+			component.HandleValidate(w, validate)
+			if w.Header().Get("X-Game-Failed") != "true" {
+				t.Errorf("validate after the overrun = %q, want it refused", w.Body.String())
+			}
+		})
+	}
+}
+
+// decodeJSON decodes a handler's JSON response body
+func decodeJSON(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return body
+}
+
+func TestHandleUpdateStringAcknowledgement(t *testing.T) {
+	cookie := startTestSession(t, "expert")
+	other := startTestSession(t, "expert")
+
+	request := func(handler http.HandlerFunc, method, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+	get := func(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		return request(handler, http.MethodGet, target, cookie)
+	}
+
+	// Before the alert is acknowledged the string stays hidden
+	w := get(HandleUpdateString, "/api/cysec/update-string")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status before acknowledgement = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if body := decodeJSON(t, w); body["update_string"] != nil {
+		t.Errorf("update string revealed before acknowledgement: %v", body)
+	}
+	if body := decodeJSON(t, get(HandleUpdateAlert, "/api/cysec/update-alert")); body["shown"] != false || body["update_string"] != nil {
+		t.Errorf("update alert status before acknowledgement = %v, want not shown and no string", body)
+	}
+
+	ack := request(HandleUpdateAlert, http.MethodPost, "/api/cysec/update-alert", cookie)
+	acknowledged, _ := decodeJSON(t, ack)["update_string"].(string)
+	if acknowledged == "" {
+		t.Fatal("acknowledging the alert returned no update string")
+	}
+
+	// Afterwards it can be fetched again, unchanged, e.g. after a reload
+	for i := 0; i < 2; i++ {
+		w := get(HandleUpdateString, "/api/cysec/update-string")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status after acknowledgement = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := decodeJSON(t, w)["update_string"]; got != acknowledged {
+			t.Errorf("update string = %v, want the acknowledged %q", got, acknowledged)
+		}
+	}
+	if body := decodeJSON(t, get(HandleUpdateAlert, "/api/cysec/update-alert")); body["shown"] != true || body["update_string"] != acknowledged {
+		t.Errorf("update alert status after acknowledgement = %v", body)
+	}
+
+	// Another player's acknowledgement doesn't reveal anything in this game
+	if w := request(HandleUpdateString, http.MethodGet, "/api/cysec/update-string", other); w.Code != http.StatusForbidden {
+		t.Errorf("status for another session = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if body := decodeJSON(t, request(HandleUpdateAlert, http.MethodGet, "/api/cysec/update-alert", other)); body["shown"] != false {
+		t.Errorf("update alert status for another session = %v, want not shown", body)
+	}
+
+	// Without a session there is no game to read from
+	if w := request(HandleUpdateString, http.MethodGet, "/api/cysec/update-string", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Resetting the run hides it again
+	session, _ := component.GetSession(cookie.Value)
+	component.ResetSessionProgress(session)
+	if w := get(HandleUpdateString, "/api/cysec/update-string"); w.Code != http.StatusForbidden {
+		t.Errorf("status after reset = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	if w := request(HandleUpdateString, http.MethodPost, "/api/cysec/update-string", cookie); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleAdWatchedUnlocksRule23(t *testing.T) {
+	cookie := startTestSession(t, "expert")
+	session, _ := component.GetSession(cookie.Value)
+	other, _ := component.GetSession(startTestSession(t, "expert").Value)
+	cyberSecurity := component.CyberSecurityFor(session)
+	unlock := cyberSecurity.RaidUnlockString()
+
+	adWatched := func(method string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/cysec/ad-watched", nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		HandleAdWatched(w, r)
+		return w
+	}
+
+	if body := decodeJSON(t, adWatched(http.MethodGet, cookie)); body["watched"] != false || body["raid_unlock_string"] != nil {
+		t.Errorf("status before the ad = %v, want not watched and no unlock string", body)
+	}
+	if cyberSecurity.PasswordLock(unlock) {
+		t.Error("rule 23 passed before the ad was watched")
+	}
+
+	if body := decodeJSON(t, adWatched(http.MethodPost, cookie)); body["raid_unlock_string"] != unlock {
+		t.Errorf("watching the ad returned %v, want the unlock string %q", body, unlock)
+	}
+	if !cyberSecurity.PasswordLock(unlock) {
+		t.Error("rule 23 failed after the ad was watched")
+	}
+
+	// Watching the ad unlocks this game only
+	if component.CyberSecurityFor(other).PasswordLock(unlock) {
+		t.Error("rule 23 passed in another session that never watched the ad")
+	}
+	if w := adWatched(http.MethodGet, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleResetCyberSecurityResetsOnlyTheCaller(t *testing.T) {
+	cookie := startTestSession(t, "expert")
+	session, _ := component.GetSession(cookie.Value)
+	other, _ := component.GetSession(startTestSession(t, "expert").Value)
+
+	for _, s := range []*component.UserSession{session, other} {
+		cyberSecurity := component.CyberSecurityFor(s)
+		cyberSecurity.SetAdWatched(true)
+		cyberSecurity.SetUpdateAlertShown(true)
+		cyberSecurity.GenerateBlackSquares()
+	}
+	otherUpdate := component.CyberSecurityFor(other).UpdateString()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/cysec/reset", nil)
+	r.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	HandleResetCyberSecurity(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if status := component.CyberSecurityFor(session).Status(); status.AdWatched || status.UpdateAlertShown || status.BlackSquaresInjected != 0 {
+		t.Errorf("caller's state after the reset = %+v, want a fresh game", status)
+	}
+	status := component.CyberSecurityFor(other).Status()
+	if !status.AdWatched || !status.UpdateAlertShown || status.BlackSquaresInjected != 1 || status.UpdateString != otherUpdate {
+		t.Errorf("another session's state after the reset = %+v, want it untouched", status)
+	}
+
+	// Restarting a run leaves other games alone the same way
+	component.ResetSessionProgress(session)
+	if !component.CyberSecurityFor(other).IsAdWatched() {
+		t.Error("resetting one session's progress reset another session's ad")
+	}
+
+	w = httptest.NewRecorder()
+	HandleResetCyberSecurity(w, httptest.NewRequest(http.MethodPost, "/api/cysec/reset", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without a session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRegisterValidateRoutesSeparateLimits(t *testing.T) {
+	rate, burst := component.Config.ValidateRatePerSecond, component.Config.ValidateBurst
+	component.Config.ValidateRatePerSecond, component.Config.ValidateBurst = 0.001, 1
+	t.Cleanup(func() { component.Config.ValidateRatePerSecond, component.Config.ValidateBurst = rate, burst })
+
+	mux := http.NewServeMux()
+	registerValidateRoutes(mux)
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader("password=abc")))
+		return w.Code
+	}
+
+	if code := post("/validate"); code == http.StatusTooManyRequests {
+		t.Fatal("first /validate request was rate limited")
+	}
+	if code := post("/validate"); code != http.StatusTooManyRequests {
+		t.Fatalf("second /validate status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// The exhausted /validate bucket doesn't block the stateless endpoint
+	if code := post("/api/validate-stateless"); code == http.StatusTooManyRequests {
+		t.Error("/api/validate-stateless shares the /validate limit")
+	}
+	if code := post("/api/validate-stateless"); code != http.StatusTooManyRequests {
+		t.Errorf("second /api/validate-stateless status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRegisterDebugRoutes(t *testing.T) {
+	get := func(mux *http.ServeMux) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/debug/answers", nil))
+		return w
+	}
+
+	t.Run("without the flag", func(t *testing.T) {
+		t.Setenv(component.DebugEnv, "")
+		mux := http.NewServeMux()
+		registerDebugRoutes(mux)
+
+		if w := get(mux); w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("with the flag", func(t *testing.T) {
+		t.Setenv(component.DebugEnv, "1")
+		mux := http.NewServeMux()
+		registerDebugRoutes(mux)
+
+		w := get(mux)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("Cache-Control = %q, want no-store", cc)
+		}
+		body := decodeJSON(t, w)
+		if body["wordle"] != rules.TestWordleAnswer {
+			t.Errorf("wordle = %v, want %s", body["wordle"], rules.TestWordleAnswer)
+		}
+		if body["raid_unlock_string"] != rules.GetRaidUnlockString() {
+			t.Errorf("raid_unlock_string = %v, want %s", body["raid_unlock_string"], rules.GetRaidUnlockString())
+		}
+	})
+}
+
+func TestRefreshHandlersResponseShape(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		kind    string
+		value   map[string]interface{}
+		legacy  map[string]interface{}
+	}{
+		{"color", RefreshColorHandler, "color",
+			map[string]interface{}{"name": rules.TestColorName, "hexCode": rules.TestColorHex},
+			map[string]interface{}{"name": rules.TestColorName, "hexCode": rules.TestColorHex}},
+		// The old top-level "value" held the constant; it's inside the value object now
+		{"constant", RefreshConstantHandler, "constant",
+			map[string]interface{}{"name": rules.TestConstantName, "value": rules.TestConstantValue},
+			map[string]interface{}{"name": rules.TestConstantName}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodPost, "/refresh-"+tt.kind, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			body := decodeJSON(t, w)
+			if body["status"] != "refreshed" || body["type"] != tt.kind {
+				t.Errorf("status %v type %v, want refreshed %s", body["status"], body["type"], tt.kind)
+			}
+			value, _ := body["value"].(map[string]interface{})
+			if !reflect.DeepEqual(value, tt.value) {
+				t.Errorf("value = %v, want %v", body["value"], tt.value)
+			}
+			delete(body, "status")
+			delete(body, "type")
+			delete(body, "value")
+			if !reflect.DeepEqual(body, tt.legacy) {
+				t.Errorf("legacy fields = %v, want %v", body, tt.legacy)
+			}
+		})
+	}
+}
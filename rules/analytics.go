@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	database "passgame/Database"
+)
+
+// DefaultRuleStatsSaveInterval is how often the rule satisfaction counters are written to the database
+const DefaultRuleStatsSaveInterval = 5 * time.Minute
+
+// Rule satisfaction counters, guarded by metricsMutex. Unlike ruleSatisfiedTotal they only
+// count validations in which the rule was visible, so the two can be compared.
+var (
+	ruleShownTotal          = make(map[int]int64)
+	ruleShownSatisfiedTotal = make(map[int]int64)
+)
+
+// RuleSatisfaction is how often a rule was shown to players and how often it was satisfied
+// while shown. A low rate marks a rule where players get stuck.
+type RuleSatisfaction struct {
+	RuleID    int     `json:"rule_id"`
+	Shown     int64   `json:"shown"`
+	Satisfied int64   `json:"satisfied"`
+	Rate      float64 `json:"rate"`
+}
+
+// recordRuleShown counts one validation of a visible rule. Callers hold metricsMutex.
+func recordRuleShown(rule Rule) {
+	ruleShownTotal[rule.ID]++
+	if rule.IsSatisfied {
+		ruleShownSatisfiedTotal[rule.ID]++
+	}
+}
+
+// RuleSatisfactionReport returns the satisfaction counters of every rule shown so far, ordered
+// by rule ID
+func RuleSatisfactionReport() []RuleSatisfaction {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	report := make([]RuleSatisfaction, 0, len(ruleShownTotal))
+	for id, shown := range ruleShownTotal {
+		entry := RuleSatisfaction{
+			RuleID:    id,
+			Shown:     shown,
+			Satisfied: ruleShownSatisfiedTotal[id],
+		}
+		if shown > 0 {
+			entry.Rate = float64(entry.Satisfied) / float64(shown)
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].RuleID < report[j].RuleID
+	})
+	return report
+}
+
+// InitRuleStatsTable creates the rule_stats table that keeps the satisfaction counters across
+// restarts
+func InitRuleStatsTable() error {
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection not available")
+	}
+
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS rule_stats (
+		rule_id INTEGER PRIMARY KEY,
+		shown INTEGER NOT NULL DEFAULT 0,
+		satisfied INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create rule_stats table: %v", err)
+	}
+	return nil
+}
+
+// SaveRuleStats writes the current satisfaction counters. Test sessions use fixed challenges,
+// so their counts are never persisted.
+func SaveRuleStats() error {
+	if testMode {
+		return nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection not available")
+	}
+
+	report := RuleSatisfactionReport()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start rule stats save: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range report {
+		_, err := tx.Exec(`
+			INSERT INTO rule_stats (rule_id, shown, satisfied, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(rule_id) DO UPDATE SET
+				shown = excluded.shown,
+				satisfied = excluded.satisfied,
+				updated_at = excluded.updated_at`,
+			entry.RuleID, entry.Shown, entry.Satisfied)
+		if err != nil {
+			return fmt.Errorf("failed to save rule stats for rule %d: %v", entry.RuleID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rule stats: %v", err)
+	}
+	return nil
+}
+
+// LoadRuleStats adds the counters saved by SaveRuleStats to the in-memory ones and reports
+// how many rules had saved counts. Call it once at startup, before the save loop starts.
+func LoadRuleStats() (int, error) {
+	if testMode {
+		return 0, nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection not available")
+	}
+
+	rows, err := db.Query("SELECT rule_id, shown, satisfied FROM rule_stats")
+	if err != nil {
+		return 0, fmt.Errorf("failed to load rule stats: %v", err)
+	}
+	defer rows.Close()
+
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	loaded := 0
+	for rows.Next() {
+		var id int
+		var shown, satisfied int64
+		if err := rows.Scan(&id, &shown, &satisfied); err != nil {
+			return loaded, fmt.Errorf("failed to scan rule stats: %v", err)
+		}
+		ruleShownTotal[id] += shown
+		ruleShownSatisfiedTotal[id] += satisfied
+		loaded++
+	}
+	if err := rows.Err(); err != nil {
+		return loaded, fmt.Errorf("error iterating rule stats: %v", err)
+	}
+	return loaded, nil
+}
+
+// StartRuleStatsSaveLoop saves the satisfaction counters every interval until ctx is cancelled.
+// A non-positive interval uses DefaultRuleStatsSaveInterval.
+func StartRuleStatsSaveLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRuleStatsSaveInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := SaveRuleStats(); err != nil {
+					log.Printf("Warning: Failed to save rule stats: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package rules
+
+import "unicode/utf8"
+
+// DefaultPasteJumpThreshold is how many characters a password may grow between two
+// validations before the change is treated as a paste
+const DefaultPasteJumpThreshold = 12
+
+// IsIncrementalGrowth reports whether the password grew by at most maxJump characters
+// since the previous validation. Shrinking the password always counts as incremental.
+func IsIncrementalGrowth(previousLength int, password string, maxJump int) bool {
+	if maxJump <= 0 {
+		maxJump = DefaultPasteJumpThreshold
+	}
+	return utf8.RuneCountInString(password)-previousLength <= maxJump
+}
+
+// BlockPastedRules marks every rule with an ID of at least fromRuleID as unsatisfied,
+// so those rules can only be completed by a password that was typed in
+func BlockPastedRules(rs *RuleSet, fromRuleID int) {
+	for i := range rs.Rules {
+		if rs.Rules[i].ID >= fromRuleID {
+			rs.Rules[i].IsSatisfied = false
+			rs.Rules[i].NewlySatisfied = false
+		}
+	}
+}
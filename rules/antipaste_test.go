@@ -0,0 +1,49 @@
+package rules
+
+import "testing"
+
+func TestIsIncrementalGrowth(t *testing.T) {
+	tests := []struct {
+		name           string
+		previousLength int
+		password       string
+		maxJump        int
+		want           bool
+	}{
+		{"one character typed", 4, "abcde", 3, true},
+		{"at the threshold", 2, "abcde", 3, true},
+		{"past the threshold", 1, "abcde", 3, false},
+		{"whole password at once", 0, "Abcdef!X7", 3, false},
+		{"shrinking", 9, "abc", 3, true},
+		{"runes, not bytes", 0, "ééé", 3, true},
+		{"default threshold", 0, "abcdefghijkl", 0, true},
+		{"past the default threshold", 0, "abcdefghijklm", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIncrementalGrowth(tt.previousLength, tt.password, tt.maxJump); got != tt.want {
+				t.Errorf("IsIncrementalGrowth(%d, %q, %d) = %v, want %v", tt.previousLength, tt.password, tt.maxJump, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockPastedRules(t *testing.T) {
+	rs := &RuleSet{Rules: []Rule{
+		{ID: 1, IsSatisfied: true, NewlySatisfied: true},
+		{ID: 3, IsSatisfied: true, NewlySatisfied: true},
+		{ID: 7, IsSatisfied: true},
+	}}
+	BlockPastedRules(rs, 3)
+
+	want := map[int]bool{1: true, 3: false, 7: false}
+	for _, rule := range rs.Rules {
+		if rule.IsSatisfied != want[rule.ID] {
+			t.Errorf("rule %d satisfied = %v, want %v", rule.ID, rule.IsSatisfied, want[rule.ID])
+		}
+		if !want[rule.ID] && rule.NewlySatisfied {
+			t.Errorf("blocked rule %d is still newly satisfied", rule.ID)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+)
+
+// blocklistFile lets a deployment extend the common-words blocklist (e.g. with the company
+// name) without recompiling. It holds one word per line; blank lines and lines starting with
+// # are ignored.
+const blocklistFile = "config/blocklist.txt"
+
+// defaultCommonWords are used when blocklistFile is absent or unusable
+var defaultCommonWords = []string{"password", "admin", "user", "login", "welcome"}
+
+// commonWordsRuleID is the rule that rejects passwords containing a blocklisted word
+const commonWordsRuleID = 32
+
+// loadCommonWords reads the blocklist from blocklistFile, lowercased for case-insensitive
+// matching. It falls back to defaultCommonWords when the file is missing, unreadable, or empty.
+func loadCommonWords() []string {
+	blocklistData, err := os.Open(blocklistFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Could not open blocklist.txt: %v", err)
+		}
+		return defaultCommonWords
+	}
+	defer blocklistData.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(blocklistData)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, strings.ToLower(word))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Warning: Could not read blocklist.txt: %v", err)
+		return defaultCommonWords
+	}
+	if len(words) == 0 {
+		log.Printf("Warning: blocklist.txt has no words, using the defaults")
+		return defaultCommonWords
+	}
+	return words
+}
+
+// ContainsCommonWord reports whether text contains any of words, ignoring case.
+// The words are expected in lower case, as loadCommonWords returns them.
+func ContainsCommonWord(text string, words []string) bool {
+	lower := strings.ToLower(text)
+	for _, word := range words {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// commonWordsRule builds Rule 32 from the configured blocklist. No difficulty plays it by
+// default; list it in assignments.json for the difficulties that should reject common words.
+func commonWordsRule() Rule {
+	words := loadCommonWords()
+
+	return Rule{
+		ID:          commonWordsRuleID,
+		Description: "Must not contain a common word",
+		Validator: func(t string) bool {
+			return !ContainsCommonWord(t, words)
+		},
+		Hint:     "Avoid common words such as " + strings.Join(words, ", "),
+		Category: "basic",
+	}
+}
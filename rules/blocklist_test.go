@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// writeTestBlocklist writes config/blocklist.txt (in the test copy) for one test and removes it
+// afterwards
+func writeTestBlocklist(t *testing.T, data string) {
+	t.Helper()
+	if err := os.WriteFile(blocklistFile, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", blocklistFile, err)
+	}
+	t.Cleanup(func() { os.Remove(blocklistFile) })
+}
+
+func TestCommonWordsRuleCustomList(t *testing.T) {
+	writeTestBlocklist(t, "# company words\nAcme\n\n  Globex  \npassword\n")
+
+	want := []string{"acme", "globex", "password"}
+	if got := loadCommonWords(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("loadCommonWords() = %v, want %v", got, want)
+	}
+
+	rule := commonWordsRule()
+	for password, want := range map[string]bool{
+		"iloveACMEx":   false,
+		"GLOBEX1!":     false,
+		"myPassWord":   false,
+		"admin123":     true,
+		"Acm e Globe":  true,
+		"nothing here": true,
+	} {
+		if got := rule.Validator(password); got != want {
+			t.Errorf("rule 32(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestCommonWordsRuleFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"absent file", ""},
+		{"only comments and blanks", "# nothing yet\n\n   \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data != "" {
+				writeTestBlocklist(t, tt.data)
+			}
+			if got := loadCommonWords(); !reflect.DeepEqual(got, defaultCommonWords) {
+				t.Errorf("loadCommonWords() = %v, want the defaults %v", got, defaultCommonWords)
+			}
+
+			rule := commonWordsRule()
+			for _, password := range []string{"PASSWORD1", "Admin!", "superuser", "Login", "welcome2"} {
+				if rule.Validator(password) {
+					t.Errorf("rule 32(%q) = true, want false", password)
+				}
+			}
+			if !rule.Validator("Tr0ub4dor&3") {
+				t.Error("rule 32 rejected a password without a common word")
+			}
+		})
+	}
+}
+
+func TestCommonWordsRuleNotAssigned(t *testing.T) {
+	for _, difficulty := range []string{"basic", "intermediate", "hard", "expert", "fun"} {
+		if slices.Contains(ruleIDs(NewRuleSet(difficulty)), commonWordsRuleID) {
+			t.Errorf("%s plays rule %d, want it opt-in only", difficulty, commonWordsRuleID)
+		}
+	}
+
+	writeTestAssignments(t, `{"basic": [1, 32]}`)
+	rs := NewRuleSet("basic")
+	if !slices.Contains(ruleIDs(rs), commonWordsRuleID) {
+		t.Fatalf("basic rules = %v, want rule %d once assigned", ruleIDs(rs), commonWordsRuleID)
+	}
+}
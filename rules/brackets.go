@@ -0,0 +1,43 @@
+package rules
+
+// balancedBracketsRuleID is the rule that asks for properly matched brackets
+const balancedBracketsRuleID = 29
+
+// closingBrackets maps each closing bracket to the opening bracket it pairs with
+var closingBrackets = map[rune]rune{
+	')': '(',
+	']': '[',
+	'}': '{',
+}
+
+// HasBalancedBrackets reports whether every (), [] and {} in s is correctly matched and nested,
+// and there's at least one pair. Other characters are ignored, so "a(b[c]d)e" passes while
+// "([)]", "(()" and a password without brackets don't.
+func HasBalancedBrackets(s string) bool {
+	var stack []rune
+	pairs := 0
+	for _, r := range s {
+		switch r {
+		case '(', '[', '{':
+			stack = append(stack, r)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != closingBrackets[r] {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+			pairs++
+		}
+	}
+	return pairs > 0 && len(stack) == 0
+}
+
+// balancedBracketsRule builds Rule 29
+func balancedBracketsRule() Rule {
+	return Rule{
+		ID:          balancedBracketsRuleID,
+		Description: "Must include balanced brackets: every (, [ and { closed in the right order",
+		Validator:   HasBalancedBrackets,
+		Hint:        "Add a matched pair such as (), [] or {}. Pairs can nest like ([{}]) but can't cross like ([)], and every bracket needs a partner.",
+		Category:    "expert",
+	}
+}
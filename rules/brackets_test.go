@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasBalancedBrackets(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"one pair", "pass()word", true},
+		{"each kind", "a(b)c[d]e{f}", true},
+		{"around text", "x(secret)y", true},
+		{"nested", "([{}])", true},
+		{"nested around text", "a(b[c]d)e", true},
+		{"deeply nested", "{[(x)]}[()]", true},
+		{"empty password", "", false},
+		{"no brackets", "password", false},
+		{"only opening", "pass(word", false},
+		{"only closing", "pass)word", false},
+		{"unclosed after a pair", "()(", false},
+		{"extra closing after a pair", "())", false},
+		{"closed in the wrong order", "([)]", false},
+		{"mismatched kinds", "(]", false},
+		{"closing before opening", ")(", false},
+		{"unclosed nesting", "(()", false},
+		{"angle brackets don't count", "<>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasBalancedBrackets(tt.s); got != tt.want {
+				t.Errorf("HasBalancedBrackets(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalancedBracketsRuleAssigned(t *testing.T) {
+	rule := balancedBracketsRule()
+	if rule.Hint == "" || !rule.Validator("([{}])") || rule.Validator("([)]") {
+		t.Errorf("rule %d = %+v, want a hint and the HasBalancedBrackets validator", balancedBracketsRuleID, rule)
+	}
+
+	if ids := ruleIDs(NewRuleSet("expert")); !slices.Contains(ids, balancedBracketsRuleID) {
+		t.Errorf("expert rules %v don't include rule %d", ids, balancedBracketsRuleID)
+	}
+	for _, difficulty := range []string{"basic", "hard"} {
+		if ids := ruleIDs(NewRuleSet(difficulty)); slices.Contains(ids, balancedBracketsRuleID) {
+			t.Errorf("%s rules %v include rule %d", difficulty, ids, balancedBracketsRuleID)
+		}
+	}
+}
+
+func TestPreAppendFinalRuleIDIgnoresBalancedBrackets(t *testing.T) {
+	// expert ended at rule 25 before rules 28 and 29 were appended
+	if got, current := PreAppendFinalRuleID("expert"), GetFinalRuleID("expert"); got != 25 || current != balancedBracketsRuleID {
+		t.Errorf("expert final rule = %d, before the appends = %d, want %d and 25", current, got, balancedBracketsRuleID)
+	}
+}
@@ -11,6 +11,8 @@ import (
 var (
 	currentCaptchaID string
 	captchaMutex     sync.RWMutex
+	// captchaStore keeps the digits of every captcha so the current one can be saved and restored
+	captchaStore = NewCustomCaptchaStore()
 )
 
 // CustomCaptchaStore implements a custom store that doesn't expire captchas
@@ -55,7 +57,17 @@ func GenerateNewCaptcha() string {
 	defer captchaMutex.Unlock()
 
 	// Create captcha ID with 5 digits
-	currentCaptchaID = captcha.NewLen(5)
+	currentCaptchaID = captcha.NewLen(captchaLength)
+	if captchaCharset == CaptchaAlphanumeric {
+		// Replace the digits with text, which only our own renderer can draw
+		text := newCaptchaText()
+		if testMode {
+			text = []byte(TestCaptchaDigits)
+		}
+		captchaStore.Set(currentCaptchaID, text)
+	} else if testMode {
+		captchaStore.Set(currentCaptchaID, testCaptchaDigits())
+	}
 
 	return currentCaptchaID
 }
@@ -86,14 +98,17 @@ func ServeCaptchaImage(w http.ResponseWriter, r *http.Request) {
 
 	// Always use the current captcha ID to serve the image
 	// This ensures the image stays consistent with the validation
+	if solution := captchaStore.Get(captchaID, false); isCaptchaText(solution) {
+		writeCaptchaTextImage(w, solution, captcha.StdWidth, captcha.StdHeight)
+		return
+	}
 	captcha.WriteImage(w, captchaID, captcha.StdWidth, captcha.StdHeight)
 }
 
 // RefreshCaptcha generates a new captcha
 func RefreshCaptcha(w http.ResponseWriter, r *http.Request) {
-	GenerateNewCaptcha()
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"status": "refreshed"}`))
+	captchaID := GenerateNewCaptcha()
+	WriteRefreshResponse(w, "captcha", map[string]string{"id": captchaID}, nil)
 }
 
 // ValidateCaptcha checks if the password contains the current captcha solution
@@ -106,9 +121,14 @@ func ValidateCaptcha(password string) bool {
 		return false
 	}
 
+	// Alphanumeric captchas are matched case-insensitively against the stored text
+	if solution := captchaStore.Get(captchaID, false); isCaptchaText(solution) {
+		return containsCaptchaText(password, solution)
+	}
+
 	// Extract all 5-digit sequences from the password and check if any match the captcha
-	for i := 0; i <= len(password)-5; i++ {
-		candidate := password[i : i+5]
+	for i := 0; i <= len(password)-captchaLength; i++ {
+		candidate := password[i : i+captchaLength]
 		// Check if this 5-character substring is all digits
 		allDigits := true
 		for _, char := range candidate {
@@ -128,6 +148,6 @@ func ValidateCaptcha(password string) bool {
 // Initialize captcha on package load
 func init() {
 	// Set custom store that doesn't expire captchas
-	captcha.SetCustomStore(NewCustomCaptchaStore())
+	captcha.SetCustomStore(captchaStore)
 	GenerateNewCaptcha()
 }
\ No newline at end of file
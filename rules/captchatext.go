@@ -0,0 +1,195 @@
+package rules
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Captcha character sets, see SetCaptchaCharset
+const (
+	// CaptchaDigits renders captchas with dchest/captcha (the default)
+	CaptchaDigits = "digits"
+	// CaptchaAlphanumeric renders letters and digits with the built-in bitmap font
+	CaptchaAlphanumeric = "alphanumeric"
+)
+
+// captchaLength is how many characters every captcha has
+const captchaLength = 5
+
+// captchaAlphabet is what alphanumeric captchas are drawn from. 0/O and 1/I/L are left out
+// because they're too easy to confuse once distorted.
+const captchaAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// captchaCharset is the configured character set, only changed at startup
+var captchaCharset = CaptchaDigits
+
+// SetCaptchaCharset selects the Rule 15 character set: CaptchaDigits or CaptchaAlphanumeric.
+// An empty value means CaptchaDigits. A new captcha is generated when the set changes. Call it
+// before the rule pool is first built, since the rule's description names the set.
+func SetCaptchaCharset(charset string) error {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" {
+		charset = CaptchaDigits
+	}
+	if charset != CaptchaDigits && charset != CaptchaAlphanumeric {
+		return fmt.Errorf("invalid captcha charset: %s (must be %q or %q)", charset, CaptchaDigits, CaptchaAlphanumeric)
+	}
+
+	changed := charset != captchaCharset
+	captchaCharset = charset
+	if changed {
+		GenerateNewCaptcha()
+	}
+	return nil
+}
+
+// captchaDescription and captchaHint word Rule 15 for the configured character set
+func captchaDescription() string {
+	if captchaCharset == CaptchaAlphanumeric {
+		return fmt.Sprintf("Must include a captcha (%d-character code)", captchaLength)
+	}
+	return fmt.Sprintf("Must include a captcha (%d-digit code)", captchaLength)
+}
+
+func captchaHint() string {
+	if captchaCharset == CaptchaAlphanumeric {
+		return fmt.Sprintf("Enter the %d letters and digits shown in the captcha image. Case doesn't matter.", captchaLength)
+	}
+	return fmt.Sprintf("Enter the %d-digit code shown in the captcha image.", captchaLength)
+}
+
+// newCaptchaText picks a random alphanumeric solution, kept in the captcha store as ASCII
+func newCaptchaText() []byte {
+	text := make([]byte, captchaLength)
+	for i := range text {
+		text[i] = captchaAlphabet[randIntn(len(captchaAlphabet))]
+	}
+	return text
+}
+
+// isCaptchaText reports whether a stored solution is ASCII text rather than dchest/captcha
+// digit values (0-9)
+func isCaptchaText(solution []byte) bool {
+	for _, b := range solution {
+		if b > 9 {
+			return true
+		}
+	}
+	return false
+}
+
+// isCaptchaChar reports whether c belongs to the alphanumeric character class
+func isCaptchaChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// containsCaptchaText slides a window over password looking for solution. Every character in
+// the window must be a letter or digit, and letters match regardless of case.
+func containsCaptchaText(password string, solution []byte) bool {
+	n := len(solution)
+	if n == 0 {
+		return false
+	}
+
+	for i := 0; i <= len(password)-n; i++ {
+		candidate := password[i : i+n]
+		matched := true
+		for j := 0; j < n; j++ {
+			if !isCaptchaChar(candidate[j]) || !strings.EqualFold(candidate[j:j+1], string(solution[j])) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCaptchaSolution turns a stored solution into the text players type
+func encodeCaptchaSolution(solution []byte) string {
+	if isCaptchaText(solution) {
+		return string(solution)
+	}
+	encoded := make([]byte, len(solution))
+	for i, d := range solution {
+		encoded[i] = '0' + d
+	}
+	return string(encoded)
+}
+
+// decodeCaptchaSolution turns saved text back into a stored solution for the configured
+// character set, returning nil when the text doesn't fit it
+func decodeCaptchaSolution(encoded string) []byte {
+	if captchaCharset == CaptchaAlphanumeric {
+		text := []byte(strings.ToUpper(encoded))
+		for _, c := range text {
+			if !isCaptchaChar(c) {
+				return nil
+			}
+		}
+		return text
+	}
+
+	digits := make([]byte, 0, len(encoded))
+	for _, c := range encoded {
+		if c < '0' || c > '9' {
+			return nil
+		}
+		digits = append(digits, byte(c-'0'))
+	}
+	return digits
+}
+
+// writeCaptchaTextImage draws text as a width×height PNG with the bitmap font: each character
+// gets its own colour and vertical offset, over noise and a few strike-through lines
+func writeCaptchaTextImage(w io.Writer, text []byte, width, height int) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	randomColor := func() color.RGBA {
+		return color.RGBA{uint8(randIntn(140)), uint8(randIntn(140)), uint8(randIntn(140)), 0xff}
+	}
+	fill := func(x, y, size int, c color.RGBA) {
+		for dy := 0; dy < size; dy++ {
+			for dx := 0; dx < size; dx++ {
+				if image.Pt(x+dx, y+dy).In(img.Bounds()) {
+					img.SetRGBA(x+dx, y+dy, c)
+				}
+			}
+		}
+	}
+
+	// Background noise
+	for i := 0; i < width*height/25; i++ {
+		fill(randIntn(width), randIntn(height), 1, randomColor())
+	}
+
+	if len(text) > 0 {
+		cell := width / len(text)
+		scale := min(cell/(glyphWidth+glyphSpacing), height*2/3/glyphHeight)
+		for i, c := range text {
+			left := i*cell + (cell-glyphWidth*scale)/2
+			top := (height-glyphHeight*scale)/2 + randIntn(scale*2+1) - scale
+			DrawText(img, left, top, string(c), scale, randomColor())
+		}
+	}
+
+	// Strike-through lines
+	for i := 0; i < 3; i++ {
+		ink := randomColor()
+		y0, y1 := randIntn(height), randIntn(height)
+		for x := 0; x < width; x++ {
+			fill(x, y0+(y1-y0)*x/width, 2, ink)
+		}
+	}
+
+	return png.Encode(w, img)
+}
@@ -0,0 +1,151 @@
+package rules
+
+import (
+	"bytes"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// useCaptchaCharset switches Rule 15 to charset for the test and back to digits afterwards
+func useCaptchaCharset(t *testing.T, charset string) {
+	t.Helper()
+	if err := SetCaptchaCharset(charset); err != nil {
+		t.Fatalf("SetCaptchaCharset(%q) error = %v", charset, err)
+	}
+	t.Cleanup(func() { SetCaptchaCharset(CaptchaDigits) })
+}
+
+func TestContainsCaptchaText(t *testing.T) {
+	solution := []byte("K7P2X")
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"K7P2X", true},
+		{"abcK7P2Xdef", true},
+		{"k7p2x", true},
+		{"xx-k7P2x!", true},
+		{"K7P2", false},
+		{"K7 P2X", false},
+		{"K7P2Y", false},
+		{"X2P7K", false},
+		{"", false},
+		// Only letters and digits can fill the window, so a symbol never stands in for a character
+		{"K7P2*", false},
+		{"K7P2XK7P2X", true},
+	}
+	for _, tt := range tests {
+		if got := containsCaptchaText(tt.password, solution); got != tt.want {
+			t.Errorf("containsCaptchaText(%q, %s) = %v, want %v", tt.password, solution, got, tt.want)
+		}
+	}
+	if containsCaptchaText("anything", nil) {
+		t.Error("an empty solution matched")
+	}
+}
+
+func TestValidateCaptchaAlphanumeric(t *testing.T) {
+	useCaptchaCharset(t, CaptchaAlphanumeric)
+	SetRandSource(rand.NewSource(7))
+
+	// Outside test mode, so the solution is random text rather than TestCaptchaDigits
+	testMode = false
+	GenerateNewCaptcha()
+	testMode = true
+	solution := captchaStore.Get(GetCurrentCaptchaID(), false)
+	if len(solution) != captchaLength {
+		t.Fatalf("solution %q has %d characters, want %d", solution, len(solution), captchaLength)
+	}
+	for _, c := range solution {
+		if !strings.ContainsRune(captchaAlphabet, rune(c)) {
+			t.Errorf("solution %q has %q, outside the alphabet", solution, c)
+		}
+	}
+
+	text := string(solution)
+	accepted := []string{text, "pw" + text + "!", "pw" + strings.ToLower(text)}
+	for _, password := range accepted {
+		if !ValidateCaptcha(password) {
+			t.Errorf("ValidateCaptcha(%q) = false for solution %s", password, text)
+		}
+	}
+	rejected := []string{text[:4], text[:2] + " " + text[2:], "12345"}
+	for _, password := range rejected {
+		if ValidateCaptcha(password) {
+			t.Errorf("ValidateCaptcha(%q) = true for solution %s", password, text)
+		}
+	}
+	// Validating doesn't use up the captcha
+	if !ValidateCaptcha(text) {
+		t.Error("the solution stopped matching after being validated")
+	}
+
+	if got := DebugAnswers()["captcha"]; got != text {
+		t.Errorf("debug captcha answer = %q, want %q", got, text)
+	}
+}
+
+func TestSetCaptchaCharset(t *testing.T) {
+	t.Cleanup(func() { SetCaptchaCharset(CaptchaDigits) })
+
+	if err := SetCaptchaCharset(" Alphanumeric "); err != nil || captchaCharset != CaptchaAlphanumeric {
+		t.Fatalf("SetCaptchaCharset(Alphanumeric) = %v, charset %q", err, captchaCharset)
+	}
+	if !strings.Contains(captchaDescription(), "character") || !strings.Contains(captchaHint(), "letters") {
+		t.Errorf("alphanumeric wording = %q / %q", captchaDescription(), captchaHint())
+	}
+	if err := SetCaptchaCharset("emoji"); err == nil {
+		t.Error("SetCaptchaCharset(emoji) = nil, want an error")
+	}
+	if captchaCharset != CaptchaAlphanumeric {
+		t.Errorf("an invalid charset changed the charset to %q", captchaCharset)
+	}
+	if err := SetCaptchaCharset(""); err != nil || captchaCharset != CaptchaDigits {
+		t.Errorf("SetCaptchaCharset(\"\") = %v, charset %q, want digits", err, captchaCharset)
+	}
+	if !strings.Contains(captchaDescription(), "digit") {
+		t.Errorf("digit wording = %q", captchaDescription())
+	}
+}
+
+func TestCaptchaSolutionEncoding(t *testing.T) {
+	if got := encodeCaptchaSolution([]byte{4, 8, 2, 9, 1}); got != "48291" {
+		t.Errorf("encodeCaptchaSolution(digits) = %q, want 48291", got)
+	}
+	if got := encodeCaptchaSolution([]byte("K7P2X")); got != "K7P2X" {
+		t.Errorf("encodeCaptchaSolution(text) = %q, want K7P2X", got)
+	}
+
+	if got := decodeCaptchaSolution("48291"); !bytes.Equal(got, []byte{4, 8, 2, 9, 1}) {
+		t.Errorf("decodeCaptchaSolution(48291) = %v", got)
+	}
+	if got := decodeCaptchaSolution("K7P2X"); got != nil {
+		t.Errorf("decodeCaptchaSolution(K7P2X) with digits = %v, want nil", got)
+	}
+
+	useCaptchaCharset(t, CaptchaAlphanumeric)
+	if got := decodeCaptchaSolution("k7p2x"); string(got) != "K7P2X" {
+		t.Errorf("decodeCaptchaSolution(k7p2x) = %q, want K7P2X", got)
+	}
+	if got := decodeCaptchaSolution("K7-2X"); got != nil {
+		t.Errorf("decodeCaptchaSolution(K7-2X) = %q, want nil", got)
+	}
+}
+
+func TestWriteCaptchaTextImage(t *testing.T) {
+	SetRandSource(rand.NewSource(1))
+	var buf bytes.Buffer
+	if err := writeCaptchaTextImage(&buf, []byte("K7P2X"), 240, 80); err != nil {
+		t.Fatalf("writeCaptchaTextImage() error = %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 240 || size.Y != 80 {
+		t.Errorf("image is %v, want 240x80", size)
+	}
+}
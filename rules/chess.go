@@ -2,12 +2,17 @@ package rules
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"log"
-	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,11 +37,16 @@ var chessPuzzles = []string{
 	"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",         // Scandinavian Defense
 }
 
-// getBestMoveFromStockfish gets the best move from Stockfish API
-func getBestMoveFromStockfish(fen string) (string, error) {
+// stockfishAPIURL is the Stockfish endpoint for a FEN; tests point it at a stub server
+var stockfishAPIURL = "https://stockfish.online/api/s/v2.php?fen=%s&depth=15"
+
+// getBestMoveFromStockfish gets the best move from Stockfish API, giving up when ctx is cancelled
+func getBestMoveFromStockfish(ctx context.Context, fen string) (bestMove string, err error) {
+	defer func() { recordExternalAPICall("stockfish", err) }()
+
 	// Encode FEN for URL
 	encodedFEN := strings.ReplaceAll(fen, " ", "%20")
-	url := fmt.Sprintf("https://stockfish.online/api/s/v2.php?fen=%s&depth=15", encodedFEN)
+	url := fmt.Sprintf(stockfishAPIURL, encodedFEN)
 	
 	// Set timeout to prevent hanging
 	client := &http.Client{
@@ -44,7 +54,11 @@ func getBestMoveFromStockfish(fen string) (string, error) {
 	}
 	
 	// Make API request to Stockfish
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Stockfish request: %v", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to call Stockfish API: %v", err)
 	}
@@ -79,17 +93,18 @@ func getBestMoveFromStockfish(fen string) (string, error) {
 	return bestMove, nil
 }
 
-// GenerateNewChessPosition creates a new chess position and calculates the best move
-func GenerateNewChessPosition() (string, error) {
+// GenerateNewChessPosition creates a new chess position and calculates the best move.
+// Cancelling ctx abandons the Stockfish lookup and falls back to a legal move.
+func GenerateNewChessPosition(ctx context.Context) (string, error) {
 	chessMutex.Lock()
 	defer chessMutex.Unlock()
 
-	// Seed random number generator
-	rand.Seed(time.Now().UnixNano())
-
 	// Select a random puzzle
-	puzzleIndex := rand.Intn(len(chessPuzzles))
+	puzzleIndex := randIntn(len(chessPuzzles))
 	selectedFEN := chessPuzzles[puzzleIndex]
+	if testMode {
+		selectedFEN = TestChessFEN
+	}
 
 	// Create new game from FEN
 	fen, err := chess.FEN(selectedFEN)
@@ -101,7 +116,11 @@ func GenerateNewChessPosition() (string, error) {
 	currentChessGame = game
 
 	// Get the best move from Stockfish
-	bestMove, err := getBestMoveFromStockfish(selectedFEN)
+	if testMode {
+		currentBestMove = TestChessMove
+		return currentBestMove, nil
+	}
+	bestMove, err := getBestMoveFromStockfish(ctx, selectedFEN)
 	if err != nil {
 		log.Printf("Failed to get best move from Stockfish: %v, falling back to random move", err)
 		// Fallback to random move if Stockfish fails
@@ -145,7 +164,7 @@ func ServeChessImage(w http.ResponseWriter, r *http.Request) {
 
 	if game == nil {
 		// Generate new position if none exists
-		_, err := GenerateNewChessPosition()
+		_, err := GenerateNewChessPosition(r.Context())
 		if err != nil {
 			http.Error(w, "Failed to generate chess position", http.StatusInternalServerError)
 			return
@@ -172,21 +191,176 @@ func ServeChessImage(w http.ResponseWriter, r *http.Request) {
 	w.Write(svgData)
 }
 
+// Chess PNG size bounds for ?size=
+const (
+	defaultChessImageSize = 400
+	minChessImageSize     = 128
+	maxChessImageSize     = 1024
+)
+
+var (
+	chessLightSquare = color.RGBA{240, 217, 181, 255}
+	chessDarkSquare  = color.RGBA{181, 136, 99, 255}
+	chessWhitePiece  = color.RGBA{255, 255, 255, 255}
+	chessBlackPiece  = color.RGBA{20, 20, 20, 255}
+)
+
+// chessPieceLetters maps piece types to the letter drawn on the raster board
+var chessPieceLetters = map[chess.PieceType]string{
+	chess.King:   "K",
+	chess.Queen:  "Q",
+	chess.Rook:   "R",
+	chess.Bishop: "B",
+	chess.Knight: "N",
+	chess.Pawn:   "P",
+}
+
+// renderChessboardPNG rasterizes the board with white at the bottom, drawing each piece as
+// its letter: white pieces in white with a dark outline, black pieces in black with a light one
+func renderChessboardPNG(game *chess.Game, size int) *image.RGBA {
+	squareSize := size / 8
+	img := image.NewRGBA(image.Rect(0, 0, squareSize*8, squareSize*8))
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			squareColor := chessLightSquare
+			if (row+col)%2 == 1 {
+				squareColor = chessDarkSquare
+			}
+			rect := image.Rect(col*squareSize, row*squareSize, (col+1)*squareSize, (row+1)*squareSize)
+			draw.Draw(img, rect, &image.Uniform{squareColor}, image.Point{}, draw.Src)
+		}
+	}
+
+	scale := squareSize * 6 / 10 / glyphHeight
+	if scale < 1 {
+		scale = 1
+	}
+	outline := scale / 2
+	if outline < 1 {
+		outline = 1
+	}
+
+	for square, piece := range game.Position().Board().SquareMap() {
+		letter, ok := chessPieceLetters[piece.Type()]
+		if !ok {
+			continue
+		}
+
+		fill, edge := chessWhitePiece, chessBlackPiece
+		if piece.Color() == chess.Black {
+			fill, edge = chessBlackPiece, chessWhitePiece
+		}
+
+		col := int(square.File())
+		row := 7 - int(square.Rank())
+		x := col*squareSize + (squareSize-TextWidth(letter, scale))/2
+		y := row*squareSize + (squareSize-glyphHeight*scale)/2
+
+		for _, offset := range [][2]int{{-outline, 0}, {outline, 0}, {0, -outline}, {0, outline}} {
+			DrawText(img, x+offset[0], y+offset[1], letter, scale, edge)
+		}
+		DrawText(img, x, y, letter, scale, fill)
+	}
+
+	return img
+}
+
+// ServeChessPNG serves the current chess board as a PNG, sized with ?size= (clamped 128-1024)
+func ServeChessPNG(w http.ResponseWriter, r *http.Request) {
+	chessMutex.RLock()
+	game := currentChessGame
+	chessMutex.RUnlock()
+
+	if game == nil {
+		// Generate new position if none exists
+		_, err := GenerateNewChessPosition(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to generate chess position", http.StatusInternalServerError)
+			return
+		}
+		chessMutex.RLock()
+		game = currentChessGame
+		chessMutex.RUnlock()
+	}
+
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil {
+		size = defaultChessImageSize
+	} else if size < minChessImageSize {
+		size = minChessImageSize
+	} else if size > maxChessImageSize {
+		size = maxChessImageSize
+	}
+
+	// Prevent caching to ensure fresh images
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+
+	if err := png.Encode(w, renderChessboardPNG(game, size)); err != nil {
+		log.Printf("Error encoding chess PNG: %v", err)
+	}
+}
+
+// ChessPosition is the current puzzle as clients see it. The best move is left out so the
+// endpoint can't be used to solve the rule.
+type ChessPosition struct {
+	FEN  string `json:"fen"`
+	Turn string `json:"turn"` // "w" or "b"
+}
+
+// currentChessPosition reads the FEN and side to move of the current game, generating a
+// position if none exists
+func currentChessPosition(ctx context.Context) (ChessPosition, error) {
+	chessMutex.RLock()
+	game := currentChessGame
+	chessMutex.RUnlock()
+
+	if game == nil {
+		if _, err := GenerateNewChessPosition(ctx); err != nil {
+			return ChessPosition{}, err
+		}
+	}
+
+	chessMutex.RLock()
+	defer chessMutex.RUnlock()
+	return ChessPosition{
+		FEN:  currentChessGame.FEN(),
+		Turn: currentChessGame.Position().Turn().String(),
+	}, nil
+}
+
+// ServeChessPosition serves GET /api/chess/position so clients can render their own board
+func ServeChessPosition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	position, err := currentChessPosition(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to generate chess position", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	json.NewEncoder(w).Encode(position)
+}
+
 // RefreshChess generates a new chess position
 func RefreshChess(w http.ResponseWriter, r *http.Request) {
-	bestMove, err := GenerateNewChessPosition()
+	bestMove, err := GenerateNewChessPosition(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to generate new chess position", http.StatusInternalServerError)
 		return
 	}
 
 	// Return the best move in the response
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{
-		"status":   "refreshed",
-		"bestMove": bestMove,
-	}
-	json.NewEncoder(w).Encode(response)
+	value := map[string]string{"bestMove": bestMove}
+	WriteRefreshResponse(w, "chess", value, value)
 }
 
 // ValidateChessMove checks if the password contains the current best chess move
@@ -199,12 +373,8 @@ func ValidateChessMove(password string) bool {
 		return false
 	}
 
-	// Convert password to lowercase for case-insensitive matching
-	lowerPassword := strings.ToLower(password)
-	lowerBestMove := strings.ToLower(bestMove)
-
 	// Check if the best move is contained in the password
-	return strings.Contains(lowerPassword, lowerBestMove)
+	return matches(password, bestMove, matchOptionsFor(19))
 }
 
 // GetChessBoardAsBase64 returns the current chess board as a base64 encoded SVG
@@ -214,7 +384,7 @@ func GetChessBoardAsBase64() (string, error) {
 	chessMutex.RUnlock()
 
 	if game == nil {
-		_, err := GenerateNewChessPosition()
+		_, err := GenerateNewChessPosition(context.Background())
 		if err != nil {
 			return "", err
 		}
@@ -236,7 +406,7 @@ func GetChessBoardAsBase64() (string, error) {
 
 // Initialize chess position on package load
 func init() {
-	_, err := GenerateNewChessPosition()
+	_, err := GenerateNewChessPosition(context.Background())
 	if err != nil {
 		log.Printf("Warning: Failed to initialize chess position: %v", err)
 	}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+)
+
+func TestServeChessPNG(t *testing.T) {
+	defaultSize := defaultChessImageSize / 8 * 8
+
+	tests := []struct {
+		query    string
+		wantSize int
+	}{
+		{"", defaultSize},
+		{"?size=abc", defaultSize},
+		{"?size=400", 400},
+		{"?size=300", 296},
+		{"?size=50", minChessImageSize},
+		{"?size=5000", maxChessImageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServeChessPNG(w, httptest.NewRequest(http.MethodGet, "/chess.png"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", ct)
+			}
+
+			img, err := png.Decode(w.Body)
+			if err != nil {
+				t.Fatalf("response is not a PNG: %v", err)
+			}
+			if bounds := img.Bounds(); bounds.Dx() != tt.wantSize || bounds.Dy() != tt.wantSize {
+				t.Errorf("image is %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantSize, tt.wantSize)
+			}
+
+			// The corner squares are a8 (light) and h8 (dark), whatever the position
+			square := tt.wantSize / 8
+			if got := img.At(1, 1); got != chessLightSquare {
+				t.Errorf("a8 corner = %v, want the light square color", got)
+			}
+			if got := img.At(square*8-2, 1); got != chessDarkSquare {
+				t.Errorf("h8 corner = %v, want the dark square color", got)
+			}
+		})
+	}
+}
+
+func TestGetBestMoveFromStockfishCancelled(t *testing.T) {
+	server := newHangingServer(t)
+	previousURL := stockfishAPIURL
+	stockfishAPIURL = server.URL + "/?fen=%s"
+	defer func() { stockfishAPIURL = previousURL }()
+
+	err := returnsPromptly(t, func(ctx context.Context) error {
+		_, err := getBestMoveFromStockfish(ctx, chessPuzzles[0])
+		return err
+	})
+	if err == nil {
+		t.Error("getBestMoveFromStockfish() succeeded after its context was cancelled")
+	}
+}
+
+// getChessPosition requests /api/chess/position and decodes the response
+func getChessPosition(t *testing.T) (ChessPosition, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ServeChessPosition(w, httptest.NewRequest(http.MethodGet, "/api/chess/position", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	body := w.Body.String()
+	var position ChessPosition
+	if err := json.Unmarshal([]byte(body), &position); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return position, body
+}
+
+func TestServeChessPosition(t *testing.T) {
+	// With no position yet, one is generated
+	chessMutex.Lock()
+	currentChessGame, currentBestMove = nil, ""
+	chessMutex.Unlock()
+
+	position, body := getChessPosition(t)
+	fen, err := chess.FEN(position.FEN)
+	if err != nil {
+		t.Fatalf("%q is not a valid FEN: %v", position.FEN, err)
+	}
+	game := chess.NewGame(fen)
+	if got := game.Position().Turn().String(); position.Turn != got {
+		t.Errorf("turn = %q, want %q from the FEN", position.Turn, got)
+	}
+	if position.Turn != "w" && position.Turn != "b" {
+		t.Errorf("turn = %q, want w or b", position.Turn)
+	}
+	if position.FEN != TestChessFEN {
+		t.Errorf("FEN = %q, want the test position %q", position.FEN, TestChessFEN)
+	}
+	if strings.Contains(body, TestChessMove) {
+		t.Errorf("response %s gives away the best move", body)
+	}
+
+	// An existing position is reported as is
+	puzzle, err := chess.FEN(chessPuzzles[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	chessMutex.Lock()
+	currentChessGame = chess.NewGame(puzzle)
+	chessMutex.Unlock()
+	t.Cleanup(func() { GenerateNewChessPosition(context.Background()) })
+
+	if position, _ := getChessPosition(t); position.FEN != chessPuzzles[1] {
+		t.Errorf("FEN = %q, want %q", position.FEN, chessPuzzles[1])
+	}
+
+	w := httptest.NewRecorder()
+	ServeChessPosition(w, httptest.NewRequest(http.MethodPost, "/api/chess/position", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -1,15 +1,16 @@
 package rules
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
 	database "passgame/Database"
+	"passgame/config"
 )
 
 // Global variables to store current mathematical constant and color
@@ -166,6 +167,10 @@ func InitColorsTable() error {
 
 // GetRandomMathConstant retrieves a random mathematical constant from the database
 func GetRandomMathConstant() (string, string, error) {
+	if testMode {
+		return TestConstantName, TestConstantValue, nil
+	}
+
 	db := database.GetDB()
 	if db == nil {
 		return "", "", fmt.Errorf("database connection not available")
@@ -185,6 +190,10 @@ func GetRandomMathConstant() (string, string, error) {
 
 // GetRandomColor retrieves a random color from the database
 func GetRandomColor() (string, string, error) {
+	if testMode {
+		return TestColorName, TestColorHex, nil
+	}
+
 	db := database.GetDB()
 	if db == nil {
 		return "", "", fmt.Errorf("database connection not available")
@@ -254,28 +263,26 @@ func ValidateMathConstant(password string) bool {
 	constant := currentConstant
 	constantsMutex.RUnlock()
 
-	if constant == "" {
+	firstThreeDigits := leadingDigits(constant, 3)
+	if len(firstThreeDigits) < 3 {
 		return false
 	}
 
-	// Extract the first 3 digits (ignoring decimal point)
-	firstThreeDigits := ""
-	digitCount := 0
-	for _, char := range constant {
+	return matches(password, firstThreeDigits, matchOptionsFor(13))
+}
+
+// leadingDigits returns up to n digits from the start of value, ignoring the decimal point
+func leadingDigits(value string, n int) string {
+	digits := ""
+	for _, char := range value {
 		if char >= '0' && char <= '9' {
-			firstThreeDigits += string(char)
-			digitCount++
-			if digitCount == 3 {
+			digits += string(char)
+			if len(digits) == n {
 				break
 			}
 		}
 	}
-
-	if len(firstThreeDigits) < 3 {
-		return false
-	}
-
-	return strings.Contains(password, firstThreeDigits)
+	return digits
 }
 
 // ValidateHexColor checks if the password contains the hex code of the current color
@@ -288,11 +295,23 @@ func ValidateHexColor(password string) bool {
 		return false
 	}
 
-	// Check for hex code with or without the # prefix
-	hexWithoutHash := strings.TrimPrefix(hexCode, "#")
+	// Accept the code as stored and its full 6-digit form, with or without the # prefix
+	opts := matchOptionsFor(18)
+	if matches(password, strings.TrimPrefix(hexCode, "#"), opts) {
+		return true
+	}
 
-	return strings.Contains(strings.ToLower(password), strings.ToLower(hexCode)) ||
-		strings.Contains(strings.ToLower(password), strings.ToLower(hexWithoutHash))
+	r, g, b, err := HexToRGB(hexCode)
+	if err != nil {
+		return false
+	}
+	return matches(password, fmt.Sprintf("%02x%02x%02x", r, g, b), opts)
+}
+
+// HexToRGB converts a hex color string such as "#ff8800", "ff8800" or the shorthand "#f80" to RGB
+// values. It shares config's parser, which also checks the colors in difficulties.json.
+func HexToRGB(hexColor string) (r, g, b uint8, err error) {
+	return config.HexToRGB(hexColor)
 }
 
 // GetMathConstantForHint returns the current mathematical constant for display in hints
@@ -325,28 +344,30 @@ func GetColorForHint() string {
 	return fmt.Sprintf("%s (%s)", currentColorName, currentColor)
 }
 
-// Initialize constants and colors on package load
-func init() {
-	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
-
-	// Initial values will be generated when the database is initialized
-	// This happens in the main.go file after the database is connected
+// DefaultConstantsRefreshInterval is how often the math constant and color are rotated by default
+const DefaultConstantsRefreshInterval = 6 * time.Hour
 
-	// We'll also set up goroutines to periodically refresh the values
-	go func() {
-		// Wait for database initialization (5 seconds should be enough)
-		time.Sleep(5 * time.Second)
+// StartConstantsRefreshLoop refreshes the math constant and color every interval until ctx is
+// cancelled. A non-positive interval uses DefaultConstantsRefreshInterval.
+func StartConstantsRefreshLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultConstantsRefreshInterval
+	}
+	go runConstantsRefreshLoop(ctx, interval)
+}
 
-		// Initial refresh
-		_ = RefreshMathConstant()
-		_ = RefreshColor()
+// runConstantsRefreshLoop blocks, refreshing on every tick, and returns once ctx is cancelled
+func runConstantsRefreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		// Refresh every 6 hours
-		for {
-			time.Sleep(6 * time.Hour)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 			_ = RefreshMathConstant()
 			_ = RefreshColor()
 		}
-	}()
+	}
 }
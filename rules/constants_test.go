@@ -0,0 +1,44 @@
+package rules
+
+import "testing"
+
+// useColor sets the current color for the test and restores the previous one afterwards
+func useColor(t *testing.T, name, hexCode string) {
+	t.Helper()
+	colorsMutex.Lock()
+	previousName, previousHex := currentColorName, currentColor
+	currentColorName, currentColor = name, hexCode
+	colorsMutex.Unlock()
+
+	t.Cleanup(func() {
+		colorsMutex.Lock()
+		currentColorName, currentColor = previousName, previousHex
+		colorsMutex.Unlock()
+	})
+}
+
+func TestValidateHexColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		hexCode  string
+		password string
+		want     bool
+	}{
+		{"full code", "#ff8800", "xx#ff8800", true},
+		{"full code without the hash", "#ff8800", "ff8800yy", true},
+		{"different case", "#ff8800", "FF8800", true},
+		{"shorthand as stored", "#f80", "f80", true},
+		{"shorthand in its full form", "#f80", "ff8800", true},
+		{"other color", "#ff8800", "ff8801", false},
+		{"no color yet", "", "ff8800", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useColor(t, "Test", tt.hexCode)
+			if got := ValidateHexColor(tt.password); got != tt.want {
+				t.Errorf("ValidateHexColor(%q) with %q = %v, want %v", tt.password, tt.hexCode, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+// Flag emojis spelled out as regional indicator pairs
+const (
+	flagFrance = "\U0001F1EB\U0001F1F7" // FR
+	flagUS     = "\U0001F1FA\U0001F1F8" // US
+	flagXX     = "\U0001F1FD\U0001F1FD" // not an ISO code
+	indicatorG = "\U0001F1EC"
+	indicatorX = "\U0001F1FD"
+)
+
+func TestCountryRule(t *testing.T) {
+	rule := countryRule()
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"country name", "I love France!", true},
+		{"name in any casing", "jApAn2024", true},
+		{"multi-word name", "newzealand", false},
+		{"multi-word name with spaces", "New Zealand", true},
+		{"flag emoji", "pw" + flagFrance + "123", true},
+		{"flag followed by a lone indicator", flagUS + indicatorG, true},
+		{"unknown flag", "pw" + flagXX, false},
+		{"indicators paired from the left", indicatorX + flagFrance, false},
+		{"lone indicator", "pw" + indicatorG, false},
+		{"non-country string", "password123!", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.Validator(tt.password); got != tt.want {
+				t.Errorf("rule 27(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountryRuleHintExamples(t *testing.T) {
+	rule := countryRule()
+	for _, example := range []string{"France", "Japan", "Peru", "\U0001F1E7\U0001F1F7"} {
+		if !rule.Validator(example) {
+			t.Errorf("hint example %q doesn't satisfy the rule", example)
+		}
+	}
+}
+
+func TestCountryRuleInFunDifficulty(t *testing.T) {
+	if !slices.Contains(ruleIDs(NewRuleSet("fun")), countryRuleID) {
+		t.Errorf("fun rules = %v, want rule %d", ruleIDs(NewRuleSet("fun")), countryRuleID)
+	}
+}
+
+func TestPreAppendFinalRuleIDIgnoresCountry(t *testing.T) {
+	// fun ended at rule 25 before the moon phase and country rules were appended
+	if got, current := PreAppendFinalRuleID("fun"), GetFinalRuleID("fun"); got != 25 || current != countryRuleID {
+		t.Errorf("fun final rule = %d, before the appends = %d, want %d and 25", current, got, countryRuleID)
+	}
+}
@@ -1,7 +1,7 @@
 package rules
 
 import (
-	"math/rand"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -12,9 +12,55 @@ const (
 	updateStringChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	// updateStringLength defines the length of the random update string
 	updateStringLength = 8
+	// noImposterMarker must be typed once every imposter character has been removed
+	noImposterMarker = "NOIMPOSTER"
+	// DefaultImposterCount is how many imposter characters Rule 25 plants when not configured
+	DefaultImposterCount = 3
+	// DefaultBlackSquareMinimum is how many black squares Rule 24 injects before it can pass
+	DefaultBlackSquareMinimum = 2
+	// updateAlertRuleID is the rule that asks for the update string
+	updateAlertRuleID = 14
+	// passwordLockRuleID is the rule locked until the ad is watched
+	passwordLockRuleID = 23
+	// ransomwareRuleID is the rule that injects black squares
+	ransomwareRuleID = 24
+	// insiderThreatRuleID is the rule that plants imposter characters
+	insiderThreatRuleID = 25
 )
 
-// CyberSecurityRules handles all cybersecurity-themed password rules
+// cyberSecSettingsMutex guards the configured imposterCount and blackSquareMinimum, which every
+// game shares
+var cyberSecSettingsMutex sync.RWMutex
+
+// imposterCount is how many imposter characters Rule 25 plants
+var imposterCount = DefaultImposterCount
+
+// blackSquareMinimum is how many black squares must be injected before Rule 24 can pass
+var blackSquareMinimum = DefaultBlackSquareMinimum
+
+// currentImposterCount returns the configured imposter count
+func currentImposterCount() int {
+	cyberSecSettingsMutex.RLock()
+	defer cyberSecSettingsMutex.RUnlock()
+	return imposterCount
+}
+
+// currentBlackSquareMinimum returns the configured black square minimum
+func currentBlackSquareMinimum() int {
+	cyberSecSettingsMutex.RLock()
+	defer cyberSecSettingsMutex.RUnlock()
+	return blackSquareMinimum
+}
+
+// blackSquareInterval is the least time between two Rule 24 injections
+const blackSquareInterval = 500 * time.Millisecond
+
+// now is the clock that paces black square injection; tests swap it for a fake one
+var now = time.Now
+
+// CyberSecurityRules handles all cybersecurity-themed password rules. Every game keeps its own
+// state (see NewCyberSecurityRules and BindCyberSecurity); the package-level functions work on a
+// shared default instance.
 type CyberSecurityRules struct {
 	mutex                     sync.RWMutex
 	updateAlertShown          bool
@@ -22,88 +68,112 @@ type CyberSecurityRules struct {
 	adWatched                 bool
 	raidUnlockString          string
 	blackSquareCount          int
+	blackSquaresInjected      int // total injected this game; deleting squares never lowers it
 	blackboxRuleValidated     bool
 	blackboxInjectionStarted  bool
 	blackboxMinimumInjected   bool
 	blackboxLastInjectionTime time.Time
 	imposterIndices           []int
-	imposterOriginalChars     []byte
+	imposterOriginalChars     []rune
 	imposterRuleValidated     bool
 	lastPasswordLength        int
 }
 
-var cyberSecRules = &CyberSecurityRules{
-	updateString:     "", // Will be generated on first use
-	raidUnlockString: "RAID-UNLOCKED",
+var cyberSecRules = NewCyberSecurityRules()
+
+// NewCyberSecurityRules returns the cybersecurity rule state of a game that has just started
+func NewCyberSecurityRules() *CyberSecurityRules {
+	return &CyberSecurityRules{
+		updateString:     "", // Will be generated on first use
+		raidUnlockString: "RAID-UNLOCKED",
+	}
+}
+
+// BindCyberSecurity points the cybersecurity rules of rs at csr, so a game validates against its
+// own update string, ad, black squares and imposters instead of the shared ones the rule pool uses
+func BindCyberSecurity(rs *RuleSet, csr *CyberSecurityRules) {
+	for i := range rs.Rules {
+		switch rs.Rules[i].ID {
+		case updateAlertRuleID:
+			rs.Rules[i].Validator = csr.UpdateAlert
+			rs.Rules[i].Hint = updateAlertHint(csr.UpdateString())
+		case passwordLockRuleID:
+			rs.Rules[i].Validator = csr.PasswordLock
+		case ransomwareRuleID:
+			rs.Rules[i].Validator = csr.RansomwareAttack
+		case insiderThreatRuleID:
+			rs.Rules[i].Validator = csr.InsiderThreat
+		}
+	}
 }
 
 // Rule14UpdateAlert validates the update alert rule
 func Rule14UpdateAlert(password string) bool {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
-
-	// If no update string has been generated yet, generate one
-	if cyberSecRules.updateString == "" {
-		cyberSecRules.mutex.RUnlock()
-		// Get a new update string (this will generate one if needed)
-		updateStr := GetUpdateString()
-		cyberSecRules.mutex.RLock()
-		return strings.Contains(password, updateStr)
-	}
+	return cyberSecRules.UpdateAlert(password)
+}
+
+// UpdateAlert validates Rule 14: the password must include this game's update string
+func (csr *CyberSecurityRules) UpdateAlert(password string) bool {
+	return matches(password, csr.UpdateString(), matchOptionsFor(updateAlertRuleID))
+}
 
-	// Check if the update string is present in the password
-	return strings.Contains(password, cyberSecRules.updateString)
+// updateAlertHint describes Rule 14 for an update string
+func updateAlertHint(updateString string) string {
+	return "After the update, include '" + updateString + "' in your password."
 }
 
 // Rule22PDFFile validates the PDF file rule
 func Rule22PDFFile(password string) bool {
 	// Simple validation - check if "pdf file" is present
-	return strings.Contains(strings.ToLower(password), "pdf file")
+	return matches(password, "pdf file", matchOptionsFor(22))
 }
 
 // Rule23PasswordLock validates the RAID unlock rule
 func Rule23PasswordLock(password string) bool {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
+	return cyberSecRules.PasswordLock(password)
+}
+
+// PasswordLock validates Rule 23 for this game
+func (csr *CyberSecurityRules) PasswordLock(password string) bool {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+
+	// The textbox is "locked" until the ad is watched, so typing the string early doesn't count
+	if !csr.adWatched {
+		return false
+	}
 
 	// Check if the RAID unlock string is present
-	return strings.Contains(password, cyberSecRules.raidUnlockString)
+	return matches(password, csr.raidUnlockString, matchOptionsFor(passwordLockRuleID))
 }
 
 // Rule24RansomwareAttack validates the ransomware defense rule
 func Rule24RansomwareAttack(password string) bool {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
+	return cyberSecRules.RansomwareAttack(password)
+}
 
-	// If the rule has already been validated for this session, return true
-	if cyberSecRules.blackboxRuleValidated {
+// RansomwareAttack validates Rule 24 for this game
+func (csr *CyberSecurityRules) RansomwareAttack(password string) bool {
+	minimum := currentBlackSquareMinimum()
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
+
+	// If the rule has already been validated for this game, return true
+	if csr.blackboxRuleValidated {
 		return true
 	}
 
 	// Count black squares in the password
 	blackSquareCount := strings.Count(password, "⬛")
-	cyberSecRules.blackSquareCount = blackSquareCount
-
-	// Start the injection process if not already started
-	if !cyberSecRules.blackboxInjectionStarted {
-		cyberSecRules.blackboxInjectionStarted = true
-		cyberSecRules.blackboxLastInjectionTime = time.Now()
-		return false
-	}
-
-	// Check if we've injected at least 2 black boxes before validating
-	if !cyberSecRules.blackboxMinimumInjected && cyberSecRules.blackSquareCount >= 2 {
-		cyberSecRules.blackboxMinimumInjected = true
-	}
-
-	// Only validate if minimum number of black boxes have been injected
-	if cyberSecRules.blackboxMinimumInjected {
-		// Rule is satisfied if there are no black squares (user deleted them all)
-		if blackSquareCount == 0 {
-			// Mark the rule as validated for this session
-			cyberSecRules.blackboxRuleValidated = true
-			return true
-		}
+	csr.blackSquareCount = blackSquareCount
+
+	// Injection is driven only by GenerateBlackSquares, which keeps its own running total. Reading
+	// that total instead of the squares currently in the password means deleting a square before
+	// the next one arrives can't hold the rule below its minimum forever.
+	if csr.blackSquaresInjected >= minimum && blackSquareCount == 0 {
+		// Mark the rule as validated for this game
+		csr.blackboxRuleValidated = true
+		return true
 	}
 
 	return false
@@ -111,250 +181,410 @@ func Rule24RansomwareAttack(password string) bool {
 
 // Rule25InsiderThreat validates the insider threat rule
 func Rule25InsiderThreat(password string) bool {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
+	return cyberSecRules.InsiderThreat(password)
+}
+
+// InsiderThreat validates Rule 25 for this game
+func (csr *CyberSecurityRules) InsiderThreat(password string) bool {
+	count := currentImposterCount()
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
 
-	// Check if the rule has already been validated for this session
-	if cyberSecRules.imposterRuleValidated {
+	// Check if the rule has already been validated for this game
+	if csr.imposterRuleValidated {
 		return true
 	}
 
+	// Work on code points so multibyte characters (é, emoji) count as one position
+	runes := []rune(password)
+
 	// If password length changed and we haven't generated indices yet, generate them
-	if len(password) != cyberSecRules.lastPasswordLength && len(cyberSecRules.imposterIndices) == 0 {
-		cyberSecRules.generateImposterIndices(password)
-		cyberSecRules.lastPasswordLength = len(password)
+	if len(runes) != csr.lastPasswordLength && len(csr.imposterIndices) == 0 {
+		csr.generateImposterIndices(runes, count)
+		csr.lastPasswordLength = len(runes)
 	}
 
 	// Check if all imposter characters have been removed
-	if len(password) < 3 || len(cyberSecRules.imposterIndices) == 0 {
+	if len(runes) < 3 || len(csr.imposterIndices) == 0 {
 		return true // Rule satisfied if password too short or no imposters
 	}
 
 	// Check if the imposter characters have been removed
 	allRemoved := true
-	for i, idx := range cyberSecRules.imposterIndices {
+	for i, idx := range csr.imposterIndices {
 		// If the index is out of bounds or the character at that position has changed
-		if idx >= len(password) || (idx < len(password) && password[idx] != cyberSecRules.imposterOriginalChars[i]) {
+		if idx >= len(runes) || runes[idx] != csr.imposterOriginalChars[i] {
 			continue // This imposter character has been removed or modified
-		} else {
-			allRemoved = false
-			break
 		}
+		allRemoved = false
+		break
 	}
 
-	// If all imposter characters have been removed, mark the rule as validated
-	if allRemoved {
-		cyberSecRules.imposterRuleValidated = true
+	// The player confirms the clean-up by typing the marker from the hint
+	if allRemoved && matches(password, noImposterMarker, matchOptionsFor(insiderThreatRuleID)) {
+		csr.imposterRuleValidated = true
 		return true
 	}
 
 	return false
 }
 
-// generateImposterIndices creates random indices for imposter characters
-func (csr *CyberSecurityRules) generateImposterIndices(password string) {
-	if len(password) < 3 {
+// generateImposterIndices creates up to count random rune indices for imposter characters
+func (csr *CyberSecurityRules) generateImposterIndices(runes []rune, count int) {
+	if len(runes) < 3 {
 		csr.imposterIndices = []int{}
-		csr.imposterOriginalChars = []byte{}
+		csr.imposterOriginalChars = []rune{}
 		return
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	indices := make(map[int]bool)
-
-	// Generate 3 unique random indices
-	for len(indices) < 3 && len(indices) < len(password) {
-		idx := rand.Intn(len(password))
-		// Avoid spaces and already selected indices
-		if password[idx] != ' ' && !indices[idx] {
-			indices[idx] = true
+	// Only non-space positions are eligible, which also bounds the selection loop
+	candidates := make([]int, 0, len(runes))
+	for i, r := range runes {
+		if r != ' ' {
+			candidates = append(candidates, i)
 		}
 	}
 
-	// Convert map to slice
-	csr.imposterIndices = make([]int, 0, len(indices))
-	csr.imposterOriginalChars = make([]byte, 0, len(indices))
+	randShuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	// Pick up to count unique indices
+	if len(candidates) < count {
+		count = len(candidates)
+	}
+
+	csr.imposterIndices = make([]int, 0, count)
+	csr.imposterOriginalChars = make([]rune, 0, count)
 
-	for idx := range indices {
+	for _, idx := range candidates[:count] {
 		csr.imposterIndices = append(csr.imposterIndices, idx)
 		// Store the original character at this position
-		csr.imposterOriginalChars = append(csr.imposterOriginalChars, password[idx])
+		csr.imposterOriginalChars = append(csr.imposterOriginalChars, runes[idx])
+	}
+}
+
+// SetImposterCount configures how many imposter characters Rule 25 plants. Values below 1 fall
+// back to DefaultImposterCount. Call it before the rule pool is first built, since the hint
+// includes the count.
+func SetImposterCount(count int) {
+	if count < 1 {
+		count = DefaultImposterCount
+	}
+	cyberSecSettingsMutex.Lock()
+	defer cyberSecSettingsMutex.Unlock()
+	imposterCount = count
+}
+
+// imposterHint describes Rule 25 for the configured imposter count
+func imposterHint() string {
+	count := currentImposterCount()
+
+	letters := "letters"
+	if count == 1 {
+		letters = "letter"
 	}
+	return fmt.Sprintf("Delete the %d imposter %s (highlighted in red) from your password! Add '%s' to your password when done.", count, letters, noImposterMarker)
 }
 
 // generateRandomString generates a random string of the specified length using the provided character set
 func generateRandomString(length int, charset string) string {
-	rand.Seed(time.Now().UnixNano())
 	sb := strings.Builder{}
 	sb.Grow(length)
 	for i := 0; i < length; i++ {
-		sb.WriteByte(charset[rand.Intn(len(charset))])
+		sb.WriteByte(charset[randIntn(len(charset))])
 	}
 	return sb.String()
 }
 
 // GetUpdateString returns the current update string for Rule 14, generating a new one if needed
 func GetUpdateString() string {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
-	
+	return cyberSecRules.UpdateString()
+}
+
+// UpdateString returns this game's update string for Rule 14, generating it on first use
+func (csr *CyberSecurityRules) UpdateString() string {
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
+
 	// Generate a new random update string if one doesn't exist
-	if cyberSecRules.updateString == "" {
-		cyberSecRules.updateString = generateRandomString(updateStringLength, updateStringChars)
+	if csr.updateString == "" {
+		csr.updateString = generateRandomString(updateStringLength, updateStringChars)
 	}
-	
-	return cyberSecRules.updateString
+
+	return csr.updateString
 }
 
 // SetUpdateAlertShown marks the update alert as shown
 func SetUpdateAlertShown(shown bool) {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
-	cyberSecRules.updateAlertShown = shown
+	cyberSecRules.SetUpdateAlertShown(shown)
+}
+
+// SetUpdateAlertShown marks this game's update alert as shown
+func (csr *CyberSecurityRules) SetUpdateAlertShown(shown bool) {
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
+	csr.updateAlertShown = shown
 }
 
 // IsUpdateAlertShown returns whether the update alert has been shown
 func IsUpdateAlertShown() bool {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
-	return cyberSecRules.updateAlertShown
+	return cyberSecRules.IsUpdateAlertShown()
+}
+
+// IsUpdateAlertShown returns whether this game's update alert has been shown
+func (csr *CyberSecurityRules) IsUpdateAlertShown() bool {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+	return csr.updateAlertShown
 }
 
 // GetRaidUnlockString returns the RAID unlock string for Rule 23
 func GetRaidUnlockString() string {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
-	return cyberSecRules.raidUnlockString
+	return cyberSecRules.RaidUnlockString()
+}
+
+// RaidUnlockString returns this game's RAID unlock string for Rule 23
+func (csr *CyberSecurityRules) RaidUnlockString() string {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+	return csr.raidUnlockString
 }
 
 // SetAdWatched marks the ad as watched for Rule 23
 func SetAdWatched(watched bool) {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
-	cyberSecRules.adWatched = watched
+	cyberSecRules.SetAdWatched(watched)
+}
+
+// SetAdWatched marks this game's ad as watched for Rule 23
+func (csr *CyberSecurityRules) SetAdWatched(watched bool) {
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
+	csr.adWatched = watched
 }
 
 // IsAdWatched returns whether the ad has been watched
 func IsAdWatched() bool {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
-	return cyberSecRules.adWatched
+	return cyberSecRules.IsAdWatched()
+}
+
+// IsAdWatched returns whether this game's ad has been watched
+func (csr *CyberSecurityRules) IsAdWatched() bool {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+	return csr.adWatched
 }
 
 // GetBlackSquareCount returns the current count of black squares
 func GetBlackSquareCount() int {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
-	return cyberSecRules.blackSquareCount
+	return cyberSecRules.BlackSquareCount()
+}
+
+// BlackSquareCount returns the count of black squares in this game's last validated password
+func (csr *CyberSecurityRules) BlackSquareCount() int {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+	return csr.blackSquareCount
+}
+
+// FatalBlackSquareCount is the most black squares Rule 24 tolerates; beyond it the game is lost
+const FatalBlackSquareCount = 12
+
+// IsBlackSquareCountFatal reports whether a black square count ends the game
+func IsBlackSquareCountFatal(count int) bool {
+	return count > FatalBlackSquareCount
 }
 
-// GenerateBlackSquares creates a black square for Rule 24 if enough time has passed
-func GenerateBlackSquares() string {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
+// GenerateBlackSquares creates a black square for Rule 24 if enough time has passed. It also
+// returns the black square count taken under the same lock, so callers report exactly what the
+// validator sees. No more squares are injected once the count is fatal.
+func GenerateBlackSquares() (string, int) {
+	return cyberSecRules.GenerateBlackSquares()
+}
+
+// GenerateBlackSquares injects this game's next Rule 24 black square, see the package-level
+// GenerateBlackSquares
+func (csr *CyberSecurityRules) GenerateBlackSquares() (string, int) {
+	minimum := currentBlackSquareMinimum()
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
 
 	// If rule is already validated, don't inject more black squares
-	if cyberSecRules.blackboxRuleValidated {
-		return ""
+	if csr.blackboxRuleValidated || IsBlackSquareCountFatal(csr.blackSquareCount) {
+		return "", csr.blackSquareCount
 	}
 
 	// Initialize the injection process if not already started
-	if !cyberSecRules.blackboxInjectionStarted {
-		cyberSecRules.blackboxInjectionStarted = true
-		cyberSecRules.blackboxLastInjectionTime = time.Now()
-		cyberSecRules.blackSquareCount = 1
-		return "⬛"
+	if !csr.blackboxInjectionStarted {
+		csr.blackboxInjectionStarted = true
+		csr.blackboxLastInjectionTime = now()
+		csr.blackSquareCount++
+		csr.recordBlackSquareInjected(minimum)
+		return "⬛", csr.blackSquareCount
 	}
 
-	// Check if 0.5 seconds have passed since the last injection
-	if time.Since(cyberSecRules.blackboxLastInjectionTime) >= 500*time.Millisecond {
+	// Check if blackSquareInterval has passed since the last injection
+	if current := now(); current.Sub(csr.blackboxLastInjectionTime) >= blackSquareInterval {
 		// Update the last injection time
-		cyberSecRules.blackboxLastInjectionTime = time.Now()
+		csr.blackboxLastInjectionTime = current
 
 		// Increment the black square count
-		cyberSecRules.blackSquareCount++
-
-		// If we've injected at least 2 black boxes, mark the minimum as reached
-		if cyberSecRules.blackSquareCount >= 2 && !cyberSecRules.blackboxMinimumInjected {
-			cyberSecRules.blackboxMinimumInjected = true
-		}
+		csr.blackSquareCount++
+		csr.recordBlackSquareInjected(minimum)
 
 		// Inject one black square
-		return "⬛"
+		return "⬛", csr.blackSquareCount
 	}
 
 	// Not enough time has passed, don't inject a black square
-	return ""
+	return "", csr.blackSquareCount
+}
+
+// recordBlackSquareInjected counts one injected square and marks the minimum once it is reached.
+// The caller must hold csr.mutex.
+func (csr *CyberSecurityRules) recordBlackSquareInjected(minimum int) {
+	csr.blackSquaresInjected++
+	if csr.blackSquaresInjected >= minimum {
+		csr.blackboxMinimumInjected = true
+	}
+}
+
+// SetBlackSquareMinimum configures how many black squares Rule 24 injects before deleting them
+// all satisfies it. Values below 1 fall back to DefaultBlackSquareMinimum.
+func SetBlackSquareMinimum(minimum int) {
+	if minimum < 1 {
+		minimum = DefaultBlackSquareMinimum
+	}
+	cyberSecSettingsMutex.Lock()
+	defer cyberSecSettingsMutex.Unlock()
+	blackSquareMinimum = minimum
 }
 
 // GetImposterIndices returns the current imposter indices for Rule 25
 func GetImposterIndices() []int {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
+	return cyberSecRules.ImposterIndices()
+}
+
+// ImposterIndices returns a copy of this game's imposter indices for Rule 25
+func (csr *CyberSecurityRules) ImposterIndices() []int {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
 
 	// Return a copy to prevent external modification
-	indices := make([]int, len(cyberSecRules.imposterIndices))
-	copy(indices, cyberSecRules.imposterIndices)
+	indices := make([]int, len(csr.imposterIndices))
+	copy(indices, csr.imposterIndices)
 	return indices
 }
 
+// InjectedPositions lists the rune positions in a password that Rules 24 and 25 care about,
+// so the client can highlight them without diffing passwords itself
+type InjectedPositions struct {
+	BlackSquares []int `json:"black_squares"`
+	Imposters    []int `json:"imposters"`
+}
+
+// GetInjectedPositions reports the rune positions of black squares in password and of the
+// imposter characters that are still in place
+func GetInjectedPositions(password string) InjectedPositions {
+	return cyberSecRules.InjectedPositions(password)
+}
+
+// InjectedPositions reports the black squares in password and the imposter characters this
+// game planted that are still in place
+func (csr *CyberSecurityRules) InjectedPositions(password string) InjectedPositions {
+	runes := []rune(password)
+	positions := InjectedPositions{BlackSquares: []int{}, Imposters: []int{}}
+	for i, r := range runes {
+		if r == '⬛' {
+			positions.BlackSquares = append(positions.BlackSquares, i)
+		}
+	}
+
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
+	if !csr.imposterRuleValidated {
+		for i, idx := range csr.imposterIndices {
+			if idx < len(runes) && runes[idx] == csr.imposterOriginalChars[i] {
+				positions.Imposters = append(positions.Imposters, idx)
+			}
+		}
+	}
+	return positions
+}
+
 // ResetCyberSecurityRules resets all cybersecurity rule states
 func ResetCyberSecurityRules() {
-	cyberSecRules.mutex.Lock()
-	defer cyberSecRules.mutex.Unlock()
+	cyberSecRules.Reset()
+}
+
+// Reset starts this game's cybersecurity rules over
+func (csr *CyberSecurityRules) Reset() {
+	csr.mutex.Lock()
+	defer csr.mutex.Unlock()
 
 	// Generate a new random update string on reset
-	cyberSecRules.updateString = generateRandomString(updateStringLength, updateStringChars)
-	cyberSecRules.updateAlertShown = false
-	cyberSecRules.adWatched = false
-	cyberSecRules.blackSquareCount = 0
-	cyberSecRules.blackboxRuleValidated = false
-	cyberSecRules.blackboxInjectionStarted = false
-	cyberSecRules.blackboxMinimumInjected = false
-	cyberSecRules.blackboxLastInjectionTime = time.Time{}
-	cyberSecRules.imposterIndices = []int{}
-	cyberSecRules.imposterOriginalChars = []byte{}
-	cyberSecRules.imposterRuleValidated = false
-	cyberSecRules.lastPasswordLength = 0
-}
-
-// CyberSecurityRuleStatus provides status information for cybersecurity rules
+	csr.updateString = generateRandomString(updateStringLength, updateStringChars)
+	csr.updateAlertShown = false
+	csr.adWatched = false
+	csr.blackSquareCount = 0
+	csr.blackSquaresInjected = 0
+	csr.blackboxRuleValidated = false
+	csr.blackboxInjectionStarted = false
+	csr.blackboxMinimumInjected = false
+	csr.blackboxLastInjectionTime = time.Time{}
+	csr.imposterIndices = []int{}
+	csr.imposterOriginalChars = []rune{}
+	csr.imposterRuleValidated = false
+	csr.lastPasswordLength = 0
+}
+
+// CyberSecurityRuleStatus provides status information for cybersecurity rules. The update and
+// raid strings are answers, so they are never serialized; the alert and ad endpoints reveal them.
 type CyberSecurityRuleStatus struct {
 	UpdateAlertShown          bool      `json:"update_alert_shown"`
-	UpdateString              string    `json:"update_string"`
+	UpdateString              string    `json:"-"`
 	AdWatched                 bool      `json:"ad_watched"`
-	RaidUnlockString          string    `json:"raid_unlock_string"`
+	RaidUnlockString          string    `json:"-"`
 	BlackSquareCount          int       `json:"black_square_count"`
+	BlackSquaresInjected      int       `json:"black_squares_injected"`
 	BlackboxRuleValidated     bool      `json:"blackbox_rule_validated"`
 	BlackboxInjectionStarted  bool      `json:"blackbox_injection_started"`
 	BlackboxMinimumInjected   bool      `json:"blackbox_minimum_injected"`
 	BlackboxLastInjectionTime time.Time `json:"blackbox_last_injection_time"`
 	ImposterIndices           []int     `json:"imposter_indices"`
-	ImposterOriginalChars     []byte    `json:"imposter_original_chars"`
+	ImposterOriginalChars     []string  `json:"imposter_original_chars"`
 	ImposterRuleValidated     bool      `json:"imposter_rule_validated"`
 }
 
 // GetCyberSecurityStatus returns the current status of all cybersecurity rules
 func GetCyberSecurityStatus() CyberSecurityRuleStatus {
-	cyberSecRules.mutex.RLock()
-	defer cyberSecRules.mutex.RUnlock()
+	return cyberSecRules.Status()
+}
+
+// Status returns the state of this game's cybersecurity rules
+func (csr *CyberSecurityRules) Status() CyberSecurityRuleStatus {
+	csr.mutex.RLock()
+	defer csr.mutex.RUnlock()
 
-	// Create a copy of the imposterOriginalChars slice
-	originalChars := make([]byte, len(cyberSecRules.imposterOriginalChars))
-	copy(originalChars, cyberSecRules.imposterOriginalChars)
+	// Render the imposter characters as strings so multibyte runes stay readable
+	originalChars := make([]string, len(csr.imposterOriginalChars))
+	for i, r := range csr.imposterOriginalChars {
+		originalChars[i] = string(r)
+	}
 
 	return CyberSecurityRuleStatus{
-		UpdateAlertShown:          cyberSecRules.updateAlertShown,
-		UpdateString:              cyberSecRules.updateString,
-		AdWatched:                 cyberSecRules.adWatched,
-		RaidUnlockString:          cyberSecRules.raidUnlockString,
-		BlackSquareCount:          cyberSecRules.blackSquareCount,
-		BlackboxRuleValidated:     cyberSecRules.blackboxRuleValidated,
-		BlackboxInjectionStarted:  cyberSecRules.blackboxInjectionStarted,
-		BlackboxMinimumInjected:   cyberSecRules.blackboxMinimumInjected,
-		BlackboxLastInjectionTime: cyberSecRules.blackboxLastInjectionTime,
-		ImposterIndices:           append([]int{}, cyberSecRules.imposterIndices...), // Copy slice
+		UpdateAlertShown:          csr.updateAlertShown,
+		UpdateString:              csr.updateString,
+		AdWatched:                 csr.adWatched,
+		RaidUnlockString:          csr.raidUnlockString,
+		BlackSquareCount:          csr.blackSquareCount,
+		BlackSquaresInjected:      csr.blackSquaresInjected,
+		BlackboxRuleValidated:     csr.blackboxRuleValidated,
+		BlackboxInjectionStarted:  csr.blackboxInjectionStarted,
+		BlackboxMinimumInjected:   csr.blackboxMinimumInjected,
+		BlackboxLastInjectionTime: csr.blackboxLastInjectionTime,
+		ImposterIndices:           append([]int{}, csr.imposterIndices...), // Copy slice
 		ImposterOriginalChars:     originalChars,
-		ImposterRuleValidated:     cyberSecRules.imposterRuleValidated,
+		ImposterRuleValidated:     csr.imposterRuleValidated,
 	}
 }
@@ -0,0 +1,336 @@
+package rules
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable stand-in for now
+type fakeClock struct {
+	current time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.current
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.current = c.current.Add(d)
+}
+
+// useFakeClock swaps now for a fake clock until the test ends
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	clock := &fakeClock{current: time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	previous := now
+	now = clock.now
+	t.Cleanup(func() { now = previous })
+	return clock
+}
+
+// resetCyberSecurity starts the test from a fresh game and restores the defaults afterwards
+func resetCyberSecurity(t *testing.T) {
+	t.Helper()
+	ResetCyberSecurityRules()
+	t.Cleanup(func() {
+		SetBlackSquareMinimum(DefaultBlackSquareMinimum)
+		ResetCyberSecurityRules()
+	})
+}
+
+func TestGenerateBlackSquaresPacing(t *testing.T) {
+	resetCyberSecurity(t)
+	clock := useFakeClock(t)
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		wantSquare bool
+		wantCount  int
+	}{
+		{"first call starts injection", 0, true, 1},
+		{"too soon", 100 * time.Millisecond, false, 1},
+		{"interval reached", blackSquareInterval - 100*time.Millisecond, true, 2},
+		{"just short of the interval", blackSquareInterval - time.Millisecond, false, 2},
+		{"interval reached again", time.Millisecond, true, 3},
+		{"long pause injects only one", 10 * time.Second, true, 4},
+	}
+
+	for _, step := range steps {
+		clock.advance(step.advance)
+		square, count := GenerateBlackSquares()
+		if got := square != ""; got != step.wantSquare {
+			t.Errorf("%s: injected = %v, want %v", step.name, got, step.wantSquare)
+		}
+		if count != step.wantCount {
+			t.Errorf("%s: count = %d, want %d", step.name, count, step.wantCount)
+		}
+	}
+}
+
+func TestRule24RansomwareLifecycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		minimum    int
+		injections int
+		password   string
+		want       bool
+	}{
+		{"nothing injected yet", 2, 0, "Password1!", false},
+		{"below the minimum", 2, 1, "Password1!", false},
+		{"squares still in the password", 2, 2, "Pass⬛word1!", false},
+		{"minimum injected and all deleted", 2, 2, "Password1!", true},
+		{"more than the minimum and all deleted", 2, 4, "Password1!", true},
+		{"configured minimum not reached", 3, 2, "Password1!", false},
+		{"configured minimum reached", 3, 3, "Password1!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCyberSecurity(t)
+			SetBlackSquareMinimum(tt.minimum)
+			clock := useFakeClock(t)
+
+			for i := 0; i < tt.injections; i++ {
+				if square, _ := GenerateBlackSquares(); square == "" {
+					t.Fatalf("injection %d produced no square", i+1)
+				}
+				clock.advance(blackSquareInterval)
+			}
+
+			if got := Rule24RansomwareAttack(tt.password); got != tt.want {
+				t.Errorf("Rule24RansomwareAttack(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule24DeletingBetweenInjections(t *testing.T) {
+	resetCyberSecurity(t)
+	clock := useFakeClock(t)
+
+	// The player deletes each square before the next arrives; the injected total still counts
+	for i := 0; i < DefaultBlackSquareMinimum; i++ {
+		square, _ := GenerateBlackSquares()
+		if Rule24RansomwareAttack("Password1!" + square) {
+			t.Fatalf("rule passed with a square still in the password after %d injection(s)", i+1)
+		}
+		if Rule24RansomwareAttack("Password1!") && i < DefaultBlackSquareMinimum-1 {
+			t.Fatalf("rule passed after only %d injection(s)", i+1)
+		}
+		clock.advance(blackSquareInterval)
+	}
+
+	if !Rule24RansomwareAttack("Password1!") {
+		t.Fatal("rule should pass once the minimum was injected and every square deleted")
+	}
+
+	// A validated rule stays satisfied and no more squares arrive
+	clock.advance(time.Minute)
+	if square, _ := GenerateBlackSquares(); square != "" {
+		t.Error("squares were injected after the rule was validated")
+	}
+	if !Rule24RansomwareAttack("Password1!" + strings.Repeat("⬛", 3)) {
+		t.Error("validated rule should stay satisfied")
+	}
+}
+
+// withoutRunes returns password minus the runes at the given positions
+func withoutRunes(password string, positions []int) string {
+	drop := make(map[int]bool, len(positions))
+	for _, idx := range positions {
+		drop[idx] = true
+	}
+	var kept []rune
+	for i, r := range []rune(password) {
+		if !drop[i] {
+			kept = append(kept, r)
+		}
+	}
+	return string(kept)
+}
+
+func TestRule25MultibytePasswords(t *testing.T) {
+	// Every rune is distinct and lowercase, so removing one can't shift a look-alike (or a
+	// marker letter) into an imposter's position
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"accented letter", "caféqwrty"},
+		{"emoji", "🙂abcdefgh"},
+		{"accent and emoji", "é🙂xkqwzvb"},
+		{"multibyte only", "éñü🙂🔥ø"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCyberSecurity(t)
+			SetRandSource(rand.NewSource(1))
+			runes := []rune(tt.password)
+
+			if Rule25InsiderThreat(tt.password) {
+				t.Fatal("rule passed while every imposter is still in place")
+			}
+
+			indices := GetImposterIndices()
+			if len(indices) != DefaultImposterCount {
+				t.Fatalf("got %d imposters, want %d", len(indices), DefaultImposterCount)
+			}
+			seen := make(map[int]bool)
+			for _, idx := range indices {
+				if idx < 0 || idx >= len(runes) {
+					t.Fatalf("imposter index %d is outside the %d runes of %q", idx, len(runes), tt.password)
+				}
+				if seen[idx] {
+					t.Fatalf("imposter index %d picked twice", idx)
+				}
+				seen[idx] = true
+			}
+
+			positions := GetInjectedPositions(tt.password).Imposters
+			if len(positions) != len(indices) {
+				t.Fatalf("GetInjectedPositions reports %v, want %v", positions, indices)
+			}
+			for i := range positions {
+				if positions[i] != indices[i] {
+					t.Fatalf("GetInjectedPositions reports %v, want %v", positions, indices)
+				}
+			}
+
+			// Imposters are tracked by position, so drop only the last one: the others stay put
+			last := 0
+			for i, idx := range indices {
+				if idx > indices[last] {
+					last = i
+				}
+			}
+			partly := withoutRunes(tt.password, indices[last:last+1]) + noImposterMarker
+			if Rule25InsiderThreat(partly) {
+				t.Fatalf("rule passed with imposters still in %q", partly)
+			}
+
+			cleaned := withoutRunes(tt.password, indices)
+			if Rule25InsiderThreat(cleaned) {
+				t.Errorf("rule passed without the %s marker", noImposterMarker)
+			}
+			if !Rule25InsiderThreat(cleaned + noImposterMarker) {
+				t.Errorf("rule failed after removing every imposter from %q", tt.password)
+			}
+		})
+	}
+}
+
+func TestGenerateBlackSquaresStopsWhenFatal(t *testing.T) {
+	resetCyberSecurity(t)
+	clock := useFakeClock(t)
+
+	// Nobody deletes the squares, so injection runs past the fatal threshold
+	for i := 1; i <= FatalBlackSquareCount+1; i++ {
+		square, count := GenerateBlackSquares()
+		if square == "" || count != i {
+			t.Fatalf("injection %d = %q, %d, want a square and count %d", i, square, count, i)
+		}
+		if got := GetBlackSquareCount(); got != count {
+			t.Fatalf("GetBlackSquareCount() = %d, want the reported %d", got, count)
+		}
+		if fatal := IsBlackSquareCountFatal(count); fatal != (i > FatalBlackSquareCount) {
+			t.Fatalf("IsBlackSquareCountFatal(%d) = %v", count, fatal)
+		}
+		clock.advance(blackSquareInterval)
+	}
+
+	// Once fatal, no more squares are injected and the count holds
+	for i := 0; i < 3; i++ {
+		square, count := GenerateBlackSquares()
+		if square != "" || count != FatalBlackSquareCount+1 {
+			t.Fatalf("after the fatal threshold GenerateBlackSquares() = %q, %d, want no square and count %d", square, count, FatalBlackSquareCount+1)
+		}
+		clock.advance(blackSquareInterval)
+	}
+
+	ResetCyberSecurityRules()
+	if got := GetBlackSquareCount(); got != 0 {
+		t.Errorf("GetBlackSquareCount() after reset = %d, want 0", got)
+	}
+	if square, count := GenerateBlackSquares(); square == "" || count != 1 {
+		t.Errorf("first injection after reset = %q, %d, want a square and count 1", square, count)
+	}
+}
+
+func TestImposterCount(t *testing.T) {
+	t.Cleanup(func() { SetImposterCount(DefaultImposterCount) })
+
+	tests := []struct {
+		name      string
+		count     int
+		password  string
+		wantCount int
+		wantHint  string
+	}{
+		{"one imposter", 1, "abcdefgh", 1, "Delete the 1 imposter letter "},
+		{"default three", 3, "abcdefgh", 3, "Delete the 3 imposter letters "},
+		{"more than the password has", 10, "ab cd e", 5, "Delete the 10 imposter letters "},
+		{"zero falls back to the default", 0, "abcdefgh", DefaultImposterCount, "Delete the 3 imposter letters "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCyberSecurity(t)
+			SetRandSource(rand.NewSource(1))
+			SetImposterCount(tt.count)
+
+			if hint := imposterHint(); !strings.HasPrefix(hint, tt.wantHint) || !strings.Contains(hint, noImposterMarker) {
+				t.Errorf("imposterHint() = %q, want it to start with %q and name %s", hint, tt.wantHint, noImposterMarker)
+			}
+
+			Rule25InsiderThreat(tt.password)
+			indices := GetImposterIndices()
+			if len(indices) != tt.wantCount {
+				t.Fatalf("got %d imposters %v, want %d", len(indices), indices, tt.wantCount)
+			}
+
+			runes := []rune(tt.password)
+			seen := make(map[int]bool)
+			for _, idx := range indices {
+				if runes[idx] == ' ' {
+					t.Errorf("imposter index %d is a space", idx)
+				}
+				if seen[idx] {
+					t.Errorf("imposter index %d picked twice", idx)
+				}
+				seen[idx] = true
+			}
+
+			// Removing every imposter and adding the marker satisfies the rule
+			if !Rule25InsiderThreat(withoutRunes(tt.password, indices) + noImposterMarker) {
+				t.Error("rule not satisfied once every imposter is removed")
+			}
+		})
+	}
+}
+
+func TestRule23RequiresAdWatched(t *testing.T) {
+	resetCyberSecurity(t)
+	unlock := GetRaidUnlockString()
+
+	if Rule23PasswordLock("abc" + unlock) {
+		t.Error("rule passed with the unlock string typed before the ad was watched")
+	}
+
+	SetAdWatched(true)
+	if !Rule23PasswordLock("abc" + unlock) {
+		t.Error("rule failed with the unlock string after the ad was watched")
+	}
+	if Rule23PasswordLock("abc") {
+		t.Error("rule passed without the unlock string")
+	}
+
+	// A new game locks the textbox again
+	ResetCyberSecurityRules()
+	if IsAdWatched() || Rule23PasswordLock(unlock) {
+		t.Error("rule still unlocked after a reset")
+	}
+}
@@ -0,0 +1,35 @@
+package rules
+
+import "time"
+
+// DebugAnswers returns the current solution of every dynamic rule. It exists for local
+// development only and is served solely when PASSGAME_DEBUG=1. The update string is per game
+// and is added by the caller.
+func DebugAnswers() map[string]string {
+	answers := make(map[string]string)
+
+	if solution := captchaStore.Get(GetCurrentCaptchaID(), false); len(solution) > 0 {
+		answers["captcha"] = encodeCaptchaSolution(solution)
+	}
+
+	answers["qr_word"] = GetCurrentQRWord()
+
+	_, hexCode := GetCurrentColor()
+	answers["color_hex"] = hexCode
+
+	_, constant := GetCurrentMathConstant()
+	answers["constant_digits"] = leadingDigits(constant, 3)
+
+	_, bestMove := GetCurrentChessPosition()
+	answers["chess_move"] = bestMove
+
+	if answer, err := GetTodaysAnswer(); err == nil {
+		answers["wordle"] = answer
+	}
+
+	answers["raid_unlock_string"] = GetRaidUnlockString()
+	answers["no_imposter_marker"] = noImposterMarker
+	answers["moon_phase"] = CurrentMoonPhase(time.Now())
+
+	return answers
+}
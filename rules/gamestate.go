@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"fmt"
+	"log"
+
+	database "passgame/Database"
+
+	"github.com/corentings/chess/v2"
+)
+
+// Keys of the "current challenge" values saved in the game_state table
+const (
+	gameStateQRWord        = "qr_word"
+	gameStateConstantName  = "constant_name"
+	gameStateConstantValue = "constant_value"
+	gameStateColorName     = "color_name"
+	gameStateColorHex      = "color_hex"
+	gameStateChessFEN      = "chess_fen"
+	gameStateChessMove     = "chess_best_move"
+	gameStateCaptchaID     = "captcha_id"
+	gameStateCaptchaDigits = "captcha_digits"
+	gameStateWordleDate    = "wordle_date"
+	gameStateWordleAnswer  = "wordle_answer"
+)
+
+// InitGameStateTable creates the game_state table that keeps the current challenge values
+// across restarts
+func InitGameStateTable() error {
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection not available")
+	}
+
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS game_state (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create game_state table: %v", err)
+	}
+	return nil
+}
+
+// snapshotGameState collects the current challenge values, skipping ones that aren't set
+func snapshotGameState() map[string]string {
+	state := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			state[key] = value
+		}
+	}
+
+	set(gameStateQRWord, GetCurrentQRWord())
+
+	name, value := GetCurrentMathConstant()
+	if name != "" && value != "" {
+		set(gameStateConstantName, name)
+		set(gameStateConstantValue, value)
+	}
+
+	colorName, colorHex := GetCurrentColor()
+	if colorName != "" && colorHex != "" {
+		set(gameStateColorName, colorName)
+		set(gameStateColorHex, colorHex)
+	}
+
+	game, bestMove := GetCurrentChessPosition()
+	if game != nil && bestMove != "" {
+		set(gameStateChessFEN, game.FEN())
+		set(gameStateChessMove, bestMove)
+	}
+
+	if captchaID := GetCurrentCaptchaID(); captchaID != "" {
+		if solution := captchaStore.Get(captchaID, false); len(solution) > 0 {
+			set(gameStateCaptchaID, captchaID)
+			set(gameStateCaptchaDigits, encodeCaptchaSolution(solution))
+		}
+	}
+
+	cache.mu.RLock()
+	if cache.Answer != "" && wordleNow().Before(cache.RefreshAt) {
+		set(gameStateWordleDate, cache.Date)
+		set(gameStateWordleAnswer, cache.Answer)
+	}
+	cache.mu.RUnlock()
+
+	return state
+}
+
+// SaveGameState stores the current QR word, math constant, color, chess position, captcha and
+// Wordle answer so a restart doesn't change the challenges mid-game. The update string belongs
+// to each game's session and is not saved.
+func SaveGameState() error {
+	// Fixed test values must never overwrite the state of a real deployment
+	if testMode {
+		return nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection not available")
+	}
+
+	state := snapshotGameState()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start game state save: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Values that are no longer set shouldn't be restored from an older save
+	if _, err := tx.Exec("DELETE FROM game_state"); err != nil {
+		return fmt.Errorf("failed to clear game state: %v", err)
+	}
+	for key, value := range state {
+		if _, err := tx.Exec("INSERT INTO game_state (key, value) VALUES (?, ?)", key, value); err != nil {
+			return fmt.Errorf("failed to save game state %s: %v", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit game state: %v", err)
+	}
+
+	log.Printf("💾 Saved %d game state values", len(state))
+	return nil
+}
+
+// LoadGameState restores the values written by SaveGameState and reports how many challenges
+// were restored. Values that are invalid or out of date, like a previous day's Wordle answer,
+// are skipped so the regular generators pick new ones.
+func LoadGameState() (int, error) {
+	if testMode {
+		return 0, nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection not available")
+	}
+
+	rows, err := db.Query("SELECT key, value FROM game_state")
+	if err != nil {
+		return 0, fmt.Errorf("failed to load game state: %v", err)
+	}
+	defer rows.Close()
+
+	state := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return 0, fmt.Errorf("failed to scan game state: %v", err)
+		}
+		state[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating game state: %v", err)
+	}
+
+	return restoreGameState(state), nil
+}
+
+// restoreGameState applies saved values and returns how many challenges were restored
+func restoreGameState(state map[string]string) int {
+	restored := 0
+
+	if word := NormalizeQRWord(state[gameStateQRWord]); word != "" {
+		if qrImageB64, err := GenerateQRCode(word); err != nil {
+			log.Printf("Warning: Could not restore QR code: %v", err)
+		} else {
+			qrMutex.Lock()
+			currentQRWord = word
+			currentQRImageB64 = qrImageB64
+			qrMutex.Unlock()
+			restored++
+		}
+	}
+
+	if name, value := state[gameStateConstantName], state[gameStateConstantValue]; name != "" && value != "" {
+		constantsMutex.Lock()
+		currentConstantName = name
+		currentConstant = value
+		constantsMutex.Unlock()
+		restored++
+	}
+
+	if name, hexCode := state[gameStateColorName], state[gameStateColorHex]; name != "" && hexCode != "" {
+		if _, _, _, err := HexToRGB(hexCode); err != nil {
+			log.Printf("Warning: Could not restore color: %v", err)
+		} else {
+			colorsMutex.Lock()
+			currentColorName = name
+			currentColor = hexCode
+			colorsMutex.Unlock()
+			restored++
+		}
+	}
+
+	if fenStr, bestMove := state[gameStateChessFEN], state[gameStateChessMove]; fenStr != "" && bestMove != "" {
+		if fen, err := chess.FEN(fenStr); err != nil {
+			log.Printf("Warning: Could not restore chess position: %v", err)
+		} else {
+			chessMutex.Lock()
+			currentChessGame = chess.NewGame(fen)
+			currentBestMove = bestMove
+			chessMutex.Unlock()
+			restored++
+		}
+	}
+
+	if captchaID, encoded := state[gameStateCaptchaID], state[gameStateCaptchaDigits]; captchaID != "" && encoded != "" {
+		// A captcha saved under the other character set is skipped and a new one is kept
+		if solution := decodeCaptchaSolution(encoded); len(solution) > 0 {
+			captchaStore.Set(captchaID, solution)
+			captchaMutex.Lock()
+			currentCaptchaID = captchaID
+			captchaMutex.Unlock()
+			restored++
+		}
+	}
+
+	// Only today's Wordle answer is still valid
+	if date, answer := state[gameStateWordleDate], state[gameStateWordleAnswer]; date != "" && answer != "" {
+		cache.mu.Lock()
+		today, refreshAt := wordleDate(wordleNow(), wordleLocation)
+		if date == today {
+			cache.Answer = answer
+			cache.Date = date
+			cache.RefreshAt = refreshAt
+			restored++
+		}
+		cache.mu.Unlock()
+	}
+
+	return restored
+}
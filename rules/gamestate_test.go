@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+// useGameStateTable leaves test mode with a fixed Wordle clock and an empty game_state table,
+// putting the challenges that were current back afterwards
+func useGameStateTable(t *testing.T) *fakeClock {
+	t.Helper()
+	if err := InitGameStateTable(); err != nil {
+		t.Fatal(err)
+	}
+	clearGameState := func() {
+		if _, err := database.GetDB().Exec("DELETE FROM game_state"); err != nil {
+			t.Fatalf("failed to clear game_state: %v", err)
+		}
+	}
+	clearGameState()
+
+	clock := &fakeClock{current: time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)}
+	previous := snapshotGameState()
+	previousNow, previousLocation := wordleNow, wordleLocation
+	wordleNow, wordleLocation = clock.now, time.UTC
+	testMode = false
+
+	t.Cleanup(func() {
+		testMode = true
+		wordleNow, wordleLocation = previousNow, previousLocation
+		resetWordleCache()
+		restoreGameState(previous)
+		clearGameState()
+	})
+	return clock
+}
+
+// savedGameState is a full set of challenge values for 2025-03-10
+func savedGameState() map[string]string {
+	return map[string]string{
+		gameStateQRWord:        "penguin",
+		gameStateConstantName:  "Pi",
+		gameStateConstantValue: "3.14159",
+		gameStateColorName:     "Teal",
+		gameStateColorHex:      "#008080",
+		gameStateChessFEN:      "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		gameStateChessMove:     "e7e5",
+		gameStateCaptchaID:     "saved-captcha",
+		gameStateCaptchaDigits: "482913",
+		gameStateWordleDate:    "2025-03-10",
+		gameStateWordleAnswer:  "CRANE",
+	}
+}
+
+func TestSaveLoadGameStateRoundTrip(t *testing.T) {
+	useGameStateTable(t)
+
+	want := savedGameState()
+	if restored := restoreGameState(want); restored != 6 {
+		t.Fatalf("restoreGameState() restored %d challenges, want 6", restored)
+	}
+	if err := SaveGameState(); err != nil {
+		t.Fatalf("SaveGameState() error = %v", err)
+	}
+
+	// A restart starts from different challenges
+	other := map[string]string{
+		gameStateQRWord:        "walrus",
+		gameStateConstantName:  "e",
+		gameStateConstantValue: "2.71828",
+		gameStateColorName:     "Coral",
+		gameStateColorHex:      "#FF7F50",
+		gameStateChessFEN:      chessPuzzles[0],
+		gameStateChessMove:     "e2e4",
+		gameStateCaptchaID:     "new-captcha",
+		gameStateCaptchaDigits: "111111",
+		gameStateWordleDate:    "2025-03-10",
+		gameStateWordleAnswer:  "SLATE",
+	}
+	restoreGameState(other)
+
+	restored, err := LoadGameState()
+	if err != nil {
+		t.Fatalf("LoadGameState() error = %v", err)
+	}
+	if restored != 6 {
+		t.Errorf("LoadGameState() restored %d challenges, want 6", restored)
+	}
+	if got := snapshotGameState(); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after loading = %v, want %v", got, want)
+	}
+	if GetCurrentQRImageB64() == "" {
+		t.Error("the restored QR word has no image")
+	}
+}
+
+func TestLoadGameStateSkipsStaleValues(t *testing.T) {
+	clock := useGameStateTable(t)
+
+	if restored := restoreGameState(savedGameState()); restored != 6 {
+		t.Fatalf("restoreGameState() restored %d challenges, want 6", restored)
+	}
+	if err := SaveGameState(); err != nil {
+		t.Fatalf("SaveGameState() error = %v", err)
+	}
+
+	// The next day the saved Wordle answer is out of date
+	resetWordleCache()
+	clock.advance(24 * time.Hour)
+	restored, err := LoadGameState()
+	if err != nil {
+		t.Fatalf("LoadGameState() error = %v", err)
+	}
+	if restored != 5 {
+		t.Errorf("LoadGameState() restored %d challenges, want 5 without the Wordle answer", restored)
+	}
+	cache.mu.RLock()
+	answer := cache.Answer
+	cache.mu.RUnlock()
+	if answer != "" {
+		t.Errorf("yesterday's Wordle answer %q was restored", answer)
+	}
+
+	// Only the QR word and constant are still usable
+	invalid := savedGameState()
+	invalid[gameStateColorHex] = "not-a-color"
+	invalid[gameStateChessFEN] = "not a position"
+	invalid[gameStateCaptchaDigits] = "12ab"
+	if restored := restoreGameState(invalid); restored != 2 {
+		t.Errorf("restoreGameState() with invalid values restored %d challenges, want 2", restored)
+	}
+}
+
+func TestSaveGameStateSkippedInTestMode(t *testing.T) {
+	useGameStateTable(t)
+	restoreGameState(savedGameState())
+	testMode = true
+	defer func() { testMode = false }()
+
+	if err := SaveGameState(); err != nil {
+		t.Fatalf("SaveGameState() error = %v", err)
+	}
+	var count int
+	if err := database.GetDB().QueryRow("SELECT COUNT(*) FROM game_state").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("test mode saved %d game state values, want none", count)
+	}
+}
@@ -0,0 +1,148 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLocale is the language day and month names are shown in unless a player's locale is accepted
+const DefaultLocale = "en"
+
+// weekdayNames and monthNames translate Rules 7 and 10, indexed by time.Weekday and time.Month-1
+var (
+	weekdayNames = map[string][7]string{
+		"en": {"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		"es": {"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		"fr": {"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		"de": {"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		"it": {"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		"pt": {"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		"nl": {"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+	}
+	monthNames = map[string][12]string{
+		"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		"fr": {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		"it": {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		"pt": {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		"nl": {"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+	}
+)
+
+// acceptedLocales are the languages Rules 7 and 10 accept; English is always among them
+var (
+	acceptedLocales      = []string{DefaultLocale}
+	acceptedLocalesMutex sync.RWMutex
+)
+
+// SetAcceptedLocales selects which languages' day and month names Rules 7 and 10 accept, e.g.
+// ["en", "es"]. English is always accepted. Unsupported languages are skipped and reported.
+func SetAcceptedLocales(locales []string) error {
+	accepted := []string{DefaultLocale}
+	var unsupported []string
+	for _, locale := range locales {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if _, ok := weekdayNames[locale]; !ok {
+			unsupported = append(unsupported, locale)
+			continue
+		}
+		if !containsString(accepted, locale) {
+			accepted = append(accepted, locale)
+		}
+	}
+
+	acceptedLocalesMutex.Lock()
+	acceptedLocales = accepted
+	acceptedLocalesMutex.Unlock()
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("unsupported day/month locales %v", unsupported)
+	}
+	return nil
+}
+
+// getAcceptedLocales returns a copy of the accepted languages
+func getAcceptedLocales() []string {
+	acceptedLocalesMutex.RLock()
+	defer acceptedLocalesMutex.RUnlock()
+	return append([]string{}, acceptedLocales...)
+}
+
+// ResolveLocale picks the first accepted language from an Accept-Language style list such as
+// "es-MX,es;q=0.9,en;q=0.8", falling back to DefaultLocale
+func ResolveLocale(acceptLanguage string) string {
+	accepted := getAcceptedLocales()
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if containsString(accepted, primary) {
+			return primary
+		}
+	}
+	return DefaultLocale
+}
+
+// LocalizedWeekday returns the name of t's weekday in locale, English if it isn't supported
+func LocalizedWeekday(t time.Time, locale string) string {
+	names, ok := weekdayNames[locale]
+	if !ok {
+		names = weekdayNames[DefaultLocale]
+	}
+	return names[t.Weekday()]
+}
+
+// LocalizedMonth returns the name of t's month in locale, English if it isn't supported
+func LocalizedMonth(t time.Time, locale string) string {
+	names, ok := monthNames[locale]
+	if !ok {
+		names = monthNames[DefaultLocale]
+	}
+	return names[t.Month()-1]
+}
+
+// containsLocalizedName reports whether password contains name(t, locale) in any accepted language
+func containsLocalizedName(password string, t time.Time, name func(time.Time, string) string, opts MatchOptions) bool {
+	for _, locale := range getAcceptedLocales() {
+		if matches(password, name(t, locale), opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayHint and monthHint describe Rules 7 and 10 in the player's language
+func weekdayHint(locale string) string {
+	return "Include today's day of the week: " + LocalizedWeekday(time.Now(), locale)
+}
+
+func monthHint(locale string) string {
+	return "Include the current month: " + LocalizedMonth(time.Now(), locale)
+}
+
+// LocalizeRuleSet rewrites the hints of Rules 7 and 10 for locale. Locales that aren't accepted
+// get English hints, since only accepted languages satisfy the rules.
+func LocalizeRuleSet(rs *RuleSet, locale string) {
+	if !containsString(getAcceptedLocales(), locale) {
+		locale = DefaultLocale
+	}
+	for i := range rs.Rules {
+		switch rs.Rules[i].ID {
+		case 7:
+			rs.Rules[i].Hint = weekdayHint(locale)
+		case 10:
+			rs.Rules[i].Hint = monthHint(locale)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useAcceptedLocales accepts the locales for the test and goes back to English only afterwards
+func useAcceptedLocales(t *testing.T, locales ...string) {
+	t.Helper()
+	if err := SetAcceptedLocales(locales); err != nil {
+		t.Fatalf("SetAcceptedLocales(%v) error = %v", locales, err)
+	}
+	t.Cleanup(func() { SetAcceptedLocales(nil) })
+}
+
+func TestLocalizedNames(t *testing.T) {
+	monday := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale  string
+		weekday string
+		month   string
+	}{
+		{"en", "Monday", "March"},
+		{"es", "lunes", "marzo"},
+		{"fr", "lundi", "mars"},
+		{"de", "Montag", "März"},
+		{"pt", "segunda-feira", "março"},
+		{"xx", "Monday", "March"},
+		{"", "Monday", "March"},
+	}
+	for _, tt := range tests {
+		if got := LocalizedWeekday(monday, tt.locale); got != tt.weekday {
+			t.Errorf("LocalizedWeekday(%q) = %q, want %q", tt.locale, got, tt.weekday)
+		}
+		if got := LocalizedMonth(monday, tt.locale); got != tt.month {
+			t.Errorf("LocalizedMonth(%q) = %q, want %q", tt.locale, got, tt.month)
+		}
+	}
+}
+
+func TestSetAcceptedLocales(t *testing.T) {
+	t.Cleanup(func() { SetAcceptedLocales(nil) })
+
+	if err := SetAcceptedLocales([]string{" ES ", "fr", "es"}); err != nil {
+		t.Fatalf("SetAcceptedLocales() error = %v", err)
+	}
+	if got := strings.Join(getAcceptedLocales(), ","); got != "en,es,fr" {
+		t.Errorf("accepted locales = %s, want en,es,fr", got)
+	}
+
+	// Unsupported languages are reported, the rest still apply
+	err := SetAcceptedLocales([]string{"de", "klingon"})
+	if err == nil || !strings.Contains(err.Error(), "klingon") {
+		t.Errorf("SetAcceptedLocales() error = %v, want klingon reported", err)
+	}
+	if got := strings.Join(getAcceptedLocales(), ","); got != "en,de" {
+		t.Errorf("accepted locales = %s, want en,de", got)
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	useAcceptedLocales(t, "es", "fr")
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"FR-ca", "fr"},
+		{"de-DE,fr;q=0.5", "fr"},
+		{"de-DE,it;q=0.5", DefaultLocale},
+		{"", DefaultLocale},
+		{"en-GB,es;q=0.9", "en"},
+	}
+	for _, tt := range tests {
+		if got := ResolveLocale(tt.acceptLanguage); got != tt.want {
+			t.Errorf("ResolveLocale(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizedDayAndMonthRules(t *testing.T) {
+	monday := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	weekday := func(password string) bool {
+		return containsLocalizedName(password, monday, LocalizedWeekday, matchOptionsFor(7))
+	}
+	month := func(password string) bool {
+		return containsLocalizedName(password, monday, LocalizedMonth, matchOptionsFor(10))
+	}
+
+	// English only by default
+	if !weekday("xMondayx") || !month("xMarchx") {
+		t.Error("English names are rejected")
+	}
+	if weekday("xlunesx") || month("xmarzox") {
+		t.Error("Spanish names are accepted before Spanish is enabled")
+	}
+
+	useAcceptedLocales(t, "es")
+	for _, password := range []string{"xlunesx", "LUNES", "xMondayx"} {
+		if !weekday(password) {
+			t.Errorf("weekday rule rejected %q with Spanish accepted", password)
+		}
+	}
+	for _, password := range []string{"xmarzox", "Marzo", "xMarchx"} {
+		if !month(password) {
+			t.Errorf("month rule rejected %q with Spanish accepted", password)
+		}
+	}
+	// Languages that aren't accepted still don't count
+	if weekday("xlundix") || month("xmarsx") {
+		t.Error("French names are accepted with only Spanish enabled")
+	}
+}
+
+func TestLocalizeRuleSetHints(t *testing.T) {
+	useAcceptedLocales(t, "es")
+	now := time.Now()
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"es", "es"},
+		{"en", "en"},
+		// French isn't accepted, so its names wouldn't pass; the hints stay English
+		{"fr", "en"},
+	}
+	for _, tt := range tests {
+		ruleSet := NewRuleSet("hard")
+		LocalizeRuleSet(ruleSet, tt.locale)
+
+		for _, rule := range ruleSet.Rules {
+			var want string
+			switch rule.ID {
+			case 7:
+				want = LocalizedWeekday(now, tt.want)
+			case 10:
+				want = LocalizedMonth(now, tt.want)
+			default:
+				continue
+			}
+			if !strings.HasSuffix(rule.Hint, want) {
+				t.Errorf("locale %s: rule %d hint = %q, want it to end with %q", tt.locale, rule.ID, rule.Hint, want)
+			}
+			if !rule.Validator(want) {
+				t.Errorf("locale %s: rule %d rejects its own hint %q", tt.locale, rule.ID, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	database "passgame/Database"
+)
+
+// testDataFiles are the files the rules read relative to the repository root
+var testDataFiles = []string{
+	"config/difficulties.json",
+	"rules/assignments.json",
+	"rules/words",
+}
+
+// TestMain runs the tests in test mode, so no challenge reaches an external API, from a
+// scratch copy of the data files that tests may rewrite, next to a fresh Database/user.db
+func TestMain(m *testing.M) {
+	testMode = true
+
+	dir, err := os.MkdirTemp("", "passgame-rules-")
+	if err != nil {
+		log.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+		for _, path := range testDataFiles {
+			if err := copyTestData(filepath.Join("..", path), filepath.Join(dir, path)); err != nil {
+				log.Printf("Failed to copy %s: %v", path, err)
+				return 1
+			}
+		}
+		if err := os.Chdir(dir); err != nil {
+			log.Printf("Failed to enter test directory: %v", err)
+			return 1
+		}
+		if err := os.Mkdir("Database", 0755); err != nil {
+			log.Printf("Failed to create the database directory: %v", err)
+			return 1
+		}
+		if err := database.InitDB(); err != nil {
+			log.Printf("Failed to initialize the test database: %v", err)
+			return 1
+		}
+		defer database.CloseDB()
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// copyTestData copies a file or directory tree from src to dst
+func copyTestData(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.CopyFS(dst, os.DirFS(src))
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
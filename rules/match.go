@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchOptions controls how a rule compares the password against the text it looks for
+type MatchOptions struct {
+	CaseInsensitive bool `json:"case_insensitive"` // compare without regard to letter case
+	Trim            bool `json:"trim"`             // drop leading/trailing whitespace from the target
+	IgnoreSpaces    bool `json:"ignore_spaces"`    // strip all whitespace from both sides before comparing
+}
+
+// ruleMatchOptions is the matching policy for every rule that searches the password for a
+// target string. Each entry is explicit so the behaviour of a rule doesn't depend on which
+// strings helper its validator happened to use.
+var ruleMatchOptions = map[int]MatchOptions{
+	// 7 and 10: day and month names are words, "monday" and "MONDAY" are the same day. Every
+	// accepted language is tried, see SetAcceptedLocales.
+	7:  {CaseInsensitive: true},
+	10: {CaseInsensitive: true},
+	// 8: sponsor names come from config/sponsors.json, so stray whitespace there is ignored
+	8: {CaseInsensitive: true, Trim: true},
+	// 13: the target is digits only, case and spacing can't apply
+	13: {},
+	// 14 and 23: random codes shown in a popup; players retype them, so case shouldn't trip them up
+	14: {CaseInsensitive: true, Trim: true},
+	23: {CaseInsensitive: true, Trim: true},
+	// 16: Wordle answers are shown uppercase but typed any way
+	16: {CaseInsensitive: true, Trim: true},
+	// 17: the QR word is already normalized to lowercase letters
+	17: {CaseInsensitive: true},
+	// 18: hex digits are case-insensitive by definition
+	18: {CaseInsensitive: true},
+	// 19: UCI moves are lowercase, but "E2E4" is clearly the same move
+	19: {CaseInsensitive: true},
+	// 22: the space is part of the joke, "pdffile" doesn't count
+	22: {CaseInsensitive: true},
+	// 25: the marker is a word, not a code, so any casing works
+	25: {CaseInsensitive: true},
+	// 26: moon phases are two words, "WaxingGibbous" counts as well as "waxing gibbous"
+	26: {CaseInsensitive: true, IgnoreSpaces: true},
+	// 27: country names, so "france" and "FRANCE" both count
+	27: {CaseInsensitive: true},
+}
+
+// matchOptionsFor returns the matching policy for a rule, the zero value (exact match) if none is set
+func matchOptionsFor(id int) MatchOptions {
+	return ruleMatchOptions[id]
+}
+
+// matches reports whether password contains target under opts. An empty target never matches.
+func matches(password, target string, opts MatchOptions) bool {
+	if opts.Trim {
+		target = strings.TrimSpace(target)
+	}
+	if opts.IgnoreSpaces {
+		password = stripSpaces(password)
+		target = stripSpaces(target)
+	}
+	if target == "" {
+		return false
+	}
+	if opts.CaseInsensitive {
+		password = strings.ToLower(password)
+		target = strings.ToLower(target)
+	}
+	return strings.Contains(password, target)
+}
+
+// stripSpaces removes every whitespace rune from s
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		target   string
+		opts     MatchOptions
+		want     bool
+	}{
+		{"exact", "xxABCxx", "ABC", MatchOptions{}, true},
+		{"exact is case-sensitive", "xxabcxx", "ABC", MatchOptions{}, false},
+		{"case-insensitive", "xxabcxx", "ABC", MatchOptions{CaseInsensitive: true}, true},
+		{"untrimmed target", "xxABCxx", " ABC ", MatchOptions{}, false},
+		{"trimmed target", "xxABCxx", " ABC ", MatchOptions{Trim: true}, true},
+		{"spaces kept", "waxinggibbous", "waxing gibbous", MatchOptions{}, false},
+		{"spaces ignored in the target", "waxinggibbous", "waxing gibbous", MatchOptions{IgnoreSpaces: true}, true},
+		{"spaces ignored in the password", "waxing  gib bous", "waxing gibbous", MatchOptions{IgnoreSpaces: true}, true},
+		{"empty target", "anything", "", MatchOptions{}, false},
+		{"blank target after trimming", "any thing", "  ", MatchOptions{Trim: true}, false},
+		{"blank target without spaces", "any thing", " ", MatchOptions{IgnoreSpaces: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(tt.password, tt.target, tt.opts); got != tt.want {
+				t.Errorf("matches(%q, %q, %+v) = %v, want %v", tt.password, tt.target, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatchOptionsExposed(t *testing.T) {
+	for id, opts := range ruleMatchOptions {
+		rule := GetRuleByID(id)
+		if rule == nil {
+			t.Errorf("match options are set for unknown rule %d", id)
+			continue
+		}
+		if rule.MatchOptions != opts {
+			t.Errorf("rule %d MatchOptions = %+v, want %+v", id, rule.MatchOptions, opts)
+		}
+	}
+}
+
+func TestRuleMatchingBehaviour(t *testing.T) {
+	resetCyberSecurity(t)
+	SetAdWatched(true)
+	useQRWord(t, TestQRWord)
+	useColor(t, TestColorName, TestColorHex)
+	if err := RefreshMathConstant(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateNewChessPosition(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	weekday := func(password string) bool {
+		return containsLocalizedName(password, monday, LocalizedWeekday, matchOptionsFor(7))
+	}
+	month := func(password string) bool {
+		return containsLocalizedName(password, monday, LocalizedMonth, matchOptionsFor(10))
+	}
+	moonPhase := func(password string) bool {
+		return matches(password, "Waxing Gibbous", matchOptionsFor(moonPhaseRuleID))
+	}
+	update := GetUpdateString()
+	raid := GetRaidUnlockString()
+
+	tests := []struct {
+		rule      int
+		validator func(string) bool
+		accepted  []string
+		rejected  []string
+	}{
+		{7, weekday, []string{"Monday", "MONDAY", "monday"}, []string{"Mon day"}},
+		{10, month, []string{"March", "MARCH", "march"}, []string{"Mar ch"}},
+		{13, ValidateMathConstant, []string{"x314x"}, []string{"3 14"}},
+		{14, Rule14UpdateAlert, []string{update, strings.ToLower(update)}, []string{update[:len(update)-1]}},
+		{16, ValidateWordleAnswer, []string{TestWordleAnswer, "slate", "Slate"}, []string{"sla te"}},
+		{17, ValidateQRCodeWord, []string{TestQRWord, "TEST", "Test"}, []string{"te st"}},
+		{18, ValidateHexColor, []string{"ff0000", "FF0000", "#Ff0000"}, []string{"ff 0000"}},
+		{19, ValidateChessMove, []string{TestChessMove, "E2E4"}, []string{"e2 e4"}},
+		{22, Rule22PDFFile, []string{"pdf file", "PDF File"}, []string{"pdffile"}},
+		{23, Rule23PasswordLock, []string{raid, strings.ToLower(raid)}, []string{raid[:len(raid)-1]}},
+		{26, moonPhase, []string{"Waxing Gibbous", "waxinggibbous", "WAXING gibbous"}, []string{"Waxing"}},
+		{27, ContainsCountryName, []string{"France", "fRANCE"}, []string{"Fran ce"}},
+	}
+
+	for _, tt := range tests {
+		for _, password := range tt.accepted {
+			if !tt.validator(password) {
+				t.Errorf("rule %d rejected %q", tt.rule, password)
+			}
+		}
+		for _, password := range tt.rejected {
+			if tt.validator(password) {
+				t.Errorf("rule %d accepted %q", tt.rule, password)
+			}
+		}
+	}
+}
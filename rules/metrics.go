@@ -0,0 +1,160 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counters exposed at /metrics in Prometheus text format
+var (
+	metricsMutex       sync.Mutex
+	validationsTotal   int64
+	ruleSatisfiedTotal = make(map[int]int64)
+	externalAPITotal   = make(map[string]map[string]int64) // source -> result -> count
+	externalAPILast    = make(map[string]ExternalAPIHealth)
+
+	// Per-rule validator timing, only collected while validatorTimingEnabled is set
+	validatorTimingEnabled atomic.Bool
+	validatorSeconds       = make(map[int]float64)
+	validatorCalls         = make(map[int]int64)
+)
+
+// SetValidatorTiming turns per-rule validator timing on or off
+func SetValidatorTiming(enabled bool) {
+	validatorTimingEnabled.Store(enabled)
+}
+
+// runValidator runs a rule's validator, timing it when validator timing is enabled
+func runValidator(rule Rule, password string) bool {
+	if !validatorTimingEnabled.Load() {
+		return rule.Validator(password)
+	}
+
+	start := time.Now()
+	satisfied := rule.Validator(password)
+	elapsed := time.Since(start)
+
+	metricsMutex.Lock()
+	validatorSeconds[rule.ID] += elapsed.Seconds()
+	validatorCalls[rule.ID]++
+	metricsMutex.Unlock()
+
+	return satisfied
+}
+
+// RecordValidation counts a password validation, every rule it satisfied and every rule it
+// showed the player
+func RecordValidation(rs *RuleSet) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	validationsTotal++
+	for _, rule := range rs.Rules {
+		if rule.IsSatisfied {
+			ruleSatisfiedTotal[rule.ID]++
+		}
+		if rule.IsVisible {
+			recordRuleShown(rule)
+		}
+	}
+}
+
+// recordExternalAPICall counts a call to an external API by source and outcome
+func recordExternalAPICall(source string, err error) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	if externalAPITotal[source] == nil {
+		externalAPITotal[source] = make(map[string]int64)
+	}
+	externalAPITotal[source][result]++
+
+	health := externalAPILast[source]
+	if err != nil {
+		health.LastFailure = time.Now()
+		health.LastError = err.Error()
+	} else {
+		health.LastSuccess = time.Now()
+	}
+	externalAPILast[source] = health
+}
+
+// ExternalAPIHealth is when an external API last answered and last failed
+type ExternalAPIHealth struct {
+	LastSuccess time.Time `json:"last_success"`
+	LastFailure time.Time `json:"last_failure"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// ExternalAPIStatus returns the health of every external API called since startup, keyed by
+// source (word_api, wordle, stockfish)
+func ExternalAPIStatus() map[string]ExternalAPIHealth {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	status := make(map[string]ExternalAPIHealth, len(externalAPILast))
+	for source, health := range externalAPILast {
+		status[source] = health
+	}
+	return status
+}
+
+// WriteMetrics writes the rule counters in Prometheus text exposition format
+func WriteMetrics(w io.Writer) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	fmt.Fprintln(w, "# HELP passgame_validations_total Total password validations.")
+	fmt.Fprintln(w, "# TYPE passgame_validations_total counter")
+	fmt.Fprintf(w, "passgame_validations_total %d\n", validationsTotal)
+
+	ruleIDs := make([]int, 0, len(ruleSatisfiedTotal))
+	for id := range ruleSatisfiedTotal {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Ints(ruleIDs)
+
+	fmt.Fprintln(w, "# HELP passgame_rule_satisfied_total Validations in which each rule was satisfied.")
+	fmt.Fprintln(w, "# TYPE passgame_rule_satisfied_total counter")
+	for _, id := range ruleIDs {
+		fmt.Fprintf(w, "passgame_rule_satisfied_total{rule=\"%d\"} %d\n", id, ruleSatisfiedTotal[id])
+	}
+
+	if len(validatorCalls) > 0 {
+		timedIDs := make([]int, 0, len(validatorCalls))
+		for id := range validatorCalls {
+			timedIDs = append(timedIDs, id)
+		}
+		sort.Ints(timedIDs)
+
+		fmt.Fprintln(w, "# HELP passgame_rule_validator_seconds Time spent in each rule's validator.")
+		fmt.Fprintln(w, "# TYPE passgame_rule_validator_seconds summary")
+		for _, id := range timedIDs {
+			fmt.Fprintf(w, "passgame_rule_validator_seconds_sum{rule=\"%d\"} %g\n", id, validatorSeconds[id])
+			fmt.Fprintf(w, "passgame_rule_validator_seconds_count{rule=\"%d\"} %d\n", id, validatorCalls[id])
+		}
+	}
+
+	sources := make([]string, 0, len(externalAPITotal))
+	for source := range externalAPITotal {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	fmt.Fprintln(w, "# HELP passgame_external_api_requests_total External API calls by source and result.")
+	fmt.Fprintln(w, "# TYPE passgame_external_api_requests_total counter")
+	for _, source := range sources {
+		for _, result := range []string{"success", "failure"} {
+			fmt.Fprintf(w, "passgame_external_api_requests_total{source=%q,result=%q} %d\n",
+				source, result, externalAPITotal[source][result])
+		}
+	}
+}
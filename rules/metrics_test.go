@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+func TestWriteMetricsExternalAPICounters(t *testing.T) {
+	const source = "test_api"
+	recordExternalAPICall(source, nil)
+	recordExternalAPICall(source, nil)
+	recordExternalAPICall(source, errors.New("timeout"))
+
+	var out strings.Builder
+	WriteMetrics(&out)
+
+	for _, want := range []string{
+		"# TYPE passgame_external_api_requests_total counter",
+		`passgame_external_api_requests_total{source="test_api",result="success"} 2`,
+		`passgame_external_api_requests_total{source="test_api",result="failure"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+
+	health := ExternalAPIStatus()[source]
+	if health.LastSuccess.IsZero() || health.LastFailure.IsZero() || health.LastError != "timeout" {
+		t.Errorf("ExternalAPIStatus()[%s] = %+v, want both times and the last error", source, health)
+	}
+}
+
+// validatorTiming returns the recorded time and call count for a rule
+func validatorTiming(ruleID int) (float64, int64) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	return validatorSeconds[ruleID], validatorCalls[ruleID]
+}
+
+func TestValidatorTiming(t *testing.T) {
+	// IDs outside the pool, so no other test's validations mix in
+	const slowID, fastID, untimedID = 9001, 9002, 9003
+	const slowDelay = 20 * time.Millisecond
+
+	rs := &RuleSet{Difficulty: "basic", Rules: []Rule{
+		{ID: slowID, Validator: func(string) bool { time.Sleep(slowDelay); return true }},
+		{ID: fastID, Validator: func(string) bool { return true }},
+	}}
+
+	SetValidatorTiming(true)
+	t.Cleanup(func() { SetValidatorTiming(false) })
+	ValidatePassword(rs, "anything", nil, nil)
+	ValidatePassword(rs, "anything", nil, nil)
+
+	slowSeconds, slowCalls := validatorTiming(slowID)
+	if slowCalls != 2 {
+		t.Errorf("slow validator calls = %d, want 2", slowCalls)
+	}
+	if slowSeconds < 2*slowDelay.Seconds() {
+		t.Errorf("slow validator time = %gs, want at least %gs", slowSeconds, 2*slowDelay.Seconds())
+	}
+	fastSeconds, fastCalls := validatorTiming(fastID)
+	if fastCalls != 2 {
+		t.Errorf("fast validator calls = %d, want 2", fastCalls)
+	}
+	if fastSeconds >= slowSeconds {
+		t.Errorf("fast validator time %gs is not below the slow one's %gs", fastSeconds, slowSeconds)
+	}
+
+	var out strings.Builder
+	WriteMetrics(&out)
+	for _, want := range []string{
+		"# TYPE passgame_rule_validator_seconds summary",
+		fmt.Sprintf(`passgame_rule_validator_seconds_count{rule="%d"} 2`, slowID),
+		fmt.Sprintf(`passgame_rule_validator_seconds_sum{rule="%d"} %g`, slowID, slowSeconds),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+
+	// With timing off, validators still run but nothing is recorded
+	SetValidatorTiming(false)
+	untimed := &RuleSet{Difficulty: "basic", Rules: []Rule{{ID: untimedID, Validator: func(string) bool { return true }}}}
+	ValidatePassword(untimed, "anything", nil, nil)
+	if !untimed.Rules[0].IsSatisfied {
+		t.Error("validator did not run with timing off")
+	}
+	if _, calls := validatorTiming(untimedID); calls != 0 {
+		t.Errorf("untimed validator recorded %d calls, want 0", calls)
+	}
+}
+
+func TestSaveLoadRuleStats(t *testing.T) {
+	if err := InitRuleStatsTable(); err != nil {
+		t.Fatal(err)
+	}
+	clearRuleStats := func() {
+		if _, err := database.GetDB().Exec("DELETE FROM rule_stats"); err != nil {
+			t.Fatalf("failed to clear rule_stats: %v", err)
+		}
+	}
+	clearRuleStats()
+
+	metricsMutex.Lock()
+	previousShown, previousSatisfied := ruleShownTotal, ruleShownSatisfiedTotal
+	ruleShownTotal = map[int]int64{1: 10, 7: 4}
+	ruleShownSatisfiedTotal = map[int]int64{1: 9, 7: 1}
+	metricsMutex.Unlock()
+	testMode = false
+
+	t.Cleanup(func() {
+		testMode = true
+		metricsMutex.Lock()
+		ruleShownTotal, ruleShownSatisfiedTotal = previousShown, previousSatisfied
+		metricsMutex.Unlock()
+		clearRuleStats()
+	})
+
+	if err := SaveRuleStats(); err != nil {
+		t.Fatalf("SaveRuleStats() error = %v", err)
+	}
+
+	// After a restart the saved counts are added to whatever was counted since
+	metricsMutex.Lock()
+	ruleShownTotal = map[int]int64{1: 2}
+	ruleShownSatisfiedTotal = map[int]int64{1: 1}
+	metricsMutex.Unlock()
+
+	loaded, err := LoadRuleStats()
+	if err != nil {
+		t.Fatalf("LoadRuleStats() error = %v", err)
+	}
+	if loaded != 2 {
+		t.Errorf("LoadRuleStats() loaded %d rules, want 2", loaded)
+	}
+	want := []RuleSatisfaction{
+		{RuleID: 1, Shown: 12, Satisfied: 10, Rate: 10.0 / 12},
+		{RuleID: 7, Shown: 4, Satisfied: 1, Rate: 0.25},
+	}
+	if got := RuleSatisfactionReport(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RuleSatisfactionReport() = %+v, want %+v", got, want)
+	}
+
+	// Test mode never persists its fixed-challenge games
+	testMode = true
+	clearRuleStats()
+	if err := SaveRuleStats(); err != nil {
+		t.Fatalf("SaveRuleStats() in test mode error = %v", err)
+	}
+	var count int
+	if err := database.GetDB().QueryRow("SELECT COUNT(*) FROM rule_stats").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("test mode saved %d rule stats rows, want none", count)
+	}
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// monotonicRunRuleID is the rule that asks for a run of consecutive rising or falling digits
+const monotonicRunRuleID = 28
+
+// Monotonic run directions for SetMonotonicRun
+const (
+	MonotonicIncreasing = "increasing"
+	MonotonicDecreasing = "decreasing"
+	MonotonicEither     = "either"
+)
+
+// DefaultMonotonicRunLength is how many digits the run needs unless configured
+const DefaultMonotonicRunLength = 3
+
+// maxMonotonicRunLength keeps the rule beatable with a readable run such as 123456789
+const maxMonotonicRunLength = 9
+
+// monotonicRunLength and monotonicRunDirection configure Rule 28
+var (
+	monotonicRunLength    = DefaultMonotonicRunLength
+	monotonicRunDirection = MonotonicIncreasing
+)
+
+// SetMonotonicRun configures Rule 28. A length below 2 falls back to DefaultMonotonicRunLength,
+// one above 9 is capped, and an empty direction means MonotonicIncreasing. Call it before the
+// rule pool is first built, since the description includes both.
+func SetMonotonicRun(length int, direction string) error {
+	if length < 2 {
+		length = DefaultMonotonicRunLength
+	}
+	if length > maxMonotonicRunLength {
+		length = maxMonotonicRunLength
+	}
+	monotonicRunLength = length
+
+	direction = strings.ToLower(strings.TrimSpace(direction))
+	if direction == "" {
+		direction = MonotonicIncreasing
+	}
+	if direction != MonotonicIncreasing && direction != MonotonicDecreasing && direction != MonotonicEither {
+		monotonicRunDirection = MonotonicIncreasing
+		return fmt.Errorf("invalid monotonic run direction: %s (must be %q, %q or %q)", direction, MonotonicIncreasing, MonotonicDecreasing, MonotonicEither)
+	}
+	monotonicRunDirection = direction
+	return nil
+}
+
+// HasMonotonicRun reports whether s contains minLen or more consecutive ASCII digits that strictly
+// increase (or, with increasing false, strictly decrease), e.g. "345" or "971". Any other
+// character breaks a run, so "3a45" has no run of 3, and repeated digits like "334" don't count.
+func HasMonotonicRun(s string, minLen int, increasing bool) bool {
+	if minLen <= 1 {
+		return minLen <= 0 || strings.ContainsAny(s, "0123456789")
+	}
+
+	run := 0
+	var previous byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			run = 0
+			continue
+		}
+
+		if run > 0 && (increasing && c > previous || !increasing && c < previous) {
+			run++
+		} else {
+			run = 1
+		}
+		if run >= minLen {
+			return true
+		}
+		previous = c
+	}
+	return false
+}
+
+// monotonicRunDescription words Rule 28 for the configured length and direction
+func monotonicRunDescription() string {
+	switch monotonicRunDirection {
+	case MonotonicDecreasing:
+		return fmt.Sprintf("Must include %d digits in a row that strictly decrease", monotonicRunLength)
+	case MonotonicEither:
+		return fmt.Sprintf("Must include %d digits in a row that strictly increase or strictly decrease", monotonicRunLength)
+	default:
+		return fmt.Sprintf("Must include %d digits in a row that strictly increase", monotonicRunLength)
+	}
+}
+
+// monotonicRunHint gives an example run for the configured length and direction
+func monotonicRunHint() string {
+	example := "123456789"[:monotonicRunLength]
+	if monotonicRunDirection == MonotonicDecreasing {
+		example = "987654321"[:monotonicRunLength]
+	}
+	return "Type digits next to each other, like " + example + ". Letters, symbols and repeated digits break the run."
+}
+
+// monotonicRunRule builds Rule 28
+func monotonicRunRule() Rule {
+	length, direction := monotonicRunLength, monotonicRunDirection
+	return Rule{
+		ID:          monotonicRunRuleID,
+		Description: monotonicRunDescription(),
+		Validator: func(t string) bool {
+			switch direction {
+			case MonotonicDecreasing:
+				return HasMonotonicRun(t, length, false)
+			case MonotonicEither:
+				return HasMonotonicRun(t, length, true) || HasMonotonicRun(t, length, false)
+			default:
+				return HasMonotonicRun(t, length, true)
+			}
+		},
+		Hint:     monotonicRunHint(),
+		Category: "hard",
+	}
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasMonotonicRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		minLen     int
+		increasing bool
+		want       bool
+	}{
+		{"increasing", "ab345cd", 3, true, true},
+		{"increasing with gaps", "x159", 3, true, true},
+		{"increasing at the end", "pass0129", 4, true, true},
+		{"too short", "ab34cd", 3, true, false},
+		{"decreasing", "pw971!", 3, false, true},
+		{"decreasing asked for increasing", "pw971!", 3, true, false},
+		{"increasing asked for decreasing", "ab345cd", 3, false, false},
+		{"broken by a letter", "3a45", 3, true, false},
+		{"broken by a symbol", "34-5", 3, true, false},
+		{"broken by a space", "9 87", 3, false, false},
+		{"broken by multibyte", "3é45", 3, true, false},
+		{"repeated digit", "334", 3, true, false},
+		{"repeated digit decreasing", "977", 3, false, false},
+		{"run restarts after a repeat", "1123", 3, true, true},
+		{"run restarts after a turn", "5312", 3, true, false},
+		{"turn then rising", "53123", 3, true, true},
+		{"full run", "0123456789", 10, true, true},
+		{"one longer than the digits", "0123456789", 11, true, false},
+		{"no digits", "password", 3, true, false},
+		{"length one needs a digit", "a1", 1, true, true},
+		{"length one without digits", "abc", 1, false, false},
+		{"length zero", "", 0, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasMonotonicRun(tt.s, tt.minLen, tt.increasing); got != tt.want {
+				t.Errorf("HasMonotonicRun(%q, %d, %v) = %v, want %v", tt.s, tt.minLen, tt.increasing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonotonicRunRule(t *testing.T) {
+	t.Cleanup(func() { SetMonotonicRun(DefaultMonotonicRunLength, MonotonicIncreasing) })
+
+	tests := []struct {
+		name      string
+		length    int
+		direction string
+		wantErr   bool
+		accepted  []string
+		rejected  []string
+	}{
+		{"default", 0, "", false, []string{"a345", "a159"}, []string{"a543", "a3a4a5"}},
+		{"decreasing", 4, "Decreasing", false, []string{"a9752"}, []string{"a975", "a2579"}},
+		{"either", 3, MonotonicEither, false, []string{"a135", "a531"}, []string{"a353"}},
+		{"capped length", 20, MonotonicIncreasing, false, []string{"x123456789"}, []string{"x12345678"}},
+		{"invalid direction", 3, "sideways", true, []string{"a345"}, []string{"a543"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetMonotonicRun(tt.length, tt.direction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetMonotonicRun(%d, %q) error = %v, want error %v", tt.length, tt.direction, err, tt.wantErr)
+			}
+
+			rule := monotonicRunRule()
+			if rule.ID != monotonicRunRuleID || rule.Description == "" || rule.Hint == "" {
+				t.Errorf("rule = %+v, want rule %d with a description and hint", rule, monotonicRunRuleID)
+			}
+			// The hint's example must itself pass
+			example := rule.Hint[len("Type digits next to each other, like "):]
+			example = example[:monotonicRunLength]
+			if !rule.Validator(example) {
+				t.Errorf("the hint's example %q fails the rule", example)
+			}
+			for _, password := range tt.accepted {
+				if !rule.Validator(password) {
+					t.Errorf("rule rejected %q", password)
+				}
+			}
+			for _, password := range tt.rejected {
+				if rule.Validator(password) {
+					t.Errorf("rule accepted %q", password)
+				}
+			}
+		})
+	}
+}
+
+func TestMonotonicRunRuleAssigned(t *testing.T) {
+	for _, difficulty := range []string{"hard", "expert"} {
+		if ids := ruleIDs(NewRuleSet(difficulty)); !slices.Contains(ids, monotonicRunRuleID) {
+			t.Errorf("%s rules %v don't include rule %d", difficulty, ids, monotonicRunRuleID)
+		}
+	}
+	if ids := ruleIDs(NewRuleSet("basic")); slices.Contains(ids, monotonicRunRuleID) {
+		t.Errorf("basic rules %v include rule %d", ids, monotonicRunRuleID)
+	}
+}
+
+func TestPreAppendFinalRuleIDIgnoresMonotonicRun(t *testing.T) {
+	// hard ended at rule 17 before rule 28 was appended; players who finished then keep it
+	if got, current := PreAppendFinalRuleID("hard"), GetFinalRuleID("hard"); got != 17 || current != monotonicRunRuleID {
+		t.Errorf("hard final rule = %d, before the append = %d, want %d and 17", current, got, monotonicRunRuleID)
+	}
+	if got := PreAppendFinalRuleID("basic"); got != GetFinalRuleID("basic") {
+		t.Errorf("PreAppendFinalRuleID(basic) = %d, want the current final %d", got, GetFinalRuleID("basic"))
+	}
+}
@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"math"
+	"time"
+)
+
+// moonPhaseRuleID is the rule that asks for the current moon phase
+const moonPhaseRuleID = 26
+
+// synodicMonth is the average time between two new moons, in days
+const synodicMonth = 29.530588853
+
+// referenceNewMoon is a known new moon (6 January 2000, 18:14 UTC) that phases are counted from
+var referenceNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// moonPhases are the eight phase names in order, starting at the new moon
+var moonPhases = []string{
+	"new moon",
+	"waxing crescent",
+	"first quarter",
+	"waxing gibbous",
+	"full moon",
+	"waning gibbous",
+	"last quarter",
+	"waning crescent",
+}
+
+// CurrentMoonPhase returns the name of the moon phase at t, e.g. "waxing gibbous". It uses the
+// mean synodic month from a reference new moon, which is accurate to within about a day.
+func CurrentMoonPhase(t time.Time) string {
+	days := t.Sub(referenceNewMoon).Hours() / 24
+	age := math.Mod(days, synodicMonth)
+	if age < 0 {
+		age += synodicMonth
+	}
+
+	// Each phase is centred on its point in the cycle, so "full moon" covers the days around it
+	index := int(math.Floor(age/synodicMonth*8+0.5)) % len(moonPhases)
+	return moonPhases[index]
+}
+
+// moonPhaseHint names the phase the validator currently expects
+func moonPhaseHint() string {
+	return "Include the current moon phase: " + CurrentMoonPhase(time.Now())
+}
+
+// moonPhaseRule builds Rule 26. Phases change every few days while the pool lives for the whole
+// process, so NewRuleSet refreshes the hint from the same clock the validator uses.
+func moonPhaseRule() Rule {
+	return Rule{
+		ID:          moonPhaseRuleID,
+		Description: "Must include the current phase of the moon 🌙",
+		Validator: func(t string) bool {
+			return matches(t, CurrentMoonPhase(time.Now()), matchOptionsFor(moonPhaseRuleID))
+		},
+		Hint:     moonPhaseHint(),
+		Category: "intermediate",
+	}
+}
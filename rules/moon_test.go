@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCurrentMoonPhase(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"reference new moon", referenceNewMoon, "new moon"},
+		{"new moon of 11 January 2024", time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC), "new moon"},
+		{"waxing crescent", time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC), "waxing crescent"},
+		{"first quarter of 18 January 2024", time.Date(2024, 1, 18, 3, 53, 0, 0, time.UTC), "first quarter"},
+		{"waxing gibbous", time.Date(2024, 1, 21, 12, 0, 0, 0, time.UTC), "waxing gibbous"},
+		{"full moon of 25 January 2024", time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC), "full moon"},
+		{"waning gibbous", time.Date(2024, 1, 29, 12, 0, 0, 0, time.UTC), "waning gibbous"},
+		{"last quarter of 2 February 2024", time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC), "last quarter"},
+		{"waning crescent", time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC), "waning crescent"},
+		{"full moon before the reference", time.Date(1999, 12, 22, 17, 31, 0, 0, time.UTC), "full moon"},
+		{"other time zone", time.Date(2024, 1, 26, 2, 54, 0, 0, time.FixedZone("JST", 9*60*60)), "full moon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CurrentMoonPhase(tt.at); got != tt.want {
+				t.Errorf("CurrentMoonPhase(%v) = %q, want %q", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoonPhaseRuleHintMatchesValidator(t *testing.T) {
+	rule := moonPhaseRule()
+	phase := CurrentMoonPhase(time.Now())
+
+	if !strings.HasSuffix(rule.Hint, phase) {
+		t.Errorf("hint = %q, want it to name %q", rule.Hint, phase)
+	}
+	if !rule.Validator("My " + strings.ToUpper(phase) + "!") {
+		t.Errorf("validator rejected the phase the hint names, %q", phase)
+	}
+	for _, other := range moonPhases {
+		if other != phase && !strings.Contains(phase, other) && rule.Validator(other) {
+			t.Errorf("validator accepted %q while the moon is in %q", other, phase)
+		}
+	}
+}
+
+func TestMoonPhaseRuleAssigned(t *testing.T) {
+	for _, difficulty := range []string{"fun", "expert", "hard", "intermediate", "basic"} {
+		for _, rule := range NewRuleSet(difficulty).Rules {
+			if rule.ID == moonPhaseRuleID {
+				return
+			}
+		}
+	}
+	t.Error("no difficulty includes the moon phase rule")
+}
+
+func TestPreAppendFinalRuleIDIgnoresMoonPhase(t *testing.T) {
+	// fun ended at rule 25 before the moon phase rule was appended
+	writeTestAssignments(t, `{"fun": [1, 22, 24, 25, 26]}`)
+	if got, current := PreAppendFinalRuleID("fun"), GetFinalRuleID("fun"); got != 25 || current != moonPhaseRuleID {
+		t.Errorf("fun final rule = %d, before the append = %d, want %d and 25", current, got, moonPhaseRuleID)
+	}
+}
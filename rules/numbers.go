@@ -0,0 +1,285 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ContainsStandaloneNegativeInteger reports whether text contains a negative integer token
+// such as "-5" or "x -3". The minus sign must not follow a letter or digit and the number
+// must not run into a letter, so hyphens in "e-5", "01-23" or "a-5b" don't count.
+func ContainsStandaloneNegativeInteger(text string) bool {
+	runes := []rune(text)
+	for i, r := range runes {
+		if r != '-' || i+1 >= len(runes) || !unicode.IsDigit(runes[i+1]) {
+			continue
+		}
+		if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && unicode.IsDigit(runes[end]) {
+			end++
+		}
+		if end < len(runes) && unicode.IsLetter(runes[end]) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// negativeNumberRuleID is the rule that asks for a standalone negative integer
+const negativeNumberRuleID = 30
+
+// negativeNumberRule builds Rule 30. No difficulty plays it by default; list it in
+// assignments.json to use it.
+func negativeNumberRule() Rule {
+	return Rule{
+		ID:          negativeNumberRuleID,
+		Description: "Must include a negative number",
+		Validator:   ContainsStandaloneNegativeInteger,
+		Hint:        "Put a minus sign right before a number, like -7, with a space or symbol in front of it. Hyphens inside words or dates such as e-5 or 01-23 don't count.",
+		Category:    "hard",
+	}
+}
+
+// DigitSum returns the running total of the decimal digits in text, so a hint can show how far
+// a password is from a digit-sum target. Signs and other characters are ignored.
+func DigitSum(text string) int {
+	sum := 0
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			sum += int(r - '0')
+		}
+	}
+	return sum
+}
+
+// digitSumRuleID is the rule that asks for the password's digits to add up to a target
+const digitSumRuleID = 31
+
+// DefaultDigitSumTarget is the Rule 31 total for difficulties without a digit_sum_target
+const DefaultDigitSumTarget = 25
+
+// digitSumTargetFunc looks up a difficulty's configured target; 0 means DefaultDigitSumTarget
+var digitSumTargetFunc = func(string) int { return 0 }
+
+// SetDigitSumTargetFunc sets how the Rule 31 target of a difficulty is looked up
+func SetDigitSumTargetFunc(fn func(difficulty string) int) {
+	if fn != nil {
+		digitSumTargetFunc = fn
+	}
+}
+
+// digitSumTarget returns the total Rule 31 expects for a difficulty
+func digitSumTarget(difficulty string) int {
+	if target := digitSumTargetFunc(NormalizeDifficulty(difficulty)); target > 0 {
+		return target
+	}
+	return DefaultDigitSumTarget
+}
+
+// digitSumHint tells the player the target and, once they've typed something, how far off they are
+func digitSumHint(target int, password string) string {
+	hint := fmt.Sprintf("Only the digits 0-9 count towards %d; signs like - don't.", target)
+	if password == "" {
+		return hint
+	}
+	return fmt.Sprintf("%s Your digits add up to %d right now.", hint, DigitSum(password))
+}
+
+// digitSumRule builds Rule 31 for the given target. It counts every digit, including the ones
+// other rules require (captcha, constant, runs), so no difficulty plays it by default; list it in
+// assignments.json for a difficulty whose other rules don't fix digits.
+func digitSumRule(target int) Rule {
+	return Rule{
+		ID:          digitSumRuleID,
+		Description: fmt.Sprintf("The digits in your password must add up to %d", target),
+		Validator:   func(t string) bool { return DigitSum(t) == target },
+		Hint:        digitSumHint(target, ""),
+		Category:    "hard",
+	}
+}
+
+// Rule 6 accepts any prime in [primeMin, primeMax] unless configured otherwise
+const (
+	DefaultPrimeMin = 2
+	DefaultPrimeMax = 47
+	// maxPrimeLimit bounds the sieve; larger primes make the rule trivial anyway
+	maxPrimeLimit = 100000
+)
+
+var (
+	primeMin = DefaultPrimeMin
+	primeMax = DefaultPrimeMax
+)
+
+// primeSieve[n] reports whether n is composite, grown on demand up to maxPrimeLimit
+var (
+	primeSieve      []bool
+	primeSieveMutex sync.Mutex
+)
+
+// SetPrimeRange configures which primes satisfy Rule 6. Zero values keep the defaults, a
+// minimum below 2 is raised to 2, and a maximum above 100000 is rejected. Call it before the
+// rule pool is first built, since the hint lists the first primes in the range.
+func SetPrimeRange(min, max int) error {
+	if min == 0 {
+		min = DefaultPrimeMin
+	}
+	if max == 0 {
+		max = DefaultPrimeMax
+	}
+	if max > maxPrimeLimit {
+		primeMin, primeMax = DefaultPrimeMin, DefaultPrimeMax
+		return fmt.Errorf("invalid prime range %d-%d: maximum is above %d", min, max, maxPrimeLimit)
+	}
+	min = clampInt(min, 2, maxPrimeLimit)
+	max = clampInt(max, 2, maxPrimeLimit)
+	if min > max {
+		primeMin, primeMax = DefaultPrimeMin, DefaultPrimeMax
+		return fmt.Errorf("invalid prime range %d-%d: minimum is above maximum", min, max)
+	}
+	if len(primesInRange(min, max)) == 0 {
+		primeMin, primeMax = DefaultPrimeMin, DefaultPrimeMax
+		return fmt.Errorf("invalid prime range %d-%d: no primes in range", min, max)
+	}
+	primeMin, primeMax = min, max
+	return nil
+}
+
+func clampInt(value, low, high int) int {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
+
+// compositeSieve returns a sieve of Eratosthenes covering at least 0..limit. The returned slice
+// is never modified, only replaced by a larger one.
+func compositeSieve(limit int) []bool {
+	primeSieveMutex.Lock()
+	defer primeSieveMutex.Unlock()
+
+	if len(primeSieve) > limit {
+		return primeSieve
+	}
+
+	sieve := make([]bool, limit+1)
+	sieve[0] = true
+	if limit >= 1 {
+		sieve[1] = true
+	}
+	for i := 2; i*i <= limit; i++ {
+		if sieve[i] {
+			continue
+		}
+		for j := i * i; j <= limit; j += i {
+			sieve[j] = true
+		}
+	}
+	primeSieve = sieve
+	return sieve
+}
+
+// primesInRange returns the primes between min and max inclusive, in ascending order
+func primesInRange(min, max int) []int {
+	if max > maxPrimeLimit {
+		max = maxPrimeLimit
+	}
+	if min < 2 {
+		min = 2
+	}
+	if max < min {
+		return nil
+	}
+
+	sieve := compositeSieve(max)
+	var primes []int
+	for n := min; n <= max; n++ {
+		if !sieve[n] {
+			primes = append(primes, n)
+		}
+	}
+	return primes
+}
+
+// IsPrimeSubstring reports whether s contains, as a substring, the decimal form of any prime
+// between min and max inclusive. "x13y" contains 13 and also 3, so it matches 2-47 and 10-20.
+// It reads the numbers starting at each nonzero digit, up to max's digit count, and looks them
+// up in the sieve, so the cost depends on the password rather than on how many primes qualify.
+func IsPrimeSubstring(s string, min, max int) bool {
+	if max > maxPrimeLimit {
+		max = maxPrimeLimit
+	}
+	if min < 2 {
+		min = 2
+	}
+	if max < min {
+		return false
+	}
+
+	sieve := compositeSieve(max)
+	maxDigits := len(strconv.Itoa(max))
+	for i := 0; i < len(s); i++ {
+		// A prime's decimal form never starts with 0
+		if s[i] < '1' || s[i] > '9' {
+			continue
+		}
+		n := 0
+		for j := i; j < len(s) && j-i < maxDigits && s[j] >= '0' && s[j] <= '9'; j++ {
+			n = n*10 + int(s[j]-'0')
+			if n > max {
+				break
+			}
+			if n >= min && !sieve[n] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// primeHint lists the first few primes Rule 6 accepts
+func primeHint() string {
+	primes := primesInRange(primeMin, primeMax)
+	shown := make([]string, 0, 6)
+	for i := 0; i < len(primes) && i < 6; i++ {
+		shown = append(shown, strconv.Itoa(primes[i]))
+	}
+	hint := "Include a prime number: " + strings.Join(shown, ", ")
+	if len(primes) > len(shown) {
+		hint += ", etc."
+	}
+	return hint
+}
+
+// primeDescription words Rule 6, naming the range when it isn't the default
+func primeDescription() string {
+	if primeMin == DefaultPrimeMin && primeMax == DefaultPrimeMax {
+		return "Must include a prime number"
+	}
+	return fmt.Sprintf("Must include a prime number between %d and %d", primeMin, primeMax)
+}
+
+// primeRule builds Rule 6
+func primeRule() Rule {
+	min, max := primeMin, primeMax
+	return Rule{
+		ID:          6,
+		Description: primeDescription(),
+		Validator: func(t string) bool {
+			return IsPrimeSubstring(t, min, max)
+		},
+		Hint:     primeHint(),
+		Category: "basic",
+	}
+}
@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestContainsStandaloneNegativeInteger(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"-5", true},
+		{"x -3", true},
+		{"pass(-12)word", true},
+		{"a!-7", true},
+		{"-5b", false},
+		{"a-5b", false},
+		{"a-5", false},
+		{"e-5", false},
+		{"01-23", false},
+		{"--", false},
+		{"- 5", false},
+		{"5-", false},
+		{"", false},
+		{"a-5b x -3", true},
+	}
+
+	for _, tt := range tests {
+		if got := ContainsStandaloneNegativeInteger(tt.text); got != tt.want {
+			t.Errorf("ContainsStandaloneNegativeInteger(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNegativeNumberRule(t *testing.T) {
+	// Opt-in: no difficulty plays it until assignments.json lists it
+	for _, difficulty := range []string{"basic", "hard", "expert"} {
+		if ids := ruleIDs(NewRuleSet(difficulty)); slices.Contains(ids, negativeNumberRuleID) {
+			t.Errorf("%s rules %v include rule %d", difficulty, ids, negativeNumberRuleID)
+		}
+	}
+
+	writeTestAssignments(t, `{"hard": [30]}`)
+	rule := NewRuleSet("hard").Rules[0]
+	if rule.ID != negativeNumberRuleID {
+		t.Fatalf("assigned rule = %d, want %d", rule.ID, negativeNumberRuleID)
+	}
+	for password, want := range map[string]bool{"x -3": true, "a-5b": false, "01-23": false} {
+		if got := rule.Validator(password); got != want {
+			t.Errorf("rule %d(%q) = %v, want %v", negativeNumberRuleID, password, got, want)
+		}
+	}
+}
+
+func TestDigitSum(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 0},
+		{"99", 18},
+		{"-5", 5},
+		{"x -3 -4", 7},
+		{"1-2-3", 6},
+		{"+7", 7},
+		{"٣", 0},
+		{"Abc123!", 6},
+	}
+
+	for _, tt := range tests {
+		if got := DigitSum(tt.text); got != tt.want {
+			t.Errorf("DigitSum(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// useDigitSumTargets configures the Rule 31 target of each difficulty for the test
+func useDigitSumTargets(t *testing.T, targets map[string]int) {
+	t.Helper()
+	previous := digitSumTargetFunc
+	SetDigitSumTargetFunc(func(difficulty string) int { return targets[difficulty] })
+	t.Cleanup(func() { digitSumTargetFunc = previous })
+}
+
+func TestDigitSumRuleTargetPerDifficulty(t *testing.T) {
+	useDigitSumTargets(t, map[string]int{"hard": 30})
+	writeTestAssignments(t, `{"hard": [31], "basic": [31]}`)
+
+	tests := []struct {
+		difficulty string
+		password   string
+		want       bool
+	}{
+		{"hard", "9993", true},
+		{"hard", "-9-9-9-3", true},
+		{"hard", "99931", false},
+		{"basic", "997", true},
+		{"basic", "9993", false},
+	}
+
+	for _, tt := range tests {
+		rs := NewRuleSet(tt.difficulty)
+		ValidatePassword(rs, tt.password, nil, nil)
+		rule := rs.Rules[0]
+		if rule.IsSatisfied != tt.want {
+			t.Errorf("%s rule 31(%q) = %v, want %v", tt.difficulty, tt.password, rule.IsSatisfied, tt.want)
+		}
+		if want := fmt.Sprintf("add up to %d right now", DigitSum(tt.password)); !strings.Contains(rule.Hint, want) {
+			t.Errorf("%s rule 31 hint %q doesn't report the current sum", tt.difficulty, rule.Hint)
+		}
+	}
+
+	if got := NewRuleSet("hard").Rules[0].Description; !strings.Contains(got, "30") {
+		t.Errorf("hard rule 31 description = %q, want the configured 30", got)
+	}
+	if got := NewRuleSet("basic").Rules[0].Description; !strings.Contains(got, fmt.Sprint(DefaultDigitSumTarget)) {
+		t.Errorf("basic rule 31 description = %q, want the default %d", got, DefaultDigitSumTarget)
+	}
+}
+
+func TestIsPrimeSubstring(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		min, max int
+		want     bool
+	}{
+		{"prime present", "pass13word", 2, 47, true},
+		{"prime absent", "pass1word", 2, 47, false},
+		{"no digits", "password", 2, 47, false},
+		{"composite only", "x1x4x6x8x9x", 2, 47, false},
+		{"lower bound", "x11x", 11, 20, true},
+		{"upper bound", "x47x", 2, 47, true},
+		{"just above the range", "x23x", 11, 20, false},
+		{"just below the range", "x7x", 11, 20, false},
+		{"contains a smaller prime", "x13x", 2, 47, true},
+		{"ninety-seven outside the default", "x94x", 2, 47, false},
+		{"inside a wider range", "x97x", 90, 100, true},
+		{"largest prime below the cap", "99991", 99990, maxPrimeLimit, true},
+		{"range above the cap", "100003", 100001, 100010, false},
+		{"empty range", "x13x", 20, 10, false},
+		{"leading zero", "x013x", 10, 20, true},
+		{"only a leading-zero form", "x07x", 7, 7, true},
+		{"digits split by a letter", "1a3", 13, 13, false},
+		{"prime inside a long digit run", "8888888889788888", 97, 97, true},
+		{"long run without one", "88888888888888", 2, maxPrimeLimit, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPrimeSubstring(tt.s, tt.min, tt.max); got != tt.want {
+				t.Errorf("IsPrimeSubstring(%q, %d, %d) = %v, want %v", tt.s, tt.min, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkIsPrimeSubstringWideRange checks a prime-free password against every prime up to the
+// cap, which used to mean one substring search per prime
+func BenchmarkIsPrimeSubstringWideRange(b *testing.B) {
+	password := strings.Repeat("abc8x", 200)
+	for i := 0; i < b.N; i++ {
+		IsPrimeSubstring(password, 2, maxPrimeLimit)
+	}
+}
+
+func TestPrimesInRange(t *testing.T) {
+	want := "2,3,5,7,11,13,17,19,23,29,31,37,41,43,47"
+	var got []string
+	for _, prime := range primesInRange(DefaultPrimeMin, DefaultPrimeMax) {
+		got = append(got, fmt.Sprint(prime))
+	}
+	if strings.Join(got, ",") != want {
+		t.Errorf("default primes = %v, want the old hardcoded list %s", got, want)
+	}
+	// Growing the sieve keeps earlier answers
+	primesInRange(1000, 2000)
+	if primes := primesInRange(0, 10); fmt.Sprint(primes) != "[2 3 5 7]" {
+		t.Errorf("primesInRange(0, 10) = %v after growing the sieve", primes)
+	}
+}
+
+func TestSetPrimeRange(t *testing.T) {
+	t.Cleanup(func() { SetPrimeRange(0, 0) })
+
+	if err := SetPrimeRange(10, 20); err != nil {
+		t.Fatalf("SetPrimeRange(10, 20) error = %v", err)
+	}
+	rule := primeRule()
+	if !strings.Contains(rule.Description, "between 10 and 20") || !strings.HasPrefix(rule.Hint, "Include a prime number: 11, 13") {
+		t.Errorf("rule 6 = %q / %q, want the 10-20 range", rule.Description, rule.Hint)
+	}
+	if rule.Validator("x7x") || !rule.Validator("x19x") {
+		t.Error("rule 6 ignores the configured range")
+	}
+
+	tests := []struct {
+		name     string
+		min, max int
+	}{
+		{"minimum above maximum", 30, 20},
+		{"no primes in range", 24, 28},
+		{"maximum above the cap", 2, maxPrimeLimit + 1},
+		{"whole range above the cap", 200000, 300000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetPrimeRange(tt.min, tt.max); err == nil {
+				t.Errorf("SetPrimeRange(%d, %d) = nil, want an error", tt.min, tt.max)
+			}
+			if primeMin != DefaultPrimeMin || primeMax != DefaultPrimeMax {
+				t.Errorf("range = %d-%d, want the defaults after an error", primeMin, primeMax)
+			}
+		})
+	}
+
+	if err := SetPrimeRange(0, 0); err != nil {
+		t.Fatalf("SetPrimeRange(0, 0) error = %v", err)
+	}
+	if got := primeRule().Description; got != "Must include a prime number" {
+		t.Errorf("default description = %q", got)
+	}
+}
@@ -20,6 +20,7 @@ type Rule struct {
 	IsVisible      bool              `json:"is_visible"`
 	HasCaptcha     bool              `json:"has_captcha"`
 	Category       string            `json:"category"`
+	MatchOptions   MatchOptions      `json:"match_options"`
 }
 
 // Cache for the rule pool
@@ -80,65 +81,21 @@ func Pool() []Rule {
 			Category: "basic",
 		},
 		// Rule 5: Must include Roman numerals (I, V, X, L, C, D, M)
-		{
-			ID:          5,
-			Description: "Must include Roman numerals (I, V, X, L, C, D, M)",
-			Validator: func(t string) bool {
-				romanNumerals := "IVXLCDM"
-				for _, char := range t {
-					if strings.ContainsRune(romanNumerals, char) {
-						return true
-					}
-				}
-				return false
-			},
-			Hint:     "Include Roman numerals: I, V, X, L, C, D, M",
-			Category: "basic",
-		},
-		// Rule 6: Must include a prime number
-		{
-			ID:          6,
-			Description: "Must include a prime number",
-			Validator: func(t string) bool {
-				primes := []string{"2", "3", "5", "7", "11", "13", "17", "19", "23", "29", "31", "37", "41", "43", "47"}
-				for _, prime := range primes {
-					if strings.Contains(t, prime) {
-						return true
-					}
-				}
-				return false
-			},
-			Hint:     "Include a prime number: 2, 3, 5, 7, 11, 13, etc.",
-			Category: "basic",
-		},
+		romanNumeralRule(),
+		// Rule 6: Must include a prime number (2-47 unless configured)
+		primeRule(),
 		// Rule 7: Must contain the current day of the week
 		{
 			ID:          7,
 			Description: "Must contain the current day of the week",
 			Validator: func(t string) bool {
-				currentDay := strings.ToLower(time.Now().Weekday().String())
-				return strings.Contains(strings.ToLower(t), currentDay)
-			},
-			Hint:     "Include today's day of the week: " + time.Now().Weekday().String(),
-			Category: "intermediate",
-		},
-		// Rule 8: Must contain one of our following sponsors: (Pepsi, Starbucks, Shell)
-		{
-			ID:          8,
-			Description: "Must contain one of our following sponsors: (Pepsi, Starbucks, Shell)",
-			Validator: func(t string) bool {
-				lowerT := strings.ToLower(t)
-				sponsors := []string{"pepsi", "starbucks", "shell"}
-				for _, sponsor := range sponsors {
-					if strings.Contains(lowerT, sponsor) {
-						return true
-					}
-				}
-				return false
+				return containsLocalizedName(t, time.Now(), LocalizedWeekday, matchOptionsFor(7))
 			},
-			Hint:     "Include one of our sponsors: Pepsi, Starbucks, Shell",
+			Hint:     weekdayHint(DefaultLocale),
 			Category: "intermediate",
 		},
+		// Rule 8: Must contain one of our sponsors (config/sponsors.json, default Pepsi, Starbucks, Shell)
+		sponsorRule(),
 		// Rule 9: Must contain at least one vowel
 		{
 			ID:          9,
@@ -160,10 +117,9 @@ func Pool() []Rule {
 			ID:          10,
 			Description: "Must include the current month name",
 			Validator: func(t string) bool {
-				currentMonth := strings.ToLower(time.Now().Month().String())
-				return strings.Contains(strings.ToLower(t), currentMonth)
+				return containsLocalizedName(t, time.Now(), LocalizedMonth, matchOptionsFor(10))
 			},
-			Hint:     "Include the current month: " + time.Now().Month().String(),
+			Hint:     monthHint(DefaultLocale),
 			Category: "intermediate",
 		},
 		// Rule 11: Must be at least 16 characters long
@@ -205,15 +161,15 @@ func Pool() []Rule {
 			ID:          14,
 			Description: "A new password rule just got updated! Please click update on the alertbox!",
 			Validator:   Rule14UpdateAlert,
-			Hint:        "After the update, include '" + GetUpdateString() + "' in your password.",
+			Hint:        updateAlertHint(GetUpdateString()),
 			Category:    "expert",
 		},
-		// Rule 15: Must include a captcha (5-digit code)
+		// Rule 15: Must include a captcha (5-digit code, or letters and digits)
 		{
 			ID:          15,
-			Description: "Must include a captcha (5-digit code)",
+			Description: captchaDescription(),
 			Validator:   ValidateCaptcha,
-			Hint:        "Enter the 5-digit code shown in the captcha image.",
+			Hint:        captchaHint(),
 			HasCaptcha:  true,
 			Category:    "hard",
 		},
@@ -260,34 +216,15 @@ func Pool() []Rule {
 			HasCaptcha: true, // Reuse captcha display logic for chess board
 			Category:   "expert",
 		},
-		// Rule 20: Your password is not strong enough 🏋️
-		{
-			ID:          20,
-			Description: "Your password is not strong enough 🏋️",
-			Validator: func(t string) bool {
-				// Satisfy if there are at least 3 🏋️ emojis
-				emoji := "🏋️"
-				count := strings.Count(t, emoji)
-				return count >= 3
-			},
-			Hint:     "Add at least 3 🏋️ emojis to your password.",
-			Category: "expert",
-		},
+		// Rule 20: Your password is not strong enough 🏋️ (emoji and count are configurable)
+		strengthRule(),
 		// Rule 21: Must contain a palindrome (3+ characters)
 		{
 			ID:          21,
 			Description: "Must contain a palindrome (3+ characters)",
 			Validator: func(t string) bool {
-				// Check for palindromes of length 3 or more
-				for i := 0; i < len(t); i++ {
-					for j := i + 3; j <= len(t); j++ {
-						substr := t[i:j]
-						if isPalindrome(substr) {
-							return true
-						}
-					}
-				}
-				return false
+				// Letters and digits only, so "a b a" counts but " a " doesn't
+				return ContainsPalindrome(t, 3, true)
 			},
 			Hint:     "Include a palindrome like 'aba', 'racecar', or '121'.",
 			Category: "expert",
@@ -321,24 +258,59 @@ func Pool() []Rule {
 			ID:          25,
 			Description: "It seems like someone here leaked your information, find the insider threat in your password!",
 			Validator:   Rule25InsiderThreat,
-			Hint:        "Delete the imposter letters (highlighted in red) from your password! Add 'NOIMPOSTER' to your password when done.",
+			Hint:        imposterHint(),
 			Category:    "expert",
 		},
+		// Rule 26: Must include the current moon phase
+		moonPhaseRule(),
+		// Rule 27: Must include a country name or flag emoji
+		countryRule(),
+		// Rule 28: Must include a run of strictly increasing (or decreasing) digits
+		monotonicRunRule(),
+		// Rule 29: Must include balanced brackets
+		balancedBracketsRule(),
+		// Rule 30: Must include a standalone negative number (not assigned by default)
+		negativeNumberRule(),
+		// Rule 31: Digits must add up to the difficulty's target (not assigned by default)
+		digitSumRule(DefaultDigitSumTarget),
+		// Rule 32: Must not contain a common word (config/blocklist.txt, not assigned by default)
+		commonWordsRule(),
+	}
+
+	for i := range rulePool {
+		rulePool[i].MatchOptions = matchOptionsFor(rulePool[i].ID)
 	}
 
 	poolLoaded = true
 	return rulePool
 }
 
-// Helper function to check if a string is a palindrome
-func isPalindrome(s string) bool {
-	s = strings.ToLower(s)
-	for i := 0; i < len(s)/2; i++ {
-		if s[i] != s[len(s)-1-i] {
-			return false
+// ContainsPalindrome reports whether s contains a case-insensitive palindrome of at least
+// minLength runes. With ignoreNonAlphanumeric, spaces and punctuation are skipped first.
+// It expands around each center and stops as soon as a long enough palindrome is found, so
+// each center costs at most minLength/2 comparisons and long pasted passwords stay O(n).
+func ContainsPalindrome(s string, minLength int, ignoreNonAlphanumeric bool) bool {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if ignoreNonAlphanumeric && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
 		}
+		runes = append(runes, unicode.ToLower(r))
 	}
-	return true
+
+	n := len(runes)
+	for center := 0; center < 2*n-1; center++ {
+		left := center / 2
+		right := left + center%2
+		for left >= 0 && right < n && runes[left] == runes[right] {
+			if right-left+1 >= minLength {
+				return true
+			}
+			left--
+			right++
+		}
+	}
+	return false
 }
 
 // GetRuleByID returns a rule by its ID from the pool
@@ -352,6 +324,17 @@ func GetRuleByID(id int) *Rule {
 	return nil
 }
 
+// MaxRule returns the highest rule ID in the pool, the ceiling for any rule a player can reach
+func MaxRule() int {
+	maxID := 0
+	for _, rule := range Pool() {
+		if rule.ID > maxID {
+			maxID = rule.ID
+		}
+	}
+	return maxID
+}
+
 // GetRulesByCategory returns all rules in a specific category
 func GetRulesByCategory(category string) []Rule {
 	pool := Pool()
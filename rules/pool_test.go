@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// naiveContainsPalindrome is the substring-by-substring search ContainsPalindrome replaced,
+// kept as a reference for behavior and speed
+func naiveContainsPalindrome(s string, minLength int, ignoreNonAlphanumeric bool) bool {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if ignoreNonAlphanumeric && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		runes = append(runes, unicode.ToLower(r))
+	}
+
+	isPalindrome := func(sub []rune) bool {
+		for i, j := 0, len(sub)-1; i < j; i, j = i+1, j-1 {
+			if sub[i] != sub[j] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := range runes {
+		for j := i + minLength; j <= len(runes); j++ {
+			if isPalindrome(runes[i:j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestContainsPalindrome(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"", false},
+		{"ab", false},
+		{"aba", true},
+		{"abba", true},
+		{"Abcba", true},
+		{"xyzRacecar1", true},
+		{"a-b-a", true},
+		{"abcdef", false},
+		{"a b", false},
+		{"noon!", true},
+		{"é1é", true},
+		{"🙂x🙂", false},
+		{"Password123", false},
+	}
+
+	for _, tt := range tests {
+		got := ContainsPalindrome(tt.password, 3, true)
+		if got != tt.want {
+			t.Errorf("ContainsPalindrome(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+		if naive := naiveContainsPalindrome(tt.password, 3, true); naive != got {
+			t.Errorf("ContainsPalindrome(%q) = %v, but the reference search says %v", tt.password, got, naive)
+		}
+	}
+}
+
+// palindromeFreePassword is a 10KB password without any palindrome of 3+ characters, the
+// worst case for both searches since neither can stop early
+var palindromeFreePassword = strings.Repeat("abcdefghijklmnopqrstuvwxyz0123456789", 10240/36+1)[:10240]
+
+func BenchmarkContainsPalindrome10KB(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ContainsPalindrome(palindromeFreePassword, 3, true)
+	}
+}
+
+func BenchmarkNaiveContainsPalindrome10KB(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		naiveContainsPalindrome(palindromeFreePassword, 3, true)
+	}
+}
+
+func TestMaxRule(t *testing.T) {
+	highest := 0
+	for _, rule := range Pool() {
+		highest = max(highest, rule.ID)
+	}
+	if got := MaxRule(); got != highest {
+		t.Fatalf("MaxRule() = %d, want the highest pool ID %d", got, highest)
+	}
+
+	for difficulty, ids := range loadAssignments() {
+		for _, id := range ids {
+			if id < 1 || id > MaxRule() {
+				t.Errorf("%s assigns rule %d outside 1-%d", difficulty, id, MaxRule())
+			}
+		}
+	}
+}
+
+func TestPalindromeRule(t *testing.T) {
+	rule := GetRuleByID(21)
+	if rule == nil {
+		t.Fatal("rule 21 is not in the pool")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"racecar", "racecar", true},
+		{"racecar in a password", "My$racecar!", true},
+		{"mixed case", "RaceCar", true},
+		{"unicode palindrome", "xyžöž1", true},
+		{"cyrillic palindrome", "дед", true},
+		{"palindrome across punctuation", "a-b-a", true},
+		{"near miss", "abcdba", false},
+		{"unicode near miss", "žöx", false},
+		{"spaces are not palindrome letters", " a ", false},
+		{"punctuation only", "!?!", false},
+		{"too short", "aa", false},
+		{"bytes of one rune don't count", "é", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.Validator(tt.password); got != tt.want {
+				t.Errorf("rule 21(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+
+	// Without skipping, punctuation takes part in the palindrome
+	if !ContainsPalindrome("!?!", 3, false) {
+		t.Error(`ContainsPalindrome("!?!", 3, false) = false, want true`)
+	}
+	if ContainsPalindrome("a-b-a", 5, false) != true || ContainsPalindrome("a-b-c", 5, false) {
+		t.Error("ContainsPalindrome without skipping mishandles punctuation")
+	}
+}
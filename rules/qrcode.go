@@ -2,6 +2,7 @@ package rules
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
@@ -9,11 +10,11 @@ import (
 	"image/png"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	database "passgame/Database"
 
@@ -33,8 +34,9 @@ type QRWord struct {
 	Word string
 }
 
-// FetchRandomWord fetches a random word from multiple APIs with fallback
-func FetchRandomWord() (string, error) {
+// FetchRandomWord fetches a random word from multiple APIs with fallback. It gives up early
+// when ctx is cancelled.
+func FetchRandomWord(ctx context.Context) (string, error) {
 	// Try multiple APIs in order
 	apis := []struct {
 		name   string
@@ -74,23 +76,28 @@ func FetchRandomWord() (string, error) {
 	}
 
 	for _, api := range apis {
-		word, err := fetchRandomWordFromAPI(api.url, api.parser)
+		word, err := fetchRandomWordFromAPI(ctx, api.url, api.parser)
 		if err == nil {
 			return word, nil
 		}
 		log.Printf("API %s failed: %v", api.name, err)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 	}
 
 	return "", fmt.Errorf("all APIs failed")
 }
 
 // fetchRandomWordFromAPI attempts to fetch a word from a specific API
-func fetchRandomWordFromAPI(apiURL string, parser func([]byte) (string, error)) (string, error) {
-	return fetchRandomWordWithRetry(apiURL, parser, 2, 2*time.Second)
+func fetchRandomWordFromAPI(ctx context.Context, apiURL string, parser func([]byte) (string, error)) (string, error) {
+	return fetchRandomWordWithRetry(ctx, apiURL, parser, 2, 2*time.Second)
 }
 
 // fetchRandomWordWithRetry attempts to fetch a random word with exponential backoff
-func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error), maxRetries int, initialDelay time.Duration) (string, error) {
+func fetchRandomWordWithRetry(ctx context.Context, apiURL string, parser func([]byte) (string, error), maxRetries int, initialDelay time.Duration) (word string, err error) {
+	defer func() { recordExternalAPICall("word_api", err) }()
+
 	// Create a client with a timeout to prevent hanging
 	client := &http.Client{
 		Timeout: 10 * time.Second,
@@ -101,12 +108,21 @@ func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error)
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		// Make the request
-		resp, err := client.Get(apiURL)
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %v", err)
+		}
+		resp, err := client.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			lastErr = fmt.Errorf("failed to fetch random word from API: %v", err)
 			if attempt < maxRetries-1 {
 				log.Printf("API attempt %d failed, retrying in %v: %v", attempt+1, delay, err)
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return "", err
+				}
 				delay *= 2 // Exponential backoff
 				continue
 			}
@@ -119,7 +135,9 @@ func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error)
 			lastErr = fmt.Errorf("API returned non-OK status: %d", resp.StatusCode)
 			if attempt < maxRetries-1 {
 				log.Printf("API attempt %d failed with status %d, retrying in %v", attempt+1, resp.StatusCode, delay)
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return "", err
+				}
 				delay *= 2
 				continue
 			}
@@ -132,7 +150,9 @@ func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error)
 			lastErr = fmt.Errorf("failed to read API response: %v", err)
 			if attempt < maxRetries-1 {
 				log.Printf("API attempt %d failed to read response, retrying in %v: %v", attempt+1, delay, err)
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return "", err
+				}
 				delay *= 2
 				continue
 			}
@@ -145,7 +165,9 @@ func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error)
 			lastErr = err
 			if attempt < maxRetries-1 {
 				log.Printf("API attempt %d failed to parse response, retrying in %v: %v", attempt+1, delay, err)
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return "", err
+				}
 				delay *= 2
 				continue
 			}
@@ -159,6 +181,30 @@ func fetchRandomWordWithRetry(apiURL string, parser func([]byte) (string, error)
 	return "", lastErr
 }
 
+// sleepContext waits for d, returning early with ctx's error if it is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NormalizeQRWord trims and lowercases a word and strips everything but letters,
+// so "Hello", " hello " and "hel-lo" all become "hello"
+func NormalizeQRWord(word string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(word)) {
+		if unicode.IsLetter(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
 // GetFallbackWords returns a list of fallback words in case the API is unavailable
 func GetFallbackWords() []string {
 	return []string{
@@ -199,6 +245,69 @@ func GetFallbackWords() []string {
 	}
 }
 
+// defaultQRLanguage uses the built-in English list from GetFallbackWords
+const defaultQRLanguage = "en"
+
+// qrLanguage selects which rules/words/<lang>.txt list seeds and backs up the QR words
+var qrLanguage = defaultQRLanguage
+
+// SetQRLanguage selects the language of QR code words, e.g. "es" for rules/words/es.txt.
+// An empty or invalid language falls back to English.
+func SetQRLanguage(lang string) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	for _, r := range lang {
+		if (r < 'a' || r > 'z') && r != '-' {
+			log.Printf("Warning: Invalid QR language '%s', using English", lang)
+			lang = ""
+			break
+		}
+	}
+	if lang == "" {
+		lang = defaultQRLanguage
+	}
+
+	qrMutex.Lock()
+	qrLanguage = lang
+	qrMutex.Unlock()
+}
+
+// getQRLanguage returns the configured QR word language
+func getQRLanguage() string {
+	qrMutex.RLock()
+	defer qrMutex.RUnlock()
+	return qrLanguage
+}
+
+// LoadFallbackWords returns the fallback words for a language from rules/words/<lang>.txt,
+// one word per line with # comments. It falls back to the English list when the file is
+// missing or empty.
+func LoadFallbackWords(lang string) []string {
+	if lang == "" || lang == defaultQRLanguage {
+		return GetFallbackWords()
+	}
+
+	data, err := ioutil.ReadFile("rules/words/" + lang + ".txt")
+	if err != nil {
+		log.Printf("Warning: Could not read QR word list for '%s', using English: %v", lang, err)
+		return GetFallbackWords()
+	}
+
+	var words []string
+	for _, line := range strings.Split(string(data), "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) == 0 {
+		log.Printf("Warning: QR word list for '%s' is empty, using English", lang)
+		return GetFallbackWords()
+	}
+	return words
+}
+
 // InitQRCodeTable initializes the QR code words table in the database
 func InitQRCodeTable() error {
 	db := database.GetDB()
@@ -226,12 +335,16 @@ func InitQRCodeTable() error {
 		return fmt.Errorf("failed to check qr_words count: %v", err)
 	}
 
-	// If the table is empty, populate it with fallback words
+	// If the table is empty, populate it with the fallback words for the configured language
 	if count == 0 {
-		fallbackWords := GetFallbackWords()
+		fallbackWords := LoadFallbackWords(getQRLanguage())
 
-		insertSQL := "INSERT INTO qr_words (word) VALUES (?)"
+		insertSQL := "INSERT INTO qr_words (word) VALUES (?) ON CONFLICT(word) DO NOTHING"
 		for _, word := range fallbackWords {
+			word = NormalizeQRWord(word)
+			if word == "" {
+				continue
+			}
 			_, err := db.Exec(insertSQL, word)
 			if err != nil {
 				log.Printf("Warning: failed to insert QR word '%s': %v", word, err)
@@ -260,7 +373,8 @@ func GetRandomQRWord() (string, error) {
 		return "", fmt.Errorf("failed to get random QR word: %v", err)
 	}
 
-	return word, nil
+	// Older rows may predate normalization
+	return NormalizeQRWord(word), nil
 }
 
 // GenerateQRCode creates a QR code for the given text and returns it as a base64-encoded PNG
@@ -290,9 +404,13 @@ func GenerateQRCode(text string) (string, error) {
 
 // GenerateNewQRCode creates a new QR code with a random word from the database
 func GenerateNewQRCode() (string, string, error) {
-	word, err := GetRandomQRWord()
-	if err != nil {
-		return "", "", fmt.Errorf("failed to get random QR word: %v", err)
+	word := TestQRWord
+	if !testMode {
+		var err error
+		word, err = GetRandomQRWord()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get random QR word: %v", err)
+		}
 	}
 
 	qrImageB64, err := GenerateQRCode(word)
@@ -341,7 +459,7 @@ func ServeQRCodeImage(w http.ResponseWriter, r *http.Request) {
 
 	if qrImageB64 == "" {
 		// Generate new QR code with a word from the API if none exists
-		err := RefreshQRCodeWithAPI()
+		_, err := RefreshQRCodeWithAPI(r.Context())
 		if err != nil {
 			// Fall back to regular refresh if API word generation fails
 			err = RefreshQRCode()
@@ -374,7 +492,7 @@ func ServeQRCodeImage(w http.ResponseWriter, r *http.Request) {
 // RefreshQRCodeHandler generates a new QR code and returns success status
 func RefreshQRCodeHandler(w http.ResponseWriter, r *http.Request) {
 	// Use the API word generator for refreshing
-	err := RefreshQRCodeWithAPI()
+	_, err := RefreshQRCodeWithAPI(r.Context())
 	if err != nil {
 		// Fall back to regular refresh if API word generation fails
 		err = RefreshQRCode()
@@ -387,8 +505,8 @@ func RefreshQRCodeHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the current word to display in the response
 	word := GetCurrentQRWord()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(fmt.Sprintf(`{"status": "refreshed", "word": "%s"}`, word)))
+	value := map[string]string{"word": word}
+	WriteRefreshResponse(w, "qrcode", value, value)
 }
 
 // ValidateQRCodeWord checks if the password contains the current QR code word
@@ -397,11 +515,12 @@ func ValidateQRCodeWord(password string) bool {
 	word := currentQRWord
 	qrMutex.RUnlock()
 
+	word = NormalizeQRWord(word)
 	if word == "" {
 		return false
 	}
 
-	return strings.Contains(strings.ToLower(password), strings.ToLower(word))
+	return matches(password, word, matchOptionsFor(17))
 }
 
 // GenerateRandomString creates a random string of specified length
@@ -409,25 +528,34 @@ func GenerateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+		b[i] = charset[randIntn(len(charset))]
 	}
 	return string(b)
 }
 
+// randomWordSource fetches the words AddRandomWordFromAPI stores, replaceable in tests
+var randomWordSource = FetchRandomWord
+
 // AddRandomWordFromAPI adds a new random word from the API to the database
-func AddRandomWordFromAPI() (string, error) {
+func AddRandomWordFromAPI(ctx context.Context) (string, error) {
 	db := database.GetDB()
 	if db == nil {
 		return "", fmt.Errorf("database connection not available")
 	}
 
 	// Fetch a random word from the API
-	randomWord, err := FetchRandomWord()
+	randomWord, err := randomWordSource(ctx)
+	if err == nil {
+		randomWord = NormalizeQRWord(randomWord)
+		if randomWord == "" {
+			err = fmt.Errorf("API word has no letters")
+		}
+	}
 	if err != nil {
 		// If API fails, fall back to a random word from our fallback list
 		log.Printf("Warning: Failed to fetch word from API: %v. Using fallback.", err)
-		fallbackWords := GetFallbackWords()
-		randomWord = fallbackWords[rand.Intn(len(fallbackWords))]
+		fallbackWords := LoadFallbackWords(getQRLanguage())
+		randomWord = NormalizeQRWord(fallbackWords[randIntn(len(fallbackWords))])
 	}
 
 	// Insert the word into the database if it doesn't exist
@@ -440,19 +568,39 @@ func AddRandomWordFromAPI() (string, error) {
 	return randomWord, nil
 }
 
-// RefreshQRCodeWithAPI generates a new QR code with a word from the API
-func RefreshQRCodeWithAPI() error {
+// RefreshQRCodeWithAPI generates a new QR code with a word from the API. It reports whether
+// the current word changed; the QR image isn't regenerated when the word is the same.
+// Cancelling ctx abandons the API lookup.
+func RefreshQRCodeWithAPI(ctx context.Context) (bool, error) {
+	// The word APIs only return English words, and test mode never calls them
+	if testMode || getQRLanguage() != defaultQRLanguage {
+		if err := RefreshQRCode(); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
 	// Add a new word from the API to the database
-	apiWord, err := AddRandomWordFromAPI()
+	apiWord, err := AddRandomWordFromAPI(ctx)
 	if err != nil {
 		// If adding an API word fails, fall back to existing words
-		return RefreshQRCode()
+		if err := RefreshQRCode(); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	qrMutex.RLock()
+	unchanged := apiWord == currentQRWord && currentQRImageB64 != ""
+	qrMutex.RUnlock()
+	if unchanged {
+		return false, nil
 	}
 
 	// Generate QR code for the API word
 	qrImageB64, err := GenerateQRCode(apiWord)
 	if err != nil {
-		return fmt.Errorf("failed to generate QR code: %v", err)
+		return false, fmt.Errorf("failed to generate QR code: %v", err)
 	}
 
 	qrMutex.Lock()
@@ -461,34 +609,40 @@ func RefreshQRCodeWithAPI() error {
 	currentQRWord = apiWord
 	currentQRImageB64 = qrImageB64
 
-	return nil
+	return true, nil
 }
 
-// Initialize QR code on package load
-func init() {
-	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
+// DefaultQRRefreshInterval is how often a fresh QR code word is picked by default
+const DefaultQRRefreshInterval = 10 * time.Minute
 
-	// Initial QR code will be generated when the database is initialized
-	// This happens in the main.go file after the database is connected
+// StartQRCodeRefreshLoop refreshes the QR code every interval until ctx is cancelled, so users
+// always get a fresh QR code when they reach this rule. A non-positive interval uses
+// DefaultQRRefreshInterval.
+func StartQRCodeRefreshLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultQRRefreshInterval
+	}
+	go runQRCodeRefreshLoop(ctx, interval)
+}
 
-	// We'll also set up a goroutine to periodically refresh the QR code
-	// This ensures users always get a fresh QR code when they reach this rule
-	go func() {
-		// Wait for database initialization (5 seconds should be enough)
-		time.Sleep(5 * time.Second)
+// runQRCodeRefreshLoop blocks, refreshing on every tick, and returns once ctx is cancelled
+func runQRCodeRefreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		// Refresh the QR code every 10 minutes
-		for {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 			// Try to refresh with a word from the API first
-			err := RefreshQRCodeWithAPI()
+			changed, err := RefreshQRCodeWithAPI(ctx)
 			if err != nil {
 				// Fall back to regular refresh if API word generation fails
 				_ = RefreshQRCode()
+			} else if !changed {
+				log.Println("QR word unchanged, keeping the current QR code")
 			}
-
-			// Wait before refreshing again
-			time.Sleep(10 * time.Minute)
 		}
-	}()
+	}
 }
@@ -0,0 +1,293 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	database "passgame/Database"
+)
+
+// useEmptyQRWords drops the qr_words table so the test starts unseeded, and drops it again
+// afterwards
+func useEmptyQRWords(t *testing.T) {
+	t.Helper()
+	drop := func() {
+		if _, err := database.GetDB().Exec("DROP TABLE IF EXISTS qr_words"); err != nil {
+			t.Fatalf("failed to drop qr_words: %v", err)
+		}
+	}
+	drop()
+	t.Cleanup(drop)
+}
+
+// storedQRWords lists the words in the qr_words table, sorted
+func storedQRWords(t *testing.T) []string {
+	t.Helper()
+	rows, err := database.GetDB().Query("SELECT word FROM qr_words")
+	if err != nil {
+		t.Fatalf("failed to read qr_words: %v", err)
+	}
+	defer rows.Close()
+
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			t.Fatal(err)
+		}
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func TestInitQRCodeTableSeedsLanguage(t *testing.T) {
+	useEmptyQRWords(t)
+	const path = "rules/words/xx.txt"
+	if err := os.WriteFile(path, []byte("# test words\nGato\n  perro  \n\nár-bol\ngato\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+	SetQRLanguage("xx")
+	t.Cleanup(func() { SetQRLanguage("") })
+
+	if err := InitQRCodeTable(); err != nil {
+		t.Fatalf("InitQRCodeTable() error = %v", err)
+	}
+	if got, want := storedQRWords(t), []string{"gato", "perro", "árbol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("seeded words = %v, want %v", got, want)
+	}
+
+	// An already seeded table is left alone
+	SetQRLanguage("")
+	if err := InitQRCodeTable(); err != nil {
+		t.Fatalf("second InitQRCodeTable() error = %v", err)
+	}
+	if got := storedQRWords(t); len(got) != 3 {
+		t.Errorf("reseeding changed the table to %d words", len(got))
+	}
+}
+
+func TestLoadFallbackWords(t *testing.T) {
+	english := GetFallbackWords()
+
+	tests := []struct {
+		name string
+		lang string
+		want []string
+	}{
+		{"English", "en", english},
+		{"default", "", english},
+		{"missing locale file", "zz", english},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LoadFallbackWords(tt.lang); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFallbackWords(%q) returned %d words, want %d", tt.lang, len(got), len(tt.want))
+			}
+		})
+	}
+
+	if spanish := LoadFallbackWords("es"); len(spanish) == 0 || reflect.DeepEqual(spanish, english) {
+		t.Error("LoadFallbackWords(es) did not load the Spanish list")
+	}
+}
+
+func TestNormalizeQRWord(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"hello", "hello"},
+		{"Hello", "hello"},
+		{"  HELLO \n", "hello"},
+		{"hel-lo", "hello"},
+		{"well-being's", "wellbeings"},
+		{"abc123", "abc"},
+		{"Árbol", "árbol"},
+		{"123-456", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeQRWord(tt.input); got != tt.want {
+			t.Errorf("NormalizeQRWord(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// useQRWord sets the current QR word for the test and restores the previous one afterwards
+func useQRWord(t *testing.T, word string) {
+	t.Helper()
+	qrMutex.Lock()
+	previous := currentQRWord
+	currentQRWord = word
+	qrMutex.Unlock()
+
+	t.Cleanup(func() {
+		qrMutex.Lock()
+		currentQRWord = previous
+		qrMutex.Unlock()
+	})
+}
+
+func TestValidateQRCodeWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		want     bool
+	}{
+		{"exact", "hello", "xxhelloyy", true},
+		{"password in upper case", "hello", "HELLO!1", true},
+		{"stored in mixed case", "Hello", "myhello", true},
+		{"stored with whitespace", "  hello\t", "hello", true},
+		{"stored with a hyphen", "hel-lo", "hello", true},
+		{"different word", "hello", "help", false},
+		{"no word yet", "", "hello", false},
+		{"stored word without letters", "123", "123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useQRWord(t, tt.stored)
+			if got := ValidateQRCodeWord(tt.password); got != tt.want {
+				t.Errorf("ValidateQRCodeWord(%q) with %q stored = %v, want %v", tt.password, tt.stored, got, tt.want)
+			}
+		})
+	}
+}
+
+// useWordSource leaves test mode and makes the QR refresh fetch the given words in turn,
+// restoring the real source afterwards. It returns a counter of the fetches made.
+func useWordSource(t *testing.T, words ...string) *int {
+	t.Helper()
+	fetches := 0
+	previous := randomWordSource
+	randomWordSource = func(ctx context.Context) (string, error) {
+		word := words[fetches%len(words)]
+		fetches++
+		return word, nil
+	}
+	testMode = false
+
+	t.Cleanup(func() {
+		randomWordSource = previous
+		testMode = true
+	})
+	return &fetches
+}
+
+func TestRefreshQRCodeWithAPISkipsUnchangedWord(t *testing.T) {
+	useEmptyQRWords(t)
+	if err := InitQRCodeTable(); err != nil {
+		t.Fatalf("InitQRCodeTable() error = %v", err)
+	}
+	useQRWord(t, "")
+	fetches := useWordSource(t, "Apple", "apple", "banana")
+
+	changed, err := RefreshQRCodeWithAPI(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("first refresh = %v, %v, want a change", changed, err)
+	}
+	if got := GetCurrentQRWord(); got != "apple" {
+		t.Fatalf("current word = %q, want apple", got)
+	}
+
+	// Mark the generated image, so a regeneration would replace it
+	qrMutex.Lock()
+	currentQRImageB64 = "first-image"
+	qrMutex.Unlock()
+
+	changed, err = RefreshQRCodeWithAPI(context.Background())
+	if err != nil || changed {
+		t.Fatalf("refresh with the same word = %v, %v, want no change", changed, err)
+	}
+	if got := GetCurrentQRImageB64(); got != "first-image" {
+		t.Error("the QR image was regenerated for an unchanged word")
+	}
+
+	changed, err = RefreshQRCodeWithAPI(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("refresh with a new word = %v, %v, want a change", changed, err)
+	}
+	if got := GetCurrentQRWord(); got != "banana" {
+		t.Errorf("current word = %q, want banana", got)
+	}
+	if got := GetCurrentQRImageB64(); got == "first-image" || got == "" {
+		t.Error("the QR image wasn't regenerated for a new word")
+	}
+	if *fetches != 3 {
+		t.Errorf("fetched %d words, want 3", *fetches)
+	}
+}
+
+// rawWord treats the whole response body as the word
+func rawWord(body []byte) (string, error) {
+	return string(body), nil
+}
+
+// newHangingServer starts a server that never answers until the client gives up
+func newHangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// returnsPromptly runs call with a context that is cancelled shortly after it starts and
+// fails the test unless call gives up soon after
+func returnsPromptly(t *testing.T, call func(ctx context.Context) error) error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- call(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("call did not return after its context was cancelled")
+		return nil
+	}
+}
+
+func TestFetchRandomWordWithRetryCancelledMidRequest(t *testing.T) {
+	server := newHangingServer(t)
+
+	err := returnsPromptly(t, func(ctx context.Context) error {
+		_, err := fetchRandomWordWithRetry(ctx, server.URL, rawWord, 3, time.Millisecond)
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchRandomWordWithRetryCancelledDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := returnsPromptly(t, func(ctx context.Context) error {
+		_, err := fetchRandomWordWithRetry(ctx, server.URL, rawWord, 3, time.Hour)
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
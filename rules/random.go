@@ -0,0 +1,37 @@
+package rules
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// rng is the random source behind every challenge pick in this package: QR words, chess
+// puzzles, imposter positions, update strings and captcha text. It's seeded from the clock
+// once at startup; SetRandSource swaps it for a fixed seed.
+var (
+	rng      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rngMutex sync.Mutex
+)
+
+// SetRandSource replaces the package's random source, e.g. rand.NewSource(1) for reproducible
+// challenges in tests
+func SetRandSource(src rand.Source) {
+	rngMutex.Lock()
+	defer rngMutex.Unlock()
+	rng = rand.New(src)
+}
+
+// randIntn returns a random int in [0, n) from the package source
+func randIntn(n int) int {
+	rngMutex.Lock()
+	defer rngMutex.Unlock()
+	return rng.Intn(n)
+}
+
+// randShuffle shuffles n elements with swap using the package source
+func randShuffle(n int, swap func(i, j int)) {
+	rngMutex.Lock()
+	defer rngMutex.Unlock()
+	rng.Shuffle(n, swap)
+}
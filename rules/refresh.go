@@ -0,0 +1,29 @@
+package rules
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteRefreshResponse answers a /refresh-* endpoint with the shape shared by all of them:
+// {"status":"refreshed","type":kind,"value":{...}}. Fields of legacy are copied to the top
+// level for clients written against the older per-endpoint responses, unless they'd clash
+// with status, type or value.
+func WriteRefreshResponse(w http.ResponseWriter, kind string, value, legacy map[string]string) {
+	if value == nil {
+		value = map[string]string{}
+	}
+	response := map[string]interface{}{
+		"status": "refreshed",
+		"type":   kind,
+		"value":  value,
+	}
+	for key, v := range legacy {
+		if _, taken := response[key]; !taken {
+			response[key] = v
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,191 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRefreshLoopsStopOnCancel(t *testing.T) {
+	loops := []struct {
+		name string
+		run  func(ctx context.Context, interval time.Duration)
+	}{
+		{"constants", runConstantsRefreshLoop},
+		{"QR code", runQRCodeRefreshLoop},
+	}
+
+	for _, loop := range loops {
+		t.Run(loop.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				loop.run(ctx, time.Hour)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				t.Fatal("loop returned before its context was cancelled")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("loop still running a second after cancel")
+			}
+		})
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRefreshLoopsTickAtInterval(t *testing.T) {
+	useQRWord(t, "stale")
+	useColor(t, "Stale", "#000001")
+
+	constantsMutex.Lock()
+	previousName, previousValue := currentConstantName, currentConstant
+	currentConstantName, currentConstant = "Stale", "0.00000"
+	constantsMutex.Unlock()
+	t.Cleanup(func() {
+		constantsMutex.Lock()
+		currentConstantName, currentConstant = previousName, previousValue
+		constantsMutex.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	StartQRCodeRefreshLoop(ctx, 5*time.Millisecond)
+	StartConstantsRefreshLoop(ctx, 5*time.Millisecond)
+
+	waitFor(t, "the QR word to refresh", func() bool { return GetCurrentQRWord() != "stale" })
+	waitFor(t, "the color to refresh", func() bool {
+		_, hexCode := GetCurrentColor()
+		return hexCode != "#000001"
+	})
+	waitFor(t, "the constant to refresh", func() bool {
+		_, value := GetCurrentMathConstant()
+		return value != "0.00000"
+	})
+
+	// Stop the loops before the cleanups restore the previous values
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
+
+// refreshResponse is the shape every /refresh-* endpoint answers with, plus the legacy fields
+type refreshResponse struct {
+	Status string            `json:"status"`
+	Type   string            `json:"type"`
+	Value  map[string]string `json:"value"`
+	Legacy map[string]interface{}
+}
+
+// decodeRefreshResponse checks a refresh handler's response and decodes it
+func decodeRefreshResponse(t *testing.T, w *httptest.ResponseRecorder) refreshResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want %d", w.Code, w.Body.String(), http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	body := w.Body.Bytes()
+	var resp refreshResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("invalid JSON %s: %v", body, err)
+	}
+	if err := json.Unmarshal(body, &resp.Legacy); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"status", "type", "value"} {
+		delete(resp.Legacy, key)
+	}
+	return resp
+}
+
+func TestWriteRefreshResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteRefreshResponse(w, "thing", map[string]string{"id": "7"}, map[string]string{"id": "7", "type": "clash", "status": "clash"})
+	resp := decodeRefreshResponse(t, w)
+	if resp.Status != "refreshed" || resp.Type != "thing" || resp.Value["id"] != "7" {
+		t.Errorf("response = %+v, want a refreshed thing with id 7", resp)
+	}
+	// Legacy fields never replace the standard ones
+	if len(resp.Legacy) != 1 || resp.Legacy["id"] != "7" {
+		t.Errorf("legacy fields = %v, want only id", resp.Legacy)
+	}
+
+	w = httptest.NewRecorder()
+	WriteRefreshResponse(w, "empty", nil, nil)
+	if resp := decodeRefreshResponse(t, w); resp.Value == nil || len(resp.Value) != 0 || len(resp.Legacy) != 0 {
+		t.Errorf("response = %+v, want an empty value object and no legacy fields", resp)
+	}
+}
+
+func TestRefreshHandlersResponseShape(t *testing.T) {
+	useQRWord(t, "stale")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		kind    string
+		value   map[string]string
+		legacy  map[string]interface{}
+	}{
+		{"QR code", RefreshQRCodeHandler, "qrcode",
+			map[string]string{"word": TestQRWord}, map[string]interface{}{"word": TestQRWord}},
+		{"chess", RefreshChess, "chess",
+			map[string]string{"bestMove": TestChessMove}, map[string]interface{}{"bestMove": TestChessMove}},
+		// The captcha's value is its new ID, checked below
+		{"captcha", RefreshCaptcha, "captcha", nil, map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+			resp := decodeRefreshResponse(t, w)
+
+			if resp.Status != "refreshed" || resp.Type != tt.kind {
+				t.Errorf("status %q type %q, want refreshed %s", resp.Status, resp.Type, tt.kind)
+			}
+			if tt.value == nil {
+				tt.value = map[string]string{"id": GetCurrentCaptchaID()}
+			}
+			if len(resp.Value) != len(tt.value) {
+				t.Errorf("value = %v, want %v", resp.Value, tt.value)
+			}
+			for key, want := range tt.value {
+				if resp.Value[key] != want {
+					t.Errorf("value[%s] = %q, want %q", key, resp.Value[key], want)
+				}
+			}
+			if len(resp.Legacy) != len(tt.legacy) {
+				t.Errorf("legacy fields = %v, want %v", resp.Legacy, tt.legacy)
+			}
+			for key, want := range tt.legacy {
+				if resp.Legacy[key] != want {
+					t.Errorf("legacy %s = %v, want %v", key, resp.Legacy[key], want)
+				}
+			}
+		})
+	}
+}
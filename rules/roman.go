@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxRomanValue is the largest number standard Roman numerals can write (MMMCMXCIX)
+const maxRomanValue = 3999
+
+// maxRomanLength is the length of the longest standard numeral (MMMDCCCLXXXVIII)
+const maxRomanLength = 15
+
+// romanNumeralMinimum, when positive, makes Rule 5 require a valid Roman number worth at least
+// this much instead of any single numeral character
+var romanNumeralMinimum int
+
+// SetRomanNumeralMinimum configures the stricter Rule 5. Call it before the rule pool is first
+// built, since the rule's description includes the value. Zero keeps the original rule.
+func SetRomanNumeralMinimum(minimum int) {
+	if minimum < 0 || minimum > maxRomanValue {
+		minimum = 0
+	}
+	romanNumeralMinimum = minimum
+}
+
+var romanValues = []struct {
+	value   int
+	numeral string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+	{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+	{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// ToRoman writes n (1-3999) as a standard uppercase Roman numeral, or "" when out of range
+func ToRoman(n int) string {
+	if n <= 0 || n > maxRomanValue {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, rv := range romanValues {
+		for n >= rv.value {
+			sb.WriteString(rv.numeral)
+			n -= rv.value
+		}
+	}
+	return sb.String()
+}
+
+// ParseRoman parses a standard uppercase Roman numeral. Non-canonical forms such as "IIII",
+// "VV" or "IC" are rejected.
+func ParseRoman(s string) (int, bool) {
+	if s == "" || len(s) > maxRomanLength {
+		return 0, false
+	}
+
+	total := 0
+	rest := s
+	for _, rv := range romanValues {
+		for strings.HasPrefix(rest, rv.numeral) {
+			total += rv.value
+			rest = rest[len(rv.numeral):]
+		}
+	}
+	if rest != "" {
+		return 0, false
+	}
+
+	// Greedy parsing accepts repeats like "IIII"; only the canonical spelling is valid
+	if ToRoman(total) != s {
+		return 0, false
+	}
+	return total, true
+}
+
+// romanNumeralRule builds Rule 5, using the stricter value check when a minimum is configured
+func romanNumeralRule() Rule {
+	if romanNumeralMinimum > 0 {
+		minimum := romanNumeralMinimum
+		return Rule{
+			ID:          5,
+			Description: fmt.Sprintf("Must include a Roman numeral worth at least %d (%s)", minimum, ToRoman(minimum)),
+			Validator: func(t string) bool {
+				return ContainsRomanNumeralAtLeast(t, minimum)
+			},
+			Hint:     fmt.Sprintf("Write a valid uppercase Roman numeral of %d or more, e.g. %s. Forms like IIII don't count.", minimum, ToRoman(minimum)),
+			Category: "basic",
+		}
+	}
+
+	return Rule{
+		ID:          5,
+		Description: "Must include Roman numerals (I, V, X, L, C, D, M)",
+		Validator: func(t string) bool {
+			romanNumerals := "IVXLCDM"
+			for _, char := range t {
+				if strings.ContainsRune(romanNumerals, char) {
+					return true
+				}
+			}
+			return false
+		},
+		Hint:     "Include Roman numerals: I, V, X, L, C, D, M",
+		Category: "basic",
+	}
+}
+
+// ContainsRomanNumeralAtLeast reports whether text contains a valid Roman numeral worth at
+// least minimum. Each run of consecutive numeral characters is read as one number, so "IX"
+// counts as 9 rather than containing an X, and "IIII" doesn't count at all.
+func ContainsRomanNumeralAtLeast(text string, minimum int) bool {
+	isNumeral := func(r rune) bool { return strings.ContainsRune("IVXLCDM", r) }
+
+	for _, run := range strings.FieldsFunc(text, func(r rune) bool { return !isNumeral(r) }) {
+		if value, ok := ParseRoman(run); ok && value >= minimum {
+			return true
+		}
+	}
+	return false
+}
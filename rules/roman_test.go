@@ -0,0 +1,104 @@
+package rules
+
+import "testing"
+
+func TestParseRoman(t *testing.T) {
+	tests := []struct {
+		numeral string
+		want    int
+		wantOK  bool
+	}{
+		{"I", 1, true},
+		{"IV", 4, true},
+		{"IX", 9, true},
+		{"X", 10, true},
+		{"XIV", 14, true},
+		{"XL", 40, true},
+		{"XC", 90, true},
+		{"CD", 400, true},
+		{"CM", 900, true},
+		{"MCMXCIV", 1994, true},
+		{"MMMDCCCLXXXVIII", 3888, true},
+		{"MMMCMXCIX", 3999, true},
+		{"", 0, false},
+		{"IIII", 0, false},
+		{"VV", 0, false},
+		{"IC", 0, false},
+		{"IL", 0, false},
+		{"XXXX", 0, false},
+		{"IIV", 0, false},
+		{"VX", 0, false},
+		{"MMMM", 0, false},
+		{"iv", 0, false},
+		{"X1", 0, false},
+		{"ABC", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseRoman(tt.numeral)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseRoman(%q) = %d, %v, want %d, %v", tt.numeral, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// Every number round-trips through its canonical spelling
+	for n := 1; n <= maxRomanValue; n++ {
+		if got, ok := ParseRoman(ToRoman(n)); !ok || got != n {
+			t.Fatalf("ParseRoman(ToRoman(%d)) = %d, %v", n, got, ok)
+		}
+	}
+}
+
+func TestContainsRomanNumeralAtLeast(t *testing.T) {
+	tests := []struct {
+		text    string
+		minimum int
+		want    bool
+	}{
+		{"abcX1!", 10, true},
+		{"abcIX1!", 10, false},
+		{"myXIVpass", 10, true},
+		{"IIII", 4, false},
+		{"IIII", 1, false},
+		{"XIIII", 10, false},
+		{"XIII", 10, true},
+		{"IIII-X", 10, true},
+		{"I-X", 10, true},
+		{"I-V", 10, false},
+		{"MCMXCIV", 1994, true},
+		{"MCMXCIV", 1995, false},
+		{"xiv", 10, false},
+		{"", 1, false},
+	}
+
+	for _, tt := range tests {
+		if got := ContainsRomanNumeralAtLeast(tt.text, tt.minimum); got != tt.want {
+			t.Errorf("ContainsRomanNumeralAtLeast(%q, %d) = %v, want %v", tt.text, tt.minimum, got, tt.want)
+		}
+	}
+}
+
+func TestRomanNumeralRuleMinimum(t *testing.T) {
+	t.Cleanup(func() { SetRomanNumeralMinimum(0) })
+
+	SetRomanNumeralMinimum(0)
+	if rule := romanNumeralRule(); !rule.Validator("I") {
+		t.Error("the original rule 5 rejects a lone I")
+	}
+
+	SetRomanNumeralMinimum(10)
+	rule := romanNumeralRule()
+	for password, want := range map[string]bool{"I": false, "IX": false, "IIII": false, "X": true, "XL": true} {
+		if got := rule.Validator(password); got != want {
+			t.Errorf("rule 5 with minimum 10 (%q) = %v, want %v", password, got, want)
+		}
+	}
+
+	// Out-of-range minimums keep the original rule
+	for _, minimum := range []int{-1, maxRomanValue + 1} {
+		SetRomanNumeralMinimum(minimum)
+		if romanNumeralMinimum != 0 {
+			t.Errorf("SetRomanNumeralMinimum(%d) set %d, want 0", minimum, romanNumeralMinimum)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+)
+
+// sponsorsFile lets a deployment or event customize the Rule 8 sponsors without recompiling
+const sponsorsFile = "config/sponsors.json"
+
+// defaultSponsors are used when sponsorsFile is absent or unusable
+var defaultSponsors = []string{"Pepsi", "Starbucks", "Shell"}
+
+// loadSponsors reads the sponsor names from sponsorsFile, a JSON array of strings.
+// It falls back to defaultSponsors when the file is missing, invalid, or empty.
+func loadSponsors() []string {
+	sponsorsData, err := os.Open(sponsorsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Could not open sponsors.json: %v", err)
+		}
+		return defaultSponsors
+	}
+	defer sponsorsData.Close()
+
+	var names []string
+	if err := json.NewDecoder(sponsorsData).Decode(&names); err != nil {
+		log.Printf("Warning: Could not decode sponsors.json: %v", err)
+		return defaultSponsors
+	}
+
+	sponsors := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			sponsors = append(sponsors, name)
+		}
+	}
+	if len(sponsors) == 0 {
+		log.Printf("Warning: sponsors.json has no sponsors, using the defaults")
+		return defaultSponsors
+	}
+	return sponsors
+}
+
+// sponsorRule builds Rule 8 from the configured sponsor list, matched case-insensitively
+func sponsorRule() Rule {
+	sponsors := loadSponsors()
+	list := strings.Join(sponsors, ", ")
+
+	return Rule{
+		ID:          8,
+		Description: "Must contain one of our following sponsors: (" + list + ")",
+		Validator: func(t string) bool {
+			for _, sponsor := range sponsors {
+				if matches(t, sponsor, matchOptionsFor(8)) {
+					return true
+				}
+			}
+			return false
+		},
+		Hint:     "Include one of our sponsors: " + list,
+		Category: "intermediate",
+	}
+}
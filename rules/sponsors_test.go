@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestSponsors writes config/sponsors.json (in the test copy) for one test and removes it
+// afterwards
+func writeTestSponsors(t *testing.T, data string) {
+	t.Helper()
+	if err := os.WriteFile(sponsorsFile, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", sponsorsFile, err)
+	}
+	t.Cleanup(func() { os.Remove(sponsorsFile) })
+}
+
+func TestSponsorRuleCustomList(t *testing.T) {
+	writeTestSponsors(t, `["Acme", "  Globex ", ""]`)
+
+	if got := loadSponsors(); !reflect.DeepEqual(got, []string{"Acme", "Globex"}) {
+		t.Fatalf("loadSponsors() = %v, want [Acme Globex]", got)
+	}
+
+	rule := sponsorRule()
+	if !strings.Contains(rule.Description, "(Acme, Globex)") || !strings.Contains(rule.Hint, "Acme, Globex") {
+		t.Errorf("rule 8 reads %q / %q, want the configured sponsors", rule.Description, rule.Hint)
+	}
+	if strings.Contains(rule.Description, "Pepsi") {
+		t.Errorf("rule 8 description %q still names a default sponsor", rule.Description)
+	}
+
+	for password, want := range map[string]bool{
+		"iloveACMEx":   true,
+		"globex1!":     true,
+		"Pepsi123":     false,
+		"Acm e":        false,
+		"nothing here": false,
+	} {
+		if got := rule.Validator(password); got != want {
+			t.Errorf("rule 8(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestSponsorRuleFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"absent file", ""},
+		{"invalid JSON", `{"sponsors": ["Acme"]}`},
+		{"empty list", `[]`},
+		{"only blank names", `["", "  "]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data != "" {
+				writeTestSponsors(t, tt.data)
+			}
+			if got := loadSponsors(); !reflect.DeepEqual(got, defaultSponsors) {
+				t.Errorf("loadSponsors() = %v, want the defaults %v", got, defaultSponsors)
+			}
+
+			rule := sponsorRule()
+			for _, password := range []string{"pepsi", "STARBUCKS", "Shell!"} {
+				if !rule.Validator(password) {
+					t.Errorf("rule 8(%q) = false, want true", password)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultStrengthEmoji is the weightlifter Rule 20 asks for by default
+	DefaultStrengthEmoji = "🏋️"
+	// DefaultStrengthRequiredCount is how many emojis Rule 20 needs by default
+	DefaultStrengthRequiredCount = 3
+	// variationSelector16 (VS-16) asks for the emoji presentation of the character before it.
+	// It is often present or missing depending on the keyboard, so it is ignored when counting.
+	variationSelector16 = "\uFE0F"
+)
+
+// Rule 20 settings; set them before the rule pool is first built, since the description and
+// hint include them
+var (
+	strengthEmoji         = DefaultStrengthEmoji
+	strengthRequiredCount = DefaultStrengthRequiredCount
+)
+
+// SetStrengthRule configures the emoji Rule 20 asks for and how many are required, so events
+// can theme the rule (e.g. "🏆"). A count below 1 keeps the default count.
+func SetStrengthRule(emoji string, count int) error {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" || strings.Trim(emoji, variationSelector16) == "" {
+		return fmt.Errorf("strength emoji cannot be empty")
+	}
+	if count < 1 {
+		count = DefaultStrengthRequiredCount
+	}
+	strengthEmoji = emoji
+	strengthRequiredCount = count
+	return nil
+}
+
+// StrengthEmoji returns the emoji Rule 20 asks for
+func StrengthEmoji() string {
+	return strengthEmoji
+}
+
+// StrengthRequiredCount returns how many emojis Rule 20 needs
+func StrengthRequiredCount() int {
+	return strengthRequiredCount
+}
+
+// CountStrengthEmoji counts the Rule 20 emojis in text. VS-16 is ignored on both sides, so the
+// bare, VS-16 and ZWJ forms of the weightlifter each count once whichever form was typed.
+func CountStrengthEmoji(text string) int {
+	emoji := strings.ReplaceAll(strengthEmoji, variationSelector16, "")
+	return strings.Count(strings.ReplaceAll(text, variationSelector16, ""), emoji)
+}
+
+// StrengthProgress returns the Rule 20 emoji count and how far along it is as a percentage
+func StrengthProgress(text string) (int, float64) {
+	count := CountStrengthEmoji(text)
+	shown := count
+	if shown > strengthRequiredCount {
+		shown = strengthRequiredCount
+	}
+	return count, float64(shown) / float64(strengthRequiredCount) * 100
+}
+
+// strengthRule builds Rule 20 from the configured emoji and count
+func strengthRule() Rule {
+	return Rule{
+		ID:          20,
+		Description: "Your password is not strong enough " + strengthEmoji,
+		Validator: func(t string) bool {
+			return CountStrengthEmoji(t) >= strengthRequiredCount
+		},
+		Hint:     "Add at least " + strconv.Itoa(strengthRequiredCount) + " " + strengthEmoji + " emojis to your password.",
+		Category: "expert",
+	}
+}
@@ -0,0 +1,123 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	lifterVS16 = "\U0001F3CB\uFE0F"                   // 🏋️ as most keyboards send it
+	lifterBare = "\U0001F3CB"                         // without the variation selector
+	lifterMan  = "\U0001F3CB\uFE0F\u200D\u2642\uFE0F" // man lifting weights, a ZWJ sequence
+	lifterZWJ  = "\U0001F3CB\u200D\u2640"             // woman lifting weights, without selectors
+)
+
+func TestCountStrengthEmoji(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"none", "password", 0},
+		{"VS-16 form", "a" + lifterVS16 + "b", 1},
+		{"bare form", "a" + lifterBare + "b", 1},
+		{"ZWJ forms", lifterMan + lifterZWJ, 2},
+		{"three mixed forms", lifterVS16 + "x" + lifterBare + "y" + lifterMan, 3},
+		{"back to back", lifterBare + lifterBare + lifterVS16 + lifterVS16, 4},
+		{"stray selectors", "\uFE0F\uFE0F", 0},
+		{"other emoji", "🏆💪", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountStrengthEmoji(tt.text); got != tt.want {
+				t.Errorf("CountStrengthEmoji(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrengthRuleEmojiForms(t *testing.T) {
+	rule := strengthRule()
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{lifterVS16 + lifterVS16 + lifterVS16, true},
+		{lifterBare + lifterBare + lifterBare, true},
+		{lifterBare + lifterVS16 + lifterMan, true},
+		{lifterVS16 + lifterBare, false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.Validator(tt.password); got != tt.want {
+			t.Errorf("rule 20(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+// useStrengthRule configures rule 20 for the test and restores the defaults afterwards
+func useStrengthRule(t *testing.T, emoji string, count int) {
+	t.Helper()
+	t.Cleanup(func() { SetStrengthRule(DefaultStrengthEmoji, DefaultStrengthRequiredCount) })
+	if err := SetStrengthRule(emoji, count); err != nil {
+		t.Fatalf("SetStrengthRule(%q, %d) error = %v", emoji, count, err)
+	}
+}
+
+func TestStrengthRuleCustomEmoji(t *testing.T) {
+	useStrengthRule(t, " 🏆 ", 5)
+
+	if StrengthEmoji() != "🏆" || StrengthRequiredCount() != 5 {
+		t.Fatalf("configured %q x%d, want 🏆 x5", StrengthEmoji(), StrengthRequiredCount())
+	}
+
+	rule := strengthRule()
+	if !strings.HasSuffix(rule.Description, " 🏆") {
+		t.Errorf("description = %q, want it to end with the trophy", rule.Description)
+	}
+	if rule.Hint != "Add at least 5 🏆 emojis to your password." {
+		t.Errorf("hint = %q", rule.Hint)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{strings.Repeat("🏆", 5), true},
+		{"a🏆b🏆c🏆d🏆e🏆f", true},
+		{strings.Repeat("🏆", 4), false},
+		{strings.Repeat(lifterVS16, 5), false},
+	}
+	for _, tt := range tests {
+		if got := rule.Validator(tt.password); got != tt.want {
+			t.Errorf("rule 20(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	if count, percent := StrengthProgress("🏆🏆"); count != 2 || percent != 40 {
+		t.Errorf("StrengthProgress(🏆🏆) = %d, %g, want 2 and 40", count, percent)
+	}
+}
+
+func TestSetStrengthRuleValidation(t *testing.T) {
+	useStrengthRule(t, "🏆", 4)
+
+	for _, emoji := range []string{"", "   ", "️"} {
+		if err := SetStrengthRule(emoji, 2); err == nil {
+			t.Errorf("SetStrengthRule(%q) = nil, want an error", emoji)
+		}
+	}
+	if StrengthEmoji() != "🏆" || StrengthRequiredCount() != 4 {
+		t.Errorf("rejected settings changed rule 20 to %q x%d", StrengthEmoji(), StrengthRequiredCount())
+	}
+
+	// A count below 1 keeps the default count
+	if err := SetStrengthRule("💪", 0); err != nil {
+		t.Fatal(err)
+	}
+	if StrengthRequiredCount() != DefaultStrengthRequiredCount {
+		t.Errorf("count 0 configured %d, want the default %d", StrengthRequiredCount(), DefaultStrengthRequiredCount)
+	}
+}
@@ -0,0 +1,45 @@
+package rules
+
+import "os"
+
+// TestModeEnv enables deterministic challenges when set to exactly "1". It is only read from
+// the environment, never from config.json, so a config file copied from a test setup can't
+// switch a production server into test mode.
+const TestModeEnv = "PASSGAME_TEST_MODE"
+
+// Fixed challenge values used in test mode
+const (
+	TestQRWord        = "test"
+	TestColorName     = "Red"
+	TestColorHex      = "#FF0000"
+	TestConstantName  = "Pi"
+	TestConstantValue = "3.14159"
+	TestWordleAnswer  = "SLATE"
+	TestCaptchaDigits = "12345"
+	TestChessFEN      = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	TestChessMove     = "e2e4"
+)
+
+// testMode is evaluated as a package variable, before any init() runs, so the chess and
+// captcha initializers already see it and never reach an external API
+var testMode = os.Getenv(TestModeEnv) == "1"
+
+// SetTestMode switches test mode on or off. It exists for the tests of other packages, which
+// can't set TestModeEnv before this package initializes; servers only ever read the variable.
+func SetTestMode(enabled bool) {
+	testMode = enabled
+}
+
+// TestMode reports whether the dynamic rules are returning fixed values
+func TestMode() bool {
+	return testMode
+}
+
+// testCaptchaDigits returns TestCaptchaDigits in the form the captcha store keeps them
+func testCaptchaDigits() []byte {
+	digits := make([]byte, len(TestCaptchaDigits))
+	for i, c := range TestCaptchaDigits {
+		digits[i] = byte(c - '0')
+	}
+	return digits
+}
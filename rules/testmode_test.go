@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useOfflineAPIs points every external API at a server that fails the test if it is called
+func useOfflineAPIs(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("test mode called an external API: %s", r.URL)
+		http.Error(w, "offline", http.StatusServiceUnavailable)
+	}))
+
+	previousWordle, previousStockfish, previousSource := wordleAPIURL, stockfishAPIURL, randomWordSource
+	wordleAPIURL = server.URL + "/%s.json"
+	stockfishAPIURL = server.URL + "/?fen=%s"
+	randomWordSource = func(ctx context.Context) (string, error) {
+		t.Error("test mode fetched a random word")
+		return "", fmt.Errorf("offline")
+	}
+
+	t.Cleanup(func() {
+		server.Close()
+		wordleAPIURL, stockfishAPIURL, randomWordSource = previousWordle, previousStockfish, previousSource
+	})
+}
+
+func TestTestModeFixedChallenges(t *testing.T) {
+	useOfflineAPIs(t)
+	resetWordleCache()
+	t.Cleanup(resetWordleCache)
+
+	if _, err := RefreshQRCodeWithAPI(context.Background()); err != nil {
+		t.Errorf("RefreshQRCodeWithAPI() error = %v", err)
+	}
+	if word := GetCurrentQRWord(); word != TestQRWord {
+		t.Errorf("QR word = %q, want %q", word, TestQRWord)
+	}
+
+	if err := RefreshColor(); err != nil {
+		t.Fatal(err)
+	}
+	if name, hexCode := GetCurrentColor(); name != TestColorName || hexCode != TestColorHex {
+		t.Errorf("color = %s %s, want %s %s", name, hexCode, TestColorName, TestColorHex)
+	}
+
+	if err := RefreshMathConstant(); err != nil {
+		t.Fatal(err)
+	}
+	if name, value := GetCurrentMathConstant(); name != TestConstantName || value != TestConstantValue {
+		t.Errorf("constant = %s %s, want %s %s", name, value, TestConstantName, TestConstantValue)
+	}
+
+	bestMove, err := GenerateNewChessPosition(context.Background())
+	if err != nil || bestMove != TestChessMove {
+		t.Errorf("GenerateNewChessPosition() = %q, %v, want %q", bestMove, err, TestChessMove)
+	}
+	if game, _ := GetCurrentChessPosition(); game == nil || game.FEN() != TestChessFEN {
+		t.Errorf("chess position = %v, want %s", game, TestChessFEN)
+	}
+
+	if answer, err := GetTodaysAnswer(); err != nil || answer != TestWordleAnswer {
+		t.Errorf("GetTodaysAnswer() = %q, %v, want %q", answer, err, TestWordleAnswer)
+	}
+
+	captchaID := GenerateNewCaptcha()
+	if got := encodeCaptchaSolution(captchaStore.Get(captchaID, false)); got != TestCaptchaDigits {
+		t.Errorf("captcha = %q, want %q", got, TestCaptchaDigits)
+	}
+}
+
+func TestTestModeFullValidation(t *testing.T) {
+	useOfflineAPIs(t)
+	resetCyberSecurity(t)
+	resetWordleCache()
+	t.Cleanup(resetWordleCache)
+
+	RefreshQRCodeWithAPI(context.Background())
+	if err := RefreshMathConstant(); err != nil {
+		t.Fatal(err)
+	}
+	GenerateNewCaptcha()
+
+	// The update string is random even in test mode, so pin one without digits
+	cyberSecRules.mutex.Lock()
+	cyberSecRules.updateString = "UPDATEXY"
+	cyberSecRules.mutex.Unlock()
+
+	now := time.Now()
+	parts := []string{
+		"Pepsi", "XIV!",
+		LocalizedWeekday(now, DefaultLocale),
+		LocalizedMonth(now, DefaultLocale),
+		TestConstantValue[:1] + TestConstantValue[2:4], // 314
+		"UPDATEXY",
+		TestCaptchaDigits,
+		TestWordleAnswer,
+		TestQRWord,
+	}
+	password := strings.Join(parts, "")
+
+	// A negative number brings the digits up to the Rule 31 target
+	missing := digitSumTarget("hard") - DigitSum(password)
+	if missing < 1 || missing > 9 {
+		t.Fatalf("digits add up to %d, can't reach %d with one negative digit", DigitSum(password), digitSumTarget("hard"))
+	}
+	password += fmt.Sprintf(" -%d", missing)
+
+	ruleSet := NewRuleSet("hard")
+	ValidatePassword(ruleSet, password, nil, nil)
+	for _, rule := range ruleSet.Rules {
+		if !rule.IsSatisfied {
+			t.Errorf("rule %d (%s) is not satisfied by %q", rule.ID, rule.Description, password)
+		}
+	}
+	if got := GetSatisfiedCount(ruleSet); got != len(ruleSet.Rules) {
+		t.Errorf("%d of %d rules satisfied", got, len(ruleSet.Rules))
+	}
+}
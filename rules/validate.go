@@ -2,9 +2,12 @@ package rules
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -52,15 +55,127 @@ func loadAssignments() map[string][]int {
 	return assignmentsCache
 }
 
+// ReloadAssignments drops the cached assignments so the next NewRuleSet re-reads assignments.json
+func ReloadAssignments() {
+	assignmentsMutex.Lock()
+	defer assignmentsMutex.Unlock()
+
+	assignmentsCache = nil
+	assignmentsLoaded = false
+}
+
+// AssignmentError lists every problem found in an assignments payload
+type AssignmentError struct {
+	UnknownDifficulties []string         `json:"unknown_difficulties,omitempty"`
+	UnknownIDs          map[string][]int `json:"unknown_ids,omitempty"`
+	DuplicateIDs        map[string][]int `json:"duplicate_ids,omitempty"`
+}
+
+func (e *AssignmentError) Error() string {
+	var problems []string
+	if len(e.UnknownDifficulties) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown difficulties %v", e.UnknownDifficulties))
+	}
+	if len(e.UnknownIDs) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown rule IDs %v (pool rules are 1-%d)", e.UnknownIDs, MaxRule()))
+	}
+	if len(e.DuplicateIDs) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate rule IDs %v", e.DuplicateIDs))
+	}
+	return "invalid assignments: " + strings.Join(problems, "; ")
+}
+
+// CheckAssignments verifies that every difficulty is known and that each rule list only
+// references pool rules, without duplicates. It returns nil when the assignments are valid.
+func CheckAssignments(assignments map[string][]int, isKnownDifficulty func(string) bool) *AssignmentError {
+	poolIDs := make(map[int]bool)
+	for _, rule := range Pool() {
+		poolIDs[rule.ID] = true
+	}
+
+	result := &AssignmentError{
+		UnknownIDs:   make(map[string][]int),
+		DuplicateIDs: make(map[string][]int),
+	}
+
+	for difficulty, ids := range assignments {
+		if !isKnownDifficulty(difficulty) {
+			result.UnknownDifficulties = append(result.UnknownDifficulties, difficulty)
+		}
+
+		seen := make(map[int]bool)
+		for _, id := range ids {
+			if !poolIDs[id] {
+				result.UnknownIDs[difficulty] = append(result.UnknownIDs[difficulty], id)
+			}
+			if seen[id] {
+				result.DuplicateIDs[difficulty] = append(result.DuplicateIDs[difficulty], id)
+			}
+			seen[id] = true
+		}
+	}
+
+	if len(result.UnknownDifficulties) == 0 && len(result.UnknownIDs) == 0 && len(result.DuplicateIDs) == 0 {
+		return nil
+	}
+	sort.Strings(result.UnknownDifficulties)
+	return result
+}
+
+// ValidateAssignments checks the loaded assignments.json against the pool. GetRulesByIDs drops IDs
+// it can't find, so an orphaned ID would otherwise just make that difficulty's rule set shorter.
+// It returns one error per difficulty that references missing rules, sorted by difficulty.
+func ValidateAssignments() []error {
+	poolIDs := make(map[int]bool)
+	for _, rule := range Pool() {
+		poolIDs[rule.ID] = true
+	}
+
+	assignments := loadAssignments()
+	difficulties := make([]string, 0, len(assignments))
+	for difficulty := range assignments {
+		difficulties = append(difficulties, difficulty)
+	}
+	sort.Strings(difficulties)
+
+	var errs []error
+	for _, difficulty := range difficulties {
+		var missing []int
+		for _, id := range assignments[difficulty] {
+			if !poolIDs[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("difficulty %q assigns rule IDs %v that are not in the pool (pool rules are 1-%d)", difficulty, missing, MaxRule()))
+		}
+	}
+	return errs
+}
+
+// difficultyAliases maps alternative difficulty names onto assignments.json keys
+var difficultyAliases = map[string]string{
+	"easy":   "basic",
+	"medium": "intermediate",
+	"normal": "intermediate",
+}
+
+// NormalizeDifficulty lowercases and trims a difficulty name and resolves known aliases
+func NormalizeDifficulty(difficulty string) string {
+	normalized := strings.ToLower(strings.TrimSpace(difficulty))
+	if alias, ok := difficultyAliases[normalized]; ok {
+		return alias
+	}
+	return normalized
+}
+
 // NewRuleSet creates a new rule set based on the difficulty level using the pool and assignments.json
 func NewRuleSet(difficulty string) *RuleSet {
-	var rules []Rule
-
 	// Load assignments from cache
 	assignments := loadAssignments()
 
 	// Get rule IDs for the specified difficulty
-	ruleIDs, exists := assignments[difficulty]
+	ruleIDs, exists := assignments[NormalizeDifficulty(difficulty)]
 	if !exists {
 		log.Printf("Warning: Difficulty '%s' not found in assignments, using basic", difficulty)
 		// fallback: return basic rules from pool
@@ -68,22 +183,164 @@ func NewRuleSet(difficulty string) *RuleSet {
 		return &RuleSet{Rules: basicRules, Difficulty: difficulty}
 	}
 
+	return NewRuleSetFromIDs(difficulty, ruleIDs)
+}
+
+// NewRuleSetFromIDs builds the rule set for difficulty from the given pool rule IDs instead of
+// the current assignments, so a game in progress keeps the rules it started with
+func NewRuleSetFromIDs(difficulty string, ruleIDs []int) *RuleSet {
 	// Get rules from pool by IDs
-	rules = GetRulesByIDs(ruleIDs)
+	rules := GetRulesByIDs(ruleIDs)
 
 	// Sort rules by ID to ensure consistent ordering
 	sort.Slice(rules, func(i, j int) bool {
 		return rules[i].ID < rules[j].ID
 	})
 
-	return &RuleSet{
+	// The pool is built once, but the moon phase changes every few days and the digit-sum
+	// target depends on the difficulty
+	for i := range rules {
+		switch rules[i].ID {
+		case moonPhaseRuleID:
+			rules[i].Hint = moonPhaseHint()
+		case digitSumRuleID:
+			rule := digitSumRule(digitSumTarget(difficulty))
+			rules[i].Description, rules[i].Validator, rules[i].Hint = rule.Description, rule.Validator, rule.Hint
+		}
+	}
+
+	ruleSet := &RuleSet{
 		Rules:      rules,
 		Difficulty: difficulty,
 	}
+	// Day and month hints go stale the same way; callers with a player locale re-localize them
+	LocalizeRuleSet(ruleSet, DefaultLocale)
+	return ruleSet
+}
+
+// RulePreview is the validator-free view of a rule shown before a game starts
+type RulePreview struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Hint        string `json:"hint"`
+	Category    string `json:"category"`
+	HasCaptcha  bool   `json:"has_captcha"`
+}
+
+// PreviewRules lists the rules NewRuleSet builds for a difficulty, in play order
+func PreviewRules(difficulty string) []RulePreview {
+	ruleSet := NewRuleSet(difficulty)
+	previews := make([]RulePreview, 0, len(ruleSet.Rules))
+	for _, rule := range ruleSet.Rules {
+		previews = append(previews, RulePreview{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Hint:        rule.Hint,
+			Category:    rule.Category,
+			HasCaptcha:  rule.HasCaptcha,
+		})
+	}
+	return previews
+}
+
+// GetRuleCount returns how many rules NewRuleSet builds for the given difficulty
+func GetRuleCount(difficulty string) int {
+	assignments := loadAssignments()
+	if ruleIDs, exists := assignments[NormalizeDifficulty(difficulty)]; exists {
+		return len(GetRulesByIDs(ruleIDs))
+	}
+	// Mirror NewRuleSet's fallback to the basic rules
+	return len(GetRulesByCategory("basic"))
+}
+
+// GetFinalRuleID returns the highest rule ID NewRuleSet builds for the given difficulty, which is
+// the rule_reached of a player who completed it, or 0 when it has no rules
+func GetFinalRuleID(difficulty string) int {
+	return finalRuleID(difficulty, nil)
+}
+
+// FinalRuleID returns the highest rule ID in the set, or 0 when it has no rules
+func (rs *RuleSet) FinalRuleID() int {
+	final := 0
+	for _, rule := range rs.Rules {
+		if rule.ID > final {
+			final = rule.ID
+		}
+	}
+	return final
+}
+
+// appendedRules lists, per difficulty, the rules added to assignments.json after players had
+// already finished it. Before completions were stored those players lost them, so the migration
+// that stores them judges old rows without these rules.
+var appendedRules = map[string][]int{
+	"hard":   {monotonicRunRuleID},
+	"expert": {monotonicRunRuleID, balancedBracketsRuleID},
+	"fun":    {moonPhaseRuleID, countryRuleID},
+}
+
+// PreAppendFinalRuleID returns the final rule ID of the given difficulty without its
+// appendedRules, which is the rule_reached of a player who completed it before they were added
+func PreAppendFinalRuleID(difficulty string) int {
+	return finalRuleID(difficulty, appendedRules[NormalizeDifficulty(difficulty)])
+}
+
+// finalRuleID returns the highest rule ID NewRuleSet builds for difficulty, ignoring skip
+func finalRuleID(difficulty string, skip []int) int {
+	ruleList := GetRulesByCategory("basic")
+	if ruleIDs, exists := loadAssignments()[NormalizeDifficulty(difficulty)]; exists {
+		ruleList = GetRulesByIDs(ruleIDs)
+	}
+
+	final := 0
+	for _, rule := range ruleList {
+		if rule.ID > final && !slices.Contains(skip, rule.ID) {
+			final = rule.ID
+		}
+	}
+	return final
+}
+
+// Rule reveal modes
+const (
+	// RevealSequential shows a rule only once every rule before it is satisfied
+	RevealSequential = "sequential"
+	// RevealAll shows every rule in the set from the start
+	RevealAll = "all"
+)
+
+var (
+	revealMode      = RevealSequential
+	revealModeMutex sync.RWMutex
+)
+
+// SetRevealMode selects how rules are revealed. An empty mode resets to RevealSequential.
+func SetRevealMode(mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = RevealSequential
+	}
+	if mode != RevealSequential && mode != RevealAll {
+		return fmt.Errorf("invalid reveal mode: %s (must be %q or %q)", mode, RevealSequential, RevealAll)
+	}
+
+	revealModeMutex.Lock()
+	revealMode = mode
+	revealModeMutex.Unlock()
+	return nil
+}
+
+// GetRevealMode returns the current reveal mode
+func GetRevealMode() string {
+	revealModeMutex.RLock()
+	defer revealModeMutex.RUnlock()
+	return revealMode
 }
 
 // ValidatePassword validates the password against all rules in the rule set
 func ValidatePassword(rs *RuleSet, password string, previousStates []bool, previousVisible []bool) {
+	revealAll := GetRevealMode() == RevealAll
+
 	for i := range rs.Rules {
 		oldSatisfied := false
 		oldVisible := false
@@ -95,7 +352,7 @@ func ValidatePassword(rs *RuleSet, password string, previousStates []bool, previ
 		}
 
 		// Once a rule is visible, it stays visible for the session
-		if oldVisible || rs.Rules[i].ID == 1 || i == 0 {
+		if revealAll || oldVisible || rs.Rules[i].ID == 1 || i == 0 {
 			rs.Rules[i].IsVisible = true
 		} else if len(password) > 0 && i > 0 {
 			allPreviousVisible := true
@@ -112,9 +369,12 @@ func ValidatePassword(rs *RuleSet, password string, previousStates []bool, previ
 
 		// Only validate visible rules to improve performance
 		if rs.Rules[i].IsVisible {
-			rs.Rules[i].IsSatisfied = rs.Rules[i].Validator(password)
+			rs.Rules[i].IsSatisfied = runValidator(rs.Rules[i], password)
 			// Mark as newly satisfied if it wasn't satisfied before but is now
 			rs.Rules[i].NewlySatisfied = !oldSatisfied && rs.Rules[i].IsSatisfied
+			if rs.Rules[i].ID == digitSumRuleID {
+				rs.Rules[i].Hint = digitSumHint(digitSumTarget(rs.Difficulty), password)
+			}
 		}
 
 		// Mark as newly revealed if it wasn't visible before but is now
@@ -122,6 +382,56 @@ func ValidatePassword(rs *RuleSet, password string, previousStates []bool, previ
 	}
 }
 
+// statefulRuleIDs are rules whose validators depend on, or change, live game state (alerts,
+// watched ads, injected black squares, planted imposters), so they can't be checked in isolation.
+// The challenge rules are listed too: checking them without a session would let anyone probe
+// the current captcha, Wordle, QR, color or chess answer.
+var statefulRuleIDs = map[int]bool{
+	14: true, // Update alert
+	15: true, // Captcha
+	16: true, // Wordle answer
+	17: true, // QR code word
+	18: true, // Hex color
+	19: true, // Chess move
+	23: true, // RAID unlock after the ad
+	24: true, // Ransomware black squares
+	25: true, // Insider threat imposters
+}
+
+// IsStatefulRule reports whether a rule needs session state to be validated
+func IsStatefulRule(id int) bool {
+	return statefulRuleIDs[id]
+}
+
+// RuleCheck is the result of checking one rule outside a game session
+type RuleCheck struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Satisfied   bool   `json:"satisfied"`
+	// Supported is false for stateful rules, which are skipped and never satisfied
+	Supported bool `json:"supported"`
+}
+
+// CheckPassword checks a password against every rule of a difficulty at once, ignoring the
+// reveal order. Stateful rules are reported as unsupported rather than run, so no game
+// state is touched.
+func CheckPassword(difficulty, password string) []RuleCheck {
+	ruleSet := NewRuleSet(difficulty)
+	checks := make([]RuleCheck, 0, len(ruleSet.Rules))
+	for _, rule := range ruleSet.Rules {
+		check := RuleCheck{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Supported:   !IsStatefulRule(rule.ID),
+		}
+		if check.Supported {
+			check.Satisfied = runValidator(rule, password)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
 // GetSatisfiedCount returns the number of satisfied rules
 func GetSatisfiedCount(rs *RuleSet) int {
 	count := 0
@@ -0,0 +1,294 @@
+package rules
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// knownTestDifficulty accepts the built-in difficulties
+func knownTestDifficulty(difficulty string) bool {
+	switch difficulty {
+	case "basic", "intermediate", "hard", "expert", "fun":
+		return true
+	}
+	return false
+}
+
+func TestCheckAssignments(t *testing.T) {
+	tests := []struct {
+		name             string
+		assignments      map[string][]int
+		wantValid        bool
+		wantDifficulties []string
+		wantUnknownIDs   map[string][]int
+		wantDuplicateIDs map[string][]int
+	}{
+		{
+			name:        "valid",
+			assignments: map[string][]int{"basic": {1, 2, 3}, "expert": {1, 14, 25}},
+			wantValid:   true,
+		},
+		{
+			name:        "empty",
+			assignments: map[string][]int{},
+			wantValid:   true,
+		},
+		{
+			name:           "unknown ID",
+			assignments:    map[string][]int{"basic": {1, 2, 999}},
+			wantUnknownIDs: map[string][]int{"basic": {999}},
+		},
+		{
+			name:             "duplicate ID",
+			assignments:      map[string][]int{"hard": {1, 2, 2, 3}},
+			wantDuplicateIDs: map[string][]int{"hard": {2}},
+		},
+		{
+			name:             "unknown difficulty",
+			assignments:      map[string][]int{"nightmare": {1}},
+			wantDifficulties: []string{"nightmare"},
+		},
+		{
+			name:             "every problem at once",
+			assignments:      map[string][]int{"basic": {0, 1, 1}, "zeta": {1}, "alpha": {1}},
+			wantDifficulties: []string{"alpha", "zeta"},
+			wantUnknownIDs:   map[string][]int{"basic": {0}},
+			wantDuplicateIDs: map[string][]int{"basic": {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAssignments(tt.assignments, knownTestDifficulty)
+			if tt.wantValid {
+				if err != nil {
+					t.Fatalf("CheckAssignments() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("CheckAssignments() = nil, want an error")
+			}
+
+			if !reflect.DeepEqual(err.UnknownDifficulties, tt.wantDifficulties) {
+				t.Errorf("UnknownDifficulties = %v, want %v", err.UnknownDifficulties, tt.wantDifficulties)
+			}
+			if len(err.UnknownIDs) != len(tt.wantUnknownIDs) || (len(tt.wantUnknownIDs) > 0 && !reflect.DeepEqual(err.UnknownIDs, tt.wantUnknownIDs)) {
+				t.Errorf("UnknownIDs = %v, want %v", err.UnknownIDs, tt.wantUnknownIDs)
+			}
+			if len(err.DuplicateIDs) != len(tt.wantDuplicateIDs) || (len(tt.wantDuplicateIDs) > 0 && !reflect.DeepEqual(err.DuplicateIDs, tt.wantDuplicateIDs)) {
+				t.Errorf("DuplicateIDs = %v, want %v", err.DuplicateIDs, tt.wantDuplicateIDs)
+			}
+			if err.Error() == "" {
+				t.Error("Error() is empty")
+			}
+		})
+	}
+}
+
+// writeTestAssignments replaces assignments.json (in the test copy) for one test and drops
+// the cache, restoring both afterwards
+func writeTestAssignments(t *testing.T, data string) {
+	t.Helper()
+	const path = "rules/assignments.json"
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := os.WriteFile(path, original, 0644); err != nil {
+			t.Errorf("failed to restore %s: %v", path, err)
+		}
+		ReloadAssignments()
+	})
+
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	ReloadAssignments()
+}
+
+// ruleIDs lists the IDs of a rule set in order
+func ruleIDs(rs *RuleSet) []int {
+	ids := make([]int, len(rs.Rules))
+	for i, rule := range rs.Rules {
+		ids[i] = rule.ID
+	}
+	return ids
+}
+
+func TestReloadAssignments(t *testing.T) {
+	writeTestAssignments(t, `{"basic": [1, 2, 3]}`)
+	if got := ruleIDs(NewRuleSet("basic")); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("NewRuleSet(basic) = %v, want [1 2 3]", got)
+	}
+
+	// Without a reload the cached assignments keep being served
+	if err := os.WriteFile("rules/assignments.json", []byte(`{"basic": [1, 4]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleIDs(NewRuleSet("basic")); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("NewRuleSet(basic) before reload = %v, want the cached [1 2 3]", got)
+	}
+
+	ReloadAssignments()
+	if got := ruleIDs(NewRuleSet("basic")); !reflect.DeepEqual(got, []int{1, 4}) {
+		t.Errorf("NewRuleSet(basic) after reload = %v, want [1 4]", got)
+	}
+	if got := GetRuleCount("basic"); got != 2 {
+		t.Errorf("GetRuleCount(basic) after reload = %d, want 2", got)
+	}
+}
+
+func TestValidateAssignments(t *testing.T) {
+	if errs := ValidateAssignments(); len(errs) != 0 {
+		t.Errorf("shipped assignments.json has problems: %v", errs)
+	}
+
+	writeTestAssignments(t, `{"basic": [1, 2, 3], "hard": [1, 900, 2, 901], "expert": [950]}`)
+	errs := ValidateAssignments()
+	if len(errs) != 2 {
+		t.Fatalf("ValidateAssignments() = %v, want one error each for expert and hard", errs)
+	}
+	// Sorted by difficulty, listing every missing ID
+	for i, want := range []string{`"expert"`, `"hard"`} {
+		if !strings.Contains(errs[i].Error(), want) {
+			t.Errorf("error %d = %q, want it to name %s", i, errs[i], want)
+		}
+	}
+	if !strings.Contains(errs[0].Error(), "[950]") || !strings.Contains(errs[1].Error(), "[900 901]") {
+		t.Errorf("errors %v don't list the missing IDs", errs)
+	}
+
+	// The orphaned IDs are what GetRulesByIDs drops
+	if got := ruleIDs(NewRuleSet("hard")); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("NewRuleSet(hard) = %v, want [1 2]", got)
+	}
+}
+
+func TestPreviewRulesMatchesAssignments(t *testing.T) {
+	for difficulty, ids := range loadAssignments() {
+		t.Run(difficulty, func(t *testing.T) {
+			want := append([]int{}, ids...)
+			sort.Ints(want)
+
+			previews := PreviewRules(difficulty)
+			got := make([]int, len(previews))
+			for i, preview := range previews {
+				got[i] = preview.ID
+				rule := GetRuleByID(preview.ID)
+				if preview.Category != rule.Category || preview.HasCaptcha != rule.HasCaptcha {
+					t.Errorf("rule %d previews as %q/%v, pool has %q/%v", preview.ID, preview.Category, preview.HasCaptcha, rule.Category, rule.HasCaptcha)
+				}
+				if preview.Description == "" {
+					t.Errorf("rule %d has no description", preview.ID)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("PreviewRules(%s) = %v, want the assigned %v", difficulty, got, want)
+			}
+		})
+	}
+}
+
+func TestNewRuleSetDifficultyLookup(t *testing.T) {
+	writeTestAssignments(t, `{"basic": [1, 2], "intermediate": [1, 2, 4], "expert": [1, 2, 3, 4, 9]}`)
+
+	tests := []struct {
+		difficulty string
+		want       []int
+	}{
+		{"expert", []int{1, 2, 3, 4, 9}},
+		{"Expert", []int{1, 2, 3, 4, 9}},
+		{"EXPERT", []int{1, 2, 3, 4, 9}},
+		{"  expert\t", []int{1, 2, 3, 4, 9}},
+		{"easy", []int{1, 2}},
+		{"Medium", []int{1, 2, 4}},
+		{"normal", []int{1, 2, 4}},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, tt := range tests {
+		t.Run(tt.difficulty, func(t *testing.T) {
+			logs.Reset()
+			if got := ruleIDs(NewRuleSet(tt.difficulty)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewRuleSet(%q) = %v, want %v", tt.difficulty, got, tt.want)
+			}
+			if got := GetRuleCount(tt.difficulty); got != len(tt.want) {
+				t.Errorf("GetRuleCount(%q) = %d, want %d", tt.difficulty, got, len(tt.want))
+			}
+			if strings.Contains(logs.String(), "not found") {
+				t.Errorf("lookup of %q logged a warning: %s", tt.difficulty, logs.String())
+			}
+		})
+	}
+
+	// Unknown names still fall back to the basic category
+	logs.Reset()
+	basic := GetRulesByCategory("basic")
+	if got := NewRuleSet("nightmare"); len(got.Rules) != len(basic) {
+		t.Errorf("NewRuleSet(nightmare) has %d rules, want the %d basic rules", len(got.Rules), len(basic))
+	}
+	if !strings.Contains(logs.String(), "'nightmare' not found") {
+		t.Errorf("unknown difficulty logged %q, want a warning", logs.String())
+	}
+}
+
+// useRevealMode switches the reveal mode for the test and restores sequential reveal afterwards
+func useRevealMode(t *testing.T, mode string) {
+	t.Helper()
+	if err := SetRevealMode(mode); err != nil {
+		t.Fatalf("SetRevealMode(%q) error = %v", mode, err)
+	}
+	t.Cleanup(func() { SetRevealMode(RevealSequential) })
+}
+
+func TestValidatePasswordRevealModes(t *testing.T) {
+	writeTestAssignments(t, `{"basic": [1, 2, 3, 4]}`)
+
+	tests := []struct {
+		name          string
+		mode          string
+		password      string
+		wantVisible   []bool
+		wantSatisfied []bool
+	}{
+		{"sequential stops at the first unsatisfied rule", RevealSequential, "abcdefgh", []bool{true, true, false, false}, []bool{true, false, false, false}},
+		{"sequential with rule 1 failing", RevealSequential, "abcdef1", []bool{true, false, false, false}, []bool{false, false, false, false}},
+		{"all shows and checks every rule", RevealAll, "abcdefgh", []bool{true, true, true, true}, []bool{true, false, false, false}},
+		{"all satisfies later rules out of order", "ALL", "abcdef1", []bool{true, true, true, true}, []bool{false, false, false, true}},
+		{"all with an empty password", RevealAll, "", []bool{true, true, true, true}, []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRevealMode(t, tt.mode)
+			rs := NewRuleSet("basic")
+			ValidatePassword(rs, tt.password, nil, nil)
+
+			for i, rule := range rs.Rules {
+				if rule.IsVisible != tt.wantVisible[i] {
+					t.Errorf("rule %d visible = %v, want %v", rule.ID, rule.IsVisible, tt.wantVisible[i])
+				}
+				if rule.IsSatisfied != tt.wantSatisfied[i] {
+					t.Errorf("rule %d satisfied = %v, want %v", rule.ID, rule.IsSatisfied, tt.wantSatisfied[i])
+				}
+			}
+		})
+	}
+
+	if err := SetRevealMode("random"); err == nil {
+		t.Error("SetRevealMode(random) = nil, want an error")
+	}
+	if got := GetRevealMode(); got != RevealSequential {
+		t.Errorf("an invalid mode changed the reveal mode to %q", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -22,43 +23,173 @@ type WordleResponse struct {
 type WordleCache struct {
 	Answer string
 	Date   string
-	mu     sync.RWMutex
+	// RefreshAt is the next puzzle rollover, after which the cached answer is stale
+	RefreshAt time.Time
+	mu        sync.RWMutex
 }
 
 var cache = &WordleCache{}
 
+// wordleAPIURL is the NYT endpoint for a date's puzzle; tests point it at a stub server
+var wordleAPIURL = "https://www.nytimes.com/svc/wordle/v2/%s.json"
+
+// Wordle API retry and circuit breaker settings
+const (
+	wordleMaxRetries = 3
+	// wordleFailureThreshold failed lookups in a row open the circuit for wordleCooldown,
+	// during which the API is skipped and the fallback answer is served
+	wordleFailureThreshold = 3
+	wordleCooldown         = 5 * time.Minute
+)
+
+// wordleCircuit stops Rule 16 from hammering the API during a sustained outage
+type wordleCircuit struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+var wordleBreaker = &wordleCircuit{}
+
+// wordleInitialDelay is the first retry backoff; tests shorten it
+var wordleInitialDelay = 500 * time.Millisecond
+
+// allow reports whether the API may be called, i.e. the circuit isn't open
+func (c *wordleCircuit) allow(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !now.Before(c.openUntil)
+}
+
+// recordSuccess closes the circuit
+func (c *wordleCircuit) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+	c.openUntil = time.Time{}
+}
+
+// recordFailure counts a failed lookup and opens the circuit once the threshold is reached
+func (c *wordleCircuit) recordFailure(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if c.failures >= wordleFailureThreshold {
+		c.openUntil = now.Add(wordleCooldown)
+		c.failures = 0
+		log.Printf("⚡ Wordle API failed %d times in a row, using the fallback answer for %v", wordleFailureThreshold, wordleCooldown)
+	}
+}
+
+// The puzzle date is resolved in wordleLocation (UTC unless configured) so the cache
+// key doesn't depend on the server's local timezone
+var (
+	wordleLocation = time.UTC
+	wordleNow      = time.Now
+)
+
+// SetWordleTimezone sets the IANA timezone (e.g. "America/New_York") used to decide which
+// day's puzzle is current. An empty name resets it to UTC.
+func SetWordleTimezone(name string) error {
+	if name == "" {
+		name = "UTC"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid wordle timezone %q: %v", name, err)
+	}
+
+	cache.mu.Lock()
+	wordleLocation = location
+	cache.RefreshAt = time.Time{} // Force the next lookup to re-resolve the date
+	cache.mu.Unlock()
+	return nil
+}
+
+// wordleDate returns the puzzle date for now and the moment the next puzzle starts
+func wordleDate(now time.Time, location *time.Location) (string, time.Time) {
+	local := now.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	return local.Format("2006-01-02"), midnight.AddDate(0, 0, 1)
+}
+
+// CurrentWordleDate returns the date of the puzzle currently in play
+func CurrentWordleDate() string {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	date, _ := wordleDate(wordleNow(), wordleLocation)
+	return date
+}
+
 // GetTodaysAnswer fetches today's Wordle answer from NYT API
 func GetTodaysAnswer() (string, error) {
-	today := time.Now().Format("2006-01-02")
+	if testMode {
+		return TestWordleAnswer, nil
+	}
+
+	now := wordleNow()
 
-	// Check cache first
 	cache.mu.RLock()
-	if cache.Date == today && cache.Answer != "" {
+	today, refreshAt := wordleDate(now, wordleLocation)
+
+	// Check cache first
+	if cache.Date == today && cache.Answer != "" && now.Before(cache.RefreshAt) {
 		answer := cache.Answer
 		cache.mu.RUnlock()
 		return answer, nil
 	}
 	cache.mu.RUnlock()
 
+	// Skip the API while the circuit is open
+	if !wordleBreaker.allow(now) {
+		return getFallbackAnswer(today)
+	}
+
 	// Fetch from API
-	answer, err := fetchWordleAnswer(today)
+	answer, err := fetchWordleAnswerWithRetry(today, wordleMaxRetries, wordleInitialDelay)
 	if err != nil {
+		wordleBreaker.recordFailure(wordleNow())
 		// If API fails, try fallback methods
 		return getFallbackAnswer(today)
 	}
+	wordleBreaker.recordSuccess()
 
 	// Update cache
 	cache.mu.Lock()
 	cache.Answer = answer
 	cache.Date = today
+	cache.RefreshAt = refreshAt
 	cache.mu.Unlock()
 
 	return answer, nil
 }
 
+// fetchWordleAnswerWithRetry fetches the answer, retrying with exponential backoff
+func fetchWordleAnswerWithRetry(date string, maxRetries int, initialDelay time.Duration) (string, error) {
+	var lastErr error
+	delay := initialDelay
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		answer, err := fetchWordleAnswer(date)
+		if err == nil {
+			return answer, nil
+		}
+		lastErr = err
+		if attempt < maxRetries-1 {
+			log.Printf("Wordle API attempt %d failed, retrying in %v: %v", attempt+1, delay, err)
+			time.Sleep(delay)
+			delay *= 2 // Exponential backoff
+		}
+	}
+
+	return "", fmt.Errorf("failed to fetch wordle answer after %d attempts: %w", maxRetries, lastErr)
+}
+
 // fetchWordleAnswer fetches the answer from NYT API
-func fetchWordleAnswer(date string) (string, error) {
-	url := fmt.Sprintf("https://www.nytimes.com/svc/wordle/v2/%s.json", date)
+func fetchWordleAnswer(date string) (answer string, err error) {
+	defer func() { recordExternalAPICall("wordle", err) }()
+
+	url := fmt.Sprintf(wordleAPIURL, date)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -136,7 +267,7 @@ func ValidateWordleAnswer(password string) bool {
 	}
 
 	// Check if password contains the wordle answer (case-insensitive)
-	return strings.Contains(strings.ToUpper(password), strings.ToUpper(answer))
+	return matches(password, answer, matchOptionsFor(16))
 }
 
 // GetTodaysAnswerForHint returns today's answer for display in hints
@@ -0,0 +1,230 @@
+package rules
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wordleStub is a stand-in for the NYT API that answers with a word per date
+type wordleStub struct {
+	mu       sync.Mutex
+	requests []string
+	fail     int // the next fail requests answer 503
+}
+
+// failNext makes the next n requests fail
+func (s *wordleStub) failNext(n int) {
+	s.mu.Lock()
+	s.fail = n
+	s.mu.Unlock()
+}
+
+func (s *wordleStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	date := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".json")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, date)
+	failing := s.fail > 0
+	if failing {
+		s.fail--
+	}
+	s.mu.Unlock()
+
+	if failing {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintf(w, `{"solution": "word-%s"}`, date)
+}
+
+// requestCount returns how many lookups reached the stub
+func (s *wordleStub) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// useWordleStub leaves test mode and points the Wordle lookups at a stub server with a fake
+// clock and an empty cache, restoring everything afterwards
+func useWordleStub(t *testing.T) (*wordleStub, *fakeClock) {
+	t.Helper()
+	stub := &wordleStub{}
+	server := httptest.NewServer(stub)
+	clock := &fakeClock{current: time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)}
+
+	previousURL, previousNow, previousLocation, previousDelay := wordleAPIURL, wordleNow, wordleLocation, wordleInitialDelay
+	wordleAPIURL = server.URL + "/%s.json"
+	wordleNow = clock.now
+	wordleInitialDelay = time.Millisecond
+	testMode = false
+	resetWordleCache()
+
+	t.Cleanup(func() {
+		server.Close()
+		wordleAPIURL, wordleNow, wordleLocation, wordleInitialDelay = previousURL, previousNow, previousLocation, previousDelay
+		testMode = true
+		resetWordleCache()
+	})
+	return stub, clock
+}
+
+// resetWordleCache empties the answer cache and closes the circuit breaker
+func resetWordleCache() {
+	cache.mu.Lock()
+	cache.Answer, cache.Date, cache.RefreshAt = "", "", time.Time{}
+	cache.mu.Unlock()
+	wordleBreaker.recordSuccess()
+}
+
+func TestWordleDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		location      *time.Location
+		wantDate      string
+		wantRefreshAt time.Time
+	}{
+		{
+			name:          "just before UTC midnight",
+			now:           time.Date(2025, 3, 10, 23, 59, 59, 0, time.UTC),
+			location:      time.UTC,
+			wantDate:      "2025-03-10",
+			wantRefreshAt: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "at UTC midnight",
+			now:           time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+			location:      time.UTC,
+			wantDate:      "2025-03-11",
+			wantRefreshAt: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "server clock in another zone",
+			now:           time.Date(2025, 3, 11, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)),
+			location:      time.UTC,
+			wantDate:      "2025-03-10",
+			wantRefreshAt: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "New York is still on the previous day",
+			now:           time.Date(2025, 3, 11, 3, 0, 0, 0, time.UTC),
+			location:      newYork,
+			wantDate:      "2025-03-10",
+			wantRefreshAt: time.Date(2025, 3, 11, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, refreshAt := wordleDate(tt.now, tt.location)
+			if date != tt.wantDate {
+				t.Errorf("date = %s, want %s", date, tt.wantDate)
+			}
+			if !refreshAt.Equal(tt.wantRefreshAt) {
+				t.Errorf("refreshAt = %v, want %v", refreshAt, tt.wantRefreshAt)
+			}
+		})
+	}
+}
+
+func TestGetTodaysAnswerMidnightRollover(t *testing.T) {
+	stub, clock := useWordleStub(t)
+	clock.current = time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+
+	steps := []struct {
+		name         string
+		advance      time.Duration
+		wantAnswer   string
+		wantRequests int
+	}{
+		{"first lookup", 0, "WORD-2025-03-10", 1},
+		{"cached a second before midnight", 59 * time.Second, "WORD-2025-03-10", 1},
+		{"new puzzle at midnight", time.Second, "WORD-2025-03-11", 2},
+		{"cached the next day", 12 * time.Hour, "WORD-2025-03-11", 2},
+	}
+
+	for _, step := range steps {
+		clock.advance(step.advance)
+		answer, err := GetTodaysAnswer()
+		if err != nil {
+			t.Fatalf("%s: GetTodaysAnswer() error = %v", step.name, err)
+		}
+		if answer != step.wantAnswer {
+			t.Errorf("%s: answer = %s, want %s", step.name, answer, step.wantAnswer)
+		}
+		if got := stub.requestCount(); got != step.wantRequests {
+			t.Errorf("%s: %d API requests, want %d", step.name, got, step.wantRequests)
+		}
+		if got := CurrentWordleDate(); got != strings.TrimPrefix(step.wantAnswer, "WORD-") {
+			t.Errorf("%s: CurrentWordleDate() = %s", step.name, got)
+		}
+	}
+}
+
+func TestGetTodaysAnswerRetriesTransientFailures(t *testing.T) {
+	stub, _ := useWordleStub(t)
+	stub.failNext(wordleMaxRetries - 1)
+
+	answer, err := GetTodaysAnswer()
+	if err != nil {
+		t.Fatalf("GetTodaysAnswer() error = %v", err)
+	}
+	if answer != "WORD-2025-03-10" {
+		t.Errorf("answer = %s, want the API answer after retrying", answer)
+	}
+	if got := stub.requestCount(); got != wordleMaxRetries {
+		t.Errorf("%d API requests, want %d", got, wordleMaxRetries)
+	}
+}
+
+func TestGetTodaysAnswerOpensCircuit(t *testing.T) {
+	stub, clock := useWordleStub(t)
+	stub.failNext(1000)
+
+	fallback, err := getFallbackAnswer("2025-03-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every failed lookup exhausts its retries and serves the fallback
+	for i := 1; i <= wordleFailureThreshold; i++ {
+		answer, err := GetTodaysAnswer()
+		if err != nil || answer != fallback {
+			t.Fatalf("lookup %d = %q, %v, want the fallback %s", i, answer, err, fallback)
+		}
+		if got := stub.requestCount(); got != i*wordleMaxRetries {
+			t.Fatalf("after lookup %d: %d API requests, want %d", i, got, i*wordleMaxRetries)
+		}
+	}
+
+	// The circuit is now open, so the API is skipped entirely
+	requests := stub.requestCount()
+	clock.advance(wordleCooldown - time.Second)
+	if answer, err := GetTodaysAnswer(); err != nil || answer != fallback {
+		t.Errorf("open circuit = %q, %v, want the fallback %s", answer, err, fallback)
+	}
+	if got := stub.requestCount(); got != requests {
+		t.Errorf("open circuit made %d API requests, want none", got-requests)
+	}
+
+	// After the cooldown the API is tried again and closes the circuit
+	stub.failNext(0)
+	clock.advance(time.Second)
+	if answer, err := GetTodaysAnswer(); err != nil || answer != "WORD-2025-03-10" {
+		t.Errorf("after cooldown = %q, %v, want the API answer", answer, err)
+	}
+	if got := stub.requestCount(); got != requests+1 {
+		t.Errorf("after cooldown: %d API requests, want 1", got-requests)
+	}
+}